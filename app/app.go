@@ -0,0 +1,364 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-blog/cache"
+	"go-blog/config"
+	"go-blog/db"
+	"go-blog/events"
+	"go-blog/graph"
+	"go-blog/grpcserver"
+	"go-blog/handlers"
+	"go-blog/jobs"
+	"go-blog/logging"
+	"go-blog/mail"
+	"go-blog/metrics"
+	"go-blog/notify"
+	"go-blog/repo"
+	"go-blog/routes"
+	"go-blog/service"
+	"go-blog/storage"
+	"go-blog/tracing"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Worker is a background process that runs until its context is cancelled.
+type Worker interface {
+	Name() string
+	Run(ctx context.Context) error
+}
+
+type App struct {
+	Config   config.Config
+	Logger   *slog.Logger
+	DB       *gorm.DB
+	Migrator *db.Migrator
+	Metrics  *metrics.Metrics
+	Tracing  *tracing.Provider
+
+	PostRepo         repo.PostRepository
+	UserRepo         repo.UserRepository
+	AuthRepo         repo.AuthRepository
+	ReactionRepo     repo.ReactionRepository
+	FollowRepo       repo.FollowRepository
+	ListRepo         repo.ReadingListRepository
+	MediaRepo        repo.MediaRepository
+	ImportRepo       repo.ImportRepository
+	WebhookRepo      repo.WebhookRepository
+	NotificationRepo repo.NotificationRepository
+	DigestRepo       repo.DigestRepository
+	Transactor       repo.Transactor
+	BlobStore        storage.BlobStore
+	Mailer           mail.Mailer
+	PostCache        cache.Store
+	Jobs             *jobs.Queue
+	Events           *events.Bus
+	Notifier         *notify.Hub
+
+	PostService         service.PostService
+	UserService         service.UserService
+	ReactionService     service.ReactionService
+	FollowService       service.FollowService
+	ListService         service.ReadingListService
+	FeedService         service.SyndicationService
+	SitemapService      service.SitemapService
+	MediaService        service.MediaService
+	ImportService       service.ImportService
+	WebhookService      service.WebhookService
+	NotificationService service.NotificationService
+	DigestService       service.DigestService
+
+	PostHandler         *handlers.PostHandler
+	UserHandler         *handlers.UserHandler
+	ReactionHandler     *handlers.ReactionHandler
+	FollowHandler       *handlers.FollowHandler
+	ListHandler         *handlers.ReadingListHandler
+	FeedHandler         *handlers.SyndicationHandler
+	SitemapHandler      *handlers.SitemapHandler
+	MediaHandler        *handlers.MediaHandler
+	ImportHandler       *handlers.ImportHandler
+	JobHandler          *handlers.JobHandler
+	WebhookHandler      *handlers.WebhookHandler
+	NotificationHandler *handlers.NotificationHandler
+	DigestHandler       *handlers.DigestHandler
+	HealthHandler       *handlers.HealthHandler
+	GraphQLHandler      *handlers.GraphQLHandler
+	Router              *gin.Engine
+	GRPCServer          *grpcserver.Server
+
+	workers []Worker
+}
+
+// New connects to the database, applies migrations when enabled and wires
+// every repository, service and handler. It does not start serving.
+func New(cfg config.Config) (*App, error) {
+	logger, err := logging.New(cfg.Log)
+	if err != nil {
+		return nil, err
+	}
+	tracerProvider, err := tracing.Setup(cfg.Tracing)
+	if err != nil {
+		return nil, fmt.Errorf("setting up tracing: %w", err)
+	}
+	gormDB, err := db.InitDB(cfg.Database)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to %s database: %w", cfg.Database.Type, err)
+	}
+	gormDB.Logger = logging.NewGormLogger(cfg.Log)
+	migrator, err := db.NewMigrator(gormDB)
+	if err != nil {
+		closeDB(gormDB)
+		return nil, fmt.Errorf("loading migrations: %w", err)
+	}
+	if cfg.Database.AutoMigrate {
+		applied, err := migrator.Up(context.Background())
+		if err != nil {
+			closeDB(gormDB)
+			return nil, fmt.Errorf("applying migrations: %w", err)
+		}
+		for _, m := range applied {
+			logger.Info("applied migration", "version", m.Version, "name", m.Name)
+		}
+	}
+
+	a := &App{Config: cfg, Logger: logger, DB: gormDB, Migrator: migrator, Tracing: tracerProvider}
+	if err := tracing.InstrumentDB(gormDB); err != nil {
+		a.Close()
+		return nil, fmt.Errorf("instrumenting database: %w", err)
+	}
+	if cfg.Metrics.Enabled {
+		a.Metrics = metrics.New()
+		if err := a.Metrics.InstrumentDB(gormDB, cfg.Database.Type); err != nil {
+			a.Close()
+			return nil, fmt.Errorf("instrumenting database: %w", err)
+		}
+	}
+
+	a.BlobStore, err = storage.New(cfg.Media)
+	if err != nil {
+		a.Close()
+		return nil, fmt.Errorf("opening media storage: %w", err)
+	}
+
+	a.PostCache, err = cache.New(cfg.Cache.Backend, cfg.Cache.Size)
+	if err != nil {
+		a.Close()
+		return nil, fmt.Errorf("setting up cache: %w", err)
+	}
+	a.Mailer, err = mail.New(cfg.Mail, logger)
+	if err != nil {
+		a.Close()
+		return nil, fmt.Errorf("setting up mail: %w", err)
+	}
+
+	a.Jobs = jobs.New(gormDB, cfg.Jobs, a.Metrics, logger)
+	a.Events = events.NewBus(a.Metrics, logger)
+	a.Notifier = notify.NewHub(gormDB, logger)
+
+	a.PostRepo = repo.NewPostRepository(gormDB)
+	a.UserRepo = repo.NewUserRepository(gormDB)
+	a.MediaRepo = repo.NewMediaRepository(gormDB)
+	a.AuthRepo = repo.NewAuthRepository(a.PostRepo, a.MediaRepo)
+	a.ReactionRepo = repo.NewReactionRepository(gormDB)
+	a.FollowRepo = repo.NewFollowRepository(gormDB)
+	a.ListRepo = repo.NewReadingListRepository(gormDB)
+	a.ImportRepo = repo.NewImportRepository(gormDB)
+	a.WebhookRepo = repo.NewWebhookRepository(gormDB)
+	a.NotificationRepo = repo.NewNotificationRepository(gormDB)
+	a.DigestRepo = repo.NewDigestRepository(gormDB)
+	a.Transactor = repo.NewTransactor(gormDB)
+
+	a.WebhookService = service.NewWebhookService(cfg.Webhooks, a.WebhookRepo, a.Transactor, a.Jobs)
+	a.NotificationService = service.NewNotificationService(a.NotificationRepo, a.UserRepo, a.Notifier)
+	a.PostService = service.NewTracedPostService(service.NewCachedPostService(
+		service.NewPostService(a.PostRepo, a.MediaRepo, a.Transactor, a.Events, a.Metrics),
+		a.PostCache, cfg.Cache.TTL, a.Events, a.Metrics))
+	a.UserService = service.NewTracedUserService(service.NewUserService(a.UserRepo, a.Transactor, a.Events))
+	a.ReactionService = service.NewTracedReactionService(service.NewReactionService(a.PostRepo, a.ReactionRepo, a.Transactor, a.Events))
+	a.FollowService = service.NewTracedFollowService(service.NewFollowService(a.UserRepo, a.FollowRepo, a.PostRepo, a.Transactor, a.Events))
+	a.ListService = service.NewTracedReadingListService(service.NewReadingListService(a.ListRepo, a.PostRepo))
+	a.FeedService = service.NewTracedSyndicationService(service.NewSyndicationService(cfg.Site, a.PostRepo, a.UserRepo))
+	a.SitemapService = service.NewSitemapService(cfg.Site, a.PostRepo)
+	a.MediaService = service.NewTracedMediaService(service.NewMediaService(cfg.Media, cfg.Site, a.MediaRepo, a.BlobStore))
+	a.ImportService = service.NewImportService(cfg.Import, a.ImportRepo, a.PostRepo, a.UserRepo, a.PostService, a.BlobStore, a.Jobs)
+	jobs.Handle(a.Jobs, func(ctx context.Context, args service.ImportArgs) error {
+		return a.ImportService.ProcessImport(ctx, args.ImportID)
+	})
+	service.SubscribeWebhooks(a.Events, a.WebhookService)
+	service.SubscribeNotifications(a.Events, a.NotificationService)
+	a.DigestService = service.NewDigestService(cfg.Digests, cfg.Site, a.DigestRepo, a.PostRepo, a.UserRepo, a.Transactor, a.Jobs, a.Mailer)
+	jobs.Handle(a.Jobs, func(ctx context.Context, args service.SendDigestConfirmationArgs) error {
+		return a.DigestService.SendConfirmation(ctx, args.SubscriptionID)
+	})
+	jobs.Handle(a.Jobs, func(ctx context.Context, args service.SendDigestArgs) error {
+		return a.DigestService.SendDigest(ctx, args.SubscriptionID)
+	})
+	jobs.Handle(a.Jobs, func(ctx context.Context, args service.DeliverWebhookArgs) error {
+		return a.WebhookService.Deliver(ctx, args.DeliveryID)
+	})
+
+	a.PostHandler = handlers.NewPostHandler(a.PostService, a.ReactionService, cfg.Cache.MaxAge)
+	a.UserHandler = handlers.NewUserHandler(a.UserService, cfg.Auth, a.Metrics)
+	a.ReactionHandler = handlers.NewReactionHandler(a.ReactionService)
+	a.FollowHandler = handlers.NewFollowHandler(a.FollowService)
+	a.ListHandler = handlers.NewReadingListHandler(a.ListService)
+	a.FeedHandler = handlers.NewSyndicationHandler(a.FeedService, cfg.Site)
+	a.SitemapHandler = handlers.NewSitemapHandler(a.SitemapService)
+	a.MediaHandler = handlers.NewMediaHandler(a.MediaService, cfg.Media.MaxUploadBytes)
+	a.ImportHandler = handlers.NewImportHandler(a.ImportService, cfg.Import.MaxBytes)
+	a.JobHandler = handlers.NewJobHandler(a.Jobs)
+	a.WebhookHandler = handlers.NewWebhookHandler(a.WebhookService)
+	a.NotificationHandler = handlers.NewNotificationHandler(a.NotificationService, a.Notifier)
+	a.DigestHandler = handlers.NewDigestHandler(a.DigestService)
+	a.HealthHandler = handlers.NewHealthHandler(gormDB, migrator)
+	graphQL, err := graph.NewServer(cfg.GraphQL, a.PostService, a.UserService, a.AuthRepo)
+	if err != nil {
+		a.Close()
+		return nil, fmt.Errorf("building the GraphQL schema: %w", err)
+	}
+	a.GraphQLHandler = handlers.NewGraphQLHandler(graphQL)
+
+	a.Router = routes.SetupRoutes(cfg, routes.Handlers{
+		Post:          a.PostHandler,
+		User:          a.UserHandler,
+		Reaction:      a.ReactionHandler,
+		Follow:        a.FollowHandler,
+		Lists:         a.ListHandler,
+		Feeds:         a.FeedHandler,
+		Sitemap:       a.SitemapHandler,
+		Media:         a.MediaHandler,
+		Import:        a.ImportHandler,
+		Webhooks:      a.WebhookHandler,
+		Notifications: a.NotificationHandler,
+		Digests:       a.DigestHandler,
+		Admin:         a.AdminHandlers(),
+		Health:        a.HealthHandler,
+		GraphQL:       a.GraphQLHandler,
+	}, a.AuthRepo, a.Metrics, tracerProvider, logger)
+
+	a.GRPCServer = grpcserver.New(cfg.GRPC.Addr, cfg.Auth.JWTSecret, cfg.Server.ShutdownTimeout,
+		a.PostService, a.UserService, a.AuthRepo, a.Events, logger)
+	if cfg.GRPC.Addr != "" {
+		a.AddWorker(a.GRPCServer)
+	}
+	if cfg.Admin.Addr != "" {
+		a.AddWorker(&serverWorker{
+			logger:          logger,
+			name:            "admin server",
+			server:          newHTTPServer(cfg.Server, cfg.Admin.Addr, routes.SetupAdminRoutes(cfg, a.Metrics, a.AdminHandlers(), logger)),
+			shutdownTimeout: cfg.Server.ShutdownTimeout,
+		})
+	}
+	if cfg.Reactions.ReconcileInterval > 0 {
+		a.AddWorker(service.NewReactionReconciler(a.ReactionService, cfg.Reactions.ReconcileInterval, logger))
+	}
+	if cfg.Digests.Interval > 0 {
+		a.AddWorker(service.NewDigestScheduler(a.DigestService, cfg.Digests.Interval, logger))
+	}
+	if cfg.Jobs.Concurrency > 0 {
+		a.AddWorker(a.Jobs)
+	}
+	a.AddWorker(a.Events)
+	a.AddWorker(a.Notifier)
+	return a, nil
+}
+
+func (a *App) AdminHandlers() routes.AdminHandlers {
+	return routes.AdminHandlers{Jobs: a.JobHandler, Webhooks: a.WebhookHandler}
+}
+
+func (a *App) AddWorker(w Worker) {
+	a.workers = append(a.workers, w)
+}
+
+// Run serves HTTP and runs the background workers until ctx is cancelled,
+// then shuts down in order: stop accepting connections and drain in-flight
+// requests, stop the workers, and finally close the database.
+func (a *App) Run(ctx context.Context) error {
+	srv := newHTTPServer(a.Config.Server, a.Config.Server.Addr, a.Router)
+	// Notification streams never finish on their own; end them when
+	// shutdown starts so draining the server does not wait them out.
+	srv.RegisterOnShutdown(a.Notifier.Close)
+	listener, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		a.Close()
+		return fmt.Errorf("listening on %s: %w", srv.Addr, err)
+	}
+
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	var workers sync.WaitGroup
+	for _, w := range a.workers {
+		workers.Add(1)
+		go func(w Worker) {
+			defer workers.Done()
+			if err := w.Run(workerCtx); err != nil && !errors.Is(err, context.Canceled) {
+				a.Logger.Error("worker stopped", "worker", w.Name(), "error", err)
+			}
+		}(w)
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		a.Logger.Info("http server listening", "addr", listener.Addr().String())
+		serveErr <- srv.Serve(listener)
+	}()
+
+	var runErr error
+	select {
+	case <-ctx.Done():
+		a.Logger.Info("shutting down")
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			runErr = fmt.Errorf("http server: %w", err)
+		}
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), a.Config.Server.ShutdownTimeout)
+	defer cancel()
+
+	a.HealthHandler.SetDraining()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		a.Logger.Error("http shutdown", "error", err)
+	}
+
+	stopWorkers()
+	done := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-shutdownCtx.Done():
+		a.Logger.Warn("timed out waiting for background workers")
+	}
+
+	if err := a.Tracing.Shutdown(shutdownCtx); err != nil {
+		a.Logger.Error("flushing traces", "error", err)
+	}
+	if err := a.Close(); err != nil {
+		a.Logger.Error("closing database", "error", err)
+	}
+	return runErr
+}
+
+func (a *App) Close() error {
+	return closeDB(a.DB)
+}
+
+func closeDB(gormDB *gorm.DB) error {
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
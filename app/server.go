@@ -0,0 +1,54 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"go-blog/config"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+func newHTTPServer(cfg config.ServerConfig, addr string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+}
+
+// serverWorker runs an auxiliary HTTP server, such as the admin listener,
+// as a background worker so it shuts down with the rest of the app.
+type serverWorker struct {
+	logger          *slog.Logger
+	name            string
+	server          *http.Server
+	shutdownTimeout time.Duration
+}
+
+func (w *serverWorker) Name() string {
+	return w.name
+}
+
+func (w *serverWorker) Run(ctx context.Context) error {
+	errCh := make(chan error, 1)
+	go func() {
+		w.logger.Info("http server listening", "server", w.name, "addr", w.server.Addr)
+		errCh <- w.server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), w.shutdownTimeout)
+		defer cancel()
+		return w.server.Shutdown(shutdownCtx)
+	}
+}
@@ -0,0 +1,248 @@
+// Package archive reads and writes the portable blog formats: the go-blog
+// export zip (one Markdown file with YAML front matter per post plus a JSON
+// manifest) and WordPress WXR. It only converts between bytes and Entry
+// values; creating posts is up to the caller.
+package archive
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-blog/models"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	ManifestFile   = "manifest.json"
+	ManifestFormat = "go-blog-export"
+	Version        = 1
+
+	// MaxEntries and MaxFileBytes bound what ReadZip will inflate, so a
+	// small zip cannot expand into gigabytes.
+	MaxEntries   = 10000
+	MaxFileBytes = 10 << 20
+)
+
+var ErrInvalidArchive = errors.New("invalid export archive")
+
+// Entry is one post in a portable format.
+type Entry struct {
+	Title       string
+	Slug        string
+	Status      models.PostStatus
+	Tags        []string
+	Content     string
+	PublishedAt *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type Manifest struct {
+	Format     string         `json:"format"`
+	Version    int            `json:"version"`
+	ExportedAt time.Time      `json:"exported_at"`
+	Author     string         `json:"author"`
+	Posts      []ManifestPost `json:"posts"`
+}
+
+type ManifestPost struct {
+	File        string            `json:"file"`
+	Slug        string            `json:"slug"`
+	Title       string            `json:"title"`
+	Status      models.PostStatus `json:"status"`
+	Tags        []string          `json:"tags"`
+	PublishedAt *time.Time        `json:"published_at,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+type frontMatter struct {
+	Title   string            `yaml:"title"`
+	Slug    string            `yaml:"slug"`
+	Status  models.PostStatus `yaml:"status"`
+	Date    *time.Time        `yaml:"date,omitempty"`
+	Created time.Time         `yaml:"created"`
+	Updated time.Time         `yaml:"updated"`
+	Tags    []string          `yaml:"tags,omitempty"`
+}
+
+// EntryFromPost converts a stored post.
+func EntryFromPost(post models.Post) Entry {
+	return Entry{
+		Title:       post.Title,
+		Slug:        post.Slug,
+		Status:      post.Status,
+		Tags:        post.Tags,
+		Content:     post.Content,
+		PublishedAt: post.PublishedAt,
+		CreatedAt:   post.CreatedAt,
+		UpdatedAt:   post.UpdatedAt,
+	}
+}
+
+// WriteZip writes posts/<slug>.md for every entry and a manifest listing
+// them in order. Entries are expected to have distinct slugs.
+func WriteZip(w io.Writer, author string, exportedAt time.Time, entries []Entry) error {
+	zw := zip.NewWriter(w)
+	manifest := Manifest{Format: ManifestFormat, Version: Version, ExportedAt: exportedAt.UTC(), Author: author, Posts: []ManifestPost{}}
+	for _, entry := range entries {
+		name := "posts/" + entry.Slug + ".md"
+		file, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: entry.UpdatedAt})
+		if err != nil {
+			return err
+		}
+		if err := writeMarkdown(file, entry); err != nil {
+			return fmt.Errorf("writing %s: %w", name, err)
+		}
+		manifest.Posts = append(manifest.Posts, ManifestPost{
+			File:        name,
+			Slug:        entry.Slug,
+			Title:       entry.Title,
+			Status:      entry.Status,
+			Tags:        entry.Tags,
+			PublishedAt: entry.PublishedAt,
+			CreatedAt:   entry.CreatedAt,
+			UpdatedAt:   entry.UpdatedAt,
+		})
+	}
+
+	file, err := zw.CreateHeader(&zip.FileHeader{Name: ManifestFile, Method: zip.Deflate, Modified: exportedAt})
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(manifest); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// ReadZip parses an export archive. The Markdown files are the source of
+// truth, so edits made to them after exporting are honoured; the manifest
+// only decides which files are read and in what order.
+func ReadZip(data []byte) ([]Entry, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	if len(zr.File) > MaxEntries {
+		return nil, fmt.Errorf("%w: more than %d files", ErrInvalidArchive, MaxEntries)
+	}
+	files := map[string]*zip.File{}
+	for _, f := range zr.File {
+		files[path.Clean(f.Name)] = f
+	}
+
+	manifestFile, ok := files[ManifestFile]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is missing", ErrInvalidArchive, ManifestFile)
+	}
+	raw, err := readZipFile(manifestFile)
+	if err != nil {
+		return nil, err
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidArchive, ManifestFile, err)
+	}
+	if manifest.Format != ManifestFormat || manifest.Version < 1 || manifest.Version > Version {
+		return nil, fmt.Errorf("%w: unsupported format %q version %d", ErrInvalidArchive, manifest.Format, manifest.Version)
+	}
+
+	entries := make([]Entry, 0, len(manifest.Posts))
+	for _, post := range manifest.Posts {
+		f, ok := files[path.Clean(post.File)]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s is listed in the manifest but missing", ErrInvalidArchive, post.File)
+		}
+		raw, err := readZipFile(f)
+		if err != nil {
+			return nil, err
+		}
+		entry, err := ParseMarkdown(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidArchive, post.File, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidArchive, f.Name, err)
+	}
+	defer rc.Close()
+	raw, err := io.ReadAll(io.LimitReader(rc, MaxFileBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidArchive, f.Name, err)
+	}
+	if len(raw) > MaxFileBytes {
+		return nil, fmt.Errorf("%w: %s is larger than %d bytes", ErrInvalidArchive, f.Name, MaxFileBytes)
+	}
+	return raw, nil
+}
+
+func writeMarkdown(w io.Writer, entry Entry) error {
+	meta, err := yaml.Marshal(frontMatter{
+		Title:   entry.Title,
+		Slug:    entry.Slug,
+		Status:  entry.Status,
+		Date:    entry.PublishedAt,
+		Created: entry.CreatedAt.UTC(),
+		Updated: entry.UpdatedAt.UTC(),
+		Tags:    entry.Tags,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "---\n%s---\n\n%s", meta, entry.Content)
+	return err
+}
+
+// ParseMarkdown splits a file into YAML front matter and body. The body is
+// returned exactly as written after the blank line that follows the front
+// matter.
+func ParseMarkdown(raw []byte) (Entry, error) {
+	text := strings.ReplaceAll(string(raw), "\r\n", "\n")
+	if !strings.HasPrefix(text, "---\n") {
+		return Entry{}, errors.New("front matter must start with ---")
+	}
+	rest := text[len("---\n"):]
+	end := strings.Index(rest, "\n---\n")
+	var meta, body string
+	switch {
+	case strings.HasPrefix(rest, "---\n"):
+		body = rest[len("---\n"):]
+	case end >= 0:
+		meta, body = rest[:end+1], rest[end+len("\n---\n"):]
+	case strings.HasSuffix(rest, "\n---"):
+		meta = rest[:len(rest)-len("---")]
+	default:
+		return Entry{}, errors.New("front matter is not closed with ---")
+	}
+
+	var fm frontMatter
+	if err := yaml.Unmarshal([]byte(meta), &fm); err != nil {
+		return Entry{}, fmt.Errorf("front matter: %w", err)
+	}
+	return Entry{
+		Title:       fm.Title,
+		Slug:        fm.Slug,
+		Status:      fm.Status,
+		Tags:        fm.Tags,
+		Content:     strings.TrimPrefix(body, "\n"),
+		PublishedAt: fm.Date,
+		CreatedAt:   fm.Created,
+		UpdatedAt:   fm.Updated,
+	}, nil
+}
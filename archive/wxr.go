@@ -0,0 +1,110 @@
+package archive
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"go-blog/models"
+	"io"
+	"strings"
+	"time"
+)
+
+// wxrDate is the layout of wp:post_date_gmt.
+const wxrDate = "2006-01-02 15:04:05"
+
+// wxrItem picks the fields we need from a WXR <item>. WordPress has used
+// several wp: namespace URIs over the years, so those fields match on the
+// local name only.
+type wxrItem struct {
+	Title      string        `xml:"title"`
+	Content    string        `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	PubDate    string        `xml:"pubDate"`
+	PostName   string        `xml:"post_name"`
+	PostType   string        `xml:"post_type"`
+	Status     string        `xml:"status"`
+	PostDate   string        `xml:"post_date_gmt"`
+	Modified   string        `xml:"post_modified_gmt"`
+	Categories []wxrCategory `xml:"category"`
+}
+
+type wxrCategory struct {
+	Domain   string `xml:"domain,attr"`
+	Nicename string `xml:"nicename,attr"`
+	Name     string `xml:",chardata"`
+}
+
+type wxrDocument struct {
+	XMLName xml.Name  `xml:"rss"`
+	Items   []wxrItem `xml:"channel>item"`
+}
+
+// ReadWXR parses a WordPress export. Only items of type "post" are
+// returned; pages, attachments, menu items and trashed or auto-draft posts
+// are left out. Published posts stay published and every other status
+// (draft, pending, private, future) becomes a draft. WordPress tags become
+// tags; categories are not carried over.
+func ReadWXR(data []byte) ([]Entry, error) {
+	var doc wxrDocument
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	// WXR files routinely declare charsets other than UTF-8 that are in
+	// practice UTF-8; take the bytes as they are.
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) { return input, nil }
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+
+	entries := []Entry{}
+	for _, item := range doc.Items {
+		if item.PostType != "" && item.PostType != "post" {
+			continue
+		}
+		status := models.PostStatusDraft
+		switch item.Status {
+		case "publish":
+			status = models.PostStatusPublished
+		case "trash", "auto-draft", "inherit":
+			continue
+		}
+
+		entry := Entry{
+			Title:   strings.TrimSpace(item.Title),
+			Slug:    item.PostName,
+			Status:  status,
+			Content: item.Content,
+			Tags:    []string{},
+		}
+		for _, category := range item.Categories {
+			if category.Domain == "post_tag" {
+				entry.Tags = append(entry.Tags, strings.TrimSpace(category.Name))
+			}
+		}
+
+		created, ok := parseWXRDate(item.PostDate, item.PubDate)
+		if ok {
+			entry.CreatedAt = created
+			if status == models.PostStatusPublished {
+				entry.PublishedAt = &created
+			}
+		}
+		if modified, ok := parseWXRDate(item.Modified, ""); ok {
+			entry.UpdatedAt = modified
+		} else {
+			entry.UpdatedAt = entry.CreatedAt
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// parseWXRDate prefers the GMT post date and falls back to the RSS pubDate.
+// Drafts carry "0000-00-00 00:00:00", which counts as missing.
+func parseWXRDate(gmt, pubDate string) (time.Time, bool) {
+	if t, err := time.Parse(wxrDate, strings.TrimSpace(gmt)); err == nil && t.Year() > 1 {
+		return t.UTC(), true
+	}
+	if t, err := time.Parse(time.RFC1123Z, strings.TrimSpace(pubDate)); err == nil {
+		return t.UTC(), true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,38 @@
+// Package cache holds byte-oriented caches for read paths. Values are
+// stored encoded, so a cached value can never be changed by the code that
+// read it, and a Store shared between instances (Redis, memcached) can be
+// dropped in behind the same interface as the in-process LRU.
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	BackendMemory = "memory"
+	BackendNone   = "none"
+)
+
+// Store is a key-value cache. Get reports a miss with ok false; errors are
+// for a store that could not be reached, and callers treat them as misses.
+// Implementations must be safe for concurrent use.
+type Store interface {
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// New returns the store for backend, or nil for BackendNone, which turns
+// caching off.
+func New(backend string, size int) (Store, error) {
+	switch backend {
+	case BackendMemory:
+		return NewLRU(size), nil
+	case BackendNone:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown cache backend %q", backend)
+	}
+}
@@ -0,0 +1,533 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const redacted = "********"
+
+type Config struct {
+	Server    ServerConfig
+	Database  DatabaseConfig
+	Auth      AuthConfig
+	Metrics   MetricsConfig
+	Admin     AdminConfig
+	Log       LogConfig
+	Tracing   TracingConfig
+	Reactions ReactionsConfig
+	Site      SiteConfig
+	Media     MediaConfig
+	Import    ImportConfig
+	Jobs      JobsConfig
+	Webhooks  WebhooksConfig
+	Mail      MailConfig
+	Digests   DigestsConfig
+	Cache     CacheConfig
+	GraphQL   GraphQLConfig
+	GRPC      GRPCConfig
+}
+
+type ServerConfig struct {
+	Addr              string
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	ShutdownTimeout   time.Duration
+	// CompressMinSize is the smallest response body worth compressing; 0
+	// turns compression off.
+	CompressMinSize int
+	// ValidateOpenAPI checks every request and response against the
+	// OpenAPI document. It buffers all responses, so it is meant for
+	// development and tests.
+	ValidateOpenAPI bool
+}
+
+type DatabaseConfig struct {
+	Type        string
+	DSN         string
+	Host        string
+	User        string
+	Password    string
+	Name        string
+	Port        string
+	SSLMode     string
+	Path        string
+	AutoMigrate bool
+}
+
+type AuthConfig struct {
+	JWTSecret string
+	TokenTTL  time.Duration
+}
+
+type MetricsConfig struct {
+	Enabled bool
+}
+
+// AdminConfig controls operational endpoints such as /metrics. When Addr is
+// set they are served on that separate listener instead of the public one,
+// and when Token is set they require "Authorization: Bearer <token>".
+type AdminConfig struct {
+	Addr  string
+	Token string
+}
+
+type LogConfig struct {
+	Level              string
+	Format             string
+	SlowQueryThreshold time.Duration
+	LogSQLParams       bool
+}
+
+type TracingConfig struct {
+	Exporter     string
+	OTLPEndpoint string
+	ServiceName  string
+	SampleRatio  float64
+}
+
+// ReactionsConfig controls the background job that recomputes the
+// denormalised reaction counters on posts. A zero interval disables it.
+type ReactionsConfig struct {
+	ReconcileInterval time.Duration
+}
+
+// SiteConfig describes the public site that links in feeds and sitemaps
+// point at.
+type SiteConfig struct {
+	BaseURL          string
+	Title            string
+	Description      string
+	FeedItems        int
+	SitemapChunkSize int
+	// RobotsDisallow is a comma-separated list of path prefixes that
+	// robots.txt asks crawlers to skip.
+	RobotsDisallow string
+}
+
+// URL joins path onto BaseURL.
+func (c SiteConfig) URL(path string) string {
+	return strings.TrimRight(c.BaseURL, "/") + "/" + strings.TrimLeft(path, "/")
+}
+
+// MediaConfig selects where uploaded files are kept. Backend is "local",
+// which writes under LocalDir, or "s3", which talks to any S3-compatible
+// endpoint.
+type MediaConfig struct {
+	Backend        string
+	LocalDir       string
+	MaxUploadBytes int
+	ThumbnailSize  int
+	S3Endpoint     string
+	S3Bucket       string
+	S3Region       string
+	S3AccessKey    string
+	S3SecretKey    string
+	S3UseSSL       bool
+}
+
+// ImportConfig bounds blog imports, which run on the job queue.
+type ImportConfig struct {
+	MaxBytes int
+}
+
+// JobsConfig tunes the background job queue. Concurrency is the number of
+// jobs this process runs at once; zero disables the worker pool, so jobs
+// are only enqueued. A failed job is retried after RetryBackoff, doubling
+// up to MaxRetryBackoff, until it has been attempted MaxAttempts times.
+// Timeout bounds a single attempt; a job locked for longer than that is
+// assumed to belong to a crashed worker and is queued again.
+type JobsConfig struct {
+	Concurrency     int
+	PollInterval    time.Duration
+	MaxAttempts     int
+	RetryBackoff    time.Duration
+	MaxRetryBackoff time.Duration
+	Timeout         time.Duration
+}
+
+// WebhooksConfig controls outgoing webhook deliveries. Each delivery is
+// attempted up to MaxAttempts times on the job queue, and every request
+// gives up after Timeout. Endpoints on loopback, private and link-local
+// addresses are refused unless AllowPrivateNetworks is set, so users cannot
+// point webhooks at internal services.
+type WebhooksConfig struct {
+	Timeout              time.Duration
+	MaxAttempts          int
+	AllowPrivateNetworks bool
+}
+
+// MailConfig selects how email is sent. Backend "log" only logs messages,
+// "smtp" relays them through SMTPHost and "memory" keeps them for tests.
+type MailConfig struct {
+	Backend      string
+	From         string
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+}
+
+// DigestsConfig controls email digests. Every Interval the scheduler queues
+// the digests that are due; zero disables it. A digest lists at most
+// MaxPosts posts.
+type DigestsConfig struct {
+	Interval time.Duration
+	MaxPosts int
+}
+
+// CacheConfig controls the post read cache. Backend "memory" keeps up to
+// Size entries in process for TTL each; "none" turns the cache off. MaxAge
+// is the Cache-Control max-age of anonymous post responses; at zero,
+// clients revalidate every time with the ETag.
+type CacheConfig struct {
+	Backend string
+	Size    int
+	TTL     time.Duration
+	MaxAge  time.Duration
+}
+
+// GraphQLConfig bounds the queries /graphql accepts. MaxDepth is the
+// deepest field nesting; MaxComplexity caps the estimated number of fields
+// resolved, where each list multiplies its fields by the page size asked
+// for.
+type GraphQLConfig struct {
+	MaxDepth      int
+	MaxComplexity int
+}
+
+// GRPCConfig controls the gRPC API for internal services, served on its own
+// listener. An empty Addr disables it.
+type GRPCConfig struct {
+	Addr string
+}
+
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Addr:              ":8080",
+			ReadTimeout:       15 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       60 * time.Second,
+			ShutdownTimeout:   20 * time.Second,
+			CompressMinSize:   1024,
+		},
+		Database: DatabaseConfig{
+			Type:        "postgres",
+			Host:        "localhost",
+			User:        "postgres",
+			Name:        "blogdb",
+			Port:        "5432",
+			SSLMode:     "disable",
+			Path:        "blog.db",
+			AutoMigrate: true,
+		},
+		Auth: AuthConfig{
+			TokenTTL: 24 * time.Hour,
+		},
+		Metrics: MetricsConfig{
+			Enabled: true,
+		},
+		Log: LogConfig{
+			Level:              "info",
+			Format:             "json",
+			SlowQueryThreshold: 200 * time.Millisecond,
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			ServiceName: "go-blog",
+			SampleRatio: 1,
+		},
+		Reactions: ReactionsConfig{
+			ReconcileInterval: time.Hour,
+		},
+		Site: SiteConfig{
+			BaseURL:          "http://localhost:8080",
+			Title:            "go-blog",
+			Description:      "Latest posts",
+			FeedItems:        20,
+			SitemapChunkSize: 50000,
+			RobotsDisallow:   "/api/",
+		},
+		Media: MediaConfig{
+			Backend:        "local",
+			LocalDir:       "media",
+			MaxUploadBytes: 10 << 20,
+			ThumbnailSize:  320,
+			S3Region:       "us-east-1",
+			S3UseSSL:       true,
+		},
+		Import: ImportConfig{
+			MaxBytes: 32 << 20,
+		},
+		Jobs: JobsConfig{
+			Concurrency:     4,
+			PollInterval:    time.Second,
+			MaxAttempts:     10,
+			RetryBackoff:    10 * time.Second,
+			MaxRetryBackoff: time.Hour,
+			Timeout:         15 * time.Minute,
+		},
+		Webhooks: WebhooksConfig{
+			Timeout:     10 * time.Second,
+			MaxAttempts: 8,
+		},
+		Mail: MailConfig{
+			Backend:  "log",
+			From:     "go-blog <no-reply@localhost>",
+			SMTPPort: "587",
+		},
+		Digests: DigestsConfig{
+			Interval: 15 * time.Minute,
+			MaxPosts: 30,
+		},
+		Cache: CacheConfig{
+			Backend: "memory",
+			Size:    1000,
+			TTL:     time.Minute,
+		},
+		GraphQL: GraphQLConfig{
+			MaxDepth:      8,
+			MaxComplexity: 1000,
+		},
+		GRPC: GRPCConfig{
+			Addr: ":9090",
+		},
+	}
+}
+
+func (c Config) Validate() error {
+	var errs []error
+	if strings.TrimSpace(c.Server.Addr) == "" {
+		errs = append(errs, errors.New("server.addr is required"))
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		errs = append(errs, errors.New("server.shutdown_timeout must be positive"))
+	}
+	if c.Server.CompressMinSize < 0 {
+		errs = append(errs, errors.New("server.compress_min_size must not be negative"))
+	}
+	if err := c.Database.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := c.Auth.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := c.Log.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := c.Tracing.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if c.Reactions.ReconcileInterval < 0 {
+		errs = append(errs, errors.New("reactions.reconcile_interval must not be negative"))
+	}
+	if err := c.Site.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := c.Media.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if c.Import.MaxBytes < 1 {
+		errs = append(errs, errors.New("import.max_bytes must be positive"))
+	}
+	if err := c.Jobs.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if c.Webhooks.Timeout <= 0 {
+		errs = append(errs, errors.New("webhooks.timeout must be positive"))
+	}
+	if c.Webhooks.MaxAttempts < 1 {
+		errs = append(errs, errors.New("webhooks.max_attempts must be at least 1"))
+	}
+	if err := c.Mail.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if c.Digests.Interval < 0 {
+		errs = append(errs, errors.New("digests.interval must not be negative"))
+	}
+	if c.Digests.MaxPosts < 1 {
+		errs = append(errs, errors.New("digests.max_posts must be at least 1"))
+	}
+	if err := c.Cache.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if c.GraphQL.MaxDepth < 1 {
+		errs = append(errs, errors.New("graphql.max_depth must be at least 1"))
+	}
+	if c.GraphQL.MaxComplexity < 1 {
+		errs = append(errs, errors.New("graphql.max_complexity must be at least 1"))
+	}
+	return errors.Join(errs...)
+}
+
+func (c DatabaseConfig) Validate() error {
+	switch c.Type {
+	case "postgres":
+		if c.DSN == "" && c.Host == "" {
+			return errors.New("database.dsn or database.host is required for postgres")
+		}
+	case "sqlite":
+		if c.Path == "" && c.DSN == "" {
+			return errors.New("database.path is required for sqlite")
+		}
+	default:
+		return fmt.Errorf("database.type must be postgres or sqlite, got %q", c.Type)
+	}
+	return nil
+}
+
+func (c AuthConfig) Validate() error {
+	var errs []error
+	if strings.TrimSpace(c.JWTSecret) == "" {
+		errs = append(errs, errors.New("auth.jwt_secret is required (set JWT_SECRET)"))
+	}
+	if c.TokenTTL <= 0 {
+		errs = append(errs, errors.New("auth.token_ttl must be positive"))
+	}
+	return errors.Join(errs...)
+}
+
+func (c LogConfig) Validate() error {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Level)); err != nil {
+		return fmt.Errorf("log.level must be debug, info, warn or error, got %q", c.Level)
+	}
+	if c.Format != "json" && c.Format != "text" {
+		return fmt.Errorf("log.format must be json or text, got %q", c.Format)
+	}
+	return nil
+}
+
+func (c TracingConfig) Validate() error {
+	switch c.Exporter {
+	case "none", "stdout", "otlp", "memory":
+	default:
+		return fmt.Errorf("tracing.exporter must be none, stdout, otlp or memory, got %q", c.Exporter)
+	}
+	if c.SampleRatio < 0 || c.SampleRatio > 1 {
+		return fmt.Errorf("tracing.sample_ratio must be between 0 and 1, got %v", c.SampleRatio)
+	}
+	return nil
+}
+
+func (c SiteConfig) Validate() error {
+	u, err := url.Parse(c.BaseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("site.base_url must be an absolute http(s) URL, got %q", c.BaseURL)
+	}
+	if c.FeedItems < 1 {
+		return errors.New("site.feed_items must be at least 1")
+	}
+	if c.SitemapChunkSize < 1 || c.SitemapChunkSize > 50000 {
+		return fmt.Errorf("site.sitemap_chunk_size must be between 1 and 50000, got %d", c.SitemapChunkSize)
+	}
+	return nil
+}
+
+func (c MediaConfig) Validate() error {
+	var errs []error
+	switch c.Backend {
+	case "local":
+		if strings.TrimSpace(c.LocalDir) == "" {
+			errs = append(errs, errors.New("media.local_dir is required for the local backend"))
+		}
+	case "s3":
+		if c.S3Endpoint == "" || c.S3Bucket == "" {
+			errs = append(errs, errors.New("media.s3_endpoint and media.s3_bucket are required for the s3 backend"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("media.backend must be local or s3, got %q", c.Backend))
+	}
+	if c.MaxUploadBytes < 1 {
+		errs = append(errs, errors.New("media.max_upload_bytes must be positive"))
+	}
+	if c.ThumbnailSize < 16 {
+		errs = append(errs, errors.New("media.thumbnail_size must be at least 16"))
+	}
+	return errors.Join(errs...)
+}
+
+func (c MailConfig) Validate() error {
+	var errs []error
+	switch c.Backend {
+	case "log", "memory":
+	case "smtp":
+		if c.SMTPHost == "" || c.SMTPPort == "" {
+			errs = append(errs, errors.New("mail.smtp_host and mail.smtp_port are required for the smtp backend"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("mail.backend must be log, smtp or memory, got %q", c.Backend))
+	}
+	if _, err := mail.ParseAddress(c.From); err != nil {
+		errs = append(errs, fmt.Errorf("mail.from must be an email address, got %q", c.From))
+	}
+	return errors.Join(errs...)
+}
+
+func (c CacheConfig) Validate() error {
+	var errs []error
+	if c.Backend != "memory" && c.Backend != "none" {
+		errs = append(errs, fmt.Errorf("cache.backend must be memory or none, got %q", c.Backend))
+	}
+	if c.Size < 1 {
+		errs = append(errs, errors.New("cache.size must be at least 1"))
+	}
+	if c.TTL < 0 {
+		errs = append(errs, errors.New("cache.ttl must not be negative"))
+	}
+	if c.MaxAge < 0 {
+		errs = append(errs, errors.New("cache.max_age must not be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+func (c JobsConfig) Validate() error {
+	var errs []error
+	if c.Concurrency < 0 {
+		errs = append(errs, errors.New("jobs.concurrency must not be negative"))
+	}
+	if c.PollInterval <= 0 {
+		errs = append(errs, errors.New("jobs.poll_interval must be positive"))
+	}
+	if c.MaxAttempts < 1 {
+		errs = append(errs, errors.New("jobs.max_attempts must be at least 1"))
+	}
+	if c.RetryBackoff <= 0 || c.MaxRetryBackoff < c.RetryBackoff {
+		errs = append(errs, errors.New("jobs.retry_backoff must be positive and no larger than jobs.max_retry_backoff"))
+	}
+	if c.Timeout <= 0 {
+		errs = append(errs, errors.New("jobs.timeout must be positive"))
+	}
+	return errors.Join(errs...)
+}
+
+// Redacted returns a copy that is safe to print or log.
+func (c Config) Redacted() Config {
+	out := c
+	if out.Database.Password != "" {
+		out.Database.Password = redacted
+	}
+	out.Database.DSN = redactDSN(out.Database.DSN)
+	if out.Auth.JWTSecret != "" {
+		out.Auth.JWTSecret = redacted
+	}
+	if out.Admin.Token != "" {
+		out.Admin.Token = redacted
+	}
+	if out.Media.S3SecretKey != "" {
+		out.Media.S3SecretKey = redacted
+	}
+	if out.Mail.SMTPPassword != "" {
+		out.Mail.SMTPPassword = redacted
+	}
+	return out
+}
@@ -0,0 +1,315 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// field binds one setting to its file key, environment variable and flag.
+// Every source goes through the same setter so values parse identically no
+// matter where they come from.
+type field struct {
+	key   string
+	env   string
+	flag  string
+	usage string
+	ptr   func(c *Config) any
+}
+
+var fields = []field{
+	{"server.addr", "SERVER_ADDR", "addr", "address the HTTP server listens on", func(c *Config) any { return &c.Server.Addr }},
+	{"server.read_timeout", "SERVER_READ_TIMEOUT", "read-timeout", "maximum duration for reading a whole request", func(c *Config) any { return &c.Server.ReadTimeout }},
+	{"server.read_header_timeout", "SERVER_READ_HEADER_TIMEOUT", "read-header-timeout", "maximum duration for reading request headers", func(c *Config) any { return &c.Server.ReadHeaderTimeout }},
+	{"server.write_timeout", "SERVER_WRITE_TIMEOUT", "write-timeout", "maximum duration before timing out a response write", func(c *Config) any { return &c.Server.WriteTimeout }},
+	{"server.idle_timeout", "SERVER_IDLE_TIMEOUT", "idle-timeout", "how long keep-alive connections may stay idle", func(c *Config) any { return &c.Server.IdleTimeout }},
+	{"server.shutdown_timeout", "SERVER_SHUTDOWN_TIMEOUT", "shutdown-timeout", "how long to wait for in-flight work on shutdown", func(c *Config) any { return &c.Server.ShutdownTimeout }},
+	{"server.compress_min_size", "SERVER_COMPRESS_MIN_SIZE", "compress-min-size", "smallest response compressed with gzip or brotli; 0 disables compression", func(c *Config) any { return &c.Server.CompressMinSize }},
+	{"server.validate_openapi", "SERVER_VALIDATE_OPENAPI", "validate-openapi", "check requests and responses against the OpenAPI document (development only)", func(c *Config) any { return &c.Server.ValidateOpenAPI }},
+	{"database.type", "DB_TYPE", "db-type", "database driver: postgres or sqlite", func(c *Config) any { return &c.Database.Type }},
+	{"database.dsn", "DB_DSN", "db-dsn", "full database DSN; overrides the individual postgres settings", func(c *Config) any { return &c.Database.DSN }},
+	{"database.host", "DB_HOST", "db-host", "postgres host", func(c *Config) any { return &c.Database.Host }},
+	{"database.user", "DB_USER", "db-user", "postgres user", func(c *Config) any { return &c.Database.User }},
+	{"database.password", "DB_PASS", "db-password", "postgres password", func(c *Config) any { return &c.Database.Password }},
+	{"database.name", "DB_NAME", "db-name", "postgres database name", func(c *Config) any { return &c.Database.Name }},
+	{"database.port", "DB_PORT", "db-port", "postgres port", func(c *Config) any { return &c.Database.Port }},
+	{"database.sslmode", "DB_SSLMODE", "db-sslmode", "postgres sslmode", func(c *Config) any { return &c.Database.SSLMode }},
+	{"database.path", "DB_PATH", "db-path", "sqlite database file, or :memory:", func(c *Config) any { return &c.Database.Path }},
+	{"database.auto_migrate", "DB_AUTO_MIGRATE", "db-auto-migrate", "apply pending migrations at startup", func(c *Config) any { return &c.Database.AutoMigrate }},
+	{"auth.jwt_secret", "JWT_SECRET", "jwt-secret", "HMAC secret used to sign access tokens", func(c *Config) any { return &c.Auth.JWTSecret }},
+	{"auth.token_ttl", "JWT_TOKEN_TTL", "jwt-token-ttl", "lifetime of issued access tokens", func(c *Config) any { return &c.Auth.TokenTTL }},
+	{"metrics.enabled", "METRICS_ENABLED", "metrics-enabled", "expose Prometheus metrics at /metrics", func(c *Config) any { return &c.Metrics.Enabled }},
+	{"admin.addr", "ADMIN_ADDR", "admin-addr", "separate listen address for admin endpoints such as /metrics", func(c *Config) any { return &c.Admin.Addr }},
+	{"admin.token", "ADMIN_TOKEN", "admin-token", "bearer token required by admin endpoints", func(c *Config) any { return &c.Admin.Token }},
+	{"log.level", "LOG_LEVEL", "log-level", "minimum log level: debug, info, warn or error", func(c *Config) any { return &c.Log.Level }},
+	{"log.format", "LOG_FORMAT", "log-format", "log output format: json or text", func(c *Config) any { return &c.Log.Format }},
+	{"log.slow_query_threshold", "LOG_SLOW_QUERY_THRESHOLD", "log-slow-query-threshold", "SQL statements slower than this are logged as warnings; 0 disables", func(c *Config) any { return &c.Log.SlowQueryThreshold }},
+	{"log.sql_params", "LOG_SQL_PARAMS", "log-sql-params", "include bound SQL parameter values in logs", func(c *Config) any { return &c.Log.LogSQLParams }},
+	{"tracing.exporter", "TRACING_EXPORTER", "tracing-exporter", "span exporter: none, stdout, otlp or memory", func(c *Config) any { return &c.Tracing.Exporter }},
+	{"tracing.otlp_endpoint", "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "tracing-otlp-endpoint", "OTLP/HTTP traces endpoint URL", func(c *Config) any { return &c.Tracing.OTLPEndpoint }},
+	{"tracing.service_name", "OTEL_SERVICE_NAME", "tracing-service-name", "service.name resource attribute", func(c *Config) any { return &c.Tracing.ServiceName }},
+	{"tracing.sample_ratio", "TRACING_SAMPLE_RATIO", "tracing-sample-ratio", "fraction of new traces to sample, between 0 and 1", func(c *Config) any { return &c.Tracing.SampleRatio }},
+	{"reactions.reconcile_interval", "REACTIONS_RECONCILE_INTERVAL", "reactions-reconcile-interval", "how often reaction counters are recomputed; 0 disables", func(c *Config) any { return &c.Reactions.ReconcileInterval }},
+	{"site.base_url", "SITE_BASE_URL", "site-base-url", "public URL of the site, used for links in feeds and sitemaps", func(c *Config) any { return &c.Site.BaseURL }},
+	{"site.title", "SITE_TITLE", "site-title", "site title used in feeds", func(c *Config) any { return &c.Site.Title }},
+	{"site.description", "SITE_DESCRIPTION", "site-description", "site description used in feeds", func(c *Config) any { return &c.Site.Description }},
+	{"site.feed_items", "SITE_FEED_ITEMS", "site-feed-items", "number of posts in RSS and Atom feeds", func(c *Config) any { return &c.Site.FeedItems }},
+	{"site.sitemap_chunk_size", "SITE_SITEMAP_CHUNK_SIZE", "site-sitemap-chunk-size", "URLs per sitemap file before switching to a sitemap index", func(c *Config) any { return &c.Site.SitemapChunkSize }},
+	{"site.robots_disallow", "SITE_ROBOTS_DISALLOW", "site-robots-disallow", "comma-separated path prefixes disallowed in robots.txt", func(c *Config) any { return &c.Site.RobotsDisallow }},
+	{"media.backend", "MEDIA_BACKEND", "media-backend", "where uploads are stored: local or s3", func(c *Config) any { return &c.Media.Backend }},
+	{"media.local_dir", "MEDIA_LOCAL_DIR", "media-local-dir", "directory for uploads when media.backend is local", func(c *Config) any { return &c.Media.LocalDir }},
+	{"media.max_upload_bytes", "MEDIA_MAX_UPLOAD_BYTES", "media-max-upload-bytes", "largest accepted upload in bytes", func(c *Config) any { return &c.Media.MaxUploadBytes }},
+	{"media.thumbnail_size", "MEDIA_THUMBNAIL_SIZE", "media-thumbnail-size", "longest edge of generated thumbnails in pixels", func(c *Config) any { return &c.Media.ThumbnailSize }},
+	{"media.s3_endpoint", "MEDIA_S3_ENDPOINT", "media-s3-endpoint", "host[:port] of the S3-compatible endpoint", func(c *Config) any { return &c.Media.S3Endpoint }},
+	{"media.s3_bucket", "MEDIA_S3_BUCKET", "media-s3-bucket", "bucket that holds uploads", func(c *Config) any { return &c.Media.S3Bucket }},
+	{"media.s3_region", "MEDIA_S3_REGION", "media-s3-region", "S3 region", func(c *Config) any { return &c.Media.S3Region }},
+	{"media.s3_access_key", "MEDIA_S3_ACCESS_KEY", "media-s3-access-key", "S3 access key ID", func(c *Config) any { return &c.Media.S3AccessKey }},
+	{"media.s3_secret_key", "MEDIA_S3_SECRET_KEY", "media-s3-secret-key", "S3 secret access key", func(c *Config) any { return &c.Media.S3SecretKey }},
+	{"media.s3_use_ssl", "MEDIA_S3_USE_SSL", "media-s3-use-ssl", "connect to the S3 endpoint over HTTPS", func(c *Config) any { return &c.Media.S3UseSSL }},
+	{"import.max_bytes", "IMPORT_MAX_BYTES", "import-max-bytes", "largest accepted import file in bytes", func(c *Config) any { return &c.Import.MaxBytes }},
+	{"jobs.concurrency", "JOBS_CONCURRENCY", "jobs-concurrency", "background jobs run at once by this process; 0 disables the worker pool", func(c *Config) any { return &c.Jobs.Concurrency }},
+	{"jobs.poll_interval", "JOBS_POLL_INTERVAL", "jobs-poll-interval", "how often idle workers look for due jobs", func(c *Config) any { return &c.Jobs.PollInterval }},
+	{"jobs.max_attempts", "JOBS_MAX_ATTEMPTS", "jobs-max-attempts", "attempts before a failing job is dead-lettered", func(c *Config) any { return &c.Jobs.MaxAttempts }},
+	{"jobs.retry_backoff", "JOBS_RETRY_BACKOFF", "jobs-retry-backoff", "delay before the first retry; doubles on every further failure", func(c *Config) any { return &c.Jobs.RetryBackoff }},
+	{"jobs.max_retry_backoff", "JOBS_MAX_RETRY_BACKOFF", "jobs-max-retry-backoff", "longest delay between retries", func(c *Config) any { return &c.Jobs.MaxRetryBackoff }},
+	{"jobs.timeout", "JOBS_TIMEOUT", "jobs-timeout", "longest a single job attempt may run", func(c *Config) any { return &c.Jobs.Timeout }},
+	{"webhooks.timeout", "WEBHOOKS_TIMEOUT", "webhooks-timeout", "how long a webhook endpoint has to respond", func(c *Config) any { return &c.Webhooks.Timeout }},
+	{"webhooks.max_attempts", "WEBHOOKS_MAX_ATTEMPTS", "webhooks-max-attempts", "attempts before a webhook delivery is given up", func(c *Config) any { return &c.Webhooks.MaxAttempts }},
+	{"webhooks.allow_private_networks", "WEBHOOKS_ALLOW_PRIVATE_NETWORKS", "webhooks-allow-private-networks", "allow webhooks to loopback and private addresses", func(c *Config) any { return &c.Webhooks.AllowPrivateNetworks }},
+	{"mail.backend", "MAIL_BACKEND", "mail-backend", "how email is sent: log, smtp or memory", func(c *Config) any { return &c.Mail.Backend }},
+	{"mail.from", "MAIL_FROM", "mail-from", "sender address of outgoing email", func(c *Config) any { return &c.Mail.From }},
+	{"mail.smtp_host", "SMTP_HOST", "smtp-host", "SMTP relay host", func(c *Config) any { return &c.Mail.SMTPHost }},
+	{"mail.smtp_port", "SMTP_PORT", "smtp-port", "SMTP relay port", func(c *Config) any { return &c.Mail.SMTPPort }},
+	{"mail.smtp_username", "SMTP_USERNAME", "smtp-username", "SMTP user; leave empty to send without authenticating", func(c *Config) any { return &c.Mail.SMTPUsername }},
+	{"mail.smtp_password", "SMTP_PASSWORD", "smtp-password", "SMTP password", func(c *Config) any { return &c.Mail.SMTPPassword }},
+	{"digests.interval", "DIGESTS_INTERVAL", "digests-interval", "how often due email digests are queued; 0 disables them", func(c *Config) any { return &c.Digests.Interval }},
+	{"digests.max_posts", "DIGESTS_MAX_POSTS", "digests-max-posts", "most posts listed in one digest", func(c *Config) any { return &c.Digests.MaxPosts }},
+	{"cache.backend", "CACHE_BACKEND", "cache-backend", "post read cache: memory or none", func(c *Config) any { return &c.Cache.Backend }},
+	{"cache.size", "CACHE_SIZE", "cache-size", "most entries in the in-process read cache", func(c *Config) any { return &c.Cache.Size }},
+	{"cache.ttl", "CACHE_TTL", "cache-ttl", "how long a cached read is served", func(c *Config) any { return &c.Cache.TTL }},
+	{"cache.max_age", "CACHE_MAX_AGE", "cache-max-age", "Cache-Control max-age of anonymous post responses", func(c *Config) any { return &c.Cache.MaxAge }},
+	{"graphql.max_depth", "GRAPHQL_MAX_DEPTH", "graphql-max-depth", "deepest field nesting a GraphQL query may use", func(c *Config) any { return &c.GraphQL.MaxDepth }},
+	{"graphql.max_complexity", "GRAPHQL_MAX_COMPLEXITY", "graphql-max-complexity", "highest estimated cost of a GraphQL query", func(c *Config) any { return &c.GraphQL.MaxComplexity }},
+	{"grpc.addr", "GRPC_ADDR", "grpc-addr", "listen address for the gRPC API; empty disables it", func(c *Config) any { return &c.GRPC.Addr }},
+}
+
+type Options struct {
+	PrintConfig bool
+	Args        []string
+}
+
+// Load builds the configuration from defaults, an optional YAML or TOML
+// file, environment variables and command-line flags, in increasing order
+// of precedence. The file is chosen with -config or CONFIG_FILE.
+func Load(args []string) (Config, Options, error) {
+	cfg := Default()
+	var opts Options
+
+	flags := flag.NewFlagSet("go-blog", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	configFile := flags.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML or TOML config file")
+	flags.BoolVar(&opts.PrintConfig, "print-config", false, "print the effective configuration with secrets redacted and exit")
+	flagValues := map[string]*string{}
+	for _, f := range fields {
+		flagValues[f.flag] = flags.String(f.flag, "", f.usage+" (env "+f.env+")")
+	}
+	if err := flags.Parse(args); err != nil {
+		flags.SetOutput(os.Stderr)
+		if err == flag.ErrHelp {
+			flags.PrintDefaults()
+		}
+		return cfg, opts, err
+	}
+	opts.Args = flags.Args()
+
+	if *configFile != "" {
+		if err := loadFile(&cfg, *configFile); err != nil {
+			return cfg, opts, err
+		}
+	}
+
+	for _, f := range fields {
+		if value, ok := os.LookupEnv(f.env); ok && value != "" {
+			if err := set(&cfg, f, value); err != nil {
+				return cfg, opts, fmt.Errorf("env %s: %w", f.env, err)
+			}
+		}
+	}
+
+	var flagErr error
+	flags.Visit(func(fl *flag.Flag) {
+		for _, f := range fields {
+			if f.flag == fl.Name && flagErr == nil {
+				if err := set(&cfg, f, *flagValues[f.flag]); err != nil {
+					flagErr = fmt.Errorf("flag -%s: %w", f.flag, err)
+				}
+			}
+		}
+	})
+	return cfg, opts, flagErr
+}
+
+func loadFile(cfg *Config, path string) error {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+
+	raw := map[string]any{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(contents, &raw)
+	case ".toml":
+		err = toml.Unmarshal(contents, &raw)
+	default:
+		return fmt.Errorf("config file %s must be .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+
+	values := map[string]string{}
+	if err := flatten("", raw, values); err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		f, ok := fieldByKey(key)
+		if !ok {
+			return fmt.Errorf("config file %s: unknown setting %q", path, key)
+		}
+		if err := set(cfg, f, values[key]); err != nil {
+			return fmt.Errorf("config file %s: %s: %w", path, key, err)
+		}
+	}
+	return nil
+}
+
+// flatten turns nested tables into dotted keys. A list becomes the
+// comma-separated form the environment and flags use for the same setting.
+func flatten(prefix string, raw map[string]any, out map[string]string) error {
+	for key, value := range raw {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		switch value := value.(type) {
+		case map[string]any:
+			if err := flatten(key, value, out); err != nil {
+				return err
+			}
+		case []any:
+			items := make([]string, len(value))
+			for i, item := range value {
+				switch item.(type) {
+				case map[string]any, []any:
+					return fmt.Errorf("%s: lists may only hold plain values", key)
+				}
+				items[i] = fmt.Sprint(item)
+			}
+			out[key] = strings.Join(items, ",")
+		default:
+			out[key] = fmt.Sprint(value)
+		}
+	}
+	return nil
+}
+
+func fieldByKey(key string) (field, bool) {
+	for _, f := range fields {
+		if f.key == key {
+			return f, true
+		}
+	}
+	return field{}, false
+}
+
+func set(cfg *Config, f field, value string) error {
+	switch ptr := f.ptr(cfg).(type) {
+	case *string:
+		*ptr = value
+	case *int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		*ptr = n
+	case *float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		*ptr = f
+	case *bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		*ptr = b
+	case *time.Duration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		*ptr = d
+	default:
+		return fmt.Errorf("unsupported config type %T", ptr)
+	}
+	return nil
+}
+
+// String renders one "key = value" line per setting with secrets redacted.
+func (c Config) String() string {
+	safe := c.Redacted()
+	var b strings.Builder
+	for _, f := range fields {
+		fmt.Fprintf(&b, "%s = %v\n", f.key, deref(f.ptr(&safe)))
+	}
+	return b.String()
+}
+
+func deref(ptr any) any {
+	switch v := ptr.(type) {
+	case *string:
+		return *v
+	case *int:
+		return *v
+	case *float64:
+		return *v
+	case *bool:
+		return *v
+	case *time.Duration:
+		return *v
+	}
+	return ptr
+}
+
+var dsnPasswordPattern = regexp.MustCompile(`(password=)\S+`)
+
+func redactDSN(dsn string) string {
+	if dsn == "" {
+		return dsn
+	}
+	if u, err := url.Parse(dsn); err == nil && u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), redacted)
+			return u.String()
+		}
+	}
+	return dsnPasswordPattern.ReplaceAllString(dsn, "${1}"+redacted)
+}
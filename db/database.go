@@ -2,42 +2,97 @@ package db
 
 import (
 	"fmt"
-	"log"
-	"os"
-	"github.com/joho/godotenv"
+	"go-blog/config"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/glebarez/sqlite"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
-func InitDB() (*gorm.DB, error) {
-	err := godotenv.Load()
-	if err != nil {
-		log.Println("No .env file found")
+const (
+	DialectPostgres = "postgres"
+	DialectSQLite   = "sqlite"
+)
+
+const sqliteMemoryPath = ":memory:"
+
+var memoryDBCounter atomic.Int64
+
+func InitDB(cfg config.DatabaseConfig) (*gorm.DB, error) {
+	switch strings.ToLower(cfg.Type) {
+	case "", DialectPostgres:
+		return initPostgreSQL(cfg)
+	case DialectSQLite:
+		return initSQLite(cfg)
+	default:
+		return nil, fmt.Errorf("unsupported database type %q", cfg.Type)
 	}
+}
 
-	var db *gorm.DB
+func Dialect(db *gorm.DB) string {
+	return db.Dialector.Name()
+}
 
-	dbType := os.Getenv("DB_TYPE")
-	if dbType == "postgres" {
-		db, err = initPostgreSQL()
-	} 
-	if err != nil {
-		return nil, err
+func IsPostgres(db *gorm.DB) bool {
+	return Dialect(db) == DialectPostgres
+}
+
+func initPostgreSQL(cfg config.DatabaseConfig) (*gorm.DB, error) {
+	dsn := cfg.DSN
+	if dsn == "" {
+		dsn = fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s",
+			cfg.Host, cfg.User, cfg.Password, cfg.Name, cfg.Port, cfg.SSLMode)
 	}
-	return db, nil
+	return gorm.Open(postgres.Open(dsn), gormConfig())
 }
 
-func initPostgreSQL() (*gorm.DB, error) {
-	dbHost := os.Getenv("DB_HOST")
-	dbUser := os.Getenv("DB_USER")
-	dbPass := os.Getenv("DB_PASS")
-	dbName := os.Getenv("DB_NAME")
-	dbPort := os.Getenv("DB_PORT")
-	dbSSLMode := os.Getenv("DB_SSLMODE")
+func initSQLite(cfg config.DatabaseConfig) (*gorm.DB, error) {
+	path := cfg.Path
+	if path == "" {
+		path = cfg.DSN
+	}
 
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s",
-		dbHost, dbUser, dbPass, dbName, dbPort, dbSSLMode)
+	var dsn string
+	if path == sqliteMemoryPath {
+		// Every in-memory database gets its own name so separate InitDB calls
+		// (one per test suite, for example) never share state.
+		dsn = fmt.Sprintf("file:blog-memory-%d?mode=memory&cache=shared", memoryDBCounter.Add(1))
+	} else {
+		dsn = "file:" + path
+	}
+	dsn += separator(dsn) + "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"
+
+	gormDB, err := gorm.Open(sqlite.Open(dsn), gormConfig())
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer; funnelling everything through one
+	// connection avoids "database is locked" errors and keeps an in-memory
+	// database alive for the lifetime of the pool.
+	sqlDB.SetMaxOpenConns(1)
+	sqlDB.SetConnMaxLifetime(0)
+	return gormDB, nil
+}
 
-	return gorm.Open(postgres.Open(dsn), &gorm.Config{})
+func gormConfig() *gorm.Config {
+	return &gorm.Config{
+		TranslateError: true,
+		// Timestamps are always stored in UTC so keyset cursors compare the
+		// same way on every dialect.
+		NowFunc: func() time.Time { return time.Now().UTC().Truncate(time.Microsecond) },
+	}
 }
 
+func separator(dsn string) string {
+	if strings.Contains(dsn, "?") {
+		return "&"
+	}
+	return "?"
+}
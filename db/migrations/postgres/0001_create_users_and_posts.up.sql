@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS users (
+    id BIGSERIAL PRIMARY KEY,
+    username TEXT,
+    password TEXT,
+    account_type TEXT
+);
+
+CREATE TABLE IF NOT EXISTS posts (
+    id BIGSERIAL PRIMARY KEY,
+    title TEXT,
+    content TEXT,
+    user_id BIGINT NOT NULL
+);
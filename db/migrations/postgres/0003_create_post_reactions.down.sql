@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS post_reactions;
+
+ALTER TABLE posts
+    DROP COLUMN IF EXISTS reactions_like,
+    DROP COLUMN IF EXISTS reactions_love,
+    DROP COLUMN IF EXISTS reactions_laugh,
+    DROP COLUMN IF EXISTS reactions_wow,
+    DROP COLUMN IF EXISTS reactions_sad;
@@ -0,0 +1,17 @@
+ALTER TABLE posts
+    ADD COLUMN reactions_like INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN reactions_love INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN reactions_laugh INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN reactions_wow INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN reactions_sad INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE post_reactions (
+    id BIGSERIAL PRIMARY KEY,
+    post_id BIGINT NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL,
+    type TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (post_id, user_id, type)
+);
+
+CREATE INDEX idx_post_reactions_user_id ON post_reactions (user_id);
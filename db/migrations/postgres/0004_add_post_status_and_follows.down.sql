@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS follows;
+
+DROP INDEX IF EXISTS idx_posts_author_published;
+
+ALTER TABLE posts
+    DROP COLUMN IF EXISTS status,
+    DROP COLUMN IF EXISTS created_at,
+    DROP COLUMN IF EXISTS updated_at,
+    DROP COLUMN IF EXISTS published_at;
@@ -0,0 +1,18 @@
+ALTER TABLE posts
+    ADD COLUMN status TEXT NOT NULL DEFAULT 'published',
+    ADD COLUMN created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ADD COLUMN updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ADD COLUMN published_at TIMESTAMP;
+
+UPDATE posts SET published_at = created_at WHERE status = 'published';
+
+CREATE INDEX idx_posts_author_published ON posts (user_id, published_at DESC, id DESC) WHERE status = 'published';
+
+CREATE TABLE follows (
+    follower_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    followee_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (follower_id, followee_id)
+);
+
+CREATE INDEX idx_follows_followee_id ON follows (followee_id);
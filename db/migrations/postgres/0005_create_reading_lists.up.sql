@@ -0,0 +1,24 @@
+CREATE TABLE reading_lists (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    share_token TEXT UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, name)
+);
+
+-- Items keep their post_id after the post is deleted so the list can show
+-- the entry as unavailable instead of silently dropping it.
+CREATE TABLE reading_list_items (
+    id BIGSERIAL PRIMARY KEY,
+    list_id BIGINT NOT NULL REFERENCES reading_lists (id) ON DELETE CASCADE,
+    post_id BIGINT NOT NULL,
+    position INTEGER NOT NULL,
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (list_id, post_id)
+);
+
+CREATE INDEX idx_reading_list_items_list_position ON reading_list_items (list_id, position);
@@ -0,0 +1,8 @@
+CREATE TABLE post_tags (
+    post_id BIGINT NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+    tag TEXT NOT NULL,
+    PRIMARY KEY (post_id, tag)
+);
+
+CREATE INDEX idx_post_tags_tag ON post_tags (tag, post_id);
+CREATE INDEX idx_posts_published ON posts (published_at DESC, id DESC) WHERE status = 'published';
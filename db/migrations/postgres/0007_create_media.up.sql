@@ -0,0 +1,23 @@
+CREATE TABLE media (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    storage_key TEXT NOT NULL UNIQUE,
+    thumbnail_key TEXT NOT NULL DEFAULT '',
+    filename TEXT NOT NULL DEFAULT '',
+    content_type TEXT NOT NULL,
+    size BIGINT NOT NULL,
+    width INTEGER NOT NULL DEFAULT 0,
+    height INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_media_user ON media (user_id, id);
+
+CREATE TABLE post_media (
+    post_id BIGINT NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+    media_id BIGINT NOT NULL REFERENCES media (id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    PRIMARY KEY (post_id, media_id)
+);
+
+CREATE INDEX idx_post_media_media ON post_media (media_id);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS import_jobs;
+
+DROP INDEX IF EXISTS idx_posts_user_slug;
+ALTER TABLE posts DROP COLUMN slug;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_posts_user_id;
+DROP INDEX IF EXISTS idx_users_username;
@@ -0,0 +1,2 @@
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username ON users (username);
+CREATE INDEX IF NOT EXISTS idx_posts_user_id ON posts (user_id);
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS post_reactions;
+
+ALTER TABLE posts DROP COLUMN reactions_like;
+ALTER TABLE posts DROP COLUMN reactions_love;
+ALTER TABLE posts DROP COLUMN reactions_laugh;
+ALTER TABLE posts DROP COLUMN reactions_wow;
+ALTER TABLE posts DROP COLUMN reactions_sad;
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS follows;
+
+DROP INDEX IF EXISTS idx_posts_author_published;
+
+ALTER TABLE posts DROP COLUMN status;
+ALTER TABLE posts DROP COLUMN created_at;
+ALTER TABLE posts DROP COLUMN updated_at;
+ALTER TABLE posts DROP COLUMN published_at;
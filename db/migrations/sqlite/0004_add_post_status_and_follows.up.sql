@@ -0,0 +1,20 @@
+-- SQLite cannot add a column with a non-constant default, so the
+-- timestamps are backfilled instead.
+ALTER TABLE posts ADD COLUMN status TEXT NOT NULL DEFAULT 'published';
+ALTER TABLE posts ADD COLUMN created_at TIMESTAMP;
+ALTER TABLE posts ADD COLUMN updated_at TIMESTAMP;
+ALTER TABLE posts ADD COLUMN published_at TIMESTAMP;
+
+UPDATE posts SET created_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP;
+UPDATE posts SET published_at = created_at WHERE status = 'published';
+
+CREATE INDEX idx_posts_author_published ON posts (user_id, published_at DESC, id DESC) WHERE status = 'published';
+
+CREATE TABLE follows (
+    follower_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    followee_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (follower_id, followee_id)
+);
+
+CREATE INDEX idx_follows_followee_id ON follows (followee_id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_posts_published;
+DROP TABLE IF EXISTS post_tags;
@@ -0,0 +1,26 @@
+-- Existing posts get a placeholder slug that is unique by construction;
+-- authors can rename them afterwards.
+ALTER TABLE posts ADD COLUMN slug TEXT NOT NULL DEFAULT '';
+UPDATE posts SET slug = 'post-' || id;
+
+CREATE UNIQUE INDEX idx_posts_user_slug ON posts (user_id, slug) WHERE slug <> '';
+
+CREATE TABLE import_jobs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    format TEXT NOT NULL,
+    storage_key TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'queued',
+    total INTEGER NOT NULL DEFAULT 0,
+    processed INTEGER NOT NULL DEFAULT 0,
+    created INTEGER NOT NULL DEFAULT 0,
+    skipped INTEGER NOT NULL DEFAULT 0,
+    failed INTEGER NOT NULL DEFAULT 0,
+    messages TEXT NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMP,
+    finished_at TIMESTAMP
+);
+
+CREATE INDEX idx_import_jobs_user ON import_jobs (user_id, id);
+CREATE INDEX idx_import_jobs_status ON import_jobs (status, id);
@@ -0,0 +1,13 @@
+CREATE TABLE notifications (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    type TEXT NOT NULL,
+    actor_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    post_id INTEGER REFERENCES posts (id) ON DELETE CASCADE,
+    reaction TEXT NOT NULL DEFAULT '',
+    read_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_notifications_user ON notifications (user_id, id);
+CREATE INDEX idx_notifications_unread ON notifications (user_id, id) WHERE read_at IS NULL;
@@ -0,0 +1,20 @@
+CREATE TABLE digest_subscriptions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    email TEXT NOT NULL,
+    -- Set when a signed-in reader subscribed; anonymous readers have none.
+    user_id INTEGER REFERENCES users (id) ON DELETE SET NULL,
+    frequency TEXT NOT NULL,
+    author_id INTEGER REFERENCES users (id) ON DELETE CASCADE,
+    tag TEXT NOT NULL DEFAULT '',
+    token TEXT NOT NULL,
+    confirmed_at TIMESTAMP,
+    sent_up_to TIMESTAMP,
+    next_run_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_digest_subscriptions_token ON digest_subscriptions (token);
+CREATE UNIQUE INDEX idx_digest_subscriptions_filter ON digest_subscriptions (email, COALESCE(author_id, 0), tag);
+CREATE INDEX idx_digest_subscriptions_user ON digest_subscriptions (user_id);
+CREATE INDEX idx_digest_subscriptions_due ON digest_subscriptions (next_run_at) WHERE confirmed_at IS NOT NULL;
@@ -0,0 +1,64 @@
+package db
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type txKey struct{}
+
+type txState struct {
+	tx          *gorm.DB
+	afterCommit []func()
+}
+
+// Transaction runs fn in a database transaction carried by the context it
+// is given. Repositories that fetch their connection with Conn join it, so a
+// service can make several repository calls atomic without knowing about
+// gorm. A Transaction started inside another one joins the outer
+// transaction; only the outermost commits.
+func Transaction(ctx context.Context, gormDB *gorm.DB, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*txState); ok {
+		return fn(ctx)
+	}
+	state := &txState{}
+	err := gormDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		state.tx = tx
+		return fn(context.WithValue(ctx, txKey{}, state))
+	})
+	if err != nil {
+		return err
+	}
+	for _, hook := range state.afterCommit {
+		hook()
+	}
+	return nil
+}
+
+// Conn returns the transaction carried by ctx, or gormDB when there is
+// none. With SQLite's single connection, a query that bypasses an open
+// transaction would wait for it forever, so every repository that can be
+// called inside Transaction must use Conn.
+func Conn(ctx context.Context, gormDB *gorm.DB) *gorm.DB {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		return state.tx.WithContext(ctx)
+	}
+	return gormDB.WithContext(ctx)
+}
+
+// WithoutTx returns ctx without the transaction it carries, for work that
+// outlives it, such as hooks run after it committed.
+func WithoutTx(ctx context.Context) context.Context {
+	return context.WithValue(ctx, txKey{}, nil)
+}
+
+// AfterCommit runs fn once the transaction carried by ctx has committed, or
+// straight away outside a transaction. It is not run on rollback.
+func AfterCommit(ctx context.Context, fn func()) {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		state.afterCommit = append(state.afterCommit, fn)
+		return
+	}
+	fn()
+}
@@ -0,0 +1,180 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"go-blog/db"
+	"go-blog/metrics"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+type subscriber struct {
+	name  string
+	async bool
+	fn    func(ctx context.Context, event Event) error
+}
+
+// Bus delivers published events to their subscribers. Subscribe before
+// anything is published. Publish on a nil *Bus does nothing, so services
+// can be built without one.
+type Bus struct {
+	metrics *metrics.Metrics
+	logger  *slog.Logger
+
+	mu          sync.RWMutex
+	subscribers map[string][]subscriber
+
+	// inFlight counts running asynchronous handlers; closed is set once Run
+	// is shutting down, after which handlers run in the publisher's
+	// goroutine instead of being left behind.
+	runMu    sync.Mutex
+	idle     *sync.Cond
+	inFlight int
+	closed   bool
+}
+
+func NewBus(m *metrics.Metrics, logger *slog.Logger) *Bus {
+	b := &Bus{metrics: m, logger: logger, subscribers: map[string][]subscriber{}}
+	b.idle = sync.NewCond(&b.runMu)
+	return b
+}
+
+// Subscribe registers fn to run inside the publisher's transaction. Its
+// error, or a panic, fails the publish and so rolls the change back. The
+// name labels the subscriber in logs and metrics.
+func Subscribe[E Event](b *Bus, name string, fn func(ctx context.Context, event E) error) {
+	subscribe(b, name, false, fn)
+}
+
+// SubscribeAsync registers fn to run in its own goroutine after the
+// publisher's transaction commits. Its context keeps the publisher's values
+// but not its cancellation.
+func SubscribeAsync[E Event](b *Bus, name string, fn func(ctx context.Context, event E) error) {
+	subscribe(b, name, true, fn)
+}
+
+func subscribe[E Event](b *Bus, name string, async bool, fn func(ctx context.Context, event E) error) {
+	var zero E
+	event := zero.EventName()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers[event] = append(b.subscribers[event], subscriber{
+		name:  name,
+		async: async,
+		fn: func(ctx context.Context, e Event) error {
+			return fn(ctx, e.(E))
+		},
+	})
+}
+
+// Publish runs the synchronous subscribers of event in order, stopping at
+// the first failure, and schedules the asynchronous ones for when the
+// transaction carried by ctx commits, or right away outside a transaction.
+func (b *Bus) Publish(ctx context.Context, event Event) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.RLock()
+	subscribers := b.subscribers[event.EventName()]
+	b.mu.RUnlock()
+
+	var async []subscriber
+	for _, s := range subscribers {
+		if s.async {
+			async = append(async, s)
+			continue
+		}
+		if err := b.call(ctx, s, event); err != nil {
+			return fmt.Errorf("%s subscriber %s: %w", event.EventName(), s.name, err)
+		}
+	}
+	if len(async) > 0 {
+		db.AfterCommit(ctx, func() {
+			// The transaction has committed; handlers that query must not
+			// join it.
+			ctx := db.WithoutTx(context.WithoutCancel(ctx))
+			for _, s := range async {
+				b.goCall(ctx, s, event)
+			}
+		})
+	}
+	return nil
+}
+
+func (b *Bus) goCall(ctx context.Context, s subscriber, event Event) {
+	b.runMu.Lock()
+	closed := b.closed
+	if !closed {
+		b.inFlight++
+	}
+	b.runMu.Unlock()
+
+	run := func() {
+		if err := b.call(ctx, s, event); err != nil {
+			b.logger.ErrorContext(ctx, "event subscriber failed", "event", event.EventName(), "subscriber", s.name, "error", err)
+		}
+	}
+	if closed {
+		run()
+		return
+	}
+	go func() {
+		defer b.finished()
+		run()
+	}()
+}
+
+func (b *Bus) finished() {
+	b.runMu.Lock()
+	defer b.runMu.Unlock()
+	b.inFlight--
+	if b.inFlight == 0 {
+		b.idle.Broadcast()
+	}
+}
+
+// call runs one handler, turning a panic into an error so a bad subscriber
+// cannot take the process down, and records how it went.
+func (b *Bus) call(ctx context.Context, s subscriber, event Event) (err error) {
+	start := time.Now()
+	defer func() {
+		outcome := "ok"
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+			outcome = "panic"
+			b.logger.ErrorContext(ctx, "event subscriber panicked", "event", event.EventName(), "subscriber", s.name,
+				"panic", fmt.Sprint(r), "stack", string(debug.Stack()))
+		} else if err != nil {
+			outcome = "error"
+		}
+		b.metrics.EventHandled(event.EventName(), s.name, outcome, time.Since(start))
+	}()
+	return s.fn(ctx, event)
+}
+
+// Wait blocks until no asynchronous handlers are running.
+func (b *Bus) Wait() {
+	b.runMu.Lock()
+	defer b.runMu.Unlock()
+	for b.inFlight > 0 {
+		b.idle.Wait()
+	}
+}
+
+func (b *Bus) Name() string {
+	return "event bus"
+}
+
+// Run only waits for ctx to be cancelled and then for the asynchronous
+// handlers still running, so that shutdown does not cut them off.
+func (b *Bus) Run(ctx context.Context) error {
+	<-ctx.Done()
+	b.runMu.Lock()
+	b.closed = true
+	b.runMu.Unlock()
+	b.Wait()
+	return ctx.Err()
+}
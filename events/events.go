@@ -0,0 +1,90 @@
+// Package events is an in-process bus for domain events. Services publish
+// what happened, such as a post being published, and other modules
+// subscribe to the events they care about instead of being called
+// directly from the service.
+//
+// A subscriber is either synchronous or asynchronous. Synchronous handlers
+// run inside the publisher's transaction, before it commits: an error or
+// panic rolls the change back, which suits work that must happen exactly
+// when the change does, like recording webhook deliveries. Asynchronous
+// handlers run in their own goroutine once the transaction has committed,
+// and never for a change that rolled back; their failures are logged and
+// counted but cannot affect the publisher.
+package events
+
+import "go-blog/models"
+
+// Event is a domain event. Name identifies it in subscriptions, logs and
+// metrics and must not change once subscribers exist.
+type Event interface {
+	EventName() string
+}
+
+// PostCreated is published for every new post, drafts included.
+type PostCreated struct {
+	Post models.Post
+}
+
+func (PostCreated) EventName() string { return "post.created" }
+
+// PostPublished is published when a post becomes visible to readers, either
+// because it was created published or because a draft was published.
+type PostPublished struct {
+	Post models.Post
+}
+
+func (PostPublished) EventName() string { return "post.published" }
+
+// PostUnpublished is published when a published post goes back to being a
+// draft, so readers that showed it can take it down.
+type PostUnpublished struct {
+	Post models.Post
+}
+
+func (PostUnpublished) EventName() string { return "post.unpublished" }
+
+type PostUpdated struct {
+	Post models.Post
+}
+
+func (PostUpdated) EventName() string { return "post.updated" }
+
+// PostDeleted carries the post as it was before it was deleted.
+type PostDeleted struct {
+	Post models.Post
+}
+
+func (PostDeleted) EventName() string { return "post.deleted" }
+
+type UserRegistered struct {
+	User models.UserProfile
+}
+
+func (UserRegistered) EventName() string { return "user.registered" }
+
+// PostReacted is published when a user adds a reaction to a post. PostOwnerID
+// is the post's author.
+type PostReacted struct {
+	PostID      int
+	PostOwnerID int
+	UserID      int
+	Type        models.ReactionType
+}
+
+func (PostReacted) EventName() string { return "post.reacted" }
+
+// PostUnreacted is published when a user takes a reaction back.
+type PostUnreacted struct {
+	PostID int
+	UserID int
+	Type   models.ReactionType
+}
+
+func (PostUnreacted) EventName() string { return "post.unreacted" }
+
+type UserFollowed struct {
+	FollowerID int
+	FolloweeID int
+}
+
+func (UserFollowed) EventName() string { return "user.followed" }
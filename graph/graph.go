@@ -0,0 +1,123 @@
+// Package graph serves the blog as a GraphQL API. It resolves through the
+// same services as the REST handlers and applies the same authentication
+// and ownership rules; lookups of authors and their posts are batched per
+// request so a query costs a fixed number of database round trips however
+// many posts it lists.
+package graph
+
+import (
+	"context"
+	"go-blog/config"
+	"go-blog/models"
+	"go-blog/repo"
+	"go-blog/service"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/location"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+// Request is a GraphQL request as posted to /graphql.
+type Request struct {
+	Query         string         `json:"query" binding:"required"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+// Viewer is who a request runs as. The zero Viewer is anonymous.
+type Viewer struct {
+	UserID      int
+	AccountType models.AccountType
+}
+
+func (v Viewer) authenticated() bool {
+	return v.UserID != 0
+}
+
+type Server struct {
+	schema   graphql.Schema
+	limits   config.GraphQLConfig
+	posts    service.PostService
+	users    service.UserService
+	authRepo repo.AuthRepository
+}
+
+// NewServer rejects queries nested deeper or estimated costlier than
+// limits allows before running them.
+func NewServer(limits config.GraphQLConfig, posts service.PostService, users service.UserService, authRepo repo.AuthRepository) (*Server, error) {
+	s := &Server{limits: limits, posts: posts, users: users, authRepo: authRepo}
+	schema, err := s.newSchema()
+	if err != nil {
+		return nil, err
+	}
+	s.schema = schema
+	return s, nil
+}
+
+// Execute runs req as viewer. Failures are reported in the result's errors,
+// as GraphQL clients expect, never as a Go error.
+func (s *Server) Execute(ctx context.Context, viewer Viewer, req Request) *graphql.Result {
+	doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"})})
+	if err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+	if validation := graphql.ValidateDocument(&s.schema, doc, nil); !validation.IsValid {
+		return &graphql.Result{Errors: validation.Errors}
+	}
+	if err := checkLimits(&s.schema, doc, req, s.limits); err != nil {
+		return &graphql.Result{Errors: []gqlerrors.FormattedError{{
+			Message:    err.Error(),
+			Locations:  []location.SourceLocation{},
+			Extensions: err.Extensions(),
+		}}}
+	}
+	ctx = context.WithValue(ctx, requestKey{}, &requestState{viewer: viewer, loaders: newLoaders(ctx, s.posts, s.users)})
+	return graphql.Execute(graphql.ExecuteParams{
+		Schema:        s.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       ctx,
+	})
+}
+
+type requestKey struct{}
+
+type requestState struct {
+	viewer  Viewer
+	loaders *loaders
+}
+
+func stateFrom(ctx context.Context) *requestState {
+	return ctx.Value(requestKey{}).(*requestState)
+}
+
+// Error codes travel in the extensions of an error, and match the status
+// the REST endpoint answers the same failure with.
+const (
+	codeBadInput        = "BAD_USER_INPUT"
+	codeUnauthenticated = "UNAUTHENTICATED"
+	codeForbidden       = "FORBIDDEN"
+	codeNotFound        = "NOT_FOUND"
+	codeConflict        = "CONFLICT"
+	codeTooComplex      = "QUERY_TOO_COMPLEX"
+)
+
+type codedError struct {
+	code    string
+	message string
+}
+
+func newError(code, message string) *codedError {
+	return &codedError{code: code, message: message}
+}
+
+func (e *codedError) Error() string {
+	return e.message
+}
+
+func (e *codedError) Extensions() map[string]any {
+	return map[string]any{"code": e.code}
+}
@@ -0,0 +1,175 @@
+package graph
+
+import (
+	"fmt"
+	"go-blog/config"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// checkLimits measures the operation req runs. Its depth is the deepest
+// field nesting, fragments included. Its complexity estimates the number of
+// fields resolved: every field costs one, and a field taking a page size
+// multiplies the cost of its selection by it. Introspection is not counted.
+func checkLimits(schema *graphql.Schema, doc *ast.Document, req Request, limits config.GraphQLConfig) *codedError {
+	m := &measure{schema: schema, variables: req.Variables, fragments: map[string]*ast.FragmentDefinition{}, maxDepth: limits.MaxDepth}
+	var operation *ast.OperationDefinition
+	for _, definition := range doc.Definitions {
+		switch definition := definition.(type) {
+		case *ast.FragmentDefinition:
+			m.fragments[definition.Name.Value] = definition
+		case *ast.OperationDefinition:
+			if req.OperationName == "" || (definition.Name != nil && definition.Name.Value == req.OperationName) {
+				operation = definition
+			}
+		}
+	}
+	if operation == nil {
+		// Execute reports the missing operation.
+		return nil
+	}
+	m.operation = operation
+	root := schema.QueryType()
+	if operation.Operation == ast.OperationTypeMutation {
+		root = schema.MutationType()
+	}
+
+	depth, complexity := m.selections(root, operation.SelectionSet, 1)
+	if depth > limits.MaxDepth {
+		return newError(codeTooComplex, fmt.Sprintf("query depth %d exceeds the limit of %d", depth, limits.MaxDepth))
+	}
+	if complexity > limits.MaxComplexity {
+		return newError(codeTooComplex, fmt.Sprintf("query complexity %d exceeds the limit of %d", complexity, limits.MaxComplexity))
+	}
+	return nil
+}
+
+type measure struct {
+	schema    *graphql.Schema
+	operation *ast.OperationDefinition
+	variables map[string]any
+	fragments map[string]*ast.FragmentDefinition
+	maxDepth  int
+}
+
+// selections returns the deepest level reached below set, which sits at
+// depth, and the complexity of set. It stops descending past the depth
+// limit, so the complexity of a rejected query may be underestimated.
+func (m *measure) selections(parent *graphql.Object, set *ast.SelectionSet, depth int) (int, int) {
+	deepest, complexity := 0, 0
+	if set == nil || parent == nil {
+		return deepest, complexity
+	}
+	add := func(d, c int) {
+		deepest = max(deepest, d)
+		complexity = saturatingAdd(complexity, c)
+	}
+	for _, selection := range set.Selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			name := selection.Name.Value
+			field := parent.Fields()[name]
+			if strings.HasPrefix(name, "__") || field == nil {
+				continue
+			}
+			child := objectOf(field.Type)
+			if child == nil || selection.SelectionSet == nil || depth >= m.maxDepth+1 {
+				add(depth, 1)
+				continue
+			}
+			d, c := m.selections(child, selection.SelectionSet, depth+1)
+			add(max(d, depth), saturatingAdd(1, saturatingMul(m.pageSize(field, selection), c)))
+		case *ast.InlineFragment:
+			add(m.selections(m.typeCondition(parent, selection.TypeCondition), selection.SelectionSet, depth))
+		case *ast.FragmentSpread:
+			if fragment := m.fragments[selection.Name.Value]; fragment != nil {
+				add(m.selections(m.typeCondition(parent, fragment.TypeCondition), fragment.SelectionSet, depth))
+			}
+		}
+	}
+	return deepest, complexity
+}
+
+func (m *measure) typeCondition(parent *graphql.Object, condition *ast.Named) *graphql.Object {
+	if condition == nil {
+		return parent
+	}
+	object, _ := m.schema.Type(condition.Name.Value).(*graphql.Object)
+	return object
+}
+
+// pageSize is the "first" argument of field as given, or its default; it is
+// 1 for fields that do not page.
+func (m *measure) pageSize(field *graphql.FieldDefinition, selection *ast.Field) int {
+	for _, arg := range field.Args {
+		if arg.Name() != "first" {
+			continue
+		}
+		size, _ := arg.DefaultValue.(int)
+		for _, given := range selection.Arguments {
+			if given.Name.Value == "first" {
+				if value, ok := m.intValue(given.Value); ok {
+					size = value
+				}
+			}
+		}
+		return max(size, 1)
+	}
+	return 1
+}
+
+func (m *measure) intValue(value ast.Value) (int, bool) {
+	switch value := value.(type) {
+	case *ast.IntValue:
+		n, err := strconv.Atoi(value.Value)
+		return n, err == nil
+	case *ast.Variable:
+		switch given := m.variables[value.Name.Value].(type) {
+		case float64:
+			return int(given), true
+		case int:
+			return given, true
+		}
+		for _, definition := range m.operation.VariableDefinitions {
+			if definition.Variable.Name.Value == value.Name.Value && definition.DefaultValue != nil {
+				return m.intValue(definition.DefaultValue)
+			}
+		}
+	}
+	return 0, false
+}
+
+// objectOf unwraps lists and non-nulls down to an object type, or returns
+// nil for scalars and enums.
+func objectOf(t graphql.Type) *graphql.Object {
+	for {
+		switch wrapped := t.(type) {
+		case *graphql.List:
+			t = wrapped.OfType
+		case *graphql.NonNull:
+			t = wrapped.OfType
+		case *graphql.Object:
+			return wrapped
+		default:
+			return nil
+		}
+	}
+}
+
+func saturatingAdd(a, b int) int {
+	if a > math.MaxInt-b {
+		return math.MaxInt
+	}
+	return a + b
+}
+
+func saturatingMul(a, b int) int {
+	if a != 0 && b > math.MaxInt/a {
+		return math.MaxInt
+	}
+	return a * b
+}
@@ -0,0 +1,105 @@
+package graph
+
+import (
+	"context"
+	"go-blog/models"
+	"go-blog/service"
+	"sync"
+)
+
+// loader batches the lookups resolvers make while a query runs. Load only
+// records the key and returns a thunk. graphql-go calls the thunks of one
+// level of the response after every resolver on that level has run, so the
+// first thunk called fetches all the keys recorded so far in one call.
+type loader[K comparable, V any] struct {
+	fetch func(keys []K) (map[K]V, error)
+
+	mu      sync.Mutex
+	pending []K
+	queued  map[K]bool
+	values  map[K]V
+	errs    map[K]error
+}
+
+func newLoader[K comparable, V any](fetch func(keys []K) (map[K]V, error)) *loader[K, V] {
+	return &loader[K, V]{fetch: fetch, queued: map[K]bool{}, values: map[K]V{}, errs: map[K]error{}}
+}
+
+// Load returns a thunk yielding the value for key, or the zero value when
+// the fetch found none.
+func (l *loader[K, V]) Load(key K) func() (V, error) {
+	l.mu.Lock()
+	_, loaded := l.values[key]
+	_, failed := l.errs[key]
+	if !loaded && !failed && !l.queued[key] {
+		l.queued[key] = true
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+	return func() (V, error) { return l.get(key) }
+}
+
+func (l *loader[K, V]) get(key K) (V, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.queued[key] {
+		keys := l.pending
+		l.pending, l.queued = nil, map[K]bool{}
+		values, err := l.fetch(keys)
+		for _, k := range keys {
+			if err != nil {
+				l.errs[k] = err
+			} else {
+				l.values[k] = values[k]
+			}
+		}
+	}
+	return l.values[key], l.errs[key]
+}
+
+// authorPosts keys the newest posts of one author; the page size is part of
+// the key as different fields may ask for different sizes.
+type authorPosts struct {
+	authorID int
+	limit    int
+}
+
+// loaders are the per-request batches.
+type loaders struct {
+	users *loader[int, *models.User]
+	posts *loader[authorPosts, []models.Post]
+}
+
+func newLoaders(ctx context.Context, posts service.PostService, users service.UserService) *loaders {
+	return &loaders{
+		users: newLoader(func(ids []int) (map[int]*models.User, error) {
+			found, err := users.GetUsersByID(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+			byID := make(map[int]*models.User, len(found))
+			for i := range found {
+				byID[found[i].ID] = &found[i]
+			}
+			return byID, nil
+		}),
+		posts: newLoader(func(keys []authorPosts) (map[authorPosts][]models.Post, error) {
+			authorsByLimit := map[int][]int{}
+			for _, key := range keys {
+				authorsByLimit[key.limit] = append(authorsByLimit[key.limit], key.authorID)
+			}
+			byKey := make(map[authorPosts][]models.Post, len(keys))
+			for limit, authorIDs := range authorsByLimit {
+				found, err := posts.GetPostsByAuthors(ctx, authorIDs, limit)
+				if err != nil {
+					return nil, err
+				}
+				for _, post := range found {
+					key := authorPosts{authorID: post.UserID, limit: limit}
+					byKey[key] = append(byKey[key], post)
+				}
+			}
+			return byKey, nil
+		}),
+	}
+}
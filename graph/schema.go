@@ -0,0 +1,369 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"go-blog/models"
+	"strconv"
+
+	"github.com/graphql-go/graphql"
+)
+
+const (
+	defaultPageSize = 10
+	maxPageSize     = 50
+)
+
+func (s *Server) newSchema() (graphql.Schema, error) {
+	status := graphql.NewEnum(graphql.EnumConfig{
+		Name: "PostStatus",
+		Values: graphql.EnumValueConfigMap{
+			"DRAFT":     {Value: models.PostStatusDraft},
+			"PUBLISHED": {Value: models.PostStatusPublished},
+		},
+	})
+	accountType := graphql.NewEnum(graphql.EnumConfig{
+		Name: "AccountType",
+		Values: graphql.EnumValueConfigMap{
+			"BLOGGER": {Value: models.AccountTypeBlogger},
+			"VIEWER":  {Value: models.AccountTypeViewer},
+		},
+	})
+	reactions := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Reactions",
+		Fields: graphql.Fields{
+			"like":  {Type: graphql.NewNonNull(graphql.Int)},
+			"love":  {Type: graphql.NewNonNull(graphql.Int)},
+			"laugh": {Type: graphql.NewNonNull(graphql.Int)},
+			"wow":   {Type: graphql.NewNonNull(graphql.Int)},
+			"sad":   {Type: graphql.NewNonNull(graphql.Int)},
+		},
+	})
+
+	var user *graphql.Object
+	post := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Post",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":      {Type: graphql.NewNonNull(graphql.ID), Resolve: postField(func(p *models.Post) any { return p.ID })},
+				"title":   {Type: graphql.NewNonNull(graphql.String), Resolve: postField(func(p *models.Post) any { return p.Title })},
+				"slug":    {Type: graphql.NewNonNull(graphql.String), Resolve: postField(func(p *models.Post) any { return p.Slug })},
+				"content": {Type: graphql.NewNonNull(graphql.String), Resolve: postField(func(p *models.Post) any { return p.Content })},
+				"status":  {Type: graphql.NewNonNull(status), Resolve: postField(func(p *models.Post) any { return p.Status })},
+				"publishedAt": {Type: graphql.DateTime, Resolve: postField(func(p *models.Post) any {
+					if p.PublishedAt == nil {
+						return nil
+					}
+					return *p.PublishedAt
+				})},
+				"createdAt": {Type: graphql.NewNonNull(graphql.DateTime), Resolve: postField(func(p *models.Post) any { return p.CreatedAt })},
+				"updatedAt": {Type: graphql.NewNonNull(graphql.DateTime), Resolve: postField(func(p *models.Post) any { return p.UpdatedAt })},
+				"tags": {Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))), Resolve: postField(func(p *models.Post) any {
+					return nonNil(p.Tags)
+				})},
+				"mediaIds": {Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.Int))), Resolve: postField(func(p *models.Post) any {
+					return nonNil(p.MediaIDs)
+				})},
+				"reactions": {Type: graphql.NewNonNull(reactions), Resolve: postField(func(p *models.Post) any { return p.Reactions })},
+				"author":    {Type: user, Resolve: s.resolveAuthor},
+			}
+		}),
+	})
+	user = graphql.NewObject(graphql.ObjectConfig{
+		Name: "User",
+		Fields: graphql.Fields{
+			"id":          {Type: graphql.NewNonNull(graphql.ID), Resolve: userField(func(u *models.User) any { return u.ID })},
+			"username":    {Type: graphql.NewNonNull(graphql.String), Resolve: userField(func(u *models.User) any { return u.Username })},
+			"accountType": {Type: graphql.NewNonNull(accountType), Resolve: userField(func(u *models.User) any { return u.AccountType })},
+			"posts": {
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(post))),
+				Description: "The newest published posts by the user.",
+				Args:        graphql.FieldConfigArgument{"first": {Type: graphql.Int, DefaultValue: defaultPageSize}},
+				Resolve:     s.resolveUserPosts,
+			},
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"post": {
+				Type:        post,
+				Description: "A post by id. Drafts are only visible to their author.",
+				Args:        graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.ID)}},
+				Resolve:     s.resolvePost,
+			},
+			"posts": {
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(post))),
+				Description: "Published posts in id order, starting after the given id.",
+				Args: graphql.FieldConfigArgument{
+					"first": {Type: graphql.Int, DefaultValue: defaultPageSize},
+					"after": {Type: graphql.ID},
+				},
+				Resolve: s.resolvePosts,
+			},
+			"user": {
+				Type:    user,
+				Args:    graphql.FieldConfigArgument{"username": {Type: graphql.NewNonNull(graphql.String)}},
+				Resolve: s.resolveUser,
+			},
+		},
+	})
+
+	postInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "PostInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"title":    {Type: graphql.NewNonNull(graphql.String)},
+			"content":  {Type: graphql.NewNonNull(graphql.String)},
+			"slug":     {Type: graphql.String},
+			"status":   {Type: status},
+			"tags":     {Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+			"mediaIds": {Type: graphql.NewList(graphql.NewNonNull(graphql.Int))},
+		},
+	})
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createPost": {
+				Type:        graphql.NewNonNull(post),
+				Description: "Requires a blogger account.",
+				Args:        graphql.FieldConfigArgument{"input": {Type: graphql.NewNonNull(postInput)}},
+				Resolve:     s.resolveCreatePost,
+			},
+			"updatePost": {
+				Type:        graphql.NewNonNull(post),
+				Description: "Title and content are replaced; the other fields keep their value when left out. Only the author may update a post.",
+				Args: graphql.FieldConfigArgument{
+					"id":    {Type: graphql.NewNonNull(graphql.ID)},
+					"input": {Type: graphql.NewNonNull(postInput)},
+				},
+				Resolve: s.resolveUpdatePost,
+			},
+			"deletePost": {
+				Type:        graphql.NewNonNull(graphql.ID),
+				Description: "Returns the id of the deleted post. Only the author may delete a post.",
+				Args:        graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.ID)}},
+				Resolve:     s.resolveDeletePost,
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query, Mutation: mutation})
+}
+
+func (s *Server) resolvePost(p graphql.ResolveParams) (any, error) {
+	id, err := idArg(p.Args["id"])
+	if err != nil {
+		return nil, err
+	}
+	post, err := s.posts.GetPostByID(p.Context, id)
+	if err != nil {
+		return nil, nil
+	}
+	if !post.IsPublished() && stateFrom(p.Context).viewer.UserID != post.UserID {
+		return nil, nil
+	}
+	return post, nil
+}
+
+func (s *Server) resolvePosts(p graphql.ResolveParams) (any, error) {
+	first, err := pageSize(p.Args)
+	if err != nil {
+		return nil, err
+	}
+	after := 0
+	if raw, ok := p.Args["after"]; ok && raw != nil {
+		if after, err = idArg(raw); err != nil {
+			return nil, err
+		}
+	}
+	posts, err := s.posts.GetPublishedPage(p.Context, after, first)
+	return nonNil(posts), err
+}
+
+func (s *Server) resolveUser(p graphql.ResolveParams) (any, error) {
+	user, err := s.users.GetUserByUsername(p.Context, p.Args["username"].(string))
+	if errors.Is(err, models.ErrUserNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *Server) resolveAuthor(p graphql.ResolveParams) (any, error) {
+	load := stateFrom(p.Context).loaders.users.Load(sourcePost(p).UserID)
+	return func() (any, error) {
+		user, err := load()
+		if user == nil {
+			return nil, err
+		}
+		return user, nil
+	}, nil
+}
+
+func (s *Server) resolveUserPosts(p graphql.ResolveParams) (any, error) {
+	first, err := pageSize(p.Args)
+	if err != nil {
+		return nil, err
+	}
+	load := stateFrom(p.Context).loaders.posts.Load(authorPosts{authorID: sourceUser(p).ID, limit: first})
+	return func() (any, error) {
+		posts, err := load()
+		return nonNil(posts), err
+	}, nil
+}
+
+// resolveCreatePost mirrors POST /api/posts behind
+// JWTAuthMiddleware(blogger).
+func (s *Server) resolveCreatePost(p graphql.ResolveParams) (any, error) {
+	viewer := stateFrom(p.Context).viewer
+	if !viewer.authenticated() {
+		return nil, newError(codeUnauthenticated, "user not authenticated")
+	}
+	if viewer.AccountType != models.AccountTypeBlogger {
+		return nil, newError(codeForbidden, "insufficient permissions")
+	}
+	post := postFromInput(p.Args["input"].(map[string]any))
+	post.UserID = viewer.UserID
+	created, err := s.posts.CreatePost(p.Context, post)
+	if err != nil {
+		return nil, postError(err)
+	}
+	return created, nil
+}
+
+// resolveUpdatePost mirrors PUT /api/posts/:id behind CheckPostOwnership.
+func (s *Server) resolveUpdatePost(p graphql.ResolveParams) (any, error) {
+	id, err := s.ownedPost(p)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := s.posts.UpdatePost(p.Context, id, postFromInput(p.Args["input"].(map[string]any)))
+	if err != nil {
+		return nil, postError(err)
+	}
+	return updated, nil
+}
+
+func (s *Server) resolveDeletePost(p graphql.ResolveParams) (any, error) {
+	id, err := s.ownedPost(p)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.posts.DeletePost(p.Context, id); err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+// ownedPost returns the id argument once the viewer is known to own it,
+// failing the way CheckPostOwnership does.
+func (s *Server) ownedPost(p graphql.ResolveParams) (int, error) {
+	viewer := stateFrom(p.Context).viewer
+	if !viewer.authenticated() {
+		return 0, newError(codeUnauthenticated, "user not authenticated")
+	}
+	id, err := idArg(p.Args["id"])
+	if err != nil {
+		return 0, err
+	}
+	switch err := s.authRepo.CheckPostOwnership(p.Context, id, viewer.UserID); err {
+	case nil:
+		return id, nil
+	case models.ErrPostNotFound:
+		return 0, newError(codeNotFound, "post not found")
+	case models.ErrPostUnauthorized:
+		return 0, newError(codeForbidden, "you can only update your own posts")
+	default:
+		return 0, errors.New("internal server error")
+	}
+}
+
+// postError classifies a PostService failure as the REST handlers do.
+func postError(err error) error {
+	switch {
+	case errors.Is(err, models.ErrInvalidStatus), errors.Is(err, models.ErrInvalidTag), errors.Is(err, models.ErrInvalidSlug),
+		errors.Is(err, models.ErrMediaNotFound), errors.Is(err, models.ErrTooManyMedia):
+		return newError(codeBadInput, err.Error())
+	case errors.Is(err, models.ErrSlugTaken):
+		return newError(codeConflict, models.ErrSlugTaken.Error())
+	case errors.Is(err, models.ErrMediaUnauthorized):
+		return newError(codeForbidden, "you can only attach your own media")
+	}
+	return err
+}
+
+// postFromInput leaves out what the input does not set, which UpdatePost
+// takes as keeping the current value.
+func postFromInput(input map[string]any) *models.Post {
+	post := &models.Post{}
+	post.Title, _ = input["title"].(string)
+	post.Content, _ = input["content"].(string)
+	post.Slug, _ = input["slug"].(string)
+	post.Status, _ = input["status"].(models.PostStatus)
+	if tags, ok := input["tags"].([]any); ok {
+		post.Tags = make([]string, 0, len(tags))
+		for _, tag := range tags {
+			post.Tags = append(post.Tags, tag.(string))
+		}
+	}
+	if ids, ok := input["mediaIds"].([]any); ok {
+		post.MediaIDs = make([]int, 0, len(ids))
+		for _, id := range ids {
+			post.MediaIDs = append(post.MediaIDs, id.(int))
+		}
+	}
+	return post
+}
+
+func idArg(raw any) (int, error) {
+	id, err := strconv.Atoi(fmt.Sprint(raw))
+	if err != nil {
+		return 0, newError(codeBadInput, "invalid post ID")
+	}
+	return id, nil
+}
+
+func pageSize(args map[string]any) (int, error) {
+	first, _ := args["first"].(int)
+	if first < 1 || first > maxPageSize {
+		return 0, newError(codeBadInput, fmt.Sprintf("first must be between 1 and %d", maxPageSize))
+	}
+	return first, nil
+}
+
+func sourcePost(p graphql.ResolveParams) *models.Post {
+	switch post := p.Source.(type) {
+	case *models.Post:
+		return post
+	case models.Post:
+		return &post
+	}
+	panic(fmt.Sprintf("unexpected post source %T", p.Source))
+}
+
+func sourceUser(p graphql.ResolveParams) *models.User {
+	return p.Source.(*models.User)
+}
+
+func postField(get func(*models.Post) any) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (any, error) {
+		return get(sourcePost(p)), nil
+	}
+}
+
+func userField(get func(*models.User) any) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (any, error) {
+		return get(sourceUser(p)), nil
+	}
+}
+
+// nonNil turns a nil slice into an empty one, for non-null list fields.
+func nonNil[T any](values []T) []T {
+	if values == nil {
+		return []T{}
+	}
+	return values
+}
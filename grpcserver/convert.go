@@ -0,0 +1,84 @@
+package grpcserver
+
+import (
+	"go-blog/models"
+
+	blogv1 "go-blog/proto/blog/v1"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func toProtoPost(post *models.Post) *blogv1.Post {
+	out := &blogv1.Post{
+		Id:        int64(post.ID),
+		Title:     post.Title,
+		Slug:      post.Slug,
+		Content:   post.Content,
+		UserId:    int64(post.UserID),
+		Status:    blogv1.PostStatus_POST_STATUS_DRAFT,
+		CreatedAt: timestamppb.New(post.CreatedAt),
+		UpdatedAt: timestamppb.New(post.UpdatedAt),
+		Tags:      post.Tags,
+		MediaIds:  toInt64s(post.MediaIDs),
+		Reactions: &blogv1.Reactions{
+			Like:  int64(post.Reactions.Like),
+			Love:  int64(post.Reactions.Love),
+			Laugh: int64(post.Reactions.Laugh),
+			Wow:   int64(post.Reactions.Wow),
+			Sad:   int64(post.Reactions.Sad),
+		},
+	}
+	if post.IsPublished() {
+		out.Status = blogv1.PostStatus_POST_STATUS_PUBLISHED
+	}
+	if post.PublishedAt != nil {
+		out.PublishedAt = timestamppb.New(*post.PublishedAt)
+	}
+	return out
+}
+
+func toProtoUser(user *models.User) *blogv1.User {
+	out := &blogv1.User{Id: int64(user.ID), Username: user.Username}
+	switch user.AccountType {
+	case models.AccountTypeBlogger:
+		out.AccountType = blogv1.AccountType_ACCOUNT_TYPE_BLOGGER
+	case models.AccountTypeViewer:
+		out.AccountType = blogv1.AccountType_ACCOUNT_TYPE_VIEWER
+	}
+	return out
+}
+
+// statusFromProto maps an unset status to the empty one, which the post
+// service takes as its default.
+func statusFromProto(s blogv1.PostStatus) (models.PostStatus, error) {
+	switch s {
+	case blogv1.PostStatus_POST_STATUS_UNSPECIFIED:
+		return "", nil
+	case blogv1.PostStatus_POST_STATUS_DRAFT:
+		return models.PostStatusDraft, nil
+	case blogv1.PostStatus_POST_STATUS_PUBLISHED:
+		return models.PostStatusPublished, nil
+	}
+	return "", status.Error(codes.InvalidArgument, models.ErrInvalidStatus.Error())
+}
+
+func toInt64s(ids []int) []int64 {
+	out := make([]int64, len(ids))
+	for i, id := range ids {
+		out[i] = int64(id)
+	}
+	return out
+}
+
+func fromInt64s(ids []int64) []int {
+	if ids == nil {
+		return nil
+	}
+	out := make([]int, len(ids))
+	for i, id := range ids {
+		out[i] = int(id)
+	}
+	return out
+}
@@ -0,0 +1,160 @@
+package grpcserver
+
+import (
+	"context"
+	"go-blog/logging"
+	"go-blog/middleware"
+	"go-blog/models"
+	"log/slog"
+	"runtime/debug"
+	"time"
+
+	blogv1 "go-blog/proto/blog/v1"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// access is what a method requires of its caller, mirroring the middleware
+// in front of the matching REST route. Methods not listed are public.
+type access int
+
+const (
+	public access = iota
+	authenticated
+	bloggers
+)
+
+var methodAccess = map[string]access{
+	blogv1.PostService_CreatePost_FullMethodName: bloggers,
+	blogv1.PostService_UpdatePost_FullMethodName: authenticated,
+	blogv1.PostService_DeletePost_FullMethodName: authenticated,
+}
+
+type identityKey struct{}
+
+// identityFrom returns the caller's identity, or nil for anonymous calls.
+func identityFrom(ctx context.Context) *middleware.Identity {
+	identity, _ := ctx.Value(identityKey{}).(*middleware.Identity)
+	return identity
+}
+
+// authenticator reads a JWT from the "authorization" metadata. Like
+// OptionalJWTAuth it lets anonymous calls through to public methods but
+// rejects a token that is present and invalid.
+type authenticator struct {
+	secret string
+}
+
+func (a *authenticator) authenticate(ctx context.Context, method string) (context.Context, error) {
+	required := methodAccess[method]
+	values := metadata.ValueFromIncomingContext(ctx, "authorization")
+	if len(values) == 0 {
+		if required != public {
+			return nil, status.Error(codes.Unauthenticated, "missing or invalid Authorization header")
+		}
+		return ctx, nil
+	}
+	token, ok := middleware.BearerToken(values[0])
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "missing or invalid Authorization header")
+	}
+	identity, err := middleware.ParseIdentity(a.secret, token)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	if required == bloggers && identity.AccountType != models.AccountTypeBlogger {
+		return nil, status.Error(codes.PermissionDenied, "insufficient permissions")
+	}
+	return context.WithValue(ctx, identityKey{}, identity), nil
+}
+
+func (a *authenticator) unary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := a.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *authenticator) stream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.authenticate(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+}
+
+// contextStream replaces the context of a stream.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+func recoverUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	defer recoverPanic(ctx, &err)
+	return handler(ctx, req)
+}
+
+func recoverStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer recoverPanic(ss.Context(), &err)
+	return handler(srv, ss)
+}
+
+func recoverPanic(ctx context.Context, err *error) {
+	if recovered := recover(); recovered != nil {
+		logging.FromContext(ctx).ErrorContext(ctx, "panic recovered",
+			slog.Any("panic", recovered),
+			slog.String("stack", string(debug.Stack())))
+		*err = status.Error(codes.Internal, "internal server error")
+	}
+}
+
+// logUnary and logStream store logger in the call's context and log every
+// call once it finishes, as RequestLogger does for HTTP.
+func logUnary(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		ctx = logging.WithContext(ctx, logger)
+		resp, err := handler(ctx, req)
+		logCall(ctx, info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+func logStream(logger *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		ctx := logging.WithContext(ss.Context(), logger)
+		err := handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+		logCall(ctx, info.FullMethod, start, err)
+		return err
+	}
+}
+
+func logCall(ctx context.Context, method string, start time.Time, err error) {
+	code := status.Code(err)
+	level := slog.LevelInfo
+	switch code {
+	case codes.OK, codes.Canceled:
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable:
+		level = slog.LevelError
+	default:
+		level = slog.LevelWarn
+	}
+	attrs := []slog.Attr{
+		slog.String("method", method),
+		slog.String("code", code.String()),
+		slog.Duration("latency", time.Since(start)),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", status.Convert(err).Message()))
+	}
+	logging.FromContext(ctx).LogAttrs(ctx, level, "grpc call", attrs...)
+}
@@ -0,0 +1,187 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"go-blog/logging"
+	"go-blog/middleware"
+	"go-blog/models"
+	"go-blog/repo"
+	"go-blog/service"
+
+	blogv1 "go-blog/proto/blog/v1"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+type postServer struct {
+	blogv1.UnimplementedPostServiceServer
+	posts    service.PostService
+	authRepo repo.AuthRepository
+	watchers *watchHub
+}
+
+func (s *postServer) ListPosts(ctx context.Context, req *blogv1.ListPostsRequest) (*blogv1.ListPostsResponse, error) {
+	size := int(req.GetPageSize())
+	if size == 0 {
+		size = defaultPageSize
+	}
+	if size < 0 || size > maxPageSize {
+		return nil, status.Errorf(codes.InvalidArgument, "page_size must be between 1 and %d", maxPageSize)
+	}
+	// One post past the page tells whether there is another.
+	posts, err := s.posts.GetPublishedPage(ctx, int(req.GetAfterId()), size+1)
+	if err != nil {
+		return nil, internal(ctx, err)
+	}
+	response := &blogv1.ListPostsResponse{}
+	for i := range posts[:min(size, len(posts))] {
+		response.Posts = append(response.Posts, toProtoPost(&posts[i]))
+	}
+	if len(posts) > size {
+		response.NextAfterId = int64(posts[size-1].ID)
+	}
+	return response, nil
+}
+
+func (s *postServer) GetPost(ctx context.Context, req *blogv1.GetPostRequest) (*blogv1.Post, error) {
+	post, err := s.posts.GetPostByID(ctx, int(req.GetId()))
+	if err != nil || !canSee(identityFrom(ctx), post) {
+		return nil, status.Error(codes.NotFound, "post not found")
+	}
+	return toProtoPost(post), nil
+}
+
+func (s *postServer) CreatePost(ctx context.Context, req *blogv1.CreatePostRequest) (*blogv1.Post, error) {
+	if req.GetTitle() == "" || req.GetContent() == "" {
+		return nil, status.Error(codes.InvalidArgument, "title and content are required")
+	}
+	postStatus, err := statusFromProto(req.GetStatus())
+	if err != nil {
+		return nil, err
+	}
+	created, err := s.posts.CreatePost(ctx, &models.Post{
+		Title:    req.GetTitle(),
+		Content:  req.GetContent(),
+		Slug:     req.GetSlug(),
+		Status:   postStatus,
+		UserID:   identityFrom(ctx).UserID,
+		Tags:     req.GetTags(),
+		MediaIDs: fromInt64s(req.GetMediaIds()),
+	})
+	if err != nil {
+		return nil, postError(ctx, err)
+	}
+	return toProtoPost(created), nil
+}
+
+func (s *postServer) UpdatePost(ctx context.Context, req *blogv1.UpdatePostRequest) (*blogv1.Post, error) {
+	if err := s.checkOwnership(ctx, req.GetId()); err != nil {
+		return nil, err
+	}
+	if req.GetTitle() == "" || req.GetContent() == "" {
+		return nil, status.Error(codes.InvalidArgument, "title and content are required")
+	}
+	postStatus, err := statusFromProto(req.GetStatus())
+	if err != nil {
+		return nil, err
+	}
+	// UpdatePost keeps the current tags and media when they are nil, and
+	// replaces them, possibly with none, otherwise.
+	post := &models.Post{Title: req.GetTitle(), Content: req.GetContent(), Slug: req.GetSlug(), Status: postStatus}
+	if req.GetUpdateTags() {
+		post.Tags = append([]string{}, req.GetTags()...)
+	}
+	if req.GetUpdateMedia() {
+		post.MediaIDs = append([]int{}, fromInt64s(req.GetMediaIds())...)
+	}
+	updated, err := s.posts.UpdatePost(ctx, int(req.GetId()), post)
+	if err != nil {
+		return nil, postError(ctx, err)
+	}
+	return toProtoPost(updated), nil
+}
+
+func (s *postServer) DeletePost(ctx context.Context, req *blogv1.DeletePostRequest) (*blogv1.DeletePostResponse, error) {
+	if err := s.checkOwnership(ctx, req.GetId()); err != nil {
+		return nil, err
+	}
+	if err := s.posts.DeletePost(ctx, int(req.GetId())); err != nil {
+		return nil, internal(ctx, err)
+	}
+	return &blogv1.DeletePostResponse{}, nil
+}
+
+// WatchPosts sends each change as it is committed until the client goes
+// away, the server shuts down or the client falls behind.
+func (s *postServer) WatchPosts(req *blogv1.WatchPostsRequest, stream grpc.ServerStreamingServer[blogv1.PostEvent]) error {
+	ctx := stream.Context()
+	w := s.watchers.watch(identityFrom(ctx))
+	defer s.watchers.unwatch(w)
+	// Headers tell the client every change from now on will reach it.
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+	for {
+		select {
+		case event := <-w.events:
+			if err := stream.Send(event); err != nil {
+				return err
+			}
+		case <-w.overflowed:
+			return status.Error(codes.ResourceExhausted, "too far behind; list posts to catch up")
+		case <-s.watchers.Done():
+			return status.Error(codes.Unavailable, "server is shutting down")
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		}
+	}
+}
+
+// checkOwnership fails the way CheckPostOwnership does.
+func (s *postServer) checkOwnership(ctx context.Context, id int64) error {
+	switch err := s.authRepo.CheckPostOwnership(ctx, int(id), identityFrom(ctx).UserID); err {
+	case nil:
+		return nil
+	case models.ErrPostNotFound:
+		return status.Error(codes.NotFound, "post not found")
+	case models.ErrPostUnauthorized:
+		return status.Error(codes.PermissionDenied, "you can only update your own posts")
+	default:
+		return internal(ctx, err)
+	}
+}
+
+// canSee reports whether post is visible to identity, which is nil for
+// anonymous callers: drafts are only visible to their author.
+func canSee(identity *middleware.Identity, post *models.Post) bool {
+	return post.IsPublished() || (identity != nil && identity.UserID == post.UserID)
+}
+
+// postError classifies a PostService failure as the REST handlers do.
+func postError(ctx context.Context, err error) error {
+	switch {
+	case errors.Is(err, models.ErrInvalidStatus), errors.Is(err, models.ErrInvalidTag), errors.Is(err, models.ErrInvalidSlug),
+		errors.Is(err, models.ErrMediaNotFound), errors.Is(err, models.ErrTooManyMedia):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, models.ErrSlugTaken):
+		return status.Error(codes.AlreadyExists, models.ErrSlugTaken.Error())
+	case errors.Is(err, models.ErrMediaUnauthorized):
+		return status.Error(codes.PermissionDenied, "you can only attach your own media")
+	}
+	return internal(ctx, err)
+}
+
+// internal logs err and hides it from the caller.
+func internal(ctx context.Context, err error) error {
+	logging.FromContext(ctx).ErrorContext(ctx, "grpc call failed", "error", err)
+	return status.Error(codes.Internal, "internal server error")
+}
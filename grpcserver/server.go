@@ -0,0 +1,102 @@
+// Package grpcserver serves the blog to internal services over gRPC, as
+// defined in proto/blog/v1. Like the GraphQL API it goes through the same
+// services as the REST handlers, and its interceptors accept the same JWTs
+// JWTAuthMiddleware does.
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-blog/events"
+	"go-blog/repo"
+	"go-blog/service"
+	"log/slog"
+	"net"
+	"time"
+
+	blogv1 "go-blog/proto/blog/v1"
+
+	"google.golang.org/grpc"
+)
+
+type Server struct {
+	server          *grpc.Server
+	watchers        *watchHub
+	logger          *slog.Logger
+	addr            string
+	shutdownTimeout time.Duration
+}
+
+// New registers the services on a server that listens on addr once Run is
+// called. Post changes published on bus are streamed to WatchPosts callers.
+func New(addr, jwtSecret string, shutdownTimeout time.Duration, posts service.PostService, users service.UserService,
+	authRepo repo.AuthRepository, bus *events.Bus, logger *slog.Logger) *Server {
+	s := &Server{
+		watchers:        newWatchHub(bus),
+		logger:          logger,
+		addr:            addr,
+		shutdownTimeout: shutdownTimeout,
+	}
+	auth := &authenticator{secret: jwtSecret}
+	s.server = grpc.NewServer(
+		grpc.ChainUnaryInterceptor(logUnary(logger), recoverUnary, auth.unary),
+		grpc.ChainStreamInterceptor(logStream(logger), recoverStream, auth.stream),
+	)
+	blogv1.RegisterPostServiceServer(s.server, &postServer{posts: posts, authRepo: authRepo, watchers: s.watchers})
+	blogv1.RegisterUserServiceServer(s.server, &userServer{users: users})
+	return s
+}
+
+func (s *Server) Name() string {
+	return "grpc server"
+}
+
+// Run serves on the configured address until ctx is cancelled, then stops
+// gracefully.
+func (s *Server) Run(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("listening on %s: %w", s.addr, err)
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		s.logger.Info("grpc server listening", "addr", listener.Addr().String())
+		errCh <- s.Serve(listener)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		s.Stop()
+		return nil
+	}
+}
+
+// Serve accepts connections on listener until Stop is called.
+func (s *Server) Serve(listener net.Listener) error {
+	err := s.server.Serve(listener)
+	if errors.Is(err, grpc.ErrServerStopped) {
+		return nil
+	}
+	return err
+}
+
+// Stop ends the WatchPosts streams, which never finish on their own, and
+// waits for the other calls to finish. Calls still running after the
+// shutdown timeout are cancelled.
+func (s *Server) Stop() {
+	s.watchers.Close()
+	done := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(s.shutdownTimeout):
+		s.logger.Warn("timed out waiting for grpc calls to finish")
+		s.server.Stop()
+	}
+}
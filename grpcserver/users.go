@@ -0,0 +1,48 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"go-blog/models"
+	"go-blog/service"
+
+	blogv1 "go-blog/proto/blog/v1"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxBatchSize bounds BatchGetUsers so one call cannot ask for the whole
+// table.
+const maxBatchSize = 100
+
+type userServer struct {
+	blogv1.UnimplementedUserServiceServer
+	users service.UserService
+}
+
+func (s *userServer) GetUser(ctx context.Context, req *blogv1.GetUserRequest) (*blogv1.User, error) {
+	user, err := s.users.GetUserByUsername(ctx, req.GetUsername())
+	if errors.Is(err, models.ErrUserNotFound) {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+	if err != nil {
+		return nil, internal(ctx, err)
+	}
+	return toProtoUser(user), nil
+}
+
+func (s *userServer) BatchGetUsers(ctx context.Context, req *blogv1.BatchGetUsersRequest) (*blogv1.BatchGetUsersResponse, error) {
+	if len(req.GetIds()) > maxBatchSize {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d ids may be asked for at once", maxBatchSize)
+	}
+	users, err := s.users.GetUsersByID(ctx, fromInt64s(req.GetIds()))
+	if err != nil {
+		return nil, internal(ctx, err)
+	}
+	response := &blogv1.BatchGetUsersResponse{}
+	for i := range users {
+		response.Users = append(response.Users, toProtoUser(&users[i]))
+	}
+	return response, nil
+}
@@ -0,0 +1,115 @@
+package grpcserver
+
+import (
+	"context"
+	"go-blog/db"
+	"go-blog/events"
+	"go-blog/middleware"
+	"go-blog/models"
+	"sync"
+
+	blogv1 "go-blog/proto/blog/v1"
+)
+
+// watchBuffer is how many events a WatchPosts stream may fall behind by
+// before it is cut off.
+const watchBuffer = 64
+
+type watcher struct {
+	identity   *middleware.Identity
+	events     chan *blogv1.PostEvent
+	overflowed chan struct{}
+}
+
+// watchHub fans post events out to the WatchPosts streams. It subscribes
+// synchronously and sends once the change commits, so streams see changes
+// in commit order and never one that rolled back. A full stream is closed
+// rather than allowed to hold up the publisher.
+type watchHub struct {
+	mu       sync.Mutex
+	watchers map[*watcher]struct{}
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newWatchHub(bus *events.Bus) *watchHub {
+	h := &watchHub{watchers: map[*watcher]struct{}{}, done: make(chan struct{})}
+	events.Subscribe(bus, "grpc watch", func(ctx context.Context, e events.PostCreated) error {
+		return h.publish(ctx, blogv1.PostEvent_TYPE_CREATED, e.Post)
+	})
+	events.Subscribe(bus, "grpc watch", func(ctx context.Context, e events.PostPublished) error {
+		return h.publish(ctx, blogv1.PostEvent_TYPE_PUBLISHED, e.Post)
+	})
+	events.Subscribe(bus, "grpc watch", func(ctx context.Context, e events.PostUpdated) error {
+		return h.publish(ctx, blogv1.PostEvent_TYPE_UPDATED, e.Post)
+	})
+	events.Subscribe(bus, "grpc watch", func(ctx context.Context, e events.PostUnpublished) error {
+		return h.publish(ctx, blogv1.PostEvent_TYPE_UNPUBLISHED, e.Post)
+	})
+	events.Subscribe(bus, "grpc watch", func(ctx context.Context, e events.PostDeleted) error {
+		return h.publish(ctx, blogv1.PostEvent_TYPE_DELETED, e.Post)
+	})
+	return h
+}
+
+func (h *watchHub) publish(ctx context.Context, eventType blogv1.PostEvent_Type, post models.Post) error {
+	event := &blogv1.PostEvent{Type: eventType, Post: toProtoPost(&post)}
+	db.AfterCommit(ctx, func() { h.broadcast(event, &post) })
+	return nil
+}
+
+func (h *watchHub) broadcast(event *blogv1.PostEvent, post *models.Post) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	// An unpublished post is a draft now, but those who saw it published
+	// must hear it is gone; they are told no more than which post it was.
+	withdrawn := &blogv1.PostEvent{Type: event.Type, Post: &blogv1.Post{
+		Id: event.Post.Id, UserId: event.Post.UserId, Status: event.Post.Status,
+	}}
+	for w := range h.watchers {
+		send := event
+		switch {
+		case canSee(w.identity, post):
+		case event.Type == blogv1.PostEvent_TYPE_UNPUBLISHED:
+			send = withdrawn
+		default:
+			continue
+		}
+		select {
+		case w.events <- send:
+		default:
+			close(w.overflowed)
+			delete(h.watchers, w)
+		}
+	}
+}
+
+// watch starts delivering the events identity, which is nil for anonymous
+// callers, may see.
+func (h *watchHub) watch(identity *middleware.Identity) *watcher {
+	w := &watcher{
+		identity:   identity,
+		events:     make(chan *blogv1.PostEvent, watchBuffer),
+		overflowed: make(chan struct{}),
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.watchers[w] = struct{}{}
+	return w
+}
+
+func (h *watchHub) unwatch(w *watcher) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.watchers, w)
+}
+
+// Done is closed by Close, which ends every stream.
+func (h *watchHub) Done() <-chan struct{} {
+	return h.done
+}
+
+func (h *watchHub) Close() {
+	h.closeOnce.Do(func() { close(h.done) })
+}
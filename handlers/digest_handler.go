@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"go-blog/models"
+	"go-blog/service"
+	"html/template"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// unsubscribePage is what a reader sees after following the unsubscribe
+// link in a digest. Unsubscribing takes a POST, so link scanners that
+// fetch every URL in an email cannot do it by accident.
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Unsubscribe</title></head>
+<body style="font-family: sans-serif; max-width: 600px; margin: 40px auto;">
+{{if .Done}}<p>You have been unsubscribed and will get no more digests.</p>
+{{else if .Subscription}}<p>Stop sending the {{.Subscription.Frequency}} digest to {{.Subscription.Email}}?</p>
+<form method="post"><button type="submit" name="List-Unsubscribe" value="One-Click">Unsubscribe</button></form>
+{{else}}<p>This link is no longer valid; you may already be unsubscribed.</p>
+{{end}}</body>
+</html>
+`))
+
+type DigestHandler struct {
+	service service.DigestService
+}
+
+func NewDigestHandler(service service.DigestService) *DigestHandler {
+	return &DigestHandler{service: service}
+}
+
+// Subscribe answers the same way whether or not the address was already
+// subscribed, so it cannot be used to find out who is.
+func (h *DigestHandler) Subscribe(c *gin.Context) {
+	var req models.DigestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.service.Subscribe(c.Request.Context(), c.GetInt("user_id"), req); err != nil {
+		writeDigestError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "check your inbox to confirm the subscription"})
+}
+
+func (h *DigestHandler) Confirm(c *gin.Context) {
+	subscription, err := h.service.Confirm(c.Request.Context(), c.Query("token"))
+	if err != nil {
+		writeDigestError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, subscription)
+}
+
+func (h *DigestHandler) UnsubscribePage(c *gin.Context) {
+	subscription, err := h.service.GetByToken(c.Request.Context(), c.Query("token"))
+	if err != nil && !errors.Is(err, models.ErrDigestNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	status := http.StatusOK
+	if subscription == nil {
+		status = http.StatusNotFound
+	}
+	renderUnsubscribePage(c, status, gin.H{"Subscription": subscription})
+}
+
+// Unsubscribe handles both the form on UnsubscribePage and RFC 8058
+// one-click requests, which mail clients POST with the body
+// "List-Unsubscribe=One-Click". It succeeds for unknown tokens too, since
+// the outcome the reader wants holds either way.
+func (h *DigestHandler) Unsubscribe(c *gin.Context) {
+	err := h.service.Unsubscribe(c.Request.Context(), c.Query("token"))
+	if err != nil && !errors.Is(err, models.ErrDigestNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	renderUnsubscribePage(c, http.StatusOK, gin.H{"Done": true})
+}
+
+func (h *DigestHandler) ListSubscriptions(c *gin.Context) {
+	subscriptions, err := h.service.ListSubscriptions(c.Request.Context(), c.GetInt("user_id"))
+	if err != nil {
+		writeDigestError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, subscriptions)
+}
+
+func (h *DigestHandler) DeleteSubscription(c *gin.Context) {
+	id, ok := intParam(c, "id", "invalid subscription id")
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteSubscription(c.Request.Context(), c.GetInt("user_id"), id); err != nil {
+		writeDigestError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func renderUnsubscribePage(c *gin.Context, status int, data gin.H) {
+	var buf bytes.Buffer
+	if err := unsubscribePage.Execute(&buf, data); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Data(status, "text/html; charset=utf-8", buf.Bytes())
+}
+
+func writeDigestError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrDigestNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrInvalidDigest):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"errors"
+	"go-blog/models"
+	"go-blog/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FollowHandler struct {
+	service service.FollowService
+}
+
+func NewFollowHandler(service service.FollowService) *FollowHandler {
+	return &FollowHandler{service: service}
+}
+
+func (h *FollowHandler) Follow(c *gin.Context) {
+	if err := h.service.Follow(c.Request.Context(), c.GetInt("user_id"), c.Param("username")); err != nil {
+		writeFollowError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"following": true})
+}
+
+func (h *FollowHandler) Unfollow(c *gin.Context) {
+	if err := h.service.Unfollow(c.Request.Context(), c.GetInt("user_id"), c.Param("username")); err != nil {
+		writeFollowError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"following": false})
+}
+
+func (h *FollowHandler) Followers(c *gin.Context) {
+	users, err := h.service.Followers(c.Request.Context(), c.Param("username"))
+	if err != nil {
+		writeFollowError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, users)
+}
+
+func (h *FollowHandler) Following(c *gin.Context) {
+	users, err := h.service.Following(c.Request.Context(), c.Param("username"))
+	if err != nil {
+		writeFollowError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, users)
+}
+
+func (h *FollowHandler) Feed(c *gin.Context) {
+	limit := 0
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+			return
+		}
+		limit = n
+	}
+	page, err := h.service.Feed(c.Request.Context(), c.GetInt("user_id"), c.Query("cursor"), limit)
+	if err != nil {
+		writeFollowError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+func writeFollowError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrCannotFollowSelf), errors.Is(err, models.ErrNotABlogger), errors.Is(err, models.ErrInvalidCursor):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"context"
+	"go-blog/db"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type HealthHandler struct {
+	db       *gorm.DB
+	migrator *db.Migrator
+	draining atomic.Bool
+}
+
+func NewHealthHandler(db *gorm.DB, migrator *db.Migrator) *HealthHandler {
+	return &HealthHandler{db: db, migrator: migrator}
+}
+
+// SetDraining makes /readyz fail so load balancers stop routing new traffic
+// while in-flight requests finish during shutdown.
+func (h *HealthHandler) SetDraining() {
+	h.draining.Store(true)
+}
+
+func (h *HealthHandler) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+
+	checks := gin.H{}
+	ready := !h.draining.Load()
+	if !ready {
+		checks["server"] = "draining"
+	}
+
+	if sqlDB, err := h.db.DB(); err != nil {
+		ready = false
+		checks["database"] = err.Error()
+	} else if err := sqlDB.PingContext(ctx); err != nil {
+		ready = false
+		checks["database"] = err.Error()
+	} else {
+		checks["database"] = "ok"
+	}
+
+	statuses, err := h.migrator.Status(ctx)
+	if err != nil {
+		ready = false
+		checks["migrations"] = err.Error()
+	} else {
+		pending := 0
+		for _, s := range statuses {
+			if !s.Applied {
+				pending++
+			}
+		}
+		if pending > 0 {
+			ready = false
+			checks["migrations"] = gin.H{"status": "pending", "pending": pending}
+		} else {
+			checks["migrations"] = gin.H{"status": "ok", "applied": len(statuses)}
+		}
+	}
+
+	status, code := "ok", http.StatusOK
+	if !ready {
+		status, code = "unavailable", http.StatusServiceUnavailable
+	}
+	c.JSON(code, gin.H{"status": status, "checks": checks})
+}
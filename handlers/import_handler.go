@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go-blog/archive"
+	"go-blog/models"
+	"go-blog/service"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ImportHandler struct {
+	service  service.ImportService
+	maxBytes int
+}
+
+func NewImportHandler(service service.ImportService, maxBytes int) *ImportHandler {
+	return &ImportHandler{service: service, maxBytes: maxBytes}
+}
+
+// Export builds the whole zip before answering so a failure halfway through
+// is still reported as an error rather than a truncated download.
+func (h *ImportHandler) Export(c *gin.Context) {
+	var buf bytes.Buffer
+	if err := h.service.Export(c.Request.Context(), c.GetInt("user_id"), &buf); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	filename := fmt.Sprintf("go-blog-export-%s.zip", time.Now().UTC().Format("20060102"))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "application/zip", buf.Bytes())
+}
+
+// Import accepts a multipart form with the file in the "file" field and
+// answers 202 with the queued job.
+func (h *ImportHandler) Import(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, int64(h.maxBytes)+multipartOverhead)
+	file, _, err := c.Request.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": models.ErrImportTooLarge.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "multipart form with a file field is required"})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, int64(h.maxBytes)+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	job, err := h.service.StartImport(c.Request.Context(), c.GetInt("user_id"), data)
+	if err != nil {
+		writeImportError(c, err)
+		return
+	}
+	c.Header("Location", "/api/me/imports/"+strconv.Itoa(job.ID))
+	c.JSON(http.StatusAccepted, job)
+}
+
+func (h *ImportHandler) ListImports(c *gin.Context) {
+	jobs, err := h.service.ListImports(c.Request.Context(), c.GetInt("user_id"))
+	if err != nil {
+		writeImportError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, jobs)
+}
+
+func (h *ImportHandler) GetImport(c *gin.Context) {
+	id, ok := intParam(c, "id", "invalid import id")
+	if !ok {
+		return
+	}
+	job, err := h.service.GetImport(c.Request.Context(), c.GetInt("user_id"), id)
+	if err != nil {
+		writeImportError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+func writeImportError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrImportNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrImportTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrUnsupportedImport):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+	case errors.Is(err, archive.ErrInvalidArchive):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"errors"
+	"go-blog/jobs"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+// JobHandler serves the operator endpoints for the background job queue.
+type JobHandler struct {
+	queue *jobs.Queue
+}
+
+func NewJobHandler(queue *jobs.Queue) *JobHandler {
+	return &JobHandler{queue: queue}
+}
+
+// ListJobs accepts ?status=, ?kind=, ?before= (a job id) and ?limit=.
+func (h *JobHandler) ListJobs(c *gin.Context) {
+	filter := jobs.Filter{Kind: c.Query("kind"), Status: jobs.Status(c.Query("status"))}
+	if filter.Status != "" && !filter.Status.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be queued, running, succeeded or dead"})
+		return
+	}
+	var ok bool
+	if filter.BeforeID, filter.Limit, ok = pageParams(c); !ok {
+		return
+	}
+	list, err := h.queue.List(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+func (h *JobHandler) Counts(c *gin.Context) {
+	counts, err := h.queue.Counts(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, counts)
+}
+
+func (h *JobHandler) GetJob(c *gin.Context) {
+	id, ok := intParam(c, "id", "invalid job id")
+	if !ok {
+		return
+	}
+	job, err := h.queue.Get(c.Request.Context(), id)
+	if err != nil {
+		writeJobError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+func (h *JobHandler) RetryJob(c *gin.Context) {
+	id, ok := intParam(c, "id", "invalid job id")
+	if !ok {
+		return
+	}
+	job, err := h.queue.Retry(c.Request.Context(), id)
+	if err != nil {
+		writeJobError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// pageParams reads ?before= (an id) and ?limit= for lists paged newest
+// first.
+func pageParams(c *gin.Context) (before, limit int, ok bool) {
+	limit = defaultPageSize
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxPageSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and " + strconv.Itoa(maxPageSize)})
+			return 0, 0, false
+		}
+		limit = n
+	}
+	if raw := c.Query("before"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid before id"})
+			return 0, 0, false
+		}
+		before = n
+	}
+	return before, limit, true
+}
+
+func writeJobError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, jobs.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, jobs.ErrNotRetryable), errors.Is(err, jobs.ErrDuplicate):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
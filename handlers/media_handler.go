@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"errors"
+	"go-blog/models"
+	"go-blog/service"
+	"go-blog/storage"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// multipartOverhead is the slack allowed on top of the file itself for
+// boundaries, part headers and other form fields.
+const multipartOverhead = 1 << 20
+
+type MediaHandler struct {
+	service        service.MediaService
+	maxUploadBytes int
+}
+
+func NewMediaHandler(service service.MediaService, maxUploadBytes int) *MediaHandler {
+	return &MediaHandler{service: service, maxUploadBytes: maxUploadBytes}
+}
+
+// Upload accepts a multipart form with the file in the "file" field.
+func (h *MediaHandler) Upload(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, int64(h.maxUploadBytes)+multipartOverhead)
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": models.ErrMediaTooLarge.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "multipart form with a file field is required"})
+		return
+	}
+	defer file.Close()
+
+	// Read one byte past the limit so an oversized file is detected without
+	// buffering all of it.
+	data, err := io.ReadAll(io.LimitReader(file, int64(h.maxUploadBytes)+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	media, err := h.service.Upload(c.Request.Context(), c.GetInt("user_id"), header.Filename, data)
+	if err != nil {
+		writeMediaError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, media)
+}
+
+func (h *MediaHandler) GetMedia(c *gin.Context) {
+	id, ok := intParam(c, "id", "invalid media id")
+	if !ok {
+		return
+	}
+	media, err := h.service.GetMedia(c.Request.Context(), id)
+	if err != nil {
+		writeMediaError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, media)
+}
+
+func (h *MediaHandler) ListMedia(c *gin.Context) {
+	media, err := h.service.ListMedia(c.Request.Context(), c.GetInt("user_id"))
+	if err != nil {
+		writeMediaError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, media)
+}
+
+// DeleteMedia runs after CheckMediaOwnership, so the caller owns the media.
+func (h *MediaHandler) DeleteMedia(c *gin.Context) {
+	id, ok := intParam(c, "id", "invalid media id")
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteMedia(c.Request.Context(), id); err != nil {
+		writeMediaError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Media deleted successfully"})
+}
+
+// Serve streams a stored file. Keys are random and never reused, so the
+// response may be cached indefinitely.
+func (h *MediaHandler) Serve(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	if !storage.ValidKey(key) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "file not found"})
+		return
+	}
+	if notModified(c, weakETag("media", key), time.Time{}) {
+		return
+	}
+	body, info, err := h.service.Open(c.Request.Context(), key)
+	if errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "file not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer body.Close()
+	c.DataFromReader(http.StatusOK, info.Size, info.ContentType, body, map[string]string{
+		"Cache-Control":          "public, max-age=31536000, immutable",
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+func writeMediaError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrMediaNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrMediaTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrUnsupportedMedia):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/ugorji/go/codec"
+)
+
+// msgpackHandle writes times as the standard timestamp extension, which
+// other MessagePack libraries understand, and reads struct keys from the
+// json tags like the JSON encoding.
+var msgpackHandle = &codec.MsgpackHandle{WriteExt: true}
+
+// negotiated renders value in the format the client asks for in Accept:
+// JSON, MessagePack or, when page is set, HTML rendered from page. Without
+// an Accept header, or with */*, the response is JSON. The body carries
+// an ETag, and a 304 is sent instead when the request already has it. A
+// client that accepts none of the formats gets a 406.
+//
+// There is no Last-Modified: posts' updated_at does not move when one is
+// deleted or unpublished, or when its reactions change, so a date would
+// let If-Modified-Since answer 304 with stale content.
+func negotiated(c *gin.Context, value any, page *template.Template) {
+	offered := []string{binding.MIMEJSON, binding.MIMEMSGPACK2, binding.MIMEMSGPACK}
+	if page != nil {
+		offered = append(offered, binding.MIMEHTML)
+	}
+	c.Writer.Header().Add("Vary", "Accept")
+
+	var body []byte
+	var contentType string
+	var err error
+	switch c.NegotiateFormat(offered...) {
+	case binding.MIMEJSON:
+		contentType = "application/json; charset=utf-8"
+		body, err = json.Marshal(value)
+	case binding.MIMEMSGPACK, binding.MIMEMSGPACK2:
+		contentType = binding.MIMEMSGPACK2
+		err = codec.NewEncoderBytes(&body, msgpackHandle).Encode(value)
+	case binding.MIMEHTML:
+		var buf bytes.Buffer
+		contentType = "text/html; charset=utf-8"
+		err = page.Execute(&buf, value)
+		body = buf.Bytes()
+	default:
+		c.JSON(http.StatusNotAcceptable, gin.H{"error": "the accepted formats are not offered", "offered": offered})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if notModified(c, contentETag(body), time.Time{}) {
+		return
+	}
+	c.Data(http.StatusOK, contentType, body)
+}
@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-blog/logging"
+	"go-blog/models"
+	"go-blog/notify"
+	"go-blog/service"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// streamBatchSize bounds how many notifications one wake-up reads, so a
+	// client resuming far behind catches up in several queries.
+	streamBatchSize = 100
+	// streamHeartbeat is comfortably below the idle timeouts of common
+	// proxies, which would otherwise drop a quiet stream.
+	streamHeartbeat = 25 * time.Second
+	streamRetry     = 3 * time.Second
+)
+
+type NotificationHandler struct {
+	service service.NotificationService
+	hub     *notify.Hub
+}
+
+func NewNotificationHandler(service service.NotificationService, hub *notify.Hub) *NotificationHandler {
+	return &NotificationHandler{service: service, hub: hub}
+}
+
+// ListNotifications accepts ?before= (a notification id), ?limit= and
+// ?unread=true.
+func (h *NotificationHandler) ListNotifications(c *gin.Context) {
+	before, limit, ok := pageParams(c)
+	if !ok {
+		return
+	}
+	page, err := h.service.List(c.Request.Context(), c.GetInt("user_id"), before, limit, c.Query("unread") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	id, ok := intParam(c, "id", "invalid notification id")
+	if !ok {
+		return
+	}
+	unread, err := h.service.MarkRead(c.Request.Context(), c.GetInt("user_id"), id)
+	if err != nil {
+		if errors.Is(err, models.ErrNotificationNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"unread": unread})
+}
+
+// MarkAllRead takes an optional {"up_to": id} body; see
+// NotificationService.MarkAllRead.
+func (h *NotificationHandler) MarkAllRead(c *gin.Context) {
+	var req struct {
+		UpTo int `json:"up_to"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	marked, unread, err := h.service.MarkAllRead(c.Request.Context(), c.GetInt("user_id"), req.UpTo)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"marked": marked, "unread": unread})
+}
+
+// Stream pushes notifications as Server-Sent Events. Each one is sent as a
+// "notification" event whose id is the notification id, followed by an
+// "unread" event with the new count. A client reconnecting with
+// Last-Event-ID receives what it missed; a fresh connection starts with
+// only the unread count.
+func (h *NotificationHandler) Stream(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := c.GetInt("user_id")
+
+	// Subscribe before reading, so nothing stored in between is missed.
+	subscription := h.hub.Subscribe(userID)
+	defer subscription.Close()
+
+	lastID, ok := h.resumeFrom(c, userID)
+	if !ok {
+		return
+	}
+
+	// The server's write timeout is meant for ordinary requests and would
+	// cut a stream off.
+	rc := http.NewResponseController(c.Writer)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		logging.FromContext(ctx).ErrorContext(ctx, "lifting the write deadline", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "streaming is not supported"})
+		return
+	}
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", streamRetry.Milliseconds())
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		var err error
+		if lastID, err = h.sendNew(c, userID, lastID); err != nil {
+			if ctx.Err() == nil {
+				logging.FromContext(ctx).ErrorContext(ctx, "streaming notifications", "user_id", userID, "error", err)
+			}
+			return
+		}
+		if err := rc.Flush(); err != nil || !h.waitForSignal(c, subscription, heartbeat) {
+			return
+		}
+	}
+}
+
+// waitForSignal blocks until the user may have something new, writing
+// heartbeats meanwhile. It returns false when the stream should end.
+func (h *NotificationHandler) waitForSignal(c *gin.Context, subscription *notify.Subscription, heartbeat *time.Ticker) bool {
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-h.hub.Done():
+			return false
+		case <-subscription.C:
+			return true
+		case <-heartbeat.C:
+			if _, err := io.WriteString(c.Writer, ": keep-alive\n\n"); err != nil {
+				return false
+			}
+			if err := http.NewResponseController(c.Writer).Flush(); err != nil {
+				return false
+			}
+		}
+	}
+}
+
+// resumeFrom returns the id after which the stream starts.
+func (h *NotificationHandler) resumeFrom(c *gin.Context, userID int) (int, bool) {
+	if header := c.GetHeader("Last-Event-ID"); header != "" {
+		id, err := strconv.Atoi(header)
+		if err != nil || id < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid Last-Event-ID"})
+			return 0, false
+		}
+		return id, true
+	}
+	id, err := h.service.LatestID(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return 0, false
+	}
+	return id, true
+}
+
+// sendNew writes the notifications after lastID and the unread count, and
+// returns the id of the last notification written.
+func (h *NotificationHandler) sendNew(c *gin.Context, userID, lastID int) (int, error) {
+	ctx := c.Request.Context()
+	for {
+		notifications, err := h.service.Since(ctx, userID, lastID, streamBatchSize)
+		if err != nil {
+			return lastID, err
+		}
+		for _, notification := range notifications {
+			data, err := json.Marshal(notification)
+			if err != nil {
+				return lastID, err
+			}
+			if _, err := fmt.Fprintf(c.Writer, "id: %d\nevent: notification\ndata: %s\n\n", notification.ID, data); err != nil {
+				return lastID, err
+			}
+			lastID = notification.ID
+		}
+		if len(notifications) < streamBatchSize {
+			break
+		}
+	}
+	unread, err := h.service.UnreadCount(ctx, userID)
+	if err != nil {
+		return lastID, err
+	}
+	_, err = fmt.Fprintf(c.Writer, "event: unread\ndata: {\"unread\":%d}\n\n", unread)
+	return lastID, err
+}
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"encoding/json"
+	"go-blog/openapi"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OpenAPIHandler struct {
+	doc *openapi.Document
+}
+
+func NewOpenAPIHandler(doc *openapi.Document) *OpenAPIHandler {
+	return &OpenAPIHandler{doc: doc}
+}
+
+func (h *OpenAPIHandler) Serve(c *gin.Context) {
+	body, err := json.Marshal(h.doc)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Cache-Control", "public, no-cache")
+	if notModified(c, contentETag(body), time.Time{}) {
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
@@ -1,29 +1,87 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"go-blog/models"
 	"go-blog/service"
+	"go-blog/syndication"
+	"html/template"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// postPage is the server-rendered preview of a post, served to clients
+// that ask for HTML.
+var postPage = template.Must(template.New("post").Funcs(template.FuncMap{
+	"paragraphs": func(text string) template.HTML { return template.HTML(syndication.TextToHTML(text)) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Title}}</title></head>
+<body style="font-family: sans-serif; max-width: 700px; margin: 40px auto; line-height: 1.5;">
+<article>
+<h1>{{.Title}}</h1>
+{{with .PublishedAt}}<p><time datetime="{{.Format "2006-01-02T15:04:05Z07:00"}}">{{.Format "2 January 2006"}}</time></p>
+{{else}}<p><em>Draft</em></p>
+{{end}}{{paragraphs .Content}}
+{{with .Tags}}<p>{{range .}}<a href="/tags/{{.}}/feed.atom">#{{.}}</a> {{end}}</p>
+{{end}}</article>
+</body>
+</html>
+`))
+
 type PostHandler struct {
-	service service.PostService
+	service   service.PostService
+	reactions service.ReactionService
+	maxAge    time.Duration
 }
 
-func NewPostHandler(service service.PostService) *PostHandler {
-	return &PostHandler{service: service}
+// NewPostHandler lets clients and shared caches keep anonymous post
+// responses for maxAge; see setCacheControl.
+func NewPostHandler(service service.PostService, reactions service.ReactionService, maxAge time.Duration) *PostHandler {
+	return &PostHandler{service: service, reactions: reactions, maxAge: maxAge}
 }
 
 func (h *PostHandler) GetPosts(c *gin.Context) {
-	posts, err := h.service.GetAllPosts()
+	posts, err := h.service.GetAllPosts(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, posts)
+	if err := h.attachUserReactions(c, posts); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.setCacheControl(c)
+	negotiated(c, posts, nil)
+}
+
+// setCacheControl lets anyone keep anonymous responses for maxAge, and
+// always revalidate after it. Authenticated responses carry the caller's
+// own reactions, so only their client may keep them.
+func (h *PostHandler) setCacheControl(c *gin.Context) {
+	c.Writer.Header().Add("Vary", "Authorization")
+	switch _, authenticated := c.Get("user_id"); {
+	case authenticated:
+		c.Header("Cache-Control", "private, no-cache")
+	case h.maxAge > 0:
+		c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(h.maxAge.Seconds())))
+	default:
+		c.Header("Cache-Control", "public, no-cache")
+	}
+}
+
+// attachUserReactions adds the caller's own reactions when the request was
+// authenticated by OptionalJWTAuth; anonymous responses are left as-is.
+func (h *PostHandler) attachUserReactions(c *gin.Context, posts []models.Post) error {
+	userID, exists := c.Get("user_id")
+	if !exists || h.reactions == nil {
+		return nil
+	}
+	return h.reactions.AttachUserReactions(c.Request.Context(), userID.(int), posts)
 }
 
 func (h *PostHandler) CreatePost(c *gin.Context) {
@@ -40,7 +98,20 @@ func (h *PostHandler) CreatePost(c *gin.Context) {
 	}
 	post.UserID = userID.(int)
 
-	createdPost, err := h.service.CreatePost(&post)
+	createdPost, err := h.service.CreatePost(c.Request.Context(), &post)
+	if errors.Is(err, models.ErrInvalidStatus) || errors.Is(err, models.ErrInvalidTag) || errors.Is(err, models.ErrInvalidSlug) ||
+		errors.Is(err, models.ErrMediaNotFound) || errors.Is(err, models.ErrTooManyMedia) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, models.ErrSlugTaken) {
+		c.JSON(http.StatusConflict, gin.H{"error": models.ErrSlugTaken.Error()})
+		return
+	}
+	if errors.Is(err, models.ErrMediaUnauthorized) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "you can only attach your own media"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -62,7 +133,20 @@ func (h *PostHandler) UpdatePost(c *gin.Context) {
 		return
 	}
 
-	updatedPost, err := h.service.UpdatePost(id, &post)
+	updatedPost, err := h.service.UpdatePost(c.Request.Context(), id, &post)
+	if errors.Is(err, models.ErrInvalidStatus) || errors.Is(err, models.ErrInvalidTag) || errors.Is(err, models.ErrInvalidSlug) ||
+		errors.Is(err, models.ErrMediaNotFound) || errors.Is(err, models.ErrTooManyMedia) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, models.ErrSlugTaken) {
+		c.JSON(http.StatusConflict, gin.H{"error": models.ErrSlugTaken.Error()})
+		return
+	}
+	if errors.Is(err, models.ErrMediaUnauthorized) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "you can only attach your own media"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -77,13 +161,15 @@ func (h *PostHandler) DeletePost(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid post id"})
 		return
 	}
-	if err := h.service.DeletePost(id); err != nil {
+	if err := h.service.DeletePost(c.Request.Context(), id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Post deleted successfully"})
 }
 
+// GetPostByID serves JSON or MessagePack, or the postPage preview to
+// clients that ask for HTML.
 func (h *PostHandler) GetPostByID(c *gin.Context) {
 	StringID := c.Param("id")
 	id, err := strconv.Atoi(StringID)
@@ -91,10 +177,21 @@ func (h *PostHandler) GetPostByID(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid post id"})
 		return
 	}
-	post, err := h.service.GetPostByID(id)
+	post, err := h.service.GetPostByID(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, post)
+	// Drafts are only visible to their author.
+	if !post.IsPublished() && c.GetInt("user_id") != post.UserID {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("post not found %d", id)})
+		return
+	}
+	posts := []models.Post{*post}
+	if err := h.attachUserReactions(c, posts); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.setCacheControl(c)
+	negotiated(c, posts[0], postPage)
 }
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"errors"
+	"go-blog/models"
+	"go-blog/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReactionHandler struct {
+	service service.ReactionService
+}
+
+func NewReactionHandler(service service.ReactionService) *ReactionHandler {
+	return &ReactionHandler{service: service}
+}
+
+// AddReaction is idempotent: reacting twice with the same type leaves the
+// counts unchanged and returns the current state.
+func (h *ReactionHandler) AddReaction(c *gin.Context) {
+	postID, reactionType, ok := reactionParams(c)
+	if !ok {
+		return
+	}
+	summary, err := h.service.React(c.Request.Context(), postID, c.GetInt("user_id"), reactionType)
+	if err != nil {
+		writeReactionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+func (h *ReactionHandler) RemoveReaction(c *gin.Context) {
+	postID, reactionType, ok := reactionParams(c)
+	if !ok {
+		return
+	}
+	summary, err := h.service.Unreact(c.Request.Context(), postID, c.GetInt("user_id"), reactionType)
+	if err != nil {
+		writeReactionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+// reactionParams reads the post id from the path and the reaction type from
+// the ?type= query parameter or, failing that, a JSON body.
+func reactionParams(c *gin.Context) (int, models.ReactionType, bool) {
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid post id"})
+		return 0, "", false
+	}
+	req := models.ReactionRequest{Type: models.ReactionType(c.Query("type"))}
+	if req.Type == "" && c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return 0, "", false
+		}
+	}
+	if !req.Type.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": models.ErrInvalidReaction.Error(), "allowed": models.ReactionTypes})
+		return 0, "", false
+	}
+	return postID, req.Type, true
+}
+
+func writeReactionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrPostNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
+	case errors.Is(err, models.ErrInvalidReaction):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
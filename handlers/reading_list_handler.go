@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"errors"
+	"go-blog/models"
+	"go-blog/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReadingListHandler struct {
+	service service.ReadingListService
+}
+
+func NewReadingListHandler(service service.ReadingListService) *ReadingListHandler {
+	return &ReadingListHandler{service: service}
+}
+
+func (h *ReadingListHandler) GetLists(c *gin.Context) {
+	lists, err := h.service.Lists(c.Request.Context(), c.GetInt("user_id"))
+	if err != nil {
+		writeReadingListError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, lists)
+}
+
+func (h *ReadingListHandler) CreateList(c *gin.Context) {
+	var req models.ReadingListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	list, err := h.service.CreateList(c.Request.Context(), c.GetInt("user_id"), req)
+	if err != nil {
+		writeReadingListError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, list)
+}
+
+func (h *ReadingListHandler) GetList(c *gin.Context) {
+	listID, ok := intParam(c, "id", "invalid list id")
+	if !ok {
+		return
+	}
+	detail, err := h.service.GetList(c.Request.Context(), c.GetInt("user_id"), listID)
+	if err != nil {
+		writeReadingListError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, detail)
+}
+
+func (h *ReadingListHandler) UpdateList(c *gin.Context) {
+	listID, ok := intParam(c, "id", "invalid list id")
+	if !ok {
+		return
+	}
+	var req models.ReadingListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	list, err := h.service.UpdateList(c.Request.Context(), c.GetInt("user_id"), listID, req)
+	if err != nil {
+		writeReadingListError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+func (h *ReadingListHandler) DeleteList(c *gin.Context) {
+	listID, ok := intParam(c, "id", "invalid list id")
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteList(c.Request.Context(), c.GetInt("user_id"), listID); err != nil {
+		writeReadingListError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Reading list deleted successfully"})
+}
+
+func (h *ReadingListHandler) GetItems(c *gin.Context) {
+	listID, ok := intParam(c, "id", "invalid list id")
+	if !ok {
+		return
+	}
+	detail, err := h.service.GetList(c.Request.Context(), c.GetInt("user_id"), listID)
+	if err != nil {
+		writeReadingListError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, detail.Items)
+}
+
+func (h *ReadingListHandler) AddItem(c *gin.Context) {
+	listID, ok := intParam(c, "id", "invalid list id")
+	if !ok {
+		return
+	}
+	var req models.ReadingListItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	detail, err := h.service.AddPost(c.Request.Context(), c.GetInt("user_id"), listID, req)
+	if err != nil {
+		writeReadingListError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, detail)
+}
+
+func (h *ReadingListHandler) UpdateItem(c *gin.Context) {
+	listID, ok := intParam(c, "id", "invalid list id")
+	if !ok {
+		return
+	}
+	postID, ok := intParam(c, "postId", "invalid post id")
+	if !ok {
+		return
+	}
+	var req models.ReadingListItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	detail, err := h.service.UpdateItem(c.Request.Context(), c.GetInt("user_id"), listID, postID, req)
+	if err != nil {
+		writeReadingListError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, detail)
+}
+
+func (h *ReadingListHandler) RemoveItem(c *gin.Context) {
+	listID, ok := intParam(c, "id", "invalid list id")
+	if !ok {
+		return
+	}
+	postID, ok := intParam(c, "postId", "invalid post id")
+	if !ok {
+		return
+	}
+	if err := h.service.RemovePost(c.Request.Context(), c.GetInt("user_id"), listID, postID); err != nil {
+		writeReadingListError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Post removed from reading list"})
+}
+
+func (h *ReadingListHandler) GetSharedList(c *gin.Context) {
+	detail, err := h.service.SharedList(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		writeReadingListError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, detail)
+}
+
+func intParam(c *gin.Context, name, message string) (int, bool) {
+	n, err := strconv.Atoi(c.Param(name))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": message})
+		return 0, false
+	}
+	return n, true
+}
+
+func writeReadingListError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrReadingListNotFound), errors.Is(err, models.ErrItemNotInList), errors.Is(err, models.ErrPostNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrReadingListNameExists), errors.Is(err, models.ErrItemAlreadyInList):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrReadingListNameEmpty), errors.Is(err, models.ErrDefaultReadingList):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
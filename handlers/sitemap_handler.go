@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"go-blog/service"
+	"go-blog/sitemap"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SitemapHandler struct {
+	service service.SitemapService
+}
+
+func NewSitemapHandler(service service.SitemapService) *SitemapHandler {
+	return &SitemapHandler{service: service}
+}
+
+func (h *SitemapHandler) Sitemap(c *gin.Context) {
+	h.serve(c, strings.HasSuffix(c.Request.URL.Path, ".gz"), func(s *sitemap.Sitemap) (sitemap.File, bool) {
+		return s.Root, true
+	})
+}
+
+// Chunk serves /sitemaps/sitemap-<n>.xml and its .xml.gz twin.
+func (h *SitemapHandler) Chunk(c *gin.Context) {
+	name := c.Param("file")
+	gzipped := strings.HasSuffix(name, ".gz")
+	name = strings.TrimSuffix(name, ".gz")
+	n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, "sitemap-"), ".xml"))
+	if err != nil || !strings.HasPrefix(name, "sitemap-") || !strings.HasSuffix(name, ".xml") {
+		c.JSON(http.StatusNotFound, gin.H{"error": "sitemap not found"})
+		return
+	}
+	h.serve(c, gzipped, func(s *sitemap.Sitemap) (sitemap.File, bool) {
+		return s.Chunk(n)
+	})
+}
+
+func (h *SitemapHandler) Robots(c *gin.Context) {
+	c.String(http.StatusOK, h.service.Robots())
+}
+
+func (h *SitemapHandler) serve(c *gin.Context, gzipped bool, pick func(*sitemap.Sitemap) (sitemap.File, bool)) {
+	s, stats, err := h.service.Sitemap(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	file, ok := pick(s)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "sitemap not found"})
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=300")
+	if notModified(c, weakETag(c.Request.URL.Path, stats.Count, stats.LastUpdated.UnixNano()), stats.LastUpdated) {
+		return
+	}
+	if gzipped {
+		c.Data(http.StatusOK, "application/gzip", file.Gzip)
+		return
+	}
+	c.Data(http.StatusOK, "application/xml; charset=utf-8", file.XML)
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"go-blog/config"
+	"go-blog/models"
+	"go-blog/service"
+	"go-blog/syndication"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	formatRSS  = "rss"
+	formatAtom = "atom"
+)
+
+type SyndicationHandler struct {
+	service service.SyndicationService
+	site    config.SiteConfig
+}
+
+func NewSyndicationHandler(service service.SyndicationService, site config.SiteConfig) *SyndicationHandler {
+	return &SyndicationHandler{service: service, site: site}
+}
+
+func (h *SyndicationHandler) RSS(c *gin.Context) {
+	h.serve(c, formatRSS)
+}
+
+func (h *SyndicationHandler) Atom(c *gin.Context) {
+	h.serve(c, formatAtom)
+}
+
+func (h *SyndicationHandler) serve(c *gin.Context, format string) {
+	scope := service.FeedScope{Author: c.Param("username"), Tag: strings.ToLower(c.Param("tag"))}
+	ctx := c.Request.Context()
+
+	stats, err := h.service.Stats(ctx, scope)
+	if err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Cache-Control", "public, max-age=60")
+	etag := weakETag(format, scope.Path(), stats.Count, stats.LastUpdated.UnixNano(), h.site.FeedItems)
+	if notModified(c, etag, stats.LastUpdated) {
+		return
+	}
+
+	selfLink := h.site.URL(scope.Path() + "." + format)
+	feed, err := h.service.Feed(ctx, scope, selfLink)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var body bytes.Buffer
+	contentType := "application/rss+xml; charset=utf-8"
+	if format == formatAtom {
+		contentType = "application/atom+xml; charset=utf-8"
+		err = syndication.WriteAtom(&body, *feed)
+	} else {
+		err = syndication.WriteRSS(&body, *feed)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, contentType, body.Bytes())
+}
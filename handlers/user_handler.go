@@ -1,10 +1,11 @@
 package handlers
 
 import (
+	"go-blog/config"
+	"go-blog/metrics"
 	"go-blog/models"
 	"go-blog/service"
 	"net/http"
-	"os"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -17,11 +18,13 @@ type AuthInput struct {
 }
 
 type UserHandler struct {
-	service *service.UserService
+	service    service.UserService
+	authConfig config.AuthConfig
+	metrics    *metrics.Metrics
 }
 
-func NewUserHandler(service *service.UserService) *UserHandler {
-	return &UserHandler{service: service}
+func NewUserHandler(service service.UserService, authConfig config.AuthConfig, metrics *metrics.Metrics) *UserHandler {
+	return &UserHandler{service: service, authConfig: authConfig, metrics: metrics}
 }
 
 func (h *UserHandler) Login(c *gin.Context) {
@@ -31,8 +34,9 @@ func (h *UserHandler) Login(c *gin.Context) {
 		return
 	}
 
-	user, err := h.service.Login(authInput.Username, authInput.Password)
+	user, err := h.service.Login(c.Request.Context(), authInput.Username, authInput.Password)
 	if err != nil {
+		h.metrics.LoginFailed()
 		if err.Error() == "invalid password" {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid password"})
 			return
@@ -41,16 +45,18 @@ func (h *UserHandler) Login(c *gin.Context) {
 		return
 	}
 	generateToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"id":  user.ID,
-		"account_type":user.AccountType,
-		"exp": time.Now().Add(time.Hour * 24).Unix(),
+		"id":           user.ID,
+		"account_type": user.AccountType,
+		"exp":          time.Now().Add(h.authConfig.TokenTTL).Unix(),
 	})
 
-	token, err := generateToken.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	token, err := generateToken.SignedString([]byte(h.authConfig.JWTSecret))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to generate token"})
 		return
 	}
+	h.metrics.LoginSucceeded()
+	h.metrics.TokenIssued()
 	c.JSON(http.StatusOK, gin.H{
 		"token": token,
 	})
@@ -62,13 +68,13 @@ func (h *UserHandler) Register(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
 		return
 	}
-	user, err := h.service.Register(&req)
+	user, err := h.service.Register(c.Request.Context(), &req)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusCreated, gin.H{
-		"message":     "user created",
+		"message":      "user created",
 		"user":         user.Username,
 		"account_type": user.AccountType,
 	})
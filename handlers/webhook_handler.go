@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"errors"
+	"go-blog/models"
+	"go-blog/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookHandler serves both /api/me/webhooks and the site-wide
+// /admin/webhooks. Admin requests carry no user, so c.GetInt("user_id") is 0
+// there, which the service takes to mean the site-wide webhooks.
+type WebhookHandler struct {
+	service service.WebhookService
+}
+
+func NewWebhookHandler(service service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{service: service}
+}
+
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	webhooks, err := h.service.ListWebhooks(c.Request.Context(), c.GetInt("user_id"))
+	if err != nil {
+		writeWebhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, webhooks)
+}
+
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var req models.WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	webhook, err := h.service.CreateWebhook(c.Request.Context(), c.GetInt("user_id"), req)
+	if err != nil {
+		writeWebhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, webhook)
+}
+
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	id, ok := intParam(c, "id", "invalid webhook id")
+	if !ok {
+		return
+	}
+	webhook, err := h.service.GetWebhook(c.Request.Context(), c.GetInt("user_id"), id)
+	if err != nil {
+		writeWebhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, webhook)
+}
+
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	id, ok := intParam(c, "id", "invalid webhook id")
+	if !ok {
+		return
+	}
+	var req models.WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	webhook, err := h.service.UpdateWebhook(c.Request.Context(), c.GetInt("user_id"), id, req)
+	if err != nil {
+		writeWebhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, webhook)
+}
+
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	id, ok := intParam(c, "id", "invalid webhook id")
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteWebhook(c.Request.Context(), c.GetInt("user_id"), id); err != nil {
+		writeWebhookError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListDeliveries accepts ?before= (a delivery id) and ?limit=.
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	id, ok := intParam(c, "id", "invalid webhook id")
+	if !ok {
+		return
+	}
+	before, limit, ok := pageParams(c)
+	if !ok {
+		return
+	}
+	deliveries, err := h.service.ListDeliveries(c.Request.Context(), c.GetInt("user_id"), id, before, limit)
+	if err != nil {
+		writeWebhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, deliveries)
+}
+
+func (h *WebhookHandler) GetDelivery(c *gin.Context) {
+	id, deliveryID, ok := deliveryParams(c)
+	if !ok {
+		return
+	}
+	delivery, err := h.service.GetDelivery(c.Request.Context(), c.GetInt("user_id"), id, deliveryID)
+	if err != nil {
+		writeWebhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, delivery)
+}
+
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	id, deliveryID, ok := deliveryParams(c)
+	if !ok {
+		return
+	}
+	delivery, err := h.service.Redeliver(c.Request.Context(), c.GetInt("user_id"), id, deliveryID)
+	if err != nil {
+		writeWebhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+func deliveryParams(c *gin.Context) (int, int, bool) {
+	id, ok := intParam(c, "id", "invalid webhook id")
+	if !ok {
+		return 0, 0, false
+	}
+	deliveryID, ok := intParam(c, "deliveryId", "invalid delivery id")
+	return id, deliveryID, ok
+}
+
+func writeWebhookError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrWebhookNotFound), errors.Is(err, models.ErrDeliveryNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrInvalidWebhook):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrDeliveryPending):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
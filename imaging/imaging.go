@@ -0,0 +1,160 @@
+// Package imaging checks uploaded files and prepares images for storage. The
+// content type is sniffed from the bytes rather than trusted from the
+// client, and images are decoded and re-encoded so that EXIF blocks (GPS
+// position, camera serial numbers) and other metadata never reach storage.
+package imaging
+
+import (
+	"bytes"
+	"fmt"
+	"go-blog/models"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+// MaxPixels bounds width*height before an image is decoded, so a small file
+// that claims enormous dimensions cannot exhaust memory.
+const MaxPixels = 40_000_000
+
+const jpegQuality = 90
+
+// Extensions maps every accepted stored content type to the extension used
+// in storage keys.
+var Extensions = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"application/pdf": ".pdf",
+}
+
+type Result struct {
+	ContentType string
+	Data        []byte
+	Width       int
+	Height      int
+	// Thumbnail is nil for files that are not images.
+	Thumbnail            []byte
+	ThumbnailContentType string
+}
+
+// Process sniffs data and returns what should be stored. Images are
+// re-encoded without metadata and get a thumbnail that fits in a
+// thumbSize x thumbSize box; WebP has no pure-Go encoder, so it is stored
+// as PNG. Other accepted types are passed through unchanged.
+func Process(data []byte, thumbSize int) (*Result, error) {
+	contentType := http.DetectContentType(data)
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif", "image/webp":
+		return processImage(data, contentType, thumbSize)
+	case "application/pdf":
+		return &Result{ContentType: contentType, Data: data}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", models.ErrUnsupportedMedia, contentType)
+	}
+}
+
+func processImage(data []byte, contentType string, thumbSize int) (*Result, error) {
+	var (
+		config image.Config
+		err    error
+	)
+	if contentType == "image/webp" {
+		config, err = webp.DecodeConfig(bytes.NewReader(data))
+	} else {
+		config, _, err = image.DecodeConfig(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: corrupt %s", models.ErrUnsupportedMedia, contentType)
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > MaxPixels {
+		return nil, fmt.Errorf("%w: %dx%d exceeds %d pixels", models.ErrMediaTooLarge, config.Width, config.Height, MaxPixels)
+	}
+
+	result := &Result{ContentType: contentType}
+	var out bytes.Buffer
+	var first image.Image
+	switch contentType {
+	case "image/jpeg":
+		img, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%w: corrupt %s", models.ErrUnsupportedMedia, contentType)
+		}
+		// The orientation tag is about to be discarded with the rest of the
+		// EXIF block, so bake it into the pixels first.
+		first = orient(img, exifOrientation(data))
+		err = jpeg.Encode(&out, first, &jpeg.Options{Quality: jpegQuality})
+		if err != nil {
+			return nil, err
+		}
+	case "image/gif":
+		// DecodeAll keeps every frame; comments and application extensions
+		// other than the loop count are dropped on re-encode.
+		anim, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil || len(anim.Image) == 0 {
+			return nil, fmt.Errorf("%w: corrupt %s", models.ErrUnsupportedMedia, contentType)
+		}
+		first = anim.Image[0]
+		if err := gif.EncodeAll(&out, anim); err != nil {
+			return nil, err
+		}
+	default:
+		var img image.Image
+		if contentType == "image/webp" {
+			img, err = webp.Decode(bytes.NewReader(data))
+		} else {
+			img, err = png.Decode(bytes.NewReader(data))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: corrupt %s", models.ErrUnsupportedMedia, contentType)
+		}
+		first = img
+		result.ContentType = "image/png"
+		if err := png.Encode(&out, img); err != nil {
+			return nil, err
+		}
+	}
+	result.Data = out.Bytes()
+	result.Width, result.Height = first.Bounds().Dx(), first.Bounds().Dy()
+
+	thumb, thumbType, err := thumbnail(first, result.ContentType, thumbSize)
+	if err != nil {
+		return nil, err
+	}
+	result.Thumbnail, result.ThumbnailContentType = thumb, thumbType
+	return result, nil
+}
+
+// thumbnail scales img to fit within size x size, never enlarging it. JPEG
+// sources give JPEG thumbnails; everything else gives PNG to keep
+// transparency.
+func thumbnail(img image.Image, contentType string, size int) ([]byte, string, error) {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > size || height > size {
+		if width >= height {
+			width, height = size, max(1, height*size/width)
+		} else {
+			width, height = max(1, width*size/height), size
+		}
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+
+	var out bytes.Buffer
+	if contentType == "image/jpeg" {
+		if err := jpeg.Encode(&out, dst, &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return nil, "", err
+		}
+		return out.Bytes(), "image/jpeg", nil
+	}
+	if err := png.Encode(&out, dst); err != nil {
+		return nil, "", err
+	}
+	return out.Bytes(), "image/png", nil
+}
@@ -0,0 +1,113 @@
+// Package jobs is a durable background job queue kept in the application
+// database. On Postgres, workers claim jobs with SELECT ... FOR UPDATE SKIP
+// LOCKED, so any number of processes can share one queue without handing
+// the same job out twice. SQLite has a single writer and needs no locking
+// clause, which keeps development and tests on the same code path.
+//
+// A job moves from queued to running when a worker claims it. It ends as
+// succeeded, or goes back to queued with a later run_at when it fails and
+// has attempts left, or is dead-lettered once it has not. Dead jobs stay in
+// the table until an operator retries them.
+package jobs
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+type Status string
+
+const (
+	StatusQueued    Status = "queued"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusDead      Status = "dead"
+)
+
+func (s Status) Valid() bool {
+	switch s {
+	case StatusQueued, StatusRunning, StatusSucceeded, StatusDead:
+		return true
+	}
+	return false
+}
+
+type Job struct {
+	ID          int             `json:"id" gorm:"primaryKey"`
+	Kind        string          `json:"kind"`
+	Payload     json.RawMessage `json:"payload" gorm:"serializer:json"`
+	Status      Status          `json:"status"`
+	UniqueKey   *string         `json:"unique_key,omitempty"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	RunAt       time.Time       `json:"run_at"`
+	LockedAt    *time.Time      `json:"locked_at,omitempty"`
+	LockedBy    string          `json:"locked_by,omitempty"`
+	LastError   string          `json:"last_error,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	FinishedAt  *time.Time      `json:"finished_at,omitempty"`
+}
+
+// Args is the payload of a job. It is stored as JSON, and Kind names the
+// handler that runs it, so it must not change once jobs of that kind exist.
+// Kind is called on the zero value and needs a value receiver.
+type Args interface {
+	Kind() string
+}
+
+// Options adjust a single enqueue. Zero values mean "now", the configured
+// attempt limit and no uniqueness.
+type Options struct {
+	RunAt       time.Time
+	MaxAttempts int
+	// UniqueKey prevents a second job with the same key while one is still
+	// queued or running. Finished jobs do not count.
+	UniqueKey string
+}
+
+// Filter narrows List. Jobs are returned newest first; BeforeID pages
+// through older ones.
+type Filter struct {
+	Kind     string
+	Status   Status
+	BeforeID int
+	Limit    int
+}
+
+// Count is the number of jobs of one kind in one status.
+type Count struct {
+	Kind   string `json:"kind"`
+	Status Status `json:"status"`
+	Count  int64  `json:"count"`
+}
+
+var (
+	ErrDuplicate    = errors.New("a job with this unique key is already pending")
+	ErrNotFound     = errors.New("job not found")
+	ErrNotRetryable = errors.New("only dead jobs can be retried")
+	ErrUnknownKind  = errors.New("no handler is registered for this job kind")
+)
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks a handler error as not worth retrying: the job is
+// dead-lettered straight away instead of being retried.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked with Permanent.
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
@@ -0,0 +1,345 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-blog/config"
+	"go-blog/db"
+	"go-blog/metrics"
+	"log/slog"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type handlerFunc func(ctx context.Context, payload json.RawMessage) error
+
+// Queue enqueues jobs and, through Run, works them off. One Queue serves the
+// whole process; handlers are registered on it with Handle before Run
+// starts.
+type Queue struct {
+	db      *gorm.DB
+	cfg     config.JobsConfig
+	metrics *metrics.Metrics
+	logger  *slog.Logger
+	// worker identifies this process in locked_by.
+	worker string
+
+	mu       sync.RWMutex
+	handlers map[string]handlerFunc
+
+	// wake is signalled by Enqueue and by finished jobs so idle workers do
+	// not wait for the next poll. Both channels are buffered and written
+	// without blocking.
+	wake chan struct{}
+}
+
+func New(gormDB *gorm.DB, cfg config.JobsConfig, m *metrics.Metrics, logger *slog.Logger) *Queue {
+	host, _ := os.Hostname()
+	return &Queue{
+		db:       gormDB,
+		cfg:      cfg,
+		metrics:  m,
+		logger:   logger,
+		worker:   fmt.Sprintf("%s:%d", host, os.Getpid()),
+		handlers: map[string]handlerFunc{},
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Handle registers fn for jobs whose args are a T. Registering a kind twice
+// replaces the earlier handler. Args that no longer decode are dead-lettered
+// without retrying.
+func Handle[T Args](q *Queue, fn func(ctx context.Context, args T) error) {
+	var zero T
+	kind := zero.Kind()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[kind] = func(ctx context.Context, payload json.RawMessage) error {
+		var args T
+		if err := json.Unmarshal(payload, &args); err != nil {
+			return Permanent(fmt.Errorf("decoding %s args: %w", kind, err))
+		}
+		return fn(ctx, args)
+	}
+}
+
+func (q *Queue) handler(kind string) (handlerFunc, bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	h, ok := q.handlers[kind]
+	return h, ok
+}
+
+func (q *Queue) kinds() []string {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	kinds := make([]string, 0, len(q.handlers))
+	for kind := range q.handlers {
+		kinds = append(kinds, kind)
+	}
+	slices.Sort(kinds)
+	return kinds
+}
+
+// Enqueue queues a job. Inside db.Transaction it joins the caller's
+// transaction, so the job only exists if that commits.
+func (q *Queue) Enqueue(ctx context.Context, args Args, opts Options) (*Job, error) {
+	return q.EnqueueTx(db.Conn(ctx, q.db), args, opts)
+}
+
+// EnqueueTx inserts the job through tx, so it is only queued if the caller's
+// transaction commits. With a UniqueKey that is already pending it returns
+// the pending job and ErrDuplicate; the insert uses ON CONFLICT DO NOTHING so
+// the caller's transaction stays usable. Workers are woken once the
+// db.Transaction that tx belongs to commits, if any.
+func (q *Queue) EnqueueTx(tx *gorm.DB, args Args, opts Options) (*Job, error) {
+	kind := args.Kind()
+	if _, ok := q.handler(kind); !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKind, kind)
+	}
+	payload, err := json.Marshal(args)
+	if err != nil {
+		return nil, fmt.Errorf("encoding %s args: %w", kind, err)
+	}
+	now := time.Now().UTC()
+	job := &Job{
+		Kind:        kind,
+		Payload:     payload,
+		Status:      StatusQueued,
+		MaxAttempts: opts.MaxAttempts,
+		RunAt:       opts.RunAt.UTC(),
+	}
+	if job.MaxAttempts < 1 {
+		job.MaxAttempts = q.cfg.MaxAttempts
+	}
+	if job.RunAt.Before(now) {
+		job.RunAt = now
+	}
+	if opts.UniqueKey == "" {
+		if err := tx.Create(job).Error; err != nil {
+			return nil, err
+		}
+		db.AfterCommit(tx.Statement.Context, q.signal)
+		return job, nil
+	}
+
+	job.UniqueKey = &opts.UniqueKey
+	result := tx.Clauses(clause.OnConflict{
+		Columns:     []clause.Column{{Name: "unique_key"}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "unique_key IS NOT NULL AND status IN ('queued', 'running')"}}},
+		DoNothing:   true,
+	}).Create(job)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		var existing Job
+		err := tx.Where("unique_key = ? AND status IN ?", opts.UniqueKey, []Status{StatusQueued, StatusRunning}).First(&existing).Error
+		if err != nil {
+			return nil, fmt.Errorf("looking up duplicate job: %w", err)
+		}
+		return &existing, ErrDuplicate
+	}
+	db.AfterCommit(tx.Statement.Context, q.signal)
+	return job, nil
+}
+
+func (q *Queue) signal() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// claim marks up to limit due jobs of the registered kinds as running and
+// returns them in queue order.
+func (q *Queue) claim(ctx context.Context, limit int) ([]Job, error) {
+	kinds := q.kinds()
+	if len(kinds) == 0 || limit < 1 {
+		return nil, nil
+	}
+	lock := ""
+	if db.IsPostgres(q.db) {
+		lock = " FOR UPDATE SKIP LOCKED"
+	}
+	now := time.Now().UTC()
+	jobs := []Job{}
+	err := q.db.WithContext(ctx).Raw(`UPDATE jobs
+SET status = ?, attempts = attempts + 1, locked_at = ?, locked_by = ?, updated_at = ?
+WHERE id IN (
+	SELECT id FROM jobs
+	WHERE status = ? AND run_at <= ? AND kind IN ?
+	ORDER BY run_at, id
+	LIMIT ?`+lock+`
+)
+RETURNING *`, StatusRunning, now, q.worker, now, StatusQueued, now, kinds, limit).Scan(&jobs).Error
+	if err != nil {
+		return nil, fmt.Errorf("claiming jobs: %w", err)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		if !jobs[i].RunAt.Equal(jobs[j].RunAt) {
+			return jobs[i].RunAt.Before(jobs[j].RunAt)
+		}
+		return jobs[i].ID < jobs[j].ID
+	})
+	return jobs, nil
+}
+
+// complete records the outcome of an attempt. The update is conditional on
+// this worker still holding the lock, so a job that was rescued from us
+// after timing out is left to whoever has it now.
+func (q *Queue) complete(ctx context.Context, job *Job, runErr error) (string, error) {
+	now := time.Now().UTC()
+	updates := map[string]any{"locked_at": nil, "locked_by": "", "updated_at": now}
+	outcome := "succeeded"
+	switch {
+	case runErr == nil:
+		updates["status"] = StatusSucceeded
+		updates["finished_at"] = now
+		updates["last_error"] = ""
+	case IsPermanent(runErr) || job.Attempts >= job.MaxAttempts:
+		outcome = "dead"
+		updates["status"] = StatusDead
+		updates["finished_at"] = now
+		updates["last_error"] = truncateError(runErr)
+	default:
+		outcome = "retried"
+		updates["status"] = StatusQueued
+		updates["run_at"] = now.Add(q.backoff(job.Attempts))
+		updates["last_error"] = truncateError(runErr)
+	}
+	result := q.db.WithContext(ctx).Model(&Job{}).
+		Where("id = ? AND status = ? AND locked_by = ?", job.ID, StatusRunning, q.worker).
+		Updates(updates)
+	if result.Error != nil {
+		return outcome, result.Error
+	}
+	if result.RowsAffected == 0 {
+		q.logger.WarnContext(ctx, "job lock was lost before it finished", "job_id", job.ID, "kind", job.Kind)
+	}
+	return outcome, nil
+}
+
+// backoff doubles RetryBackoff for every attempt already made, caps it at
+// MaxRetryBackoff and adds up to 10% jitter so retries of jobs that failed
+// together spread out.
+func (q *Queue) backoff(attempts int) time.Duration {
+	delay := q.cfg.RetryBackoff
+	for i := 1; i < attempts && delay < q.cfg.MaxRetryBackoff; i++ {
+		delay *= 2
+	}
+	delay = min(delay, q.cfg.MaxRetryBackoff)
+	return delay + rand.N(delay/10+1)
+}
+
+// rescue requeues jobs whose worker has held them longer than the job
+// timeout, which only happens when that worker died. Jobs that have used up
+// their attempts are dead-lettered instead, so a job that crashes the
+// process cannot loop forever.
+func (q *Queue) rescue(ctx context.Context) (int64, error) {
+	now := time.Now().UTC()
+	stale := now.Add(-q.cfg.Timeout)
+	var rescued int64
+	err := q.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		expired := tx.Model(&Job{}).Where("status = ? AND locked_at < ?", StatusRunning, stale)
+		dead := expired.Session(&gorm.Session{}).Where("attempts >= max_attempts").Updates(map[string]any{
+			"status": StatusDead, "locked_at": nil, "locked_by": "", "finished_at": now, "updated_at": now,
+			"last_error": "worker stopped responding",
+		})
+		if dead.Error != nil {
+			return dead.Error
+		}
+		requeued := expired.Session(&gorm.Session{}).Updates(map[string]any{
+			"status": StatusQueued, "locked_at": nil, "locked_by": "", "run_at": now, "updated_at": now,
+			"last_error": "worker stopped responding",
+		})
+		rescued = dead.RowsAffected + requeued.RowsAffected
+		return requeued.Error
+	})
+	return rescued, err
+}
+
+func (q *Queue) List(ctx context.Context, filter Filter) ([]Job, error) {
+	query := q.db.WithContext(ctx).Order("id DESC")
+	if filter.Kind != "" {
+		query = query.Where("kind = ?", filter.Kind)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.BeforeID > 0 {
+		query = query.Where("id < ?", filter.BeforeID)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	jobs := []Job{}
+	return jobs, query.Find(&jobs).Error
+}
+
+func (q *Queue) Get(ctx context.Context, id int) (*Job, error) {
+	var job Job
+	if err := q.db.WithContext(ctx).First(&job, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Retry puts a dead job back in the queue with a fresh set of attempts.
+func (q *Queue) Retry(ctx context.Context, id int) (*Job, error) {
+	job, err := q.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != StatusDead {
+		return nil, ErrNotRetryable
+	}
+	now := time.Now().UTC()
+	result := q.db.WithContext(ctx).Model(&Job{}).Where("id = ? AND status = ?", id, StatusDead).Updates(map[string]any{
+		"status": StatusQueued, "attempts": 0, "run_at": now, "finished_at": nil, "updated_at": now,
+	})
+	if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+		return nil, ErrDuplicate
+	}
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrNotRetryable
+	}
+	q.signal()
+	return q.Get(ctx, id)
+}
+
+// Counts reports how many jobs each kind has in each status.
+func (q *Queue) Counts(ctx context.Context) ([]Count, error) {
+	counts := []Count{}
+	err := q.db.WithContext(ctx).Model(&Job{}).
+		Select("kind, status, COUNT(*) AS count").
+		Group("kind, status").Order("kind, status").
+		Scan(&counts).Error
+	return counts, err
+}
+
+// truncateError keeps last_error readable when a handler returns something
+// enormous, such as a whole response body.
+func truncateError(err error) string {
+	msg := err.Error()
+	if len(msg) > 2000 {
+		msg = strings.ToValidUTF8(msg[:2000], "") + "…"
+	}
+	return msg
+}
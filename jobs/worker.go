@@ -0,0 +1,145 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"go-blog/tracing"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func (q *Queue) Name() string {
+	return "job queue"
+}
+
+// Run works off due jobs with up to Concurrency running at once until ctx is
+// cancelled. Cancelling only stops new claims: jobs already running keep a
+// context of their own, bounded by the job timeout, and Run returns once
+// they have finished. Stopping a worker mid-job is therefore safe, and a
+// job lost to a crash is rescued after the timeout.
+func (q *Queue) Run(ctx context.Context) error {
+	slots := make(chan struct{}, max(q.cfg.Concurrency, 1))
+	var running sync.WaitGroup
+	defer running.Wait()
+
+	// Queue bookkeeping is not cancelled with ctx: a claim interrupted
+	// after its UPDATE committed would strand jobs as running until they
+	// are rescued.
+	dbCtx := context.WithoutCancel(ctx)
+	ticker := time.NewTicker(q.cfg.PollInterval)
+	defer ticker.Stop()
+	q.logger.InfoContext(ctx, "job workers started", "concurrency", cap(slots), "kinds", q.kinds())
+	for {
+		if n, err := q.rescue(dbCtx); err != nil {
+			q.logger.ErrorContext(ctx, "rescuing stalled jobs", "error", err)
+		} else if n > 0 {
+			q.logger.WarnContext(ctx, "rescued stalled jobs", "jobs", n)
+		}
+
+		for ctx.Err() == nil {
+			free := cap(slots) - len(slots)
+			jobs, err := q.claim(dbCtx, free)
+			if err != nil {
+				q.logger.ErrorContext(ctx, "claiming jobs", "error", err)
+				break
+			}
+			for _, job := range jobs {
+				slots <- struct{}{}
+				running.Add(1)
+				go func(job Job) {
+					defer running.Done()
+					defer func() { <-slots; q.signal() }()
+					q.execute(dbCtx, &job)
+				}(job)
+			}
+			// A full batch suggests more are due; a short one means the
+			// queue is drained for now.
+			if len(jobs) < free || free == 0 {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			q.logger.InfoContext(ctx, "job workers stopping", "running", len(slots))
+			return ctx.Err()
+		case <-ticker.C:
+		case <-q.wake:
+		}
+	}
+}
+
+// Drain runs every due job one at a time in the calling goroutine and
+// returns how many it ran. Jobs retried with a backoff are not due yet and
+// are left queued. It is meant for tests and one-off maintenance commands.
+func (q *Queue) Drain(ctx context.Context) (int, error) {
+	ran := 0
+	for {
+		jobs, err := q.claim(ctx, 1)
+		if err != nil || len(jobs) == 0 {
+			return ran, err
+		}
+		q.execute(ctx, &jobs[0])
+		ran++
+	}
+}
+
+func (q *Queue) execute(ctx context.Context, job *Job) {
+	ctx, cancel := context.WithTimeout(ctx, q.cfg.Timeout)
+	defer cancel()
+	ctx = context.WithValue(ctx, attemptKey{}, attempt{job.Attempts, job.MaxAttempts})
+	ctx, span := otel.Tracer("go-blog/jobs").Start(ctx, "job "+job.Kind, trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(attribute.Int("job.id", job.ID), attribute.Int("job.attempt", job.Attempts)))
+	logger := q.logger.With("job_id", job.ID, "kind", job.Kind, "attempt", job.Attempts)
+
+	start := time.Now()
+	err := q.call(ctx, job)
+	elapsed := time.Since(start)
+	tracing.End(span, err)
+
+	outcome, saveErr := q.complete(context.WithoutCancel(ctx), job, err)
+	if saveErr != nil {
+		logger.ErrorContext(ctx, "recording job result", "error", saveErr)
+	}
+	q.metrics.JobFinished(job.Kind, outcome, elapsed)
+	switch outcome {
+	case "succeeded":
+		logger.DebugContext(ctx, "job succeeded", "duration", elapsed)
+	case "retried":
+		logger.WarnContext(ctx, "job failed, will retry", "error", err, "duration", elapsed)
+	default:
+		logger.ErrorContext(ctx, "job dead-lettered", "error", err, "duration", elapsed)
+	}
+}
+
+type attemptKey struct{}
+
+type attempt struct{ n, max int }
+
+// Attempt reports which attempt of the running job this is, counting from
+// one, and how many it gets. Handlers use it to tell a final failure from one
+// that will be retried. Outside a job both are zero.
+func Attempt(ctx context.Context) (n, max int) {
+	a, _ := ctx.Value(attemptKey{}).(attempt)
+	return a.n, a.max
+}
+
+// call runs the handler, turning a panic into an error so one bad job
+// cannot take the worker down.
+func (q *Queue) call(ctx context.Context, job *Job) (err error) {
+	h, ok := q.handler(job.Kind)
+	if !ok {
+		return Permanent(fmt.Errorf("%w: %q", ErrUnknownKind, job.Kind))
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v\n%s", r, debug.Stack())
+		}
+	}()
+	return h(ctx, job.Payload)
+}
@@ -0,0 +1,94 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-blog/config"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// GormLogger routes GORM's SQL logging into the request-scoped slog logger.
+// Statements are logged at debug level, slow ones at warn and failures at
+// error. Unless LogSQLParams is enabled the bound parameters are left as
+// placeholders so user data never reaches the logs.
+type GormLogger struct {
+	slowThreshold time.Duration
+	logParams     bool
+	level         gormlogger.LogLevel
+}
+
+func NewGormLogger(cfg config.LogConfig) *GormLogger {
+	return &GormLogger{
+		slowThreshold: cfg.SlowQueryThreshold,
+		logParams:     cfg.LogSQLParams,
+		level:         gormlogger.Info,
+	}
+}
+
+func (l *GormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	clone := *l
+	clone.level = level
+	return &clone
+}
+
+func (l *GormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Info {
+		FromContext(ctx).InfoContext(ctx, fmt.Sprintf(msg, args...), "component", "gorm")
+	}
+}
+
+func (l *GormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Warn {
+		FromContext(ctx).WarnContext(ctx, fmt.Sprintf(msg, args...), "component", "gorm")
+	}
+}
+
+func (l *GormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Error {
+		FromContext(ctx).ErrorContext(ctx, fmt.Sprintf(msg, args...), "component", "gorm")
+	}
+}
+
+func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.level <= gormlogger.Silent {
+		return
+	}
+	elapsed := time.Since(begin)
+	logger := FromContext(ctx)
+
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && l.level >= gormlogger.Error:
+		sql, rows := fc()
+		logger.ErrorContext(ctx, "sql error", sqlAttrs(sql, rows, elapsed, slog.String("error", err.Error()))...)
+	case l.slowThreshold > 0 && elapsed > l.slowThreshold && l.level >= gormlogger.Warn:
+		sql, rows := fc()
+		logger.WarnContext(ctx, "slow sql", sqlAttrs(sql, rows, elapsed, slog.Duration("threshold", l.slowThreshold))...)
+	case l.level >= gormlogger.Info && logger.Enabled(ctx, slog.LevelDebug):
+		sql, rows := fc()
+		logger.DebugContext(ctx, "sql", sqlAttrs(sql, rows, elapsed)...)
+	}
+}
+
+// ParamsFilter is consulted by GORM before it interpolates bound values into
+// the SQL passed to Trace. Returning nil params keeps the placeholders.
+func (l *GormLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	if l.logParams {
+		return sql, params
+	}
+	return sql, nil
+}
+
+func sqlAttrs(sql string, rows int64, elapsed time.Duration, extra ...any) []any {
+	attrs := []any{
+		slog.String("component", "gorm"),
+		slog.String("sql", sql),
+		slog.Int64("rows", rows),
+		slog.Duration("elapsed", elapsed),
+	}
+	return append(attrs, extra...)
+}
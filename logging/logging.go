@@ -0,0 +1,49 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"go-blog/config"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+type contextKey struct{}
+
+func New(cfg config.LogConfig) (*slog.Logger, error) {
+	return NewWithWriter(cfg, os.Stdout)
+}
+
+func NewWithWriter(cfg config.LogConfig, w io.Writer) (*slog.Logger, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q", cfg.Level)
+	}
+	opts := &slog.HandlerOptions{Level: level}
+
+	switch strings.ToLower(cfg.Format) {
+	case "json", "":
+		return slog.New(traceHandler{slog.NewJSONHandler(w, opts)}), nil
+	case "text":
+		return slog.New(traceHandler{slog.NewTextHandler(w, opts)}), nil
+	default:
+		return nil, fmt.Errorf("invalid log format %q", cfg.Format)
+	}
+}
+
+// WithContext stores a request-scoped logger, typically one that already
+// carries the request ID, so every layer below the handler logs with it.
+func WithContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+func FromContext(ctx context.Context) *slog.Logger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+			return logger
+		}
+	}
+	return slog.Default()
+}
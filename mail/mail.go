@@ -0,0 +1,129 @@
+// Package mail sends email through a pluggable backend.
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"go-blog/config"
+	"log/slog"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	BackendLog    = "log"
+	BackendSMTP   = "smtp"
+	BackendMemory = "memory"
+)
+
+// Message is one email. Text is required; HTML, when set, is sent as an
+// alternative to it. Headers are added verbatim, for example
+// List-Unsubscribe.
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+	Headers map[string]string
+}
+
+// Mailer is the interface every backend implements.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New opens the backend selected by cfg.Backend.
+func New(cfg config.MailConfig, logger *slog.Logger) (Mailer, error) {
+	switch cfg.Backend {
+	case BackendLog, "":
+		return &LogMailer{logger: logger}, nil
+	case BackendSMTP:
+		return NewSMTPMailer(cfg), nil
+	case BackendMemory:
+		return &MemoryMailer{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported mail backend %q", cfg.Backend)
+	}
+}
+
+// Build renders msg as an RFC 5322 message from the given sender.
+func (msg Message) Build(from string, now time.Time) ([]byte, error) {
+	if _, err := mail.ParseAddress(msg.To); err != nil {
+		return nil, fmt.Errorf("invalid recipient %q: %w", msg.To, err)
+	}
+	var buf bytes.Buffer
+	header := func(name, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", name, value)
+	}
+	header("From", from)
+	header("To", msg.To)
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", now.Format(time.RFC1123Z))
+	header("Message-ID", "<"+randomHex(16)+"@"+domain(from)+">")
+	header("MIME-Version", "1.0")
+	names := make([]string, 0, len(msg.Headers))
+	for name := range msg.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		header(name, msg.Headers[name])
+	}
+
+	if msg.HTML == "" {
+		header("Content-Type", `text/plain; charset="utf-8"`)
+		header("Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		if err := writeQuotedPrintable(&buf, msg.Text); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	boundary := "alt-" + randomHex(12)
+	header("Content-Type", `multipart/alternative; boundary="`+boundary+`"`)
+	buf.WriteString("\r\n")
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain", msg.Text},
+		{"text/html", msg.HTML},
+	} {
+		fmt.Fprintf(&buf, "--%s\r\n", boundary)
+		fmt.Fprintf(&buf, "Content-Type: %s; charset=\"utf-8\"\r\nContent-Transfer-Encoding: quoted-printable\r\n\r\n", part.contentType)
+		if err := writeQuotedPrintable(&buf, part.body); err != nil {
+			return nil, err
+		}
+		buf.WriteString("\r\n")
+	}
+	fmt.Fprintf(&buf, "--%s--\r\n", boundary)
+	return buf.Bytes(), nil
+}
+
+func writeQuotedPrintable(buf *bytes.Buffer, body string) error {
+	w := quotedprintable.NewWriter(buf)
+	if _, err := w.Write([]byte(strings.ReplaceAll(body, "\n", "\r\n"))); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+func domain(address string) string {
+	if parsed, err := mail.ParseAddress(address); err == nil {
+		address = parsed.Address
+	}
+	if _, host, ok := strings.Cut(address, "@"); ok {
+		return host
+	}
+	return "localhost"
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
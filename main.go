@@ -1,46 +1,49 @@
 package main
 
 import (
-	"go-blog/handlers"
-	"go-blog/models"
-	"go-blog/repo"
-	"go-blog/routes"
-	"go-blog/service"
-	"log"
+	"context"
+	"fmt"
+	"go-blog/app"
+	"go-blog/config"
+	"log/slog"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/joho/godotenv"
-	"gorm.io/driver/postgres"
-	"gorm.io/gorm"
 )
 
 func init() {
 	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found")
+		slog.Debug("no .env file found")
 	}
 }
 
-func initPostgreSQL() *gorm.DB {
-	dsn := os.Getenv("DB_DSN")
-	if dsn == "" {
-		dsn = "host=localhost user=postgres password=postgres dbname=blogdb port=5432 sslmode=disable"
+func main() {
+	cfg, opts, err := config.Load(os.Args[1:])
+	exitOnError("loading configuration", err)
+	if opts.PrintConfig {
+		fmt.Print(cfg)
+		return
 	}
-	db, _ := gorm.Open(postgres.Open(dsn), &gorm.Config{})
-	return db
-}
+	if len(opts.Args) > 0 && opts.Args[0] == "migrate" {
+		exitOnError("migrate", runMigrate(cfg, opts.Args[1:]))
+		return
+	}
+	exitOnError("invalid configuration", cfg.Validate())
 
-func main() {
-	db := initPostgreSQL()
-	db.AutoMigrate(&models.Post{}, &models.User{})
+	application, err := app.New(cfg)
+	exitOnError("startup failed", err)
+	slog.SetDefault(application.Logger)
 
-	postRepo := repo.NewPostRepository(db)
-	authRepo := repo.NewAuthRepository(postRepo)
-	postService := service.NewPostService(postRepo)
-	postHandler := handlers.NewPostHandler(postService)
-	userRepo := repo.NewUserRepository(db)
-	userService := service.NewUserService(userRepo)
-	userHandler := handlers.NewUserHandler(userService)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	exitOnError("server error", application.Run(ctx))
+}
 
-	r := routes.SetupRoutes(postHandler, userHandler, authRepo)
-	r.Run(":8080")
+func exitOnError(action string, err error) {
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "go-blog: %s: %v\n", action, err)
+		os.Exit(1)
+	}
 }
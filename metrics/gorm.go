@@ -0,0 +1,80 @@
+package metrics
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"gorm.io/gorm"
+)
+
+const startTimeKey = "metrics:start_time"
+
+// InstrumentDB times every GORM statement through callbacks and exports the
+// connection pool statistics of the underlying *sql.DB.
+func (m *Metrics) InstrumentDB(gormDB *gorm.DB, dbName string) error {
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		return err
+	}
+	if err := m.Registry.Register(collectors.NewDBStatsCollector(sqlDB, dbName)); err != nil {
+		return err
+	}
+	return gormDB.Use(&gormPlugin{metrics: m})
+}
+
+type gormPlugin struct {
+	metrics *Metrics
+}
+
+func (p *gormPlugin) Name() string {
+	return "metrics"
+}
+
+func (p *gormPlugin) Initialize(db *gorm.DB) error {
+	callbacks := []struct {
+		operation string
+		before    func(name string, fn func(*gorm.DB)) error
+		after     func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", db.Callback().Create().Before("gorm:create").Register, db.Callback().Create().After("gorm:create").Register},
+		{"query", db.Callback().Query().Before("gorm:query").Register, db.Callback().Query().After("gorm:query").Register},
+		{"update", db.Callback().Update().Before("gorm:update").Register, db.Callback().Update().After("gorm:update").Register},
+		{"delete", db.Callback().Delete().Before("gorm:delete").Register, db.Callback().Delete().After("gorm:delete").Register},
+		{"row", db.Callback().Row().Before("gorm:row").Register, db.Callback().Row().After("gorm:row").Register},
+		{"raw", db.Callback().Raw().Before("gorm:raw").Register, db.Callback().Raw().After("gorm:raw").Register},
+	}
+	for _, cb := range callbacks {
+		if err := cb.before("metrics:before_"+cb.operation, before); err != nil {
+			return err
+		}
+		if err := cb.after("metrics:after_"+cb.operation, p.after(cb.operation)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func before(db *gorm.DB) {
+	db.InstanceSet(startTimeKey, time.Now())
+}
+
+func (p *gormPlugin) after(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(startTimeKey)
+		if !ok {
+			return
+		}
+		start, ok := value.(time.Time)
+		if !ok {
+			return
+		}
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+		failed := db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound)
+		p.metrics.dbQueryDuration.WithLabelValues(operation, table, strconv.FormatBool(failed)).Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,184 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "blog"
+
+// Metrics owns its own registry so several instances (one per test suite,
+// for example) never collide on registration. All recording methods are
+// safe to call on a nil *Metrics.
+type Metrics struct {
+	Registry *prometheus.Registry
+
+	httpRequests    *prometheus.CounterVec
+	httpDuration    *prometheus.HistogramVec
+	dbQueryDuration *prometheus.HistogramVec
+	postsCreated    prometheus.Counter
+	logins          *prometheus.CounterVec
+	tokensIssued    prometheus.Counter
+	jobsFinished    *prometheus.CounterVec
+	jobDuration     *prometheus.HistogramVec
+	eventsHandled   *prometheus.CounterVec
+	eventDuration   *prometheus.HistogramVec
+	cacheLookups    *prometheus.CounterVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		Registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by method, route template and status code.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by method, route template and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		dbQueryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "db_query_duration_seconds",
+			Help:      "Database statement latency by operation and table.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"operation", "table", "error"}),
+		postsCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "posts_created_total",
+			Help:      "Posts successfully created.",
+		}),
+		logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "logins_total",
+			Help:      "Login attempts by result.",
+		}, []string{"result"}),
+		tokensIssued: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "tokens_issued_total",
+			Help:      "Access tokens issued.",
+		}),
+		jobsFinished: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "jobs_finished_total",
+			Help:      "Background job attempts by kind and outcome (succeeded, retried or dead).",
+		}, []string{"kind", "outcome"}),
+		jobDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "job_duration_seconds",
+			Help:      "Background job attempt latency by kind.",
+			Buckets:   []float64{.01, .05, .1, .5, 1, 5, 10, 30, 60, 300, 900},
+		}, []string{"kind"}),
+		eventsHandled: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "events_handled_total",
+			Help:      "Domain events handled by event, subscriber and outcome (ok, error or panic).",
+		}, []string{"event", "subscriber", "outcome"}),
+		eventDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "event_handler_duration_seconds",
+			Help:      "Domain event handler latency by event and subscriber.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"event", "subscriber"}),
+		cacheLookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_lookups_total",
+			Help:      "Read cache lookups by cache and result (hit or miss).",
+		}, []string{"cache", "result"}),
+	}
+	m.Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.dbQueryDuration,
+		m.postsCreated,
+		m.logins,
+		m.tokensIssued,
+		m.jobsFinished,
+		m.jobDuration,
+		m.eventsHandled,
+		m.eventDuration,
+		m.cacheLookups,
+	)
+	for _, result := range []string{"succeeded", "failed"} {
+		m.logins.WithLabelValues(result)
+	}
+	return m
+}
+
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.Registry, promhttp.HandlerOpts{Registry: m.Registry})
+}
+
+func (m *Metrics) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(c.Writer.Status())
+		m.httpRequests.WithLabelValues(c.Request.Method, route, status).Inc()
+		m.httpDuration.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+func (m *Metrics) PostCreated() {
+	if m != nil {
+		m.postsCreated.Inc()
+	}
+}
+
+func (m *Metrics) LoginSucceeded() {
+	if m != nil {
+		m.logins.WithLabelValues("succeeded").Inc()
+	}
+}
+
+func (m *Metrics) LoginFailed() {
+	if m != nil {
+		m.logins.WithLabelValues("failed").Inc()
+	}
+}
+
+func (m *Metrics) TokenIssued() {
+	if m != nil {
+		m.tokensIssued.Inc()
+	}
+}
+
+func (m *Metrics) JobFinished(kind, outcome string, elapsed time.Duration) {
+	if m != nil {
+		m.jobsFinished.WithLabelValues(kind, outcome).Inc()
+		m.jobDuration.WithLabelValues(kind).Observe(elapsed.Seconds())
+	}
+}
+
+func (m *Metrics) EventHandled(event, subscriber, outcome string, elapsed time.Duration) {
+	if m != nil {
+		m.eventsHandled.WithLabelValues(event, subscriber, outcome).Inc()
+		m.eventDuration.WithLabelValues(event, subscriber).Observe(elapsed.Seconds())
+	}
+}
+
+func (m *Metrics) CacheLookup(cache string, hit bool) {
+	if m != nil {
+		result := "miss"
+		if hit {
+			result = "hit"
+		}
+		m.cacheLookups.WithLabelValues(cache, result).Inc()
+	}
+}
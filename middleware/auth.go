@@ -1,88 +1,143 @@
 package middleware
 
 import (
+	"errors"
 	"fmt"
+	"go-blog/config"
 	"go-blog/models"
 	"go-blog/repo"
 	"net/http"
-	"os"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func JWTAuthMiddleware(requiredType *models.AccountType) gin.HandlerFunc {
+func JWTAuthMiddleware(authConfig config.AuthConfig, requiredType *models.AccountType) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		header := c.GetHeader("Authorization")
-		if header == "" || len(header) < 8 || header[:7] != "Bearer " {
+		token, ok := BearerToken(c.GetHeader("Authorization"))
+		if !ok {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing or invalid Authorization header"})
 			c.Abort()
 			return
 		}
+		authenticate(c, authConfig.JWTSecret, token, requiredType)
+	}
+}
 
-		tokenString := header[7:]
-		secret := os.Getenv("JWT_SECRET")
-		if secret == "" {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "JWT secret not configured"})
-			c.Abort()
-			return
-		}
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return []byte(secret), nil
-		})
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
-			c.Abort()
-			return
-		}
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
-			c.Abort()
-			return
-		}
-		userID, ok := claims["id"].(float64)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user ID in token"})
-			c.Abort()
+// OptionalJWTAuth lets anonymous requests through untouched but still
+// rejects a token that is present and invalid, so public endpoints can
+// personalise their response for signed-in users.
+func OptionalJWTAuth(authConfig config.AuthConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if header == "" {
+			c.Next()
 			return
 		}
-		accountType, ok := claims["account_type"].(string)
+		token, ok := BearerToken(header)
 		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid account type in token"})
-			c.Abort()
-			return
-		}
-		if requiredType != nil && models.AccountType(accountType) != *requiredType {
-			c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing or invalid Authorization header"})
 			c.Abort()
 			return
 		}
-		c.Set("user_id", int(userID))
-		c.Set("account_type", accountType)
-		if username, exists := claims["username"]; exists {
-			c.Set("username", username)
+		authenticate(c, authConfig.JWTSecret, token, nil)
+	}
+}
+
+// HasValidJWT reports whether the request carries a token the JWT
+// middlewares would accept, whatever its account type.
+func HasValidJWT(c *gin.Context, authConfig config.AuthConfig) bool {
+	token, ok := BearerToken(c.GetHeader("Authorization"))
+	if !ok {
+		return false
+	}
+	_, err := ParseIdentity(authConfig.JWTSecret, token)
+	return err == nil
+}
+
+// BearerToken returns the token of an "Authorization: Bearer <token>"
+// header value.
+func BearerToken(header string) (string, bool) {
+	if len(header) < 8 || header[:7] != "Bearer " {
+		return "", false
+	}
+	return header[7:], true
+}
+
+// Identity is who a token was issued to.
+type Identity struct {
+	UserID      int
+	AccountType models.AccountType
+	// Username is only set when the token carries it.
+	Username string
+}
+
+// ParseIdentity validates tokenString as the JWT middlewares do. Its errors
+// are the messages they answer a 401 with, so other transports can reject
+// tokens the same way.
+func ParseIdentity(secret, tokenString string) (*Identity, error) {
+	token, err := parseJWT(secret, tokenString)
+	if err != nil || !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("invalid token claims")
+	}
+	userID, ok := claims["id"].(float64)
+	if !ok {
+		return nil, errors.New("invalid user ID in token")
+	}
+	accountType, ok := claims["account_type"].(string)
+	if !ok {
+		return nil, errors.New("invalid account type in token")
+	}
+	username, _ := claims["username"].(string)
+	return &Identity{UserID: int(userID), AccountType: models.AccountType(accountType), Username: username}, nil
+}
+
+func parseJWT(secret, tokenString string) (*jwt.Token, error) {
+	return jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		c.Next()
+		return []byte(secret), nil
+	})
+}
+
+func authenticate(c *gin.Context, secret, tokenString string, requiredType *models.AccountType) {
+	identity, err := ParseIdentity(secret, tokenString)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		c.Abort()
+		return
+	}
+	if requiredType != nil && identity.AccountType != *requiredType {
+		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+		c.Abort()
+		return
 	}
+	c.Set("user_id", identity.UserID)
+	c.Set("account_type", string(identity.AccountType))
+	if identity.Username != "" {
+		c.Set("username", identity.Username)
+	}
+	c.Next()
 }
 
-func JWTAuth() gin.HandlerFunc {
-	return JWTAuthMiddleware(nil)
+func JWTAuth(authConfig config.AuthConfig) gin.HandlerFunc {
+	return JWTAuthMiddleware(authConfig, nil)
 }
 
-func JWTAuthBlogger() gin.HandlerFunc {
+func JWTAuthBlogger(authConfig config.AuthConfig) gin.HandlerFunc {
 	bloggerType := models.AccountTypeBlogger
-	return JWTAuthMiddleware(&bloggerType)
+	return JWTAuthMiddleware(authConfig, &bloggerType)
 }
 
-func JWTAuthViewer() gin.HandlerFunc {
+func JWTAuthViewer(authConfig config.AuthConfig) gin.HandlerFunc {
 	viewerType := models.AccountTypeViewer
-	return JWTAuthMiddleware(&viewerType)
+	return JWTAuthMiddleware(authConfig, &viewerType)
 }
 
 func CheckPostOwnership(authRepo repo.AuthRepository) gin.HandlerFunc {
@@ -102,7 +157,7 @@ func CheckPostOwnership(authRepo repo.AuthRepository) gin.HandlerFunc {
 			return
 		}
 
-		err = authRepo.CheckPostOwnership(postID, userID.(int))
+		err = authRepo.CheckPostOwnership(c.Request.Context(), postID, userID.(int))
 		if err != nil {
 			switch err {
 			case models.ErrPostNotFound:
@@ -118,3 +173,36 @@ func CheckPostOwnership(authRepo repo.AuthRepository) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+func CheckMediaOwnership(authRepo repo.AuthRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+			c.Abort()
+			return
+		}
+
+		mediaID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid media ID"})
+			c.Abort()
+			return
+		}
+
+		err = authRepo.CheckMediaOwnership(c.Request.Context(), mediaID, userID.(int))
+		if err != nil {
+			switch err {
+			case models.ErrMediaNotFound:
+				c.JSON(http.StatusNotFound, gin.H{"error": "media not found"})
+			case models.ErrMediaUnauthorized:
+				c.JSON(http.StatusForbidden, gin.H{"error": "you can only manage your own media"})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+			}
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
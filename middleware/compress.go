@@ -0,0 +1,209 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	encodingBrotli = "br"
+	encodingGzip   = "gzip"
+)
+
+var (
+	gzipWriters   = sync.Pool{New: func() any { return gzip.NewWriter(io.Discard) }}
+	brotliWriters = sync.Pool{New: func() any { return brotli.NewWriterLevel(io.Discard, 5) }}
+)
+
+// Compress encodes responses with brotli or gzip, whichever the client's
+// Accept-Encoding prefers, brotli on a tie. Only compressible content types
+// of at least minSize bytes are encoded. A response that is flushed before
+// reaching minSize, such as an event stream, passes through untouched, as
+// does one that sets its own Content-Encoding or is a range.
+func Compress(minSize int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		encoding := negotiateEncoding(c.GetHeader("Accept-Encoding"))
+		if encoding == "" || c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+		w := &compressWriter{ResponseWriter: c.Writer, encoding: encoding, minSize: minSize}
+		c.Writer = w
+		defer func() {
+			w.finish()
+			c.Writer = w.ResponseWriter
+		}()
+		c.Next()
+	}
+}
+
+// negotiateEncoding returns the supported coding with the highest quality
+// in header, or "" when none is acceptable.
+func negotiateEncoding(header string) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		candidates := []string{name}
+		if name == "*" {
+			candidates = []string{encodingBrotli, encodingGzip}
+		}
+		for _, candidate := range candidates {
+			if candidate != encodingBrotli && candidate != encodingGzip {
+				continue
+			}
+			if q > bestQ || (q == bestQ && q > 0 && candidate == encodingBrotli) {
+				best, bestQ = candidate, q
+			}
+		}
+	}
+	return best
+}
+
+// compressible reports whether contentType is worth compressing. Images,
+// archives and the like are already compressed.
+func compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch {
+	case strings.HasPrefix(mediaType, "text/"):
+		return mediaType != "text/event-stream"
+	case strings.HasSuffix(mediaType, "+json"), strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/xml", "application/javascript",
+		"application/msgpack", "application/x-msgpack", "image/svg+xml":
+		return true
+	}
+	return false
+}
+
+// compressWriter holds the start of the body back until it knows whether
+// the response is large enough to compress.
+type compressWriter struct {
+	gin.ResponseWriter
+	encoding string
+	minSize  int
+	buf      []byte
+	decided  bool
+	encoder  io.WriteCloser
+}
+
+func (w *compressWriter) Write(p []byte) (int, error) {
+	if w.decided {
+		if w.encoder != nil {
+			return w.encoder.Write(p)
+		}
+		return w.ResponseWriter.Write(p)
+	}
+	w.buf = append(w.buf, p...)
+	if len(w.buf) >= w.minSize {
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// WriteHeaderNow is how gin sends a response without a body.
+func (w *compressWriter) WriteHeaderNow() {
+	if !w.decided {
+		_ = w.decide(false)
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *compressWriter) Written() bool {
+	return w.decided && w.ResponseWriter.Written()
+}
+
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		_ = w.decide(false)
+	}
+	if flusher, ok := w.encoder.(interface{ Flush() error }); ok {
+		_ = flusher.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// Unwrap lets http.ResponseController reach the connection, for instance to
+// lift the write deadline on a stream.
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// decide fixes the headers and writes out what has been held back.
+func (w *compressWriter) decide(compress bool) error {
+	w.decided = true
+	header := w.Header()
+	status := w.Status()
+	eligible := header.Get("Content-Encoding") == "" && header.Get("Content-Range") == "" &&
+		status != http.StatusPartialContent && status != http.StatusNoContent && status != http.StatusNotModified &&
+		compressible(header.Get("Content-Type"))
+	if eligible {
+		header.Add("Vary", "Accept-Encoding")
+	}
+	if compress && eligible {
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+		switch w.encoding {
+		case encodingBrotli:
+			encoder := brotliWriters.Get().(*brotli.Writer)
+			encoder.Reset(w.ResponseWriter)
+			w.encoder = encoder
+		default:
+			encoder := gzipWriters.Get().(*gzip.Writer)
+			encoder.Reset(w.ResponseWriter)
+			w.encoder = encoder
+		}
+	}
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	_, err := w.Write(buf)
+	return err
+}
+
+// finish writes out a body too small to compress, or ends the encoded one
+// and returns its encoder to the pool.
+func (w *compressWriter) finish() {
+	if !w.decided {
+		_ = w.decide(false)
+		return
+	}
+	switch encoder := w.encoder.(type) {
+	case *brotli.Writer:
+		_ = encoder.Close()
+		brotliWriters.Put(encoder)
+	case *gzip.Writer:
+		_ = encoder.Close()
+		gzipWriters.Put(encoder)
+	}
+	w.encoder = nil
+}
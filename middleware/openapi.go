@@ -0,0 +1,174 @@
+package middleware
+
+import (
+	"bytes"
+	"go-blog/logging"
+	"go-blog/openapi"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ValidateOpenAPI checks requests and responses against the OpenAPI
+// document. An invalid request is answered with a 400 before it reaches
+// the handler. An invalid response is logged and replaced by a 500, so a
+// handler drifting from the document fails loudly; it is meant for
+// development and tests, as every response is buffered to check it.
+// Responses flushed early, such as event streams, pass through unchecked.
+//
+// Requests without valid credentials are not checked, so they still get
+// the 401 of the route's own auth middleware; authenticated reports
+// whether c satisfies the named security scheme.
+func ValidateOpenAPI(validator *openapi.Validator, authenticated func(c *gin.Context, scheme string) bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" {
+			c.Next()
+			return
+		}
+		ctx := c.Request.Context()
+		method, path := c.Request.Method, openapi.PathFor(route)
+		if !validator.Knows(method, path) {
+			logging.FromContext(ctx).ErrorContext(ctx, "route missing from the OpenAPI document", "method", method, "route", route)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "route is not documented"})
+			return
+		}
+
+		if !satisfies(c, validator.Security(method, path), authenticated) {
+			c.Next()
+			return
+		}
+
+		var body []byte
+		if c.Request.Body != nil {
+			var err error
+			if body, err = io.ReadAll(c.Request.Body); err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		params := make(map[string]string, len(c.Params))
+		for _, param := range c.Params {
+			params[param.Key] = param.Value
+		}
+		err := validator.ValidateRequest(openapi.Request{
+			Method:      method,
+			Path:        path,
+			PathParams:  params,
+			Query:       c.Request.URL.Query(),
+			Header:      c.Request.Header,
+			ContentType: c.GetHeader("Content-Type"),
+			Body:        body,
+		})
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		w := &validatingWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		// After a panic the buffered response is dropped, and Recovery
+		// answers on the original writer.
+		defer func() { c.Writer = w.ResponseWriter }()
+		c.Next()
+		if w.streamed {
+			return
+		}
+		status := w.ResponseWriter.Status()
+		if err := validator.ValidateResponse(method, path, status, w.Header().Get("Content-Type"), w.buf.Bytes()); err != nil {
+			logging.FromContext(ctx).ErrorContext(ctx, "response does not match the OpenAPI document",
+				"method", method, "route", route, "status", status, "error", err)
+			for _, header := range []string{"Cache-Control", "Content-Disposition", "Content-Length", "ETag", "Last-Modified", "Location"} {
+				w.Header().Del(header)
+			}
+			w.ResponseWriter.WriteHeader(http.StatusInternalServerError)
+			c.Writer = w.ResponseWriter
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "response does not match the API description: " + err.Error()})
+			return
+		}
+		w.release()
+	}
+}
+
+func satisfies(c *gin.Context, security []openapi.SecurityRequirement, authenticated func(*gin.Context, string) bool) bool {
+	if len(security) == 0 {
+		return true
+	}
+	for _, requirement := range security {
+		satisfied := true
+		for scheme := range requirement {
+			satisfied = satisfied && authenticated(c, scheme)
+		}
+		if satisfied {
+			return true
+		}
+	}
+	return false
+}
+
+// validatingWriter holds the response back until it has been checked.
+type validatingWriter struct {
+	gin.ResponseWriter
+	buf      bytes.Buffer
+	written  bool
+	streamed bool
+}
+
+func (w *validatingWriter) Write(p []byte) (int, error) {
+	if w.streamed {
+		return w.ResponseWriter.Write(p)
+	}
+	w.written = true
+	return w.buf.Write(p)
+}
+
+func (w *validatingWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *validatingWriter) WriteHeaderNow() {
+	if w.streamed {
+		w.ResponseWriter.WriteHeaderNow()
+		return
+	}
+	w.written = true
+}
+
+func (w *validatingWriter) Written() bool {
+	return w.written || w.ResponseWriter.Written()
+}
+
+func (w *validatingWriter) Size() int {
+	if w.streamed {
+		return w.ResponseWriter.Size()
+	}
+	if !w.written {
+		return -1
+	}
+	return w.buf.Len()
+}
+
+func (w *validatingWriter) Flush() {
+	if !w.streamed {
+		w.release()
+		w.streamed = true
+	}
+	w.ResponseWriter.Flush()
+}
+
+// Unwrap lets http.ResponseController reach the connection.
+func (w *validatingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// release writes out what has been held back.
+func (w *validatingWriter) release() {
+	if w.buf.Len() == 0 {
+		w.ResponseWriter.WriteHeaderNow()
+		return
+	}
+	_, _ = w.ResponseWriter.Write(w.buf.Bytes())
+	w.buf.Reset()
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"go-blog/config"
+	"go-blog/db"
+	"strconv"
+)
+
+const migrateUsage = `usage: go-blog [flags] migrate <command> [arguments]
+
+commands:
+  up               apply all pending migrations
+  down [n]         roll back the last n applied migrations (default 1)
+  status           list migrations and whether they have been applied
+  create <name>    write a new empty up/down migration pair`
+
+func runMigrate(cfg config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+	command, args := args[0], args[1:]
+
+	if command == "create" {
+		flags := flag.NewFlagSet("migrate create", flag.ContinueOnError)
+		dir := flags.String("dir", "db/migrations/"+cfg.Database.Type, "directory to write the migration files to")
+		if err := flags.Parse(args); err != nil {
+			return err
+		}
+		if flags.NArg() != 1 {
+			return errors.New("usage: go-blog migrate create [-dir path] <name>")
+		}
+		upPath, downPath, err := db.CreateMigration(*dir, flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("created %s\ncreated %s\n", upPath, downPath)
+		return nil
+	}
+
+	if err := cfg.Database.Validate(); err != nil {
+		return err
+	}
+	gormDB, err := db.InitDB(cfg.Database)
+	if err != nil {
+		return err
+	}
+	migrator, err := db.NewMigrator(gormDB)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	switch command {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			fmt.Printf("applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("no pending migrations")
+		}
+	case "down":
+		steps := 1
+		if len(args) > 0 {
+			if steps, err = strconv.Atoi(args[0]); err != nil {
+				return fmt.Errorf("invalid step count %q", args[0])
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		for _, m := range reverted {
+			fmt.Printf("reverted %04d_%s\n", m.Version, m.Name)
+		}
+		return err
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-40s %s\n", s.Version, s.Name, state)
+		}
+	default:
+		return errors.New(migrateUsage)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+type DigestFrequency string
+
+const (
+	DigestDaily  DigestFrequency = "daily"
+	DigestWeekly DigestFrequency = "weekly"
+)
+
+func (f DigestFrequency) Valid() bool {
+	return f == DigestDaily || f == DigestWeekly
+}
+
+// Period is the time between two digests.
+func (f DigestFrequency) Period() time.Duration {
+	if f == DigestWeekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// DigestSubscription sends Email a periodic digest of new posts, optionally
+// only those by one author or with one tag. It does nothing until the
+// address is confirmed. Token is the secret in the confirmation and
+// unsubscribe links.
+type DigestSubscription struct {
+	ID          int             `json:"id" gorm:"primaryKey"`
+	Email       string          `json:"email"`
+	UserID      *int            `json:"-"`
+	Frequency   DigestFrequency `json:"frequency"`
+	AuthorID    *int            `json:"author_id,omitempty"`
+	Author      string          `json:"author,omitempty" gorm:"-"`
+	Tag         string          `json:"tag,omitempty"`
+	Token       string          `json:"-"`
+	ConfirmedAt *time.Time      `json:"confirmed_at,omitempty"`
+	// SentUpTo is the publication time of the newest post already covered,
+	// so the next digest starts after it.
+	SentUpTo  *time.Time `json:"-"`
+	NextRunAt *time.Time `json:"next_run_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+type DigestRequest struct {
+	Email     string          `json:"email"`
+	Frequency DigestFrequency `json:"frequency"`
+	Author    string          `json:"author"`
+	Tag       string          `json:"tag"`
+}
+
+var (
+	ErrDigestNotFound = errors.New("digest subscription not found")
+	ErrInvalidDigest  = errors.New("invalid digest subscription")
+)
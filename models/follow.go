@@ -0,0 +1,31 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+type Follow struct {
+	FollowerID int       `json:"follower_id" gorm:"primaryKey;autoIncrement:false"`
+	FolloweeID int       `json:"followee_id" gorm:"primaryKey;autoIncrement:false"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// UserProfile is the public view of a user; it never carries the password
+// hash.
+type UserProfile struct {
+	ID          int         `json:"id"`
+	Username    string      `json:"username"`
+	AccountType AccountType `json:"account_type"`
+}
+
+type FeedPage struct {
+	Posts      []Post `json:"posts"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+var (
+	ErrCannotFollowSelf = errors.New("you cannot follow yourself")
+	ErrNotABlogger      = errors.New("only blogger accounts can be followed")
+	ErrInvalidCursor    = errors.New("invalid cursor")
+)
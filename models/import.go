@@ -0,0 +1,53 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+type ImportFormat string
+
+const (
+	// ImportFormatArchive is the zip produced by GET /api/me/export.
+	ImportFormatArchive ImportFormat = "archive"
+	ImportFormatWXR     ImportFormat = "wxr"
+)
+
+type ImportStatus string
+
+const (
+	ImportQueued    ImportStatus = "queued"
+	ImportRunning   ImportStatus = "running"
+	ImportCompleted ImportStatus = "completed"
+	ImportFailed    ImportStatus = "failed"
+)
+
+// ImportJob tracks one uploaded import. The uploaded file is parked in the
+// blob store under StorageKey until the job has run.
+type ImportJob struct {
+	ID         int          `json:"id" gorm:"primaryKey"`
+	UserID     int          `json:"user_id" gorm:"not null"`
+	Format     ImportFormat `json:"format"`
+	StorageKey string       `json:"-"`
+	Status     ImportStatus `json:"status"`
+	Total      int          `json:"total"`
+	Processed  int          `json:"processed"`
+	Created    int          `json:"created"`
+	Skipped    int          `json:"skipped"`
+	Failed     int          `json:"failed"`
+	// Messages explains every skipped or failed entry.
+	Messages   []string   `json:"messages" gorm:"serializer:json"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+}
+
+func (j *ImportJob) Done() bool {
+	return j.Status == ImportCompleted || j.Status == ImportFailed
+}
+
+var (
+	ErrImportNotFound    = errors.New("import not found")
+	ErrUnsupportedImport = errors.New("import must be a go-blog export zip or a WordPress WXR file")
+	ErrImportTooLarge    = errors.New("import file is too large")
+)
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Media is an uploaded file. The blob itself lives in the configured
+// BlobStore under StorageKey; only its metadata is kept in the database.
+type Media struct {
+	ID           int       `json:"id" gorm:"primaryKey"`
+	UserID       int       `json:"user_id" gorm:"not null"`
+	StorageKey   string    `json:"-"`
+	ThumbnailKey string    `json:"-"`
+	Filename     string    `json:"filename"`
+	ContentType  string    `json:"content_type"`
+	Size         int64     `json:"size"`
+	Width        int       `json:"width,omitempty"`
+	Height       int       `json:"height,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	URL          string    `json:"url" gorm:"-"`
+	ThumbnailURL string    `json:"thumbnail_url,omitempty" gorm:"-"`
+}
+
+func (Media) TableName() string {
+	return "media"
+}
+
+func (m *Media) IsImage() bool {
+	return m.Width > 0 && m.Height > 0
+}
+
+// PostMedia links a post to the media it references, in the order the
+// author listed them.
+type PostMedia struct {
+	PostID   int `gorm:"primaryKey;autoIncrement:false"`
+	MediaID  int `gorm:"primaryKey;autoIncrement:false"`
+	Position int
+}
+
+func (PostMedia) TableName() string {
+	return "post_media"
+}
+
+var (
+	ErrMediaNotFound     = errors.New("media not found")
+	ErrMediaUnauthorized = errors.New("unauthorized to access this media")
+	ErrMediaTooLarge     = errors.New("file is too large")
+	ErrUnsupportedMedia  = errors.New("unsupported file type")
+	ErrTooManyMedia      = errors.New("too many media attached")
+)
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+type NotificationType string
+
+const (
+	NotificationReaction NotificationType = "reaction"
+	NotificationFollow   NotificationType = "follow"
+)
+
+// Notification tells UserID that ActorID did something concerning them.
+// PostID and Reaction are set for reactions only.
+type Notification struct {
+	ID            int              `json:"id" gorm:"primaryKey"`
+	UserID        int              `json:"-"`
+	Type          NotificationType `json:"type"`
+	ActorID       int              `json:"actor_id"`
+	ActorUsername string           `json:"actor_username" gorm:"-"`
+	PostID        *int             `json:"post_id,omitempty"`
+	Reaction      ReactionType     `json:"reaction,omitempty"`
+	ReadAt        *time.Time       `json:"read_at,omitempty"`
+	CreatedAt     time.Time        `json:"created_at"`
+}
+
+type NotificationPage struct {
+	Notifications []Notification `json:"notifications"`
+	Unread        int64          `json:"unread"`
+}
+
+var ErrNotificationNotFound = errors.New("notification not found")
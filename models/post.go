@@ -2,13 +2,79 @@ package models
 
 import (
 	"errors"
+	"time"
 )
 
+type PostStatus string
+
+const (
+	PostStatusDraft     PostStatus = "draft"
+	PostStatusPublished PostStatus = "published"
+)
+
+func (s PostStatus) Valid() bool {
+	return s == PostStatusDraft || s == PostStatusPublished
+}
+
 type Post struct {
-	ID      int    `json:"id" gorm:"primaryKey"`
-	Title   string `json:"title"`
-	Content string `json:"content"`
-	UserID  int    `json:"user_id" gorm:"not null"`
+	ID          int            `json:"id" gorm:"primaryKey"`
+	Title       string         `json:"title"`
+	Slug        string         `json:"slug"`
+	Content     string         `json:"content"`
+	UserID      int            `json:"user_id" gorm:"not null"`
+	Status      PostStatus     `json:"status"`
+	PublishedAt *time.Time     `json:"published_at"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	Tags        []string       `json:"tags" gorm:"-"`
+	MediaIDs    []int          `json:"media_ids" gorm:"-"`
+	Reactions   ReactionCounts `json:"reactions" gorm:"embedded;embeddedPrefix:reactions_"`
+	MyReactions []ReactionType `json:"my_reactions,omitempty" gorm:"-"`
+}
+
+type PostTag struct {
+	PostID int    `gorm:"primaryKey;autoIncrement:false"`
+	Tag    string `gorm:"primaryKey"`
+}
+
+// PostFilter narrows a listing of published posts to one author and/or tag,
+// and to posts published after PublishedAfter. Zero values mean no
+// restriction.
+type PostFilter struct {
+	AuthorID       int
+	Tag            string
+	PublishedAfter time.Time
+}
+
+// PostStats is a cheap summary of a set of published posts. Count catches
+// deletions and unpublishing, LastUpdated catches edits.
+type PostStats struct {
+	Count       int64
+	LastUpdated time.Time
+}
+
+// SetStatus changes the status and keeps PublishedAt in step with it: it is
+// stamped on publish and cleared when a post goes back to draft.
+func (p *Post) SetStatus(status PostStatus, now time.Time) {
+	if status == PostStatusPublished && (p.Status != PostStatusPublished || p.PublishedAt == nil) {
+		published := now.UTC().Truncate(time.Microsecond)
+		p.PublishedAt = &published
+	}
+	if status == PostStatusDraft {
+		p.PublishedAt = nil
+	}
+	p.Status = status
+}
+
+func (p *Post) IsPublished() bool {
+	return p.Status == PostStatusPublished
+}
+
+// PostCursor is the keyset position of the last post on a page. Pages are
+// ordered by (PublishedAt, ID) descending.
+type PostCursor struct {
+	PublishedAt time.Time
+	ID          int
 }
 
 type UpdatePostRequest struct {
@@ -21,4 +87,8 @@ var (
 	ErrInvalidPost      = errors.New("invalid post")
 	ErrPostUnauthorized = errors.New("unauthorized to access this post")
 	ErrDatabaseError    = errors.New("database error")
+	ErrInvalidStatus    = errors.New("status must be draft or published")
+	ErrInvalidTag       = errors.New("tags may only contain letters, digits and dashes")
+	ErrInvalidSlug      = errors.New("slug must contain at least one letter or digit")
+	ErrSlugTaken        = errors.New("you already have a post with this slug")
 )
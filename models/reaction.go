@@ -0,0 +1,76 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+type ReactionType string
+
+const (
+	ReactionLike  ReactionType = "like"
+	ReactionLove  ReactionType = "love"
+	ReactionLaugh ReactionType = "laugh"
+	ReactionWow   ReactionType = "wow"
+	ReactionSad   ReactionType = "sad"
+)
+
+var ReactionTypes = []ReactionType{ReactionLike, ReactionLove, ReactionLaugh, ReactionWow, ReactionSad}
+
+func (t ReactionType) Valid() bool {
+	for _, valid := range ReactionTypes {
+		if t == valid {
+			return true
+		}
+	}
+	return false
+}
+
+type Reaction struct {
+	ID        int          `json:"id" gorm:"primaryKey"`
+	PostID    int          `json:"post_id"`
+	UserID    int          `json:"user_id"`
+	Type      ReactionType `json:"type"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+func (Reaction) TableName() string {
+	return "post_reactions"
+}
+
+// ReactionCounts is stored denormalised on the posts row, one column per
+// reaction type, so listings never have to aggregate the reactions table.
+type ReactionCounts struct {
+	Like  int `json:"like"`
+	Love  int `json:"love"`
+	Laugh int `json:"laugh"`
+	Wow   int `json:"wow"`
+	Sad   int `json:"sad"`
+}
+
+func (c *ReactionCounts) Add(t ReactionType, delta int) {
+	switch t {
+	case ReactionLike:
+		c.Like += delta
+	case ReactionLove:
+		c.Love += delta
+	case ReactionLaugh:
+		c.Laugh += delta
+	case ReactionWow:
+		c.Wow += delta
+	case ReactionSad:
+		c.Sad += delta
+	}
+}
+
+type ReactionRequest struct {
+	Type ReactionType `json:"type"`
+}
+
+type ReactionSummary struct {
+	PostID      int            `json:"post_id"`
+	Reactions   ReactionCounts `json:"reactions"`
+	MyReactions []ReactionType `json:"my_reactions"`
+}
+
+var ErrInvalidReaction = errors.New("invalid reaction type")
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+const DefaultReadingListName = "Read later"
+
+type ReadingList struct {
+	ID         int       `json:"id" gorm:"primaryKey"`
+	UserID     int       `json:"user_id"`
+	Name       string    `json:"name"`
+	IsDefault  bool      `json:"is_default"`
+	ShareToken *string   `json:"share_token,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func (l *ReadingList) Shared() bool {
+	return l.ShareToken != nil
+}
+
+type ReadingListItem struct {
+	ID        int       `json:"-" gorm:"primaryKey"`
+	ListID    int       `json:"-"`
+	PostID    int       `json:"post_id"`
+	Position  int       `json:"position"`
+	Note      string    `json:"note"`
+	CreatedAt time.Time `json:"added_at"`
+	// Available is false once the post has been deleted or unpublished; Post
+	// is then omitted so drafts never leak through a shared list.
+	Available bool  `json:"available" gorm:"-"`
+	Post      *Post `json:"post,omitempty" gorm:"-"`
+}
+
+type ReadingListDetail struct {
+	ReadingList
+	Items []ReadingListItem `json:"items"`
+}
+
+type ReadingListRequest struct {
+	Name   *string `json:"name"`
+	Shared *bool   `json:"shared"`
+}
+
+type ReadingListItemRequest struct {
+	PostID   int     `json:"post_id"`
+	Note     *string `json:"note"`
+	Position *int    `json:"position"`
+}
+
+var (
+	ErrReadingListNotFound   = errors.New("reading list not found")
+	ErrReadingListNameExists = errors.New("a reading list with that name already exists")
+	ErrReadingListNameEmpty  = errors.New("reading list name cannot be empty")
+	ErrDefaultReadingList    = errors.New("the default reading list cannot be deleted")
+	ErrItemNotInList         = errors.New("post is not in this reading list")
+	ErrItemAlreadyInList     = errors.New("post is already in this reading list")
+)
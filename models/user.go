@@ -1,5 +1,9 @@
 package models
 
+import (
+	"errors"
+)
+
 type AccountType string
 
 const (
@@ -19,3 +23,8 @@ type RegisterRequest struct {
 	Password    string      `json:"password"`
 	AccountType AccountType `json:"account_type"`
 }
+
+var (
+	ErrUserNotFound   = errors.New("user not found")
+	ErrUsernameExists = errors.New("username exists")
+)
@@ -0,0 +1,115 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventPostCreated    EventType = "post.created"
+	EventPostUpdated    EventType = "post.updated"
+	EventPostPublished  EventType = "post.published"
+	EventPostDeleted    EventType = "post.deleted"
+	EventUserRegistered EventType = "user.registered"
+)
+
+// EventTypes lists every event a webhook can subscribe to.
+var EventTypes = []EventType{EventPostCreated, EventPostUpdated, EventPostPublished, EventPostDeleted, EventUserRegistered}
+
+func (t EventType) Valid() bool {
+	for _, known := range EventTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Event is the envelope sent to webhook endpoints. UserID is the user the
+// event concerns; their own webhooks and the site-wide ones receive it.
+type Event struct {
+	ID         string    `json:"id"`
+	Type       EventType `json:"type"`
+	OccurredAt time.Time `json:"occurred_at"`
+	UserID     int       `json:"-"`
+	Data       any       `json:"data"`
+}
+
+// Webhook is an endpoint that receives events. Webhooks without a UserID
+// are site-wide ones managed through the admin API.
+type Webhook struct {
+	ID        int         `json:"id" gorm:"primaryKey"`
+	UserID    *int        `json:"user_id,omitempty"`
+	URL       string      `json:"url"`
+	Secret    string      `json:"-"`
+	Events    []EventType `json:"events" gorm:"serializer:json"`
+	Active    bool        `json:"active"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+func (w *Webhook) Subscribed(event EventType) bool {
+	for _, subscribed := range w.Events {
+		if subscribed == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookRequest creates or updates a webhook. On update, nil fields are
+// left unchanged.
+type WebhookRequest struct {
+	URL    *string     `json:"url"`
+	Secret *string     `json:"secret"`
+	Events []EventType `json:"events"`
+	Active *bool       `json:"active"`
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	// DeliveryRetrying means the last attempt failed and another one is
+	// scheduled.
+	DeliveryRetrying DeliveryStatus = "retrying"
+	DeliveryFailed   DeliveryStatus = "failed"
+)
+
+// WebhookDelivery is one event bound for one webhook. Its Payload is fixed
+// when the event happens, so retries and redeliveries send the same body.
+type WebhookDelivery struct {
+	ID          int              `json:"id" gorm:"primaryKey"`
+	WebhookID   int              `json:"webhook_id"`
+	EventID     string           `json:"event_id"`
+	Event       EventType        `json:"event"`
+	Payload     json.RawMessage  `json:"payload" gorm:"serializer:json"`
+	Status      DeliveryStatus   `json:"status"`
+	Attempts    int              `json:"attempts"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+	DeliveredAt *time.Time       `json:"delivered_at,omitempty"`
+	Log         []WebhookAttempt `json:"log,omitempty" gorm:"-"`
+}
+
+// WebhookAttempt records a single HTTP request made for a delivery.
+type WebhookAttempt struct {
+	ID           int       `json:"id" gorm:"primaryKey"`
+	DeliveryID   int       `json:"-"`
+	StatusCode   int       `json:"status_code,omitempty"`
+	Error        string    `json:"error,omitempty"`
+	ResponseBody string    `json:"response_body,omitempty"`
+	DurationMS   int64     `json:"duration_ms"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+var (
+	ErrWebhookNotFound  = errors.New("webhook not found")
+	ErrDeliveryNotFound = errors.New("delivery not found")
+	ErrInvalidWebhook   = errors.New("invalid webhook")
+	ErrDeliveryPending  = errors.New("delivery is already queued")
+)
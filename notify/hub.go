@@ -0,0 +1,212 @@
+// Package notify wakes up the clients streaming a user's notifications when
+// something new is stored for them.
+//
+// The hub only carries "look again" signals, never the notifications
+// themselves: a woken stream reads what it missed from the database, so a
+// dropped or duplicated signal costs at most one query. Within one process
+// signals go through channels. With Postgres they are also sent with
+// NOTIFY, which the database delivers on commit to every instance
+// LISTENing, so streams see changes made on other servers.
+package notify
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"go-blog/db"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/stdlib"
+	"gorm.io/gorm"
+)
+
+// Channel is the Postgres NOTIFY channel; its payload is a user id.
+const Channel = "blog_notifications"
+
+const (
+	minReconnectDelay = time.Second
+	maxReconnectDelay = 30 * time.Second
+)
+
+// Subscription receives a value on C whenever its user may have something
+// new. C is buffered so signals arriving while the subscriber is busy
+// collapse into one.
+type Subscription struct {
+	C <-chan struct{}
+
+	c      chan struct{}
+	hub    *Hub
+	userID int
+}
+
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	delete(s.hub.subscriptions[s.userID], s)
+	if len(s.hub.subscriptions[s.userID]) == 0 {
+		delete(s.hub.subscriptions, s.userID)
+	}
+}
+
+type Hub struct {
+	db     *gorm.DB
+	logger *slog.Logger
+
+	mu            sync.Mutex
+	subscriptions map[int]map[*Subscription]struct{}
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func NewHub(gormDB *gorm.DB, logger *slog.Logger) *Hub {
+	return &Hub{
+		db:            gormDB,
+		logger:        logger,
+		subscriptions: map[int]map[*Subscription]struct{}{},
+		done:          make(chan struct{}),
+	}
+}
+
+func (h *Hub) Subscribe(userID int) *Subscription {
+	c := make(chan struct{}, 1)
+	s := &Subscription{C: c, c: c, hub: h, userID: userID}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subscriptions[userID] == nil {
+		h.subscriptions[userID] = map[*Subscription]struct{}{}
+	}
+	h.subscriptions[userID][s] = struct{}{}
+	return s
+}
+
+// Notify signals userID's subscribers once the transaction carried by ctx
+// commits, on this instance and, with Postgres, on every other one.
+func (h *Hub) Notify(ctx context.Context, userID int) error {
+	if db.IsPostgres(h.db) {
+		err := db.Conn(ctx, h.db).Exec("SELECT pg_notify(?, ?)", Channel, strconv.Itoa(userID)).Error
+		if err != nil {
+			return fmt.Errorf("notifying user %d: %w", userID, err)
+		}
+	}
+	// Local streams are signalled directly as well, so they do not depend on
+	// the listener being connected; the listener's copy of the signal is
+	// redundant but harmless.
+	db.AfterCommit(ctx, func() { h.signal(userID) })
+	return nil
+}
+
+func (h *Hub) signal(userID int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for s := range h.subscriptions[userID] {
+		wake(s)
+	}
+}
+
+func (h *Hub) signalAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, subscriptions := range h.subscriptions {
+		for s := range subscriptions {
+			wake(s)
+		}
+	}
+}
+
+func wake(s *Subscription) {
+	select {
+	case s.c <- struct{}{}:
+	default:
+	}
+}
+
+// Done is closed by Close. Streams select on it so they end when the
+// server shuts down instead of holding it open.
+func (h *Hub) Done() <-chan struct{} {
+	return h.done
+}
+
+func (h *Hub) Close() {
+	h.closeOnce.Do(func() { close(h.done) })
+}
+
+func (h *Hub) Name() string {
+	return "notification listener"
+}
+
+// Run LISTENs for notifications from other instances until ctx is
+// cancelled, reconnecting with backoff when the connection drops. Other
+// dialects have nothing to listen to, so it only waits.
+func (h *Hub) Run(ctx context.Context) error {
+	if !db.IsPostgres(h.db) {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	delay := minReconnectDelay
+	for {
+		start := time.Now()
+		err := h.listen(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if time.Since(start) > maxReconnectDelay {
+			delay = minReconnectDelay
+		}
+		h.logger.ErrorContext(ctx, "notification listener disconnected", "error", err, "retry_in", delay)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay = min(2*delay, maxReconnectDelay)
+	}
+}
+
+// listen holds one connection out of the pool for as long as it is
+// listening.
+func (h *Hub) listen(ctx context.Context) error {
+	sqlDB, err := h.db.DB()
+	if err != nil {
+		return err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	// Returning driver.ErrBadConn makes database/sql discard the
+	// connection rather than pool a session that is still listening.
+	var listenErr error
+	conn.Raw(func(driverConn any) error {
+		stdConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			listenErr = fmt.Errorf("LISTEN needs a pgx connection, got %T", driverConn)
+			return nil
+		}
+		pgConn := stdConn.Conn()
+		if _, listenErr = pgConn.Exec(ctx, "LISTEN "+Channel); listenErr != nil {
+			return driver.ErrBadConn
+		}
+		// Signals sent while the listener was down were lost, so every
+		// stream has to look again.
+		h.signalAll()
+		for {
+			notification, err := pgConn.WaitForNotification(ctx)
+			if err != nil {
+				listenErr = err
+				return driver.ErrBadConn
+			}
+			userID, err := strconv.Atoi(notification.Payload)
+			if err != nil {
+				h.logger.WarnContext(ctx, "ignoring malformed notification", "payload", notification.Payload)
+				continue
+			}
+			h.signal(userID)
+		}
+	})
+	return listenErr
+}
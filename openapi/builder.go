@@ -0,0 +1,248 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	// ErrorSchema is the {"error": "..."} body every handler fails with.
+	// Some errors carry extra fields, such as the allowed values.
+	ErrorSchema = "Error"
+
+	JSON = "application/json"
+)
+
+// Builder assembles a Document one operation at a time.
+type Builder struct {
+	doc     *Document
+	schemas *schemas
+}
+
+func NewBuilder(info Info) *Builder {
+	b := &Builder{
+		doc: &Document{
+			OpenAPI: Version,
+			Info:    info,
+			Paths:   map[string]*PathItem{},
+			Components: Components{
+				SecuritySchemes: map[string]*SecurityScheme{},
+			},
+		},
+		schemas: newSchemas(),
+	}
+	b.schemas.components[ErrorSchema] = &Schema{
+		Type:       "object",
+		Properties: map[string]*Schema{"error": String()},
+		Required:   []string{"error"},
+	}
+	return b
+}
+
+// Enum documents the values a named string type may take, wherever it
+// appears in a schema.
+func Enum[T ~string](b *Builder, values ...T) {
+	enum := make([]any, len(values))
+	for i, value := range values {
+		enum[i] = string(value)
+	}
+	b.schemas.enums[reflect.TypeFor[T]()] = enum
+}
+
+func (b *Builder) SecurityScheme(name string, scheme *SecurityScheme) {
+	b.doc.Components.SecuritySchemes[name] = scheme
+}
+
+func (b *Builder) Server(url string) {
+	b.doc.Servers = append(b.doc.Servers, Server{URL: url})
+}
+
+func (b *Builder) Tag(name, description string) {
+	b.doc.Tags = append(b.doc.Tags, Tag{Name: name, Description: description})
+}
+
+// Schema returns the schema of v's type, or v itself when it already is a
+// *Schema. A nil v has no schema.
+func (b *Builder) Schema(v any) *Schema {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case *Schema:
+		return v
+	}
+	return b.schemas.of(reflect.TypeOf(v))
+}
+
+// Op declares the operation served for method on the gin route. Its path
+// parameters are declared from the route: "id" and names ending in "Id"
+// are integers, the rest strings. Every operation may fail with a 500.
+func (b *Builder) Op(method, route, summary, tag string) *OperationBuilder {
+	path := PathFor(route)
+	item := b.doc.Paths[path]
+	if item == nil {
+		item = &PathItem{}
+		b.doc.Paths[path] = item
+	}
+	op := &Operation{
+		OperationID: operationID(method, path),
+		Summary:     summary,
+		Tags:        []string{tag},
+		Responses:   map[string]*Response{},
+	}
+	(*item)[strings.ToLower(method)] = op
+	for _, segment := range strings.Split(route, "/") {
+		if segment == "" || (segment[0] != ':' && segment[0] != '*') {
+			continue
+		}
+		name, schema := segment[1:], String()
+		if name == "id" || strings.HasSuffix(name, "Id") {
+			schema = Integer()
+		}
+		op.Parameters = append(op.Parameters, &Parameter{Name: name, In: "path", Required: true, Schema: schema})
+	}
+	return (&OperationBuilder{b: b, op: op}).Errors(http.StatusInternalServerError)
+}
+
+// Document returns the assembled document. Operations whose parameters or
+// body can be invalid get a 400, which is also what the validation
+// middleware answers with.
+func (b *Builder) Document() *Document {
+	for _, item := range b.doc.Paths {
+		for _, op := range *item {
+			if op.RequestBody != nil || slices.ContainsFunc(op.Parameters, validated) {
+				(&OperationBuilder{b: b, op: op}).Errors(http.StatusBadRequest)
+			}
+		}
+	}
+	b.doc.Components.Schemas = b.schemas.components
+	return b.doc
+}
+
+// validated reports whether p can hold an invalid value; a string path
+// parameter cannot.
+func validated(p *Parameter) bool {
+	return p.In != "path" || p.Schema.Type != "string" || p.Schema.Enum != nil
+}
+
+// operationID names an operation after its method and path, e.g.
+// getApiPostsById for GET /api/posts/{id}.
+func operationID(method, path string) string {
+	var id strings.Builder
+	id.WriteString(strings.ToLower(method))
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "{") {
+			id.WriteString("By")
+			segment = strings.Trim(segment, "{}")
+		}
+		for _, word := range strings.FieldsFunc(segment, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+			id.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return id.String()
+}
+
+type OperationBuilder struct {
+	b  *Builder
+	op *Operation
+}
+
+func (o *OperationBuilder) Describe(description string) *OperationBuilder {
+	o.op.Description = description
+	return o
+}
+
+// Auth requires one of the named security schemes and documents the 401
+// for a missing or invalid credential.
+func (o *OperationBuilder) Auth(schemes ...string) *OperationBuilder {
+	for _, scheme := range schemes {
+		o.op.Security = append(o.op.Security, SecurityRequirement{scheme: {}})
+	}
+	return o.Errors(http.StatusUnauthorized)
+}
+
+// OptionalAuth is Auth for operations that anonymous clients may call too.
+func (o *OperationBuilder) OptionalAuth(schemes ...string) *OperationBuilder {
+	o.Auth(schemes...)
+	o.op.Security = append(o.op.Security, SecurityRequirement{})
+	return o
+}
+
+func (o *OperationBuilder) Query(name string, schema *Schema, description string) *OperationBuilder {
+	o.op.Parameters = append(o.op.Parameters, &Parameter{Name: name, In: "query", Description: description, Schema: schema})
+	return o
+}
+
+func (o *OperationBuilder) RequiredQuery(name string, schema *Schema, description string) *OperationBuilder {
+	o.Query(name, schema, description)
+	o.op.Parameters[len(o.op.Parameters)-1].Required = true
+	return o
+}
+
+func (o *OperationBuilder) Header(name string, schema *Schema, description string) *OperationBuilder {
+	o.op.Parameters = append(o.op.Parameters, &Parameter{Name: name, In: "header", Description: description, Schema: schema})
+	return o
+}
+
+// Body declares a required JSON request body shaped like v.
+func (o *OperationBuilder) Body(v any) *OperationBuilder {
+	return o.BodyContent(JSON, o.b.Schema(v), true)
+}
+
+// OptionalBody declares a JSON request body the client may leave out.
+func (o *OperationBuilder) OptionalBody(v any) *OperationBuilder {
+	return o.BodyContent(JSON, o.b.Schema(v), false)
+}
+
+func (o *OperationBuilder) BodyContent(contentType string, schema *Schema, required bool) *OperationBuilder {
+	if o.op.RequestBody == nil {
+		o.op.RequestBody = &RequestBody{Content: map[string]MediaType{}}
+	}
+	o.op.RequestBody.Required = o.op.RequestBody.Required || required
+	o.op.RequestBody.Content[contentType] = MediaType{Schema: schema}
+	return o
+}
+
+// Returns declares a JSON response shaped like v, or one without a body
+// when v is nil.
+func (o *OperationBuilder) Returns(status int, v any) *OperationBuilder {
+	if v == nil {
+		o.response(status)
+		return o
+	}
+	return o.ReturnsContent(status, JSON, o.b.Schema(v))
+}
+
+// ReturnsContent declares a response of another content type; schema may
+// be nil for opaque bodies.
+func (o *OperationBuilder) ReturnsContent(status int, contentType string, schema *Schema) *OperationBuilder {
+	response := o.response(status)
+	if response.Content == nil {
+		response.Content = map[string]MediaType{}
+	}
+	response.Content[contentType] = MediaType{Schema: schema}
+	return o
+}
+
+// Errors declares the statuses the operation fails with, all carrying the
+// Error body.
+func (o *OperationBuilder) Errors(statuses ...int) *OperationBuilder {
+	ref := &Schema{Ref: "#/components/schemas/" + ErrorSchema}
+	for _, status := range statuses {
+		o.ReturnsContent(status, JSON, ref)
+	}
+	return o
+}
+
+func (o *OperationBuilder) response(status int) *Response {
+	key := strconv.Itoa(status)
+	response := o.op.Responses[key]
+	if response == nil {
+		response = &Response{Description: http.StatusText(status)}
+		o.op.Responses[key] = response
+	}
+	return response
+}
@@ -0,0 +1,149 @@
+package openapi
+
+import (
+	"encoding/json"
+	"path"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	timeType       = reflect.TypeFor[time.Time]()
+	rawMessageType = reflect.TypeFor[json.RawMessage]()
+)
+
+// schemas turns Go types into schemas the way encoding/json would encode
+// them. Named structs become components referenced by $ref.
+type schemas struct {
+	components map[string]*Schema
+	names      map[reflect.Type]string
+	enums      map[reflect.Type][]any
+}
+
+func newSchemas() *schemas {
+	return &schemas{components: map[string]*Schema{}, names: map[reflect.Type]string{}, enums: map[reflect.Type][]any{}}
+}
+
+func (g *schemas) of(t reflect.Type) *Schema {
+	if t.Kind() == reflect.Pointer {
+		return nullable(g.of(t.Elem()))
+	}
+	if values, ok := g.enums[t]; ok {
+		return &Schema{Type: "string", Enum: values}
+	}
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case rawMessageType:
+		return &Schema{}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return Boolean()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Integer()
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return String()
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		// encoding/json writes a nil slice as null.
+		return nullable(ArrayOf(g.of(t.Elem())))
+	case reflect.Map:
+		return nullable(&Schema{Type: "object", AdditionalProperties: g.of(t.Elem())})
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + g.component(t)}
+	}
+	// Interfaces and anything else may hold any value.
+	return &Schema{}
+}
+
+// component registers the schema of the named struct t and returns its
+// name, qualified by package only when two packages use the same one.
+func (g *schemas) component(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	if _, taken := g.components[name]; taken {
+		pkg := path.Base(t.PkgPath())
+		name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+	}
+	g.names[t] = name
+	// Reserve the name first, so recursive types end in a $ref.
+	g.components[name] = &Schema{}
+	*g.components[name] = *g.object(t)
+	return name
+}
+
+// object builds an object schema from the exported fields of struct t,
+// flattening embedded structs as encoding/json does. Fields tagged
+// binding:"required" are required.
+func (g *schemas) object(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	g.addFields(s, t)
+	slices.Sort(s.Required)
+	return s
+}
+
+func (g *schemas) addFields(s *Schema, t reflect.Type) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				g.addFields(s, embedded)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schema := g.of(field.Type)
+		if slices.Contains(strings.Split(options, ","), "string") {
+			schema = String()
+		}
+		s.Properties[name] = schema
+		if slices.Contains(strings.Split(field.Tag.Get("binding"), ","), "required") {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+// nullable lets s also be null. A $ref cannot carry a type, so it is
+// wrapped instead.
+func nullable(s *Schema) *Schema {
+	switch t := s.Type.(type) {
+	case string:
+		s.Type = []string{t, "null"}
+		if s.Enum != nil {
+			s.Enum = append(s.Enum, nil)
+		}
+		return s
+	case nil:
+		if s.Ref != "" {
+			return &Schema{AnyOf: []*Schema{s, {Type: "null"}}}
+		}
+	}
+	return s
+}
@@ -0,0 +1,285 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+const resourceURL = "mem:///openapi.json"
+
+var printer = message.NewPrinter(language.English)
+
+// Validator checks requests and responses against a Document. Parameters
+// and JSON bodies are validated against their schemas; other bodies only
+// need a documented content type.
+type Validator struct {
+	operations map[string]*compiledOperation
+}
+
+type compiledOperation struct {
+	security   []SecurityRequirement
+	parameters []compiledParameter
+	body       *RequestBody
+	bodies     map[string]*jsonschema.Schema
+	responses  map[string]map[string]*jsonschema.Schema
+}
+
+type compiledParameter struct {
+	*Parameter
+	schema *jsonschema.Schema
+}
+
+// Request is what ValidateRequest looks at. Path is the templated OpenAPI
+// path, e.g. /api/posts/{id}.
+type Request struct {
+	Method      string
+	Path        string
+	PathParams  map[string]string
+	Query       url.Values
+	Header      http.Header
+	ContentType string
+	Body        []byte
+}
+
+func NewValidator(doc *Document) (*Validator, error) {
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	resource, err := jsonschema.UnmarshalJSON(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(resourceURL, resource); err != nil {
+		return nil, err
+	}
+	compile := func(schema *Schema, pointer ...string) (*jsonschema.Schema, error) {
+		if schema == nil {
+			return nil, nil
+		}
+		for i, token := range pointer {
+			pointer[i] = strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+		}
+		return compiler.Compile(resourceURL + "#/" + strings.Join(pointer, "/"))
+	}
+
+	v := &Validator{operations: map[string]*compiledOperation{}}
+	for path, item := range doc.Paths {
+		for method, op := range *item {
+			compiled := &compiledOperation{
+				security:  op.Security,
+				body:      op.RequestBody,
+				bodies:    map[string]*jsonschema.Schema{},
+				responses: map[string]map[string]*jsonschema.Schema{},
+			}
+			for i, parameter := range op.Parameters {
+				schema, err := compile(parameter.Schema, "paths", path, method, "parameters", strconv.Itoa(i), "schema")
+				if err != nil {
+					return nil, err
+				}
+				compiled.parameters = append(compiled.parameters, compiledParameter{Parameter: parameter, schema: schema})
+			}
+			if op.RequestBody != nil {
+				for contentType, media := range op.RequestBody.Content {
+					if compiled.bodies[contentType], err = compile(media.Schema, "paths", path, method, "requestBody", "content", contentType, "schema"); err != nil {
+						return nil, err
+					}
+				}
+			}
+			for status, response := range op.Responses {
+				compiled.responses[status] = map[string]*jsonschema.Schema{}
+				for contentType, media := range response.Content {
+					if compiled.responses[status][contentType], err = compile(media.Schema, "paths", path, method, "responses", status, "content", contentType, "schema"); err != nil {
+						return nil, err
+					}
+				}
+			}
+			v.operations[strings.ToUpper(method)+" "+path] = compiled
+		}
+	}
+	return v, nil
+}
+
+// Knows reports whether the document describes method on path.
+func (v *Validator) Knows(method, path string) bool {
+	return v.operations[method+" "+path] != nil
+}
+
+// Security returns the security requirements of method on path; any one
+// of them grants access.
+func (v *Validator) Security(method, path string) []SecurityRequirement {
+	if op := v.operations[method+" "+path]; op != nil {
+		return op.security
+	}
+	return nil
+}
+
+func (v *Validator) ValidateRequest(r Request) error {
+	op := v.operations[r.Method+" "+r.Path]
+	if op == nil {
+		return fmt.Errorf("%s %s is not documented", r.Method, r.Path)
+	}
+	for _, parameter := range op.parameters {
+		var raw string
+		var present bool
+		switch parameter.In {
+		case "path":
+			raw, present = r.PathParams[parameter.Name]
+		case "query":
+			raw, present = r.Query.Get(parameter.Name), r.Query.Has(parameter.Name)
+		case "header":
+			raw = r.Header.Get(parameter.Name)
+			present = raw != ""
+		}
+		if !present {
+			if parameter.Required {
+				return fmt.Errorf("%s parameter %s is required", parameter.In, parameter.Name)
+			}
+			continue
+		}
+		if err := validateParameter(parameter, raw); err != nil {
+			return fmt.Errorf("%s parameter %s %w", parameter.In, parameter.Name, err)
+		}
+	}
+
+	if op.body == nil || (len(r.Body) == 0 && !op.body.Required) {
+		return nil
+	}
+	if len(r.Body) == 0 {
+		return errors.New("request body is required")
+	}
+	contentType := JSON
+	if r.ContentType != "" {
+		mediaType, _, err := mime.ParseMediaType(r.ContentType)
+		if err != nil {
+			return fmt.Errorf("invalid Content-Type: %w", err)
+		}
+		contentType = mediaType
+	}
+	schema, ok := op.bodies[contentType]
+	if !ok {
+		return fmt.Errorf("request body must be one of %s", strings.Join(mapKeys(op.body.Content), ", "))
+	}
+	if err := validateJSON(schema, contentType, r.Body); err != nil {
+		return fmt.Errorf("request body %w", err)
+	}
+	return nil
+}
+
+// ValidateResponse checks that status is documented for the operation and
+// that the body matches it.
+func (v *Validator) ValidateResponse(method, path string, status int, contentType string, body []byte) error {
+	op := v.operations[method+" "+path]
+	if op == nil {
+		return fmt.Errorf("%s %s is not documented", method, path)
+	}
+	contents, ok := op.responses[strconv.Itoa(status)]
+	if !ok {
+		return fmt.Errorf("status %d is not documented", status)
+	}
+	if len(body) == 0 {
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fmt.Errorf("invalid Content-Type %q", contentType)
+	}
+	schema, ok := contents[mediaType]
+	if !ok {
+		// Files are served with whatever type they were uploaded as.
+		kind, _, _ := strings.Cut(mediaType, "/")
+		if schema, ok = contents[kind+"/*"]; !ok {
+			if schema, ok = contents["*/*"]; !ok {
+				return fmt.Errorf("status %d is not documented with %s", status, mediaType)
+			}
+		}
+	}
+	if err := validateJSON(schema, mediaType, body); err != nil {
+		return fmt.Errorf("status %d body %w", status, err)
+	}
+	return nil
+}
+
+// validateParameter converts a raw parameter to the type its schema asks
+// for before validating it.
+func validateParameter(parameter compiledParameter, raw string) error {
+	var value any = raw
+	switch parameter.Parameter.Schema.Type {
+	case "integer":
+		if _, err := strconv.ParseInt(raw, 10, 64); err != nil {
+			return errors.New("must be an integer")
+		}
+		value = json.Number(raw)
+	case "boolean":
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return errors.New("must be true or false")
+		}
+		value = b
+	}
+	if parameter.schema == nil {
+		return nil
+	}
+	if err := parameter.schema.Validate(value); err != nil {
+		return describe(err)
+	}
+	return nil
+}
+
+func validateJSON(schema *jsonschema.Schema, contentType string, body []byte) error {
+	if schema == nil || (contentType != JSON && !strings.HasSuffix(contentType, "+json")) {
+		return nil
+	}
+	value, err := jsonschema.UnmarshalJSON(bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("is not valid JSON: %w", err)
+	}
+	if err := schema.Validate(value); err != nil {
+		return describe(err)
+	}
+	return nil
+}
+
+// describe flattens a validation error into its root causes, each prefixed
+// with where in the value it is.
+func describe(err error) error {
+	var validation *jsonschema.ValidationError
+	if !errors.As(err, &validation) {
+		return err
+	}
+	var causes []string
+	var walk func(*jsonschema.ValidationError)
+	walk = func(e *jsonschema.ValidationError) {
+		if len(e.Causes) == 0 {
+			location := "/" + strings.Join(e.InstanceLocation, "/")
+			causes = append(causes, fmt.Sprintf("at %s: %s", location, e.ErrorKind.LocalizedString(printer)))
+		}
+		for _, cause := range e.Causes {
+			walk(cause)
+		}
+	}
+	walk(validation)
+	return fmt.Errorf("is invalid: %s", strings.Join(causes, "; "))
+}
+
+func mapKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,1305 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: proto/blog/v1/blog.proto
+
+// The blog's gRPC API, for internal services. Every call accepts a JWT from
+// POST /api/login in the "authorization" metadata as "Bearer <token>" and
+// applies the same rules as the REST API: reads work without one, a token
+// that is present must be valid, creating posts needs a blogger account and
+// only authors may change or delete their posts.
+//
+// Regenerate the Go code with:
+//
+//	protoc --go_out=. --go_opt=paths=source_relative \
+//	  --go-grpc_out=. --go-grpc_opt=paths=source_relative proto/blog/v1/blog.proto
+
+package blogv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type PostStatus int32
+
+const (
+	PostStatus_POST_STATUS_UNSPECIFIED PostStatus = 0
+	PostStatus_POST_STATUS_DRAFT       PostStatus = 1
+	PostStatus_POST_STATUS_PUBLISHED   PostStatus = 2
+)
+
+// Enum value maps for PostStatus.
+var (
+	PostStatus_name = map[int32]string{
+		0: "POST_STATUS_UNSPECIFIED",
+		1: "POST_STATUS_DRAFT",
+		2: "POST_STATUS_PUBLISHED",
+	}
+	PostStatus_value = map[string]int32{
+		"POST_STATUS_UNSPECIFIED": 0,
+		"POST_STATUS_DRAFT":       1,
+		"POST_STATUS_PUBLISHED":   2,
+	}
+)
+
+func (x PostStatus) Enum() *PostStatus {
+	p := new(PostStatus)
+	*p = x
+	return p
+}
+
+func (x PostStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (PostStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_blog_v1_blog_proto_enumTypes[0].Descriptor()
+}
+
+func (PostStatus) Type() protoreflect.EnumType {
+	return &file_proto_blog_v1_blog_proto_enumTypes[0]
+}
+
+func (x PostStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use PostStatus.Descriptor instead.
+func (PostStatus) EnumDescriptor() ([]byte, []int) {
+	return file_proto_blog_v1_blog_proto_rawDescGZIP(), []int{0}
+}
+
+type AccountType int32
+
+const (
+	AccountType_ACCOUNT_TYPE_UNSPECIFIED AccountType = 0
+	AccountType_ACCOUNT_TYPE_BLOGGER     AccountType = 1
+	AccountType_ACCOUNT_TYPE_VIEWER      AccountType = 2
+)
+
+// Enum value maps for AccountType.
+var (
+	AccountType_name = map[int32]string{
+		0: "ACCOUNT_TYPE_UNSPECIFIED",
+		1: "ACCOUNT_TYPE_BLOGGER",
+		2: "ACCOUNT_TYPE_VIEWER",
+	}
+	AccountType_value = map[string]int32{
+		"ACCOUNT_TYPE_UNSPECIFIED": 0,
+		"ACCOUNT_TYPE_BLOGGER":     1,
+		"ACCOUNT_TYPE_VIEWER":      2,
+	}
+)
+
+func (x AccountType) Enum() *AccountType {
+	p := new(AccountType)
+	*p = x
+	return p
+}
+
+func (x AccountType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (AccountType) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_blog_v1_blog_proto_enumTypes[1].Descriptor()
+}
+
+func (AccountType) Type() protoreflect.EnumType {
+	return &file_proto_blog_v1_blog_proto_enumTypes[1]
+}
+
+func (x AccountType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use AccountType.Descriptor instead.
+func (AccountType) EnumDescriptor() ([]byte, []int) {
+	return file_proto_blog_v1_blog_proto_rawDescGZIP(), []int{1}
+}
+
+type PostEvent_Type int32
+
+const (
+	PostEvent_TYPE_UNSPECIFIED PostEvent_Type = 0
+	PostEvent_TYPE_CREATED     PostEvent_Type = 1
+	PostEvent_TYPE_PUBLISHED   PostEvent_Type = 2
+	PostEvent_TYPE_UPDATED     PostEvent_Type = 3
+	PostEvent_TYPE_DELETED     PostEvent_Type = 4
+	// Sent to everyone who could see the post while it was published. Only
+	// its author gets more than its id, user_id and status.
+	PostEvent_TYPE_UNPUBLISHED PostEvent_Type = 5
+)
+
+// Enum value maps for PostEvent_Type.
+var (
+	PostEvent_Type_name = map[int32]string{
+		0: "TYPE_UNSPECIFIED",
+		1: "TYPE_CREATED",
+		2: "TYPE_PUBLISHED",
+		3: "TYPE_UPDATED",
+		4: "TYPE_DELETED",
+		5: "TYPE_UNPUBLISHED",
+	}
+	PostEvent_Type_value = map[string]int32{
+		"TYPE_UNSPECIFIED": 0,
+		"TYPE_CREATED":     1,
+		"TYPE_PUBLISHED":   2,
+		"TYPE_UPDATED":     3,
+		"TYPE_DELETED":     4,
+		"TYPE_UNPUBLISHED": 5,
+	}
+)
+
+func (x PostEvent_Type) Enum() *PostEvent_Type {
+	p := new(PostEvent_Type)
+	*p = x
+	return p
+}
+
+func (x PostEvent_Type) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (PostEvent_Type) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_blog_v1_blog_proto_enumTypes[2].Descriptor()
+}
+
+func (PostEvent_Type) Type() protoreflect.EnumType {
+	return &file_proto_blog_v1_blog_proto_enumTypes[2]
+}
+
+func (x PostEvent_Type) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use PostEvent_Type.Descriptor instead.
+func (PostEvent_Type) EnumDescriptor() ([]byte, []int) {
+	return file_proto_blog_v1_blog_proto_rawDescGZIP(), []int{11, 0}
+}
+
+type Post struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Id      int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Title   string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Slug    string                 `protobuf:"bytes,3,opt,name=slug,proto3" json:"slug,omitempty"`
+	Content string                 `protobuf:"bytes,4,opt,name=content,proto3" json:"content,omitempty"`
+	UserId  int64                  `protobuf:"varint,5,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Status  PostStatus             `protobuf:"varint,6,opt,name=status,proto3,enum=blog.v1.PostStatus" json:"status,omitempty"`
+	// Unset for drafts.
+	PublishedAt   *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=published_at,json=publishedAt,proto3" json:"published_at,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	Tags          []string               `protobuf:"bytes,10,rep,name=tags,proto3" json:"tags,omitempty"`
+	MediaIds      []int64                `protobuf:"varint,11,rep,packed,name=media_ids,json=mediaIds,proto3" json:"media_ids,omitempty"`
+	Reactions     *Reactions             `protobuf:"bytes,12,opt,name=reactions,proto3" json:"reactions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Post) Reset() {
+	*x = Post{}
+	mi := &file_proto_blog_v1_blog_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Post) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Post) ProtoMessage() {}
+
+func (x *Post) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_blog_v1_blog_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Post.ProtoReflect.Descriptor instead.
+func (*Post) Descriptor() ([]byte, []int) {
+	return file_proto_blog_v1_blog_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Post) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Post) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Post) GetSlug() string {
+	if x != nil {
+		return x.Slug
+	}
+	return ""
+}
+
+func (x *Post) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *Post) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *Post) GetStatus() PostStatus {
+	if x != nil {
+		return x.Status
+	}
+	return PostStatus_POST_STATUS_UNSPECIFIED
+}
+
+func (x *Post) GetPublishedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.PublishedAt
+	}
+	return nil
+}
+
+func (x *Post) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Post) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *Post) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Post) GetMediaIds() []int64 {
+	if x != nil {
+		return x.MediaIds
+	}
+	return nil
+}
+
+func (x *Post) GetReactions() *Reactions {
+	if x != nil {
+		return x.Reactions
+	}
+	return nil
+}
+
+type Reactions struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Like          int64                  `protobuf:"varint,1,opt,name=like,proto3" json:"like,omitempty"`
+	Love          int64                  `protobuf:"varint,2,opt,name=love,proto3" json:"love,omitempty"`
+	Laugh         int64                  `protobuf:"varint,3,opt,name=laugh,proto3" json:"laugh,omitempty"`
+	Wow           int64                  `protobuf:"varint,4,opt,name=wow,proto3" json:"wow,omitempty"`
+	Sad           int64                  `protobuf:"varint,5,opt,name=sad,proto3" json:"sad,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Reactions) Reset() {
+	*x = Reactions{}
+	mi := &file_proto_blog_v1_blog_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Reactions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Reactions) ProtoMessage() {}
+
+func (x *Reactions) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_blog_v1_blog_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Reactions.ProtoReflect.Descriptor instead.
+func (*Reactions) Descriptor() ([]byte, []int) {
+	return file_proto_blog_v1_blog_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Reactions) GetLike() int64 {
+	if x != nil {
+		return x.Like
+	}
+	return 0
+}
+
+func (x *Reactions) GetLove() int64 {
+	if x != nil {
+		return x.Love
+	}
+	return 0
+}
+
+func (x *Reactions) GetLaugh() int64 {
+	if x != nil {
+		return x.Laugh
+	}
+	return 0
+}
+
+func (x *Reactions) GetWow() int64 {
+	if x != nil {
+		return x.Wow
+	}
+	return 0
+}
+
+func (x *Reactions) GetSad() int64 {
+	if x != nil {
+		return x.Sad
+	}
+	return 0
+}
+
+type User struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Username      string                 `protobuf:"bytes,2,opt,name=username,proto3" json:"username,omitempty"`
+	AccountType   AccountType            `protobuf:"varint,3,opt,name=account_type,json=accountType,proto3,enum=blog.v1.AccountType" json:"account_type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *User) Reset() {
+	*x = User{}
+	mi := &file_proto_blog_v1_blog_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *User) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*User) ProtoMessage() {}
+
+func (x *User) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_blog_v1_blog_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use User.ProtoReflect.Descriptor instead.
+func (*User) Descriptor() ([]byte, []int) {
+	return file_proto_blog_v1_blog_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *User) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *User) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *User) GetAccountType() AccountType {
+	if x != nil {
+		return x.AccountType
+	}
+	return AccountType_ACCOUNT_TYPE_UNSPECIFIED
+}
+
+type ListPostsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// At most 100; 0 means 20.
+	PageSize int32 `protobuf:"varint,1,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// The last id of the previous page.
+	AfterId       int64 `protobuf:"varint,2,opt,name=after_id,json=afterId,proto3" json:"after_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPostsRequest) Reset() {
+	*x = ListPostsRequest{}
+	mi := &file_proto_blog_v1_blog_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPostsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPostsRequest) ProtoMessage() {}
+
+func (x *ListPostsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_blog_v1_blog_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPostsRequest.ProtoReflect.Descriptor instead.
+func (*ListPostsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_blog_v1_blog_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListPostsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListPostsRequest) GetAfterId() int64 {
+	if x != nil {
+		return x.AfterId
+	}
+	return 0
+}
+
+type ListPostsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Posts []*Post                `protobuf:"bytes,1,rep,name=posts,proto3" json:"posts,omitempty"`
+	// The after_id of the next page, or 0 on the last page.
+	NextAfterId   int64 `protobuf:"varint,2,opt,name=next_after_id,json=nextAfterId,proto3" json:"next_after_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPostsResponse) Reset() {
+	*x = ListPostsResponse{}
+	mi := &file_proto_blog_v1_blog_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPostsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPostsResponse) ProtoMessage() {}
+
+func (x *ListPostsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_blog_v1_blog_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPostsResponse.ProtoReflect.Descriptor instead.
+func (*ListPostsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_blog_v1_blog_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListPostsResponse) GetPosts() []*Post {
+	if x != nil {
+		return x.Posts
+	}
+	return nil
+}
+
+func (x *ListPostsResponse) GetNextAfterId() int64 {
+	if x != nil {
+		return x.NextAfterId
+	}
+	return 0
+}
+
+type GetPostRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPostRequest) Reset() {
+	*x = GetPostRequest{}
+	mi := &file_proto_blog_v1_blog_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPostRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPostRequest) ProtoMessage() {}
+
+func (x *GetPostRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_blog_v1_blog_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPostRequest.ProtoReflect.Descriptor instead.
+func (*GetPostRequest) Descriptor() ([]byte, []int) {
+	return file_proto_blog_v1_blog_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetPostRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type CreatePostRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Title   string                 `protobuf:"bytes,1,opt,name=title,proto3" json:"title,omitempty"`
+	Content string                 `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	Slug    string                 `protobuf:"bytes,3,opt,name=slug,proto3" json:"slug,omitempty"`
+	// Defaults to published.
+	Status        PostStatus `protobuf:"varint,4,opt,name=status,proto3,enum=blog.v1.PostStatus" json:"status,omitempty"`
+	Tags          []string   `protobuf:"bytes,5,rep,name=tags,proto3" json:"tags,omitempty"`
+	MediaIds      []int64    `protobuf:"varint,6,rep,packed,name=media_ids,json=mediaIds,proto3" json:"media_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreatePostRequest) Reset() {
+	*x = CreatePostRequest{}
+	mi := &file_proto_blog_v1_blog_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreatePostRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreatePostRequest) ProtoMessage() {}
+
+func (x *CreatePostRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_blog_v1_blog_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreatePostRequest.ProtoReflect.Descriptor instead.
+func (*CreatePostRequest) Descriptor() ([]byte, []int) {
+	return file_proto_blog_v1_blog_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *CreatePostRequest) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *CreatePostRequest) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *CreatePostRequest) GetSlug() string {
+	if x != nil {
+		return x.Slug
+	}
+	return ""
+}
+
+func (x *CreatePostRequest) GetStatus() PostStatus {
+	if x != nil {
+		return x.Status
+	}
+	return PostStatus_POST_STATUS_UNSPECIFIED
+}
+
+func (x *CreatePostRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *CreatePostRequest) GetMediaIds() []int64 {
+	if x != nil {
+		return x.MediaIds
+	}
+	return nil
+}
+
+type UpdatePostRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Id      int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Title   string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Content string                 `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
+	Slug    string                 `protobuf:"bytes,4,opt,name=slug,proto3" json:"slug,omitempty"`
+	Status  PostStatus             `protobuf:"varint,5,opt,name=status,proto3,enum=blog.v1.PostStatus" json:"status,omitempty"`
+	// Tags are replaced when update_tags is set, so they can be cleared.
+	Tags       []string `protobuf:"bytes,6,rep,name=tags,proto3" json:"tags,omitempty"`
+	UpdateTags bool     `protobuf:"varint,7,opt,name=update_tags,json=updateTags,proto3" json:"update_tags,omitempty"`
+	// Media are replaced when update_media is set.
+	MediaIds      []int64 `protobuf:"varint,8,rep,packed,name=media_ids,json=mediaIds,proto3" json:"media_ids,omitempty"`
+	UpdateMedia   bool    `protobuf:"varint,9,opt,name=update_media,json=updateMedia,proto3" json:"update_media,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdatePostRequest) Reset() {
+	*x = UpdatePostRequest{}
+	mi := &file_proto_blog_v1_blog_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdatePostRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdatePostRequest) ProtoMessage() {}
+
+func (x *UpdatePostRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_blog_v1_blog_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdatePostRequest.ProtoReflect.Descriptor instead.
+func (*UpdatePostRequest) Descriptor() ([]byte, []int) {
+	return file_proto_blog_v1_blog_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *UpdatePostRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *UpdatePostRequest) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *UpdatePostRequest) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *UpdatePostRequest) GetSlug() string {
+	if x != nil {
+		return x.Slug
+	}
+	return ""
+}
+
+func (x *UpdatePostRequest) GetStatus() PostStatus {
+	if x != nil {
+		return x.Status
+	}
+	return PostStatus_POST_STATUS_UNSPECIFIED
+}
+
+func (x *UpdatePostRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *UpdatePostRequest) GetUpdateTags() bool {
+	if x != nil {
+		return x.UpdateTags
+	}
+	return false
+}
+
+func (x *UpdatePostRequest) GetMediaIds() []int64 {
+	if x != nil {
+		return x.MediaIds
+	}
+	return nil
+}
+
+func (x *UpdatePostRequest) GetUpdateMedia() bool {
+	if x != nil {
+		return x.UpdateMedia
+	}
+	return false
+}
+
+type DeletePostRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeletePostRequest) Reset() {
+	*x = DeletePostRequest{}
+	mi := &file_proto_blog_v1_blog_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeletePostRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeletePostRequest) ProtoMessage() {}
+
+func (x *DeletePostRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_blog_v1_blog_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeletePostRequest.ProtoReflect.Descriptor instead.
+func (*DeletePostRequest) Descriptor() ([]byte, []int) {
+	return file_proto_blog_v1_blog_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *DeletePostRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type DeletePostResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeletePostResponse) Reset() {
+	*x = DeletePostResponse{}
+	mi := &file_proto_blog_v1_blog_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeletePostResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeletePostResponse) ProtoMessage() {}
+
+func (x *DeletePostResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_blog_v1_blog_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeletePostResponse.ProtoReflect.Descriptor instead.
+func (*DeletePostResponse) Descriptor() ([]byte, []int) {
+	return file_proto_blog_v1_blog_proto_rawDescGZIP(), []int{9}
+}
+
+type WatchPostsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchPostsRequest) Reset() {
+	*x = WatchPostsRequest{}
+	mi := &file_proto_blog_v1_blog_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchPostsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchPostsRequest) ProtoMessage() {}
+
+func (x *WatchPostsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_blog_v1_blog_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchPostsRequest.ProtoReflect.Descriptor instead.
+func (*WatchPostsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_blog_v1_blog_proto_rawDescGZIP(), []int{10}
+}
+
+type PostEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Type  PostEvent_Type         `protobuf:"varint,1,opt,name=type,proto3,enum=blog.v1.PostEvent_Type" json:"type,omitempty"`
+	// For TYPE_DELETED, the post as it was before it was deleted.
+	Post          *Post `protobuf:"bytes,2,opt,name=post,proto3" json:"post,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PostEvent) Reset() {
+	*x = PostEvent{}
+	mi := &file_proto_blog_v1_blog_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PostEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PostEvent) ProtoMessage() {}
+
+func (x *PostEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_blog_v1_blog_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PostEvent.ProtoReflect.Descriptor instead.
+func (*PostEvent) Descriptor() ([]byte, []int) {
+	return file_proto_blog_v1_blog_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *PostEvent) GetType() PostEvent_Type {
+	if x != nil {
+		return x.Type
+	}
+	return PostEvent_TYPE_UNSPECIFIED
+}
+
+func (x *PostEvent) GetPost() *Post {
+	if x != nil {
+		return x.Post
+	}
+	return nil
+}
+
+type GetUserRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Username      string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUserRequest) Reset() {
+	*x = GetUserRequest{}
+	mi := &file_proto_blog_v1_blog_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUserRequest) ProtoMessage() {}
+
+func (x *GetUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_blog_v1_blog_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUserRequest.ProtoReflect.Descriptor instead.
+func (*GetUserRequest) Descriptor() ([]byte, []int) {
+	return file_proto_blog_v1_blog_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *GetUserRequest) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+type BatchGetUsersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ids           []int64                `protobuf:"varint,1,rep,packed,name=ids,proto3" json:"ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetUsersRequest) Reset() {
+	*x = BatchGetUsersRequest{}
+	mi := &file_proto_blog_v1_blog_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetUsersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetUsersRequest) ProtoMessage() {}
+
+func (x *BatchGetUsersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_blog_v1_blog_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetUsersRequest.ProtoReflect.Descriptor instead.
+func (*BatchGetUsersRequest) Descriptor() ([]byte, []int) {
+	return file_proto_blog_v1_blog_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *BatchGetUsersRequest) GetIds() []int64 {
+	if x != nil {
+		return x.Ids
+	}
+	return nil
+}
+
+type BatchGetUsersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Users         []*User                `protobuf:"bytes,1,rep,name=users,proto3" json:"users,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetUsersResponse) Reset() {
+	*x = BatchGetUsersResponse{}
+	mi := &file_proto_blog_v1_blog_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetUsersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetUsersResponse) ProtoMessage() {}
+
+func (x *BatchGetUsersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_blog_v1_blog_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetUsersResponse.ProtoReflect.Descriptor instead.
+func (*BatchGetUsersResponse) Descriptor() ([]byte, []int) {
+	return file_proto_blog_v1_blog_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *BatchGetUsersResponse) GetUsers() []*User {
+	if x != nil {
+		return x.Users
+	}
+	return nil
+}
+
+var File_proto_blog_v1_blog_proto protoreflect.FileDescriptor
+
+const file_proto_blog_v1_blog_proto_rawDesc = "" +
+	"\n" +
+	"\x18proto/blog/v1/blog.proto\x12\ablog.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xb8\x03\n" +
+	"\x04Post\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12\x12\n" +
+	"\x04slug\x18\x03 \x01(\tR\x04slug\x12\x18\n" +
+	"\acontent\x18\x04 \x01(\tR\acontent\x12\x17\n" +
+	"\auser_id\x18\x05 \x01(\x03R\x06userId\x12+\n" +
+	"\x06status\x18\x06 \x01(\x0e2\x13.blog.v1.PostStatusR\x06status\x12=\n" +
+	"\fpublished_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\vpublishedAt\x129\n" +
+	"\n" +
+	"created_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12\x12\n" +
+	"\x04tags\x18\n" +
+	" \x03(\tR\x04tags\x12\x1b\n" +
+	"\tmedia_ids\x18\v \x03(\x03R\bmediaIds\x120\n" +
+	"\treactions\x18\f \x01(\v2\x12.blog.v1.ReactionsR\treactions\"m\n" +
+	"\tReactions\x12\x12\n" +
+	"\x04like\x18\x01 \x01(\x03R\x04like\x12\x12\n" +
+	"\x04love\x18\x02 \x01(\x03R\x04love\x12\x14\n" +
+	"\x05laugh\x18\x03 \x01(\x03R\x05laugh\x12\x10\n" +
+	"\x03wow\x18\x04 \x01(\x03R\x03wow\x12\x10\n" +
+	"\x03sad\x18\x05 \x01(\x03R\x03sad\"k\n" +
+	"\x04User\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x1a\n" +
+	"\busername\x18\x02 \x01(\tR\busername\x127\n" +
+	"\faccount_type\x18\x03 \x01(\x0e2\x14.blog.v1.AccountTypeR\vaccountType\"J\n" +
+	"\x10ListPostsRequest\x12\x1b\n" +
+	"\tpage_size\x18\x01 \x01(\x05R\bpageSize\x12\x19\n" +
+	"\bafter_id\x18\x02 \x01(\x03R\aafterId\"\\\n" +
+	"\x11ListPostsResponse\x12#\n" +
+	"\x05posts\x18\x01 \x03(\v2\r.blog.v1.PostR\x05posts\x12\"\n" +
+	"\rnext_after_id\x18\x02 \x01(\x03R\vnextAfterId\" \n" +
+	"\x0eGetPostRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"\xb5\x01\n" +
+	"\x11CreatePostRequest\x12\x14\n" +
+	"\x05title\x18\x01 \x01(\tR\x05title\x12\x18\n" +
+	"\acontent\x18\x02 \x01(\tR\acontent\x12\x12\n" +
+	"\x04slug\x18\x03 \x01(\tR\x04slug\x12+\n" +
+	"\x06status\x18\x04 \x01(\x0e2\x13.blog.v1.PostStatusR\x06status\x12\x12\n" +
+	"\x04tags\x18\x05 \x03(\tR\x04tags\x12\x1b\n" +
+	"\tmedia_ids\x18\x06 \x03(\x03R\bmediaIds\"\x89\x02\n" +
+	"\x11UpdatePostRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12\x18\n" +
+	"\acontent\x18\x03 \x01(\tR\acontent\x12\x12\n" +
+	"\x04slug\x18\x04 \x01(\tR\x04slug\x12+\n" +
+	"\x06status\x18\x05 \x01(\x0e2\x13.blog.v1.PostStatusR\x06status\x12\x12\n" +
+	"\x04tags\x18\x06 \x03(\tR\x04tags\x12\x1f\n" +
+	"\vupdate_tags\x18\a \x01(\bR\n" +
+	"updateTags\x12\x1b\n" +
+	"\tmedia_ids\x18\b \x03(\x03R\bmediaIds\x12!\n" +
+	"\fupdate_media\x18\t \x01(\bR\vupdateMedia\"#\n" +
+	"\x11DeletePostRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"\x14\n" +
+	"\x12DeletePostResponse\"\x13\n" +
+	"\x11WatchPostsRequest\"\xd9\x01\n" +
+	"\tPostEvent\x12+\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x17.blog.v1.PostEvent.TypeR\x04type\x12!\n" +
+	"\x04post\x18\x02 \x01(\v2\r.blog.v1.PostR\x04post\"|\n" +
+	"\x04Type\x12\x14\n" +
+	"\x10TYPE_UNSPECIFIED\x10\x00\x12\x10\n" +
+	"\fTYPE_CREATED\x10\x01\x12\x12\n" +
+	"\x0eTYPE_PUBLISHED\x10\x02\x12\x10\n" +
+	"\fTYPE_UPDATED\x10\x03\x12\x10\n" +
+	"\fTYPE_DELETED\x10\x04\x12\x14\n" +
+	"\x10TYPE_UNPUBLISHED\x10\x05\",\n" +
+	"\x0eGetUserRequest\x12\x1a\n" +
+	"\busername\x18\x01 \x01(\tR\busername\"(\n" +
+	"\x14BatchGetUsersRequest\x12\x10\n" +
+	"\x03ids\x18\x01 \x03(\x03R\x03ids\"<\n" +
+	"\x15BatchGetUsersResponse\x12#\n" +
+	"\x05users\x18\x01 \x03(\v2\r.blog.v1.UserR\x05users*[\n" +
+	"\n" +
+	"PostStatus\x12\x1b\n" +
+	"\x17POST_STATUS_UNSPECIFIED\x10\x00\x12\x15\n" +
+	"\x11POST_STATUS_DRAFT\x10\x01\x12\x19\n" +
+	"\x15POST_STATUS_PUBLISHED\x10\x02*^\n" +
+	"\vAccountType\x12\x1c\n" +
+	"\x18ACCOUNT_TYPE_UNSPECIFIED\x10\x00\x12\x18\n" +
+	"\x14ACCOUNT_TYPE_BLOGGER\x10\x01\x12\x17\n" +
+	"\x13ACCOUNT_TYPE_VIEWER\x10\x022\xfd\x02\n" +
+	"\vPostService\x12B\n" +
+	"\tListPosts\x12\x19.blog.v1.ListPostsRequest\x1a\x1a.blog.v1.ListPostsResponse\x121\n" +
+	"\aGetPost\x12\x17.blog.v1.GetPostRequest\x1a\r.blog.v1.Post\x127\n" +
+	"\n" +
+	"CreatePost\x12\x1a.blog.v1.CreatePostRequest\x1a\r.blog.v1.Post\x127\n" +
+	"\n" +
+	"UpdatePost\x12\x1a.blog.v1.UpdatePostRequest\x1a\r.blog.v1.Post\x12E\n" +
+	"\n" +
+	"DeletePost\x12\x1a.blog.v1.DeletePostRequest\x1a\x1b.blog.v1.DeletePostResponse\x12>\n" +
+	"\n" +
+	"WatchPosts\x12\x1a.blog.v1.WatchPostsRequest\x1a\x12.blog.v1.PostEvent0\x012\x90\x01\n" +
+	"\vUserService\x121\n" +
+	"\aGetUser\x12\x17.blog.v1.GetUserRequest\x1a\r.blog.v1.User\x12N\n" +
+	"\rBatchGetUsers\x12\x1d.blog.v1.BatchGetUsersRequest\x1a\x1e.blog.v1.BatchGetUsersResponseB\x1eZ\x1cgo-blog/proto/blog/v1;blogv1b\x06proto3"
+
+var (
+	file_proto_blog_v1_blog_proto_rawDescOnce sync.Once
+	file_proto_blog_v1_blog_proto_rawDescData []byte
+)
+
+func file_proto_blog_v1_blog_proto_rawDescGZIP() []byte {
+	file_proto_blog_v1_blog_proto_rawDescOnce.Do(func() {
+		file_proto_blog_v1_blog_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_blog_v1_blog_proto_rawDesc), len(file_proto_blog_v1_blog_proto_rawDesc)))
+	})
+	return file_proto_blog_v1_blog_proto_rawDescData
+}
+
+var file_proto_blog_v1_blog_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_proto_blog_v1_blog_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
+var file_proto_blog_v1_blog_proto_goTypes = []any{
+	(PostStatus)(0),               // 0: blog.v1.PostStatus
+	(AccountType)(0),              // 1: blog.v1.AccountType
+	(PostEvent_Type)(0),           // 2: blog.v1.PostEvent.Type
+	(*Post)(nil),                  // 3: blog.v1.Post
+	(*Reactions)(nil),             // 4: blog.v1.Reactions
+	(*User)(nil),                  // 5: blog.v1.User
+	(*ListPostsRequest)(nil),      // 6: blog.v1.ListPostsRequest
+	(*ListPostsResponse)(nil),     // 7: blog.v1.ListPostsResponse
+	(*GetPostRequest)(nil),        // 8: blog.v1.GetPostRequest
+	(*CreatePostRequest)(nil),     // 9: blog.v1.CreatePostRequest
+	(*UpdatePostRequest)(nil),     // 10: blog.v1.UpdatePostRequest
+	(*DeletePostRequest)(nil),     // 11: blog.v1.DeletePostRequest
+	(*DeletePostResponse)(nil),    // 12: blog.v1.DeletePostResponse
+	(*WatchPostsRequest)(nil),     // 13: blog.v1.WatchPostsRequest
+	(*PostEvent)(nil),             // 14: blog.v1.PostEvent
+	(*GetUserRequest)(nil),        // 15: blog.v1.GetUserRequest
+	(*BatchGetUsersRequest)(nil),  // 16: blog.v1.BatchGetUsersRequest
+	(*BatchGetUsersResponse)(nil), // 17: blog.v1.BatchGetUsersResponse
+	(*timestamppb.Timestamp)(nil), // 18: google.protobuf.Timestamp
+}
+var file_proto_blog_v1_blog_proto_depIdxs = []int32{
+	0,  // 0: blog.v1.Post.status:type_name -> blog.v1.PostStatus
+	18, // 1: blog.v1.Post.published_at:type_name -> google.protobuf.Timestamp
+	18, // 2: blog.v1.Post.created_at:type_name -> google.protobuf.Timestamp
+	18, // 3: blog.v1.Post.updated_at:type_name -> google.protobuf.Timestamp
+	4,  // 4: blog.v1.Post.reactions:type_name -> blog.v1.Reactions
+	1,  // 5: blog.v1.User.account_type:type_name -> blog.v1.AccountType
+	3,  // 6: blog.v1.ListPostsResponse.posts:type_name -> blog.v1.Post
+	0,  // 7: blog.v1.CreatePostRequest.status:type_name -> blog.v1.PostStatus
+	0,  // 8: blog.v1.UpdatePostRequest.status:type_name -> blog.v1.PostStatus
+	2,  // 9: blog.v1.PostEvent.type:type_name -> blog.v1.PostEvent.Type
+	3,  // 10: blog.v1.PostEvent.post:type_name -> blog.v1.Post
+	5,  // 11: blog.v1.BatchGetUsersResponse.users:type_name -> blog.v1.User
+	6,  // 12: blog.v1.PostService.ListPosts:input_type -> blog.v1.ListPostsRequest
+	8,  // 13: blog.v1.PostService.GetPost:input_type -> blog.v1.GetPostRequest
+	9,  // 14: blog.v1.PostService.CreatePost:input_type -> blog.v1.CreatePostRequest
+	10, // 15: blog.v1.PostService.UpdatePost:input_type -> blog.v1.UpdatePostRequest
+	11, // 16: blog.v1.PostService.DeletePost:input_type -> blog.v1.DeletePostRequest
+	13, // 17: blog.v1.PostService.WatchPosts:input_type -> blog.v1.WatchPostsRequest
+	15, // 18: blog.v1.UserService.GetUser:input_type -> blog.v1.GetUserRequest
+	16, // 19: blog.v1.UserService.BatchGetUsers:input_type -> blog.v1.BatchGetUsersRequest
+	7,  // 20: blog.v1.PostService.ListPosts:output_type -> blog.v1.ListPostsResponse
+	3,  // 21: blog.v1.PostService.GetPost:output_type -> blog.v1.Post
+	3,  // 22: blog.v1.PostService.CreatePost:output_type -> blog.v1.Post
+	3,  // 23: blog.v1.PostService.UpdatePost:output_type -> blog.v1.Post
+	12, // 24: blog.v1.PostService.DeletePost:output_type -> blog.v1.DeletePostResponse
+	14, // 25: blog.v1.PostService.WatchPosts:output_type -> blog.v1.PostEvent
+	5,  // 26: blog.v1.UserService.GetUser:output_type -> blog.v1.User
+	17, // 27: blog.v1.UserService.BatchGetUsers:output_type -> blog.v1.BatchGetUsersResponse
+	20, // [20:28] is the sub-list for method output_type
+	12, // [12:20] is the sub-list for method input_type
+	12, // [12:12] is the sub-list for extension type_name
+	12, // [12:12] is the sub-list for extension extendee
+	0,  // [0:12] is the sub-list for field type_name
+}
+
+func init() { file_proto_blog_v1_blog_proto_init() }
+func file_proto_blog_v1_blog_proto_init() {
+	if File_proto_blog_v1_blog_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_blog_v1_blog_proto_rawDesc), len(file_proto_blog_v1_blog_proto_rawDesc)),
+			NumEnums:      3,
+			NumMessages:   15,
+			NumExtensions: 0,
+			NumServices:   2,
+		},
+		GoTypes:           file_proto_blog_v1_blog_proto_goTypes,
+		DependencyIndexes: file_proto_blog_v1_blog_proto_depIdxs,
+		EnumInfos:         file_proto_blog_v1_blog_proto_enumTypes,
+		MessageInfos:      file_proto_blog_v1_blog_proto_msgTypes,
+	}.Build()
+	File_proto_blog_v1_blog_proto = out.File
+	file_proto_blog_v1_blog_proto_goTypes = nil
+	file_proto_blog_v1_blog_proto_depIdxs = nil
+}
@@ -1,23 +1,26 @@
 package repo
 
 import (
+	"context"
 	"go-blog/models"
 )
 
 type AuthRepository interface {
-	CheckPostOwnership(postID int, userID int) error
+	CheckPostOwnership(ctx context.Context, postID int, userID int) error
+	CheckMediaOwnership(ctx context.Context, mediaID int, userID int) error
 }
 
 type authRepository struct {
-	postRepo PostRepository
+	postRepo  PostRepository
+	mediaRepo MediaRepository
 }
 
-func NewAuthRepository(postRepo PostRepository) AuthRepository {
-	return &authRepository{postRepo: postRepo}
+func NewAuthRepository(postRepo PostRepository, mediaRepo MediaRepository) AuthRepository {
+	return &authRepository{postRepo: postRepo, mediaRepo: mediaRepo}
 }
 
-func (r *authRepository) CheckPostOwnership(postID int, userID int) error {
-	post, err := r.postRepo.GetPost(postID)
+func (r *authRepository) CheckPostOwnership(ctx context.Context, postID int, userID int) error {
+	post, err := r.postRepo.GetPost(ctx, postID)
 	if err != nil {
 		return models.ErrPostNotFound
 	}
@@ -28,3 +31,16 @@ func (r *authRepository) CheckPostOwnership(postID int, userID int) error {
 
 	return nil
 }
+
+func (r *authRepository) CheckMediaOwnership(ctx context.Context, mediaID int, userID int) error {
+	media, err := r.mediaRepo.GetMedia(ctx, mediaID)
+	if err != nil {
+		return models.ErrMediaNotFound
+	}
+
+	if media.UserID != userID {
+		return models.ErrMediaUnauthorized
+	}
+
+	return nil
+}
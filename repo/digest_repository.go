@@ -0,0 +1,92 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"go-blog/db"
+	"go-blog/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type DigestRepository interface {
+	Create(ctx context.Context, subscription *models.DigestSubscription) error
+	Get(ctx context.Context, id int) (*models.DigestSubscription, error)
+	GetByToken(ctx context.Context, token string) (*models.DigestSubscription, error)
+	// Find returns the subscription of email with the given filter; an
+	// authorID of 0 means none.
+	Find(ctx context.Context, email string, authorID int, tag string) (*models.DigestSubscription, error)
+	ListByUser(ctx context.Context, userID int) ([]models.DigestSubscription, error)
+	// ListDue returns confirmed subscriptions whose next digest is due at
+	// now, oldest due first.
+	ListDue(ctx context.Context, now time.Time, limit int) ([]models.DigestSubscription, error)
+	Update(ctx context.Context, subscription *models.DigestSubscription) error
+	Delete(ctx context.Context, id int) error
+}
+
+type digestRepository struct {
+	db *gorm.DB
+}
+
+func NewDigestRepository(db *gorm.DB) DigestRepository {
+	return &digestRepository{db: db}
+}
+
+func (r *digestRepository) Create(ctx context.Context, subscription *models.DigestSubscription) error {
+	return db.Conn(ctx, r.db).Create(subscription).Error
+}
+
+func (r *digestRepository) Get(ctx context.Context, id int) (*models.DigestSubscription, error) {
+	return r.first(db.Conn(ctx, r.db).Where("id = ?", id))
+}
+
+func (r *digestRepository) GetByToken(ctx context.Context, token string) (*models.DigestSubscription, error) {
+	return r.first(db.Conn(ctx, r.db).Where("token = ?", token))
+}
+
+func (r *digestRepository) Find(ctx context.Context, email string, authorID int, tag string) (*models.DigestSubscription, error) {
+	return r.first(db.Conn(ctx, r.db).Where("email = ? AND COALESCE(author_id, 0) = ? AND tag = ?", email, authorID, tag))
+}
+
+func (r *digestRepository) first(query *gorm.DB) (*models.DigestSubscription, error) {
+	var subscription models.DigestSubscription
+	if err := query.First(&subscription).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrDigestNotFound
+		}
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+func (r *digestRepository) ListByUser(ctx context.Context, userID int) ([]models.DigestSubscription, error) {
+	subscriptions := []models.DigestSubscription{}
+	err := db.Conn(ctx, r.db).Where("user_id = ?", userID).Order("id").Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+func (r *digestRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]models.DigestSubscription, error) {
+	subscriptions := []models.DigestSubscription{}
+	err := db.Conn(ctx, r.db).
+		Where("confirmed_at IS NOT NULL AND next_run_at <= ?", now.UTC()).
+		Order("next_run_at, id").Limit(limit).Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+func (r *digestRepository) Update(ctx context.Context, subscription *models.DigestSubscription) error {
+	return db.Conn(ctx, r.db).Model(subscription).
+		Select("user_id", "frequency", "token", "confirmed_at", "sent_up_to", "next_run_at", "updated_at").
+		Updates(subscription).Error
+}
+
+func (r *digestRepository) Delete(ctx context.Context, id int) error {
+	result := db.Conn(ctx, r.db).Delete(&models.DigestSubscription{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrDigestNotFound
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package repo
+
+import (
+	"context"
+	"go-blog/db"
+	"go-blog/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type FollowRepository interface {
+	Follow(ctx context.Context, followerID, followeeID int) (bool, error)
+	Unfollow(ctx context.Context, followerID, followeeID int) (bool, error)
+	FolloweeIDs(ctx context.Context, followerID int) ([]int, error)
+	Followers(ctx context.Context, userID int) ([]models.UserProfile, error)
+	Following(ctx context.Context, userID int) ([]models.UserProfile, error)
+}
+
+type followRepository struct {
+	db *gorm.DB
+}
+
+func NewFollowRepository(db *gorm.DB) FollowRepository {
+	return &followRepository{db: db}
+}
+
+func (r *followRepository) Follow(ctx context.Context, followerID, followeeID int) (bool, error) {
+	result := db.Conn(ctx, r.db).Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.Follow{FollowerID: followerID, FolloweeID: followeeID})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *followRepository) Unfollow(ctx context.Context, followerID, followeeID int) (bool, error) {
+	result := db.Conn(ctx, r.db).
+		Where("follower_id = ? AND followee_id = ?", followerID, followeeID).
+		Delete(&models.Follow{})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *followRepository) FolloweeIDs(ctx context.Context, followerID int) ([]int, error) {
+	ids := []int{}
+	if err := db.Conn(ctx, r.db).Model(&models.Follow{}).
+		Where("follower_id = ?", followerID).Pluck("followee_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (r *followRepository) Followers(ctx context.Context, userID int) ([]models.UserProfile, error) {
+	return r.profiles(ctx, "follows.follower_id", "follows.followee_id = ?", userID)
+}
+
+func (r *followRepository) Following(ctx context.Context, userID int) ([]models.UserProfile, error) {
+	return r.profiles(ctx, "follows.followee_id", "follows.follower_id = ?", userID)
+}
+
+func (r *followRepository) profiles(ctx context.Context, joinColumn, condition string, userID int) ([]models.UserProfile, error) {
+	profiles := []models.UserProfile{}
+	err := db.Conn(ctx, r.db).Table("follows").
+		Select("users.id, users.username, users.account_type").
+		Joins("JOIN users ON users.id = "+joinColumn).
+		Where(condition, userID).
+		Order("users.username").
+		Scan(&profiles).Error
+	if err != nil {
+		return nil, err
+	}
+	return profiles, nil
+}
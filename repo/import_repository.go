@@ -0,0 +1,59 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"go-blog/models"
+
+	"gorm.io/gorm"
+)
+
+type ImportRepository interface {
+	CreateImport(ctx context.Context, job *models.ImportJob) (*models.ImportJob, error)
+	GetImport(ctx context.Context, id int) (*models.ImportJob, error)
+	ListImportsByUser(ctx context.Context, userID int) ([]models.ImportJob, error)
+	// SaveProgress writes the status, counters, messages and timestamps.
+	SaveProgress(ctx context.Context, job *models.ImportJob) error
+}
+
+type importRepository struct {
+	db *gorm.DB
+}
+
+func NewImportRepository(db *gorm.DB) ImportRepository {
+	return &importRepository{db: db}
+}
+
+func (r *importRepository) CreateImport(ctx context.Context, job *models.ImportJob) (*models.ImportJob, error) {
+	if job.Messages == nil {
+		job.Messages = []string{}
+	}
+	if err := r.db.WithContext(ctx).Create(job).Error; err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func (r *importRepository) GetImport(ctx context.Context, id int) (*models.ImportJob, error) {
+	var job models.ImportJob
+	if err := r.db.WithContext(ctx).First(&job, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrImportNotFound
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// ListImportsByUser returns a user's imports, newest first.
+func (r *importRepository) ListImportsByUser(ctx context.Context, userID int) ([]models.ImportJob, error) {
+	jobs := []models.ImportJob{}
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("id DESC").Find(&jobs).Error
+	return jobs, err
+}
+
+func (r *importRepository) SaveProgress(ctx context.Context, job *models.ImportJob) error {
+	return r.db.WithContext(ctx).Model(&models.ImportJob{}).Where("id = ?", job.ID).
+		Select("status", "total", "processed", "created", "skipped", "failed", "messages", "started_at", "finished_at").
+		Updates(job).Error
+}
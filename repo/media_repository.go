@@ -0,0 +1,65 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"go-blog/models"
+
+	"gorm.io/gorm"
+)
+
+type MediaRepository interface {
+	CreateMedia(ctx context.Context, media *models.Media) (*models.Media, error)
+	GetMedia(ctx context.Context, id int) (*models.Media, error)
+	ListMediaByUser(ctx context.Context, userID int) ([]models.Media, error)
+	ListMediaByIDs(ctx context.Context, ids []int) ([]models.Media, error)
+	DeleteMedia(ctx context.Context, id int) error
+}
+
+type mediaRepository struct {
+	db *gorm.DB
+}
+
+func NewMediaRepository(db *gorm.DB) MediaRepository {
+	return &mediaRepository{db: db}
+}
+
+func (r *mediaRepository) CreateMedia(ctx context.Context, media *models.Media) (*models.Media, error) {
+	if err := r.db.WithContext(ctx).Create(media).Error; err != nil {
+		return nil, err
+	}
+	return media, nil
+}
+
+func (r *mediaRepository) GetMedia(ctx context.Context, id int) (*models.Media, error) {
+	var media models.Media
+	if err := r.db.WithContext(ctx).First(&media, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrMediaNotFound
+		}
+		return nil, err
+	}
+	return &media, nil
+}
+
+// ListMediaByUser returns a user's uploads, newest first.
+func (r *mediaRepository) ListMediaByUser(ctx context.Context, userID int) ([]models.Media, error) {
+	media := []models.Media{}
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("id DESC").Find(&media).Error
+	return media, err
+}
+
+func (r *mediaRepository) ListMediaByIDs(ctx context.Context, ids []int) ([]models.Media, error) {
+	media := []models.Media{}
+	if len(ids) == 0 {
+		return media, nil
+	}
+	err := r.db.WithContext(ctx).Where("id IN ?", ids).Order("id").Find(&media).Error
+	return media, err
+}
+
+// DeleteMedia removes the record; references from posts go with it through
+// the post_media foreign key.
+func (r *mediaRepository) DeleteMedia(ctx context.Context, id int) error {
+	return r.db.WithContext(ctx).Delete(&models.Media{}, "id = ?", id).Error
+}
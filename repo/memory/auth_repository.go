@@ -0,0 +1,9 @@
+package memory
+
+import (
+	"go-blog/repo"
+)
+
+func NewAuthRepository(posts *PostRepository, media *MediaRepository) repo.AuthRepository {
+	return repo.NewAuthRepository(posts, media)
+}
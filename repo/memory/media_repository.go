@@ -0,0 +1,89 @@
+package memory
+
+import (
+	"context"
+	"go-blog/models"
+	"go-blog/repo"
+	"slices"
+	"sort"
+	"time"
+)
+
+// MediaRepository keeps media records alongside a memory PostRepository so
+// that deleting media drops it from posts, as the post_media foreign key
+// does.
+type MediaRepository struct {
+	posts  *PostRepository
+	media  map[int]models.Media
+	nextID int
+}
+
+var _ repo.MediaRepository = (*MediaRepository)(nil)
+
+func NewMediaRepository(posts *PostRepository) *MediaRepository {
+	return &MediaRepository{posts: posts, media: map[int]models.Media{}, nextID: 1}
+}
+
+func (r *MediaRepository) CreateMedia(ctx context.Context, media *models.Media) (*models.Media, error) {
+	r.posts.mu.Lock()
+	defer r.posts.mu.Unlock()
+
+	media.ID = r.nextID
+	r.nextID++
+	media.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	r.media[media.ID] = *media
+	return media, nil
+}
+
+func (r *MediaRepository) GetMedia(ctx context.Context, id int) (*models.Media, error) {
+	r.posts.mu.RLock()
+	defer r.posts.mu.RUnlock()
+
+	media, ok := r.media[id]
+	if !ok {
+		return nil, models.ErrMediaNotFound
+	}
+	return &media, nil
+}
+
+func (r *MediaRepository) ListMediaByUser(ctx context.Context, userID int) ([]models.Media, error) {
+	r.posts.mu.RLock()
+	defer r.posts.mu.RUnlock()
+
+	media := []models.Media{}
+	for _, m := range r.media {
+		if m.UserID == userID {
+			media = append(media, m)
+		}
+	}
+	sort.Slice(media, func(i, j int) bool { return media[i].ID > media[j].ID })
+	return media, nil
+}
+
+func (r *MediaRepository) ListMediaByIDs(ctx context.Context, ids []int) ([]models.Media, error) {
+	r.posts.mu.RLock()
+	defer r.posts.mu.RUnlock()
+
+	media := []models.Media{}
+	for _, m := range r.media {
+		if slices.Contains(ids, m.ID) {
+			media = append(media, m)
+		}
+	}
+	sort.Slice(media, func(i, j int) bool { return media[i].ID < media[j].ID })
+	return media, nil
+}
+
+func (r *MediaRepository) DeleteMedia(ctx context.Context, id int) error {
+	r.posts.mu.Lock()
+	defer r.posts.mu.Unlock()
+
+	delete(r.media, id)
+	for postID, post := range r.posts.posts {
+		if slices.Contains(post.MediaIDs, id) {
+			post.MediaIDs = slices.DeleteFunc(slices.Clone(post.MediaIDs), func(mediaID int) bool { return mediaID == id })
+			r.posts.posts[postID] = post
+		}
+	}
+	return nil
+}
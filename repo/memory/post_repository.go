@@ -0,0 +1,319 @@
+package memory
+
+import (
+	"context"
+	"go-blog/models"
+	"go-blog/repo"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+type PostRepository struct {
+	mu     sync.RWMutex
+	posts  map[int]models.Post
+	nextID int
+}
+
+var _ repo.PostRepository = (*PostRepository)(nil)
+
+func NewPostRepository() *PostRepository {
+	return &PostRepository{posts: map[int]models.Post{}, nextID: 1}
+}
+
+func (r *PostRepository) ListPosts(ctx context.Context) ([]models.Post, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	posts := make([]models.Post, 0, len(r.posts))
+	for _, post := range r.posts {
+		if post.IsPublished() {
+			posts = append(posts, post)
+		}
+	}
+	sort.Slice(posts, func(i, j int) bool { return posts[i].ID < posts[j].ID })
+	return posts, nil
+}
+
+func (r *PostRepository) GetPost(ctx context.Context, postID int) (*models.Post, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	post, ok := r.posts[postID]
+	if !ok {
+		return nil, models.ErrPostNotFound
+	}
+	post.Tags = cloneTags(post.Tags)
+	post.MediaIDs = cloneMediaIDs(post.MediaIDs)
+	return &post, nil
+}
+
+func (r *PostRepository) CreatePost(ctx context.Context, post *models.Post) (*models.Post, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.slugTaken(post.UserID, post.Slug, 0) {
+		return nil, models.ErrSlugTaken
+	}
+	post.ID = r.nextID
+	r.nextID++
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	// Like GORM's autoCreateTime, preset timestamps are kept.
+	if post.CreatedAt.IsZero() {
+		post.CreatedAt = now
+	}
+	if post.UpdatedAt.IsZero() {
+		post.UpdatedAt = post.CreatedAt
+	}
+	if post.Status == "" {
+		post.SetStatus(models.PostStatusPublished, now)
+	}
+	post.Tags = cloneTags(post.Tags)
+	post.MediaIDs = cloneMediaIDs(post.MediaIDs)
+	r.posts[post.ID] = *post
+	return post, nil
+}
+
+func (r *PostRepository) Update(ctx context.Context, id int, post *models.Post) (*models.Post, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.posts[id]
+	if !ok {
+		return nil, models.ErrPostNotFound
+	}
+	// Mirrors GORM's Updates(struct), which skips zero-valued fields.
+	if post.Title != "" {
+		existing.Title = post.Title
+	}
+	if post.Content != "" {
+		existing.Content = post.Content
+	}
+	if post.Slug != "" {
+		if r.slugTaken(existing.UserID, post.Slug, id) {
+			return nil, models.ErrSlugTaken
+		}
+		existing.Slug = post.Slug
+	}
+	if post.Status != "" {
+		existing.Status = post.Status
+		existing.PublishedAt = post.PublishedAt
+	}
+	if post.Tags != nil {
+		existing.Tags = cloneTags(post.Tags)
+	}
+	if post.MediaIDs != nil {
+		existing.MediaIDs = cloneMediaIDs(post.MediaIDs)
+	}
+	existing.UpdatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	r.posts[id] = existing
+	return &existing, nil
+}
+
+func (r *PostRepository) DeletePost(ctx context.Context, postID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.posts, postID)
+	return nil
+}
+
+func (r *PostRepository) ListPublishedByAuthors(ctx context.Context, authorIDs []int, after *models.PostCursor, limit int) ([]models.Post, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	authors := map[int]bool{}
+	for _, id := range authorIDs {
+		authors[id] = true
+	}
+	posts := []models.Post{}
+	for _, post := range r.posts {
+		if !post.IsPublished() || !authors[post.UserID] || post.PublishedAt == nil {
+			continue
+		}
+		if after != nil && !post.PublishedAt.Before(after.PublishedAt) &&
+			!(post.PublishedAt.Equal(after.PublishedAt) && post.ID < after.ID) {
+			continue
+		}
+		posts = append(posts, post)
+	}
+	sort.Slice(posts, func(i, j int) bool {
+		if !posts[i].PublishedAt.Equal(*posts[j].PublishedAt) {
+			return posts[i].PublishedAt.After(*posts[j].PublishedAt)
+		}
+		return posts[i].ID > posts[j].ID
+	})
+	if len(posts) > limit {
+		posts = posts[:limit]
+	}
+	return posts, nil
+}
+
+func (r *PostRepository) ListPublished(ctx context.Context, filter models.PostFilter, limit int) ([]models.Post, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	posts := r.published(filter)
+	sort.Slice(posts, func(i, j int) bool {
+		if !posts[i].PublishedAt.Equal(*posts[j].PublishedAt) {
+			return posts[i].PublishedAt.After(*posts[j].PublishedAt)
+		}
+		return posts[i].ID > posts[j].ID
+	})
+	if len(posts) > limit {
+		posts = posts[:limit]
+	}
+	return posts, nil
+}
+
+func (r *PostRepository) PublishedStats(ctx context.Context, filter models.PostFilter) (models.PostStats, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var stats models.PostStats
+	for _, post := range r.published(filter) {
+		stats.Count++
+		if post.UpdatedAt.After(stats.LastUpdated) {
+			stats.LastUpdated = post.UpdatedAt
+		}
+	}
+	return stats, nil
+}
+
+func (r *PostRepository) ListPublishedAfterID(ctx context.Context, afterID, limit int) ([]models.Post, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	posts := []models.Post{}
+	for _, post := range r.published(models.PostFilter{}) {
+		if post.ID > afterID {
+			posts = append(posts, post)
+		}
+	}
+	sort.Slice(posts, func(i, j int) bool { return posts[i].ID < posts[j].ID })
+	if len(posts) > limit {
+		posts = posts[:limit]
+	}
+	return posts, nil
+}
+
+func (r *PostRepository) ListPublishedPage(ctx context.Context, afterID, limit int) ([]models.Post, error) {
+	posts, err := r.ListPublishedAfterID(ctx, afterID, limit)
+	for i := range posts {
+		posts[i].MediaIDs = cloneMediaIDs(posts[i].MediaIDs)
+	}
+	return posts, err
+}
+
+func (r *PostRepository) GetPostBySlug(ctx context.Context, userID int, slug string) (*models.Post, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, post := range r.posts {
+		if post.UserID == userID && post.Slug == slug {
+			post.Tags = cloneTags(post.Tags)
+			post.MediaIDs = cloneMediaIDs(post.MediaIDs)
+			return &post, nil
+		}
+	}
+	return nil, models.ErrPostNotFound
+}
+
+func (r *PostRepository) ListPublishedPerAuthor(ctx context.Context, authorIDs []int, limit int) ([]models.Post, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	authors := map[int]bool{}
+	for _, id := range authorIDs {
+		authors[id] = true
+	}
+	posts := []models.Post{}
+	for _, post := range r.posts {
+		if post.IsPublished() && authors[post.UserID] && post.PublishedAt != nil {
+			post.Tags = cloneTags(post.Tags)
+			post.MediaIDs = cloneMediaIDs(post.MediaIDs)
+			posts = append(posts, post)
+		}
+	}
+	sort.Slice(posts, func(i, j int) bool {
+		if !posts[i].PublishedAt.Equal(*posts[j].PublishedAt) {
+			return posts[i].PublishedAt.After(*posts[j].PublishedAt)
+		}
+		return posts[i].ID > posts[j].ID
+	})
+	counts := map[int]int{}
+	newest := posts[:0]
+	for _, post := range posts {
+		if counts[post.UserID] < limit {
+			counts[post.UserID]++
+			newest = append(newest, post)
+		}
+	}
+	return newest, nil
+}
+
+func (r *PostRepository) ListPostsByUser(ctx context.Context, userID int) ([]models.Post, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	posts := []models.Post{}
+	for _, post := range r.posts {
+		if post.UserID == userID {
+			post.Tags = cloneTags(post.Tags)
+			post.MediaIDs = cloneMediaIDs(post.MediaIDs)
+			posts = append(posts, post)
+		}
+	}
+	sort.Slice(posts, func(i, j int) bool { return posts[i].ID < posts[j].ID })
+	return posts, nil
+}
+
+// slugTaken mirrors the partial unique index on (user_id, slug). Callers
+// hold the lock.
+func (r *PostRepository) slugTaken(userID int, slug string, exceptID int) bool {
+	if slug == "" {
+		return false
+	}
+	for id, post := range r.posts {
+		if id != exceptID && post.UserID == userID && post.Slug == slug {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *PostRepository) published(filter models.PostFilter) []models.Post {
+	posts := []models.Post{}
+	for _, post := range r.posts {
+		if !post.IsPublished() || post.PublishedAt == nil {
+			continue
+		}
+		if filter.AuthorID != 0 && post.UserID != filter.AuthorID {
+			continue
+		}
+		if filter.Tag != "" && !slices.Contains(post.Tags, filter.Tag) {
+			continue
+		}
+		if !filter.PublishedAfter.IsZero() && !post.PublishedAt.After(filter.PublishedAfter) {
+			continue
+		}
+		post.Tags = cloneTags(post.Tags)
+		posts = append(posts, post)
+	}
+	return posts
+}
+
+func cloneTags(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return slices.Clone(tags)
+}
+
+func cloneMediaIDs(ids []int) []int {
+	if ids == nil {
+		return []int{}
+	}
+	return slices.Clone(ids)
+}
@@ -0,0 +1,101 @@
+package memory
+
+import (
+	"context"
+	"go-blog/models"
+	"go-blog/repo"
+)
+
+type reactionKey struct {
+	postID int
+	userID int
+	typ    models.ReactionType
+}
+
+// ReactionRepository keeps reactions alongside a memory PostRepository and
+// updates the counters on its posts under the same lock.
+type ReactionRepository struct {
+	posts     *PostRepository
+	reactions map[reactionKey]struct{}
+}
+
+var _ repo.ReactionRepository = (*ReactionRepository)(nil)
+
+func NewReactionRepository(posts *PostRepository) *ReactionRepository {
+	return &ReactionRepository{posts: posts, reactions: map[reactionKey]struct{}{}}
+}
+
+func (r *ReactionRepository) AddReaction(ctx context.Context, postID, userID int, reactionType models.ReactionType) (bool, error) {
+	return r.change(postID, userID, reactionType, true)
+}
+
+func (r *ReactionRepository) RemoveReaction(ctx context.Context, postID, userID int, reactionType models.ReactionType) (bool, error) {
+	return r.change(postID, userID, reactionType, false)
+}
+
+func (r *ReactionRepository) change(postID, userID int, reactionType models.ReactionType, add bool) (bool, error) {
+	if !reactionType.Valid() {
+		return false, models.ErrInvalidReaction
+	}
+	r.posts.mu.Lock()
+	defer r.posts.mu.Unlock()
+
+	post, ok := r.posts.posts[postID]
+	if !ok {
+		return false, models.ErrPostNotFound
+	}
+	key := reactionKey{postID: postID, userID: userID, typ: reactionType}
+	_, exists := r.reactions[key]
+	if exists == add {
+		return false, nil
+	}
+	if add {
+		r.reactions[key] = struct{}{}
+		post.Reactions.Add(reactionType, 1)
+	} else {
+		delete(r.reactions, key)
+		post.Reactions.Add(reactionType, -1)
+	}
+	r.posts.posts[postID] = post
+	return true, nil
+}
+
+func (r *ReactionRepository) UserReactions(ctx context.Context, userID int, postIDs []int) (map[int][]models.ReactionType, error) {
+	r.posts.mu.RLock()
+	defer r.posts.mu.RUnlock()
+
+	byPost := map[int][]models.ReactionType{}
+	for _, postID := range postIDs {
+		for _, reactionType := range models.ReactionTypes {
+			if _, ok := r.reactions[reactionKey{postID: postID, userID: userID, typ: reactionType}]; ok {
+				byPost[postID] = append(byPost[postID], reactionType)
+			}
+		}
+	}
+	return byPost, nil
+}
+
+func (r *ReactionRepository) ReconcileCounts(ctx context.Context) (int64, error) {
+	r.posts.mu.Lock()
+	defer r.posts.mu.Unlock()
+
+	counts := map[int]models.ReactionCounts{}
+	for key := range r.reactions {
+		if _, ok := r.posts.posts[key.postID]; !ok {
+			delete(r.reactions, key)
+			continue
+		}
+		c := counts[key.postID]
+		c.Add(key.typ, 1)
+		counts[key.postID] = c
+	}
+	var drifted int64
+	for id, post := range r.posts.posts {
+		if post.Reactions != counts[id] {
+			post.Reactions = counts[id]
+			r.posts.posts[id] = post
+			drifted++
+		}
+	}
+	return drifted, nil
+}
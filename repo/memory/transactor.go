@@ -0,0 +1,16 @@
+package memory
+
+import (
+	"context"
+	"go-blog/repo"
+)
+
+// Transactor runs fn directly. The in-memory repositories apply writes as
+// they are made, so a failing fn does not roll anything back.
+type Transactor struct{}
+
+var _ repo.Transactor = Transactor{}
+
+func (Transactor) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
@@ -0,0 +1,85 @@
+package memory
+
+import (
+	"context"
+	"go-blog/models"
+	"go-blog/repo"
+	"sort"
+	"sync"
+)
+
+type UserRepository struct {
+	mu         sync.RWMutex
+	users      map[int]models.User
+	byUsername map[string]int
+	nextID     int
+}
+
+var _ repo.UserRepository = (*UserRepository)(nil)
+
+func NewUserRepository() *UserRepository {
+	return &UserRepository{users: map[int]models.User{}, byUsername: map[string]int{}, nextID: 1}
+}
+
+func (r *UserRepository) UsernameExists(ctx context.Context, username string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, ok := r.byUsername[username]
+	return ok, nil
+}
+
+func (r *UserRepository) CreateUser(ctx context.Context, user *models.User) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.byUsername[user.Username]; ok {
+		return nil, models.ErrUsernameExists
+	}
+	user.ID = r.nextID
+	r.nextID++
+	r.users[user.ID] = *user
+	r.byUsername[user.Username] = user.ID
+	return user, nil
+}
+
+func (r *UserRepository) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	id, ok := r.byUsername[username]
+	if !ok {
+		return nil, models.ErrUserNotFound
+	}
+	user := r.users[id]
+	return &user, nil
+}
+
+func (r *UserRepository) UsernamesByID(ctx context.Context, ids []int) (map[int]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := map[int]string{}
+	for _, id := range ids {
+		if user, ok := r.users[id]; ok {
+			names[id] = user.Username
+		}
+	}
+	return names, nil
+}
+
+func (r *UserRepository) GetUsersByID(ctx context.Context, ids []int) ([]models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	users := []models.User{}
+	seen := map[int]bool{}
+	for _, id := range ids {
+		if user, ok := r.users[id]; ok && !seen[id] {
+			seen[id] = true
+			users = append(users, user)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return users, nil
+}
@@ -0,0 +1,96 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"go-blog/db"
+	"go-blog/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type NotificationRepository interface {
+	Create(ctx context.Context, notification *models.Notification) error
+	// List returns one page of a user's notifications, newest first.
+	List(ctx context.Context, userID, beforeID, limit int, unreadOnly bool) ([]models.Notification, error)
+	// ListAfter returns the user's notifications with an id above afterID,
+	// oldest first, for streaming them in order.
+	ListAfter(ctx context.Context, userID, afterID, limit int) ([]models.Notification, error)
+	// LatestID returns the id of the user's newest notification, or 0.
+	LatestID(ctx context.Context, userID int) (int, error)
+	UnreadCount(ctx context.Context, userID int) (int64, error)
+	MarkRead(ctx context.Context, userID, id int) error
+	// MarkAllRead marks the user's unread notifications read, up to and
+	// including upTo when it is positive, and returns how many it marked.
+	MarkAllRead(ctx context.Context, userID, upTo int) (int64, error)
+}
+
+type notificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) NotificationRepository {
+	return &notificationRepository{db: db}
+}
+
+func (r *notificationRepository) Create(ctx context.Context, notification *models.Notification) error {
+	return db.Conn(ctx, r.db).Create(notification).Error
+}
+
+func (r *notificationRepository) List(ctx context.Context, userID, beforeID, limit int, unreadOnly bool) ([]models.Notification, error) {
+	notifications := []models.Notification{}
+	query := db.Conn(ctx, r.db).Where("user_id = ?", userID)
+	if beforeID > 0 {
+		query = query.Where("id < ?", beforeID)
+	}
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	err := query.Order("id DESC").Limit(limit).Find(&notifications).Error
+	return notifications, err
+}
+
+func (r *notificationRepository) ListAfter(ctx context.Context, userID, afterID, limit int) ([]models.Notification, error) {
+	notifications := []models.Notification{}
+	err := db.Conn(ctx, r.db).
+		Where("user_id = ? AND id > ?", userID, afterID).
+		Order("id").Limit(limit).Find(&notifications).Error
+	return notifications, err
+}
+
+func (r *notificationRepository) LatestID(ctx context.Context, userID int) (int, error) {
+	var id int
+	err := db.Conn(ctx, r.db).Model(&models.Notification{}).
+		Where("user_id = ?", userID).
+		Select("COALESCE(MAX(id), 0)").Scan(&id).Error
+	return id, err
+}
+
+func (r *notificationRepository) UnreadCount(ctx context.Context, userID int) (int64, error) {
+	var count int64
+	err := db.Conn(ctx, r.db).Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+func (r *notificationRepository) MarkRead(ctx context.Context, userID, id int) error {
+	var notification models.Notification
+	err := db.Conn(ctx, r.db).First(&notification, "id = ? AND user_id = ?", id, userID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.ErrNotificationNotFound
+	}
+	if err != nil || notification.ReadAt != nil {
+		return err
+	}
+	return db.Conn(ctx, r.db).Model(&notification).Update("read_at", time.Now().UTC()).Error
+}
+
+func (r *notificationRepository) MarkAllRead(ctx context.Context, userID, upTo int) (int64, error) {
+	query := db.Conn(ctx, r.db).Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", userID)
+	if upTo > 0 {
+		query = query.Where("id <= ?", upTo)
+	}
+	result := query.Update("read_at", time.Now().UTC())
+	return result.RowsAffected, result.Error
+}
@@ -1,17 +1,32 @@
 package repo
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"go-blog/db"
 	"go-blog/models"
+	"slices"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
 
 type PostRepository interface {
-	ListPosts() ([]models.Post, error)
-	GetPost(postID int) (*models.Post, error)
-	CreatePost(post *models.Post) (*models.Post, error)
-	Update(id int, post *models.Post) (*models.Post, error)
-	DeletePost(postID int) error
+	ListPosts(ctx context.Context) ([]models.Post, error)
+	GetPost(ctx context.Context, postID int) (*models.Post, error)
+	CreatePost(ctx context.Context, post *models.Post) (*models.Post, error)
+	Update(ctx context.Context, id int, post *models.Post) (*models.Post, error)
+	DeletePost(ctx context.Context, postID int) error
+	ListPublishedByAuthors(ctx context.Context, authorIDs []int, after *models.PostCursor, limit int) ([]models.Post, error)
+	ListPublishedPerAuthor(ctx context.Context, authorIDs []int, limit int) ([]models.Post, error)
+	ListPublished(ctx context.Context, filter models.PostFilter, limit int) ([]models.Post, error)
+	PublishedStats(ctx context.Context, filter models.PostFilter) (models.PostStats, error)
+	ListPublishedAfterID(ctx context.Context, afterID, limit int) ([]models.Post, error)
+	ListPublishedPage(ctx context.Context, afterID, limit int) ([]models.Post, error)
+	GetPostBySlug(ctx context.Context, userID int, slug string) (*models.Post, error)
+	ListPostsByUser(ctx context.Context, userID int) ([]models.Post, error)
 }
 
 type postRepository struct {
@@ -21,46 +36,360 @@ type postRepository struct {
 func NewPostRepository(db *gorm.DB) PostRepository {
 	return &postRepository{db: db}
 }
-func (r *postRepository) ListPosts() ([]models.Post, error) {
+func (r *postRepository) ListPosts(ctx context.Context) ([]models.Post, error) {
 	var posts []models.Post
-	if err := r.db.Find(&posts).Error; err != nil {
+	if err := db.Conn(ctx, r.db).Where("status = ?", models.PostStatusPublished).Order("id").Find(&posts).Error; err != nil {
 		return nil, err
 	}
-	return posts, nil
+	return posts, attachDetails(db.Conn(ctx, r.db), posts)
 }
-func (r *postRepository) GetPost(postID int) (*models.Post, error) {
+func (r *postRepository) GetPost(ctx context.Context, postID int) (*models.Post, error) {
 	var post models.Post
-	if err := r.db.First(&post, "id = ?", postID).Error; err != nil {
+	if err := db.Conn(ctx, r.db).First(&post, "id = ?", postID).Error; err != nil {
+		return nil, translatePostError(err)
+	}
+	posts := []models.Post{post}
+	if err := attachDetails(db.Conn(ctx, r.db), posts); err != nil {
 		return nil, err
 	}
-	return &post, nil
+	return &posts[0], nil
 }
 
-func (r *postRepository) CreatePost(post *models.Post) (*models.Post, error) {
-	if err := r.db.Create(post).Error; err != nil {
-		return nil, err
+func (r *postRepository) CreatePost(ctx context.Context, post *models.Post) (*models.Post, error) {
+	if post.Status == "" {
+		post.SetStatus(models.PostStatusPublished, time.Now())
+	}
+	err := db.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(post).Error; err != nil {
+			return err
+		}
+		if err := replaceTags(tx, post.ID, post.Tags); err != nil {
+			return err
+		}
+		return replaceMedia(tx, post.ID, post.MediaIDs)
+	})
+	if err != nil {
+		return nil, translatePostError(err)
+	}
+	if post.Tags == nil {
+		post.Tags = []string{}
+	}
+	if post.MediaIDs == nil {
+		post.MediaIDs = []int{}
 	}
 	return post, nil
 }
 
-func (r *postRepository) Update(id int, post *models.Post) (*models.Post, error) {
-	if err := r.db.Model(&models.Post{}).Where("id = ?", id).Updates(models.Post{
-		Title:   post.Title,
-		Content: post.Content,
-	}).Error; err != nil {
+// Update changes only the fields that are set: empty strings and nil Tags or
+// MediaIDs slices leave the stored values alone.
+func (r *postRepository) Update(ctx context.Context, id int, post *models.Post) (*models.Post, error) {
+	updates := map[string]any{}
+	if post.Title != "" {
+		updates["title"] = post.Title
+	}
+	if post.Content != "" {
+		updates["content"] = post.Content
+	}
+	if post.Slug != "" {
+		updates["slug"] = post.Slug
+	}
+	if post.Status != "" {
+		updates["status"] = post.Status
+		updates["published_at"] = post.PublishedAt
+	}
+	err := db.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if post.Tags != nil {
+			if err := replaceTags(tx, id, post.Tags); err != nil {
+				return err
+			}
+			// Retagging changes what feeds contain, so it counts as an edit.
+			updates["updated_at"] = tx.NowFunc()
+		}
+		if post.MediaIDs != nil {
+			if err := replaceMedia(tx, id, post.MediaIDs); err != nil {
+				return err
+			}
+			updates["updated_at"] = tx.NowFunc()
+		}
+		return tx.Model(&models.Post{}).Where("id = ?", id).Updates(updates).Error
+	})
+	if err != nil {
+		return nil, translatePostError(err)
+	}
+	return r.GetPost(ctx, id)
+}
+
+func (r *postRepository) DeletePost(ctx context.Context, postID int) error {
+	if err := db.Conn(ctx, r.db).Delete(&models.Post{}, "id = ?", postID).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// maxFeedBranches is how many authors one feed query covers. SQLite
+// refuses compound SELECTs of more than 500 terms.
+const maxFeedBranches = 500
+
+// ListPublishedByAuthors returns one keyset page of published posts by the
+// given authors, newest first. Each author gets its own branch that reads at
+// most limit rows from idx_posts_author_published, and the branches are
+// merged, so the cost grows with the number of authors times the page size
+// rather than with how much they have written. A single IN query would have
+// to read and sort every matching post before applying the limit. Authors
+// are queried maxFeedBranches at a time and the pages merged.
+func (r *postRepository) ListPublishedByAuthors(ctx context.Context, authorIDs []int, after *models.PostCursor, limit int) ([]models.Post, error) {
+	posts := []models.Post{}
+	authorIDs = slices.Compact(slices.Sorted(slices.Values(authorIDs)))
+	if len(authorIDs) == 0 || limit < 1 {
+		return posts, nil
+	}
+	conn := db.Conn(ctx, r.db)
+	for chunk := range slices.Chunk(authorIDs, maxFeedBranches) {
+		newest, err := newestByAuthors(conn, chunk, after, limit)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, newest...)
+	}
+	slices.SortFunc(posts, func(a, b models.Post) int {
+		if c := b.PublishedAt.Compare(*a.PublishedAt); c != 0 {
+			return c
+		}
+		return b.ID - a.ID
+	})
+	posts = posts[:min(limit, len(posts))]
+	return posts, attachDetails(conn, posts)
+}
+
+// newestByAuthors runs the UNION ALL query behind ListPublishedByAuthors
+// for at most maxFeedBranches authors.
+func newestByAuthors(conn *gorm.DB, authorIDs []int, after *models.PostCursor, limit int) ([]models.Post, error) {
+	branches := make([]string, len(authorIDs))
+	var args []any
+	for i, authorID := range authorIDs {
+		// The status is inlined so the planner can match the partial index.
+		branch := "SELECT * FROM posts WHERE status = '" + string(models.PostStatusPublished) + "' AND user_id = ?"
+		args = append(args, authorID)
+		if after != nil {
+			branch += " AND (published_at < ? OR (published_at = ? AND id < ?))"
+			args = append(args, after.PublishedAt, after.PublishedAt, after.ID)
+		}
+		branch += " ORDER BY published_at DESC, id DESC LIMIT ?"
+		args = append(args, limit)
+		branches[i] = fmt.Sprintf("SELECT * FROM (%s) AS author_%d", branch, i)
+	}
+	query := "SELECT * FROM (" + strings.Join(branches, " UNION ALL ") + ") AS newest ORDER BY published_at DESC, id DESC LIMIT ?"
+	posts := []models.Post{}
+	if err := conn.Raw(query, append(args, limit)...).Scan(&posts).Error; err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+// ListPublishedPerAuthor returns the newest limit published posts of each
+// of the given authors, newest first, in one query.
+func (r *postRepository) ListPublishedPerAuthor(ctx context.Context, authorIDs []int, limit int) ([]models.Post, error) {
+	posts := []models.Post{}
+	if len(authorIDs) == 0 || limit < 1 {
+		return posts, nil
+	}
+	conn := db.Conn(ctx, r.db)
+	ranked := conn.Model(&models.Post{}).
+		Select("id, ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY published_at DESC, id DESC) AS position").
+		Where("status = ? AND user_id IN ?", models.PostStatusPublished, authorIDs)
+	newest := conn.Table("(?) AS ranked", ranked).Select("id").Where("position <= ?", limit)
+	if err := conn.Where("id IN (?)", newest).Order("published_at DESC, id DESC").Find(&posts).Error; err != nil {
+		return nil, err
+	}
+	return posts, attachDetails(conn, posts)
+}
+
+// ListPublished returns the newest published posts matching filter.
+func (r *postRepository) ListPublished(ctx context.Context, filter models.PostFilter, limit int) ([]models.Post, error) {
+	posts := []models.Post{}
+	err := r.published(ctx, filter).Order("published_at DESC, id DESC").Limit(limit).Find(&posts).Error
+	if err != nil {
+		return nil, err
+	}
+	return posts, attachDetails(db.Conn(ctx, r.db), posts)
+}
+
+func (r *postRepository) PublishedStats(ctx context.Context, filter models.PostFilter) (models.PostStats, error) {
+	var stats models.PostStats
+	if err := r.published(ctx, filter).Count(&stats.Count).Error; err != nil {
+		return stats, err
+	}
+	// MAX() loses the column type on SQLite, so read the newest row instead.
+	var updated []time.Time
+	if err := r.published(ctx, filter).Order("updated_at DESC").Limit(1).Pluck("updated_at", &updated).Error; err != nil {
+		return stats, err
+	}
+	if len(updated) > 0 {
+		stats.LastUpdated = updated[0].UTC()
+	}
+	return stats, nil
+}
+
+// ListPublishedAfterID pages through every published post in id order,
+// loading only the id and timestamps. It is meant for batch jobs such as the
+// sitemap that must visit all posts without holding them in memory.
+func (r *postRepository) ListPublishedAfterID(ctx context.Context, afterID, limit int) ([]models.Post, error) {
+	posts := []models.Post{}
+	err := r.published(ctx, models.PostFilter{}).
+		Select("id", "user_id", "created_at", "updated_at", "published_at").
+		Where("id > ?", afterID).Order("id").Limit(limit).Find(&posts).Error
+	return posts, err
+}
+
+// ListPublishedPage pages through published posts in id order, like
+// ListPublishedAfterID but with every field, tags and media included.
+func (r *postRepository) ListPublishedPage(ctx context.Context, afterID, limit int) ([]models.Post, error) {
+	posts := []models.Post{}
+	conn := db.Conn(ctx, r.db)
+	err := r.published(ctx, models.PostFilter{}).Where("id > ?", afterID).Order("id").Limit(limit).Find(&posts).Error
+	if err != nil {
+		return nil, err
+	}
+	return posts, attachDetails(conn, posts)
+}
+
+func (r *postRepository) GetPostBySlug(ctx context.Context, userID int, slug string) (*models.Post, error) {
+	var post models.Post
+	if err := db.Conn(ctx, r.db).First(&post, "user_id = ? AND slug = ?", userID, slug).Error; err != nil {
+		return nil, translatePostError(err)
+	}
+	posts := []models.Post{post}
+	if err := attachDetails(db.Conn(ctx, r.db), posts); err != nil {
 		return nil, err
 	}
+	return &posts[0], nil
+}
 
-	var updatedPost models.Post
-	if err := r.db.First(&updatedPost, "id = ?", id).Error; err != nil {
+// ListPostsByUser returns every post by one author, drafts included, in id
+// order.
+func (r *postRepository) ListPostsByUser(ctx context.Context, userID int) ([]models.Post, error) {
+	posts := []models.Post{}
+	if err := db.Conn(ctx, r.db).Where("user_id = ?", userID).Order("id").Find(&posts).Error; err != nil {
 		return nil, err
 	}
-	return &updatedPost, nil
+	return posts, attachDetails(db.Conn(ctx, r.db), posts)
+}
+
+func (r *postRepository) published(ctx context.Context, filter models.PostFilter) *gorm.DB {
+	query := db.Conn(ctx, r.db).Model(&models.Post{}).Where("status = ?", models.PostStatusPublished)
+	if filter.AuthorID != 0 {
+		query = query.Where("user_id = ?", filter.AuthorID)
+	}
+	if filter.Tag != "" {
+		query = query.Where("id IN (SELECT post_id FROM post_tags WHERE tag = ?)", filter.Tag)
+	}
+	if !filter.PublishedAfter.IsZero() {
+		query = query.Where("published_at > ?", filter.PublishedAfter.UTC())
+	}
+	return query
+}
+
+// attachDetails fills the fields that live in side tables.
+func attachDetails(db *gorm.DB, posts []models.Post) error {
+	if err := attachTags(db, posts); err != nil {
+		return err
+	}
+	return attachMedia(db, posts)
+}
+
+// attachTags loads the tags for a page of posts with a single query.
+func attachTags(db *gorm.DB, posts []models.Post) error {
+	if len(posts) == 0 {
+		return nil
+	}
+	ids := make([]int, len(posts))
+	for i := range posts {
+		ids[i] = posts[i].ID
+		posts[i].Tags = []string{}
+	}
+	var tags []models.PostTag
+	if err := db.Where("post_id IN ?", ids).Order("tag").Find(&tags).Error; err != nil {
+		return err
+	}
+	byPost := map[int][]string{}
+	for _, tag := range tags {
+		byPost[tag.PostID] = append(byPost[tag.PostID], tag.Tag)
+	}
+	for i := range posts {
+		if tags, ok := byPost[posts[i].ID]; ok {
+			posts[i].Tags = tags
+		}
+	}
+	return nil
+}
+
+func replaceTags(tx *gorm.DB, postID int, tags []string) error {
+	if err := tx.Where("post_id = ?", postID).Delete(&models.PostTag{}).Error; err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+	rows := make([]models.PostTag, len(tags))
+	for i, tag := range tags {
+		rows[i] = models.PostTag{PostID: postID, Tag: tag}
+	}
+	return tx.Create(&rows).Error
 }
 
-func (r *postRepository) DeletePost(postID int) error {
-	if err := r.db.Delete(&models.Post{}, "id = ?", postID).Error; err != nil {
+// attachMedia loads the referenced media IDs for a page of posts, in the
+// order the author gave them.
+func attachMedia(db *gorm.DB, posts []models.Post) error {
+	if len(posts) == 0 {
+		return nil
+	}
+	ids := make([]int, len(posts))
+	for i := range posts {
+		ids[i] = posts[i].ID
+		posts[i].MediaIDs = []int{}
+	}
+	var links []models.PostMedia
+	if err := db.Where("post_id IN ?", ids).Order("post_id, position").Find(&links).Error; err != nil {
 		return err
 	}
+	byPost := map[int][]int{}
+	for _, link := range links {
+		byPost[link.PostID] = append(byPost[link.PostID], link.MediaID)
+	}
+	for i := range posts {
+		if mediaIDs, ok := byPost[posts[i].ID]; ok {
+			posts[i].MediaIDs = mediaIDs
+		}
+	}
 	return nil
 }
+
+func replaceMedia(tx *gorm.DB, postID int, mediaIDs []int) error {
+	if err := tx.Where("post_id = ?", postID).Delete(&models.PostMedia{}).Error; err != nil {
+		return err
+	}
+	if len(mediaIDs) == 0 {
+		return nil
+	}
+	rows := make([]models.PostMedia, len(mediaIDs))
+	for i, mediaID := range mediaIDs {
+		rows[i] = models.PostMedia{PostID: postID, MediaID: mediaID, Position: i + 1}
+	}
+	if err := tx.Create(&rows).Error; err != nil {
+		if errors.Is(err, gorm.ErrForeignKeyViolated) {
+			return models.ErrMediaNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func translatePostError(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return models.ErrPostNotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return models.ErrSlugTaken
+	}
+	return err
+}
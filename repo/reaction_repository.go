@@ -0,0 +1,154 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"go-blog/db"
+	"go-blog/models"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReactionRepository interface {
+	AddReaction(ctx context.Context, postID, userID int, reactionType models.ReactionType) (bool, error)
+	RemoveReaction(ctx context.Context, postID, userID int, reactionType models.ReactionType) (bool, error)
+	UserReactions(ctx context.Context, userID int, postIDs []int) (map[int][]models.ReactionType, error)
+	ReconcileCounts(ctx context.Context) (int64, error)
+}
+
+type reactionRepository struct {
+	db *gorm.DB
+}
+
+func NewReactionRepository(db *gorm.DB) ReactionRepository {
+	return &reactionRepository{db: db}
+}
+
+// AddReaction records the reaction and bumps the post's counter in the same
+// transaction. It reports false when the user had already reacted, in which
+// case nothing changes.
+func (r *reactionRepository) AddReaction(ctx context.Context, postID, userID int, reactionType models.ReactionType) (bool, error) {
+	if !reactionType.Valid() {
+		return false, models.ErrInvalidReaction
+	}
+	added := false
+	err := db.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := postExists(tx, postID); err != nil {
+			return err
+		}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.Reaction{PostID: postID, UserID: userID, Type: reactionType})
+		if result.Error != nil {
+			return translateReactionError(result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		added = true
+		column := countColumn(reactionType)
+		return tx.Model(&models.Post{}).Where("id = ?", postID).
+			UpdateColumn(column, gorm.Expr(column+" + 1")).Error
+	})
+	return added, err
+}
+
+func (r *reactionRepository) RemoveReaction(ctx context.Context, postID, userID int, reactionType models.ReactionType) (bool, error) {
+	if !reactionType.Valid() {
+		return false, models.ErrInvalidReaction
+	}
+	removed := false
+	err := db.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := postExists(tx, postID); err != nil {
+			return err
+		}
+		result := tx.Where("post_id = ? AND user_id = ? AND type = ?", postID, userID, reactionType).
+			Delete(&models.Reaction{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		removed = true
+		column := countColumn(reactionType)
+		return tx.Model(&models.Post{}).Where("id = ? AND "+column+" > 0", postID).
+			UpdateColumn(column, gorm.Expr(column+" - 1")).Error
+	})
+	return removed, err
+}
+
+func (r *reactionRepository) UserReactions(ctx context.Context, userID int, postIDs []int) (map[int][]models.ReactionType, error) {
+	byPost := map[int][]models.ReactionType{}
+	if len(postIDs) == 0 {
+		return byPost, nil
+	}
+	var reactions []models.Reaction
+	if err := db.Conn(ctx, r.db).Where("user_id = ? AND post_id IN ?", userID, postIDs).Find(&reactions).Error; err != nil {
+		return nil, err
+	}
+	for _, reaction := range reactions {
+		byPost[reaction.PostID] = append(byPost[reaction.PostID], reaction.Type)
+	}
+	for _, types := range byPost {
+		SortReactionTypes(types)
+	}
+	return byPost, nil
+}
+
+// ReconcileCounts recomputes every counter column from the reactions table
+// and returns how many posts had drifted.
+func (r *reactionRepository) ReconcileCounts(ctx context.Context) (int64, error) {
+	var set, drifted []string
+	var setArgs, driftArgs []any
+	for _, reactionType := range models.ReactionTypes {
+		column := countColumn(reactionType)
+		count := "(SELECT COUNT(*) FROM post_reactions WHERE post_reactions.post_id = posts.id AND post_reactions.type = ?)"
+		set = append(set, column+" = "+count)
+		drifted = append(drifted, column+" <> "+count)
+		setArgs = append(setArgs, reactionType)
+		driftArgs = append(driftArgs, reactionType)
+	}
+	query := "UPDATE posts SET " + strings.Join(set, ", ") + " WHERE " + strings.Join(drifted, " OR ")
+	result := db.Conn(ctx, r.db).Exec(query, append(setArgs, driftArgs...)...)
+	return result.RowsAffected, result.Error
+}
+
+// countColumn maps a validated reaction type to its counter column on posts.
+func countColumn(reactionType models.ReactionType) string {
+	return "reactions_" + string(reactionType)
+}
+
+func postExists(tx *gorm.DB, postID int) error {
+	var count int64
+	if err := tx.Model(&models.Post{}).Where("id = ?", postID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return models.ErrPostNotFound
+	}
+	return nil
+}
+
+func translateReactionError(err error) error {
+	if errors.Is(err, gorm.ErrForeignKeyViolated) {
+		return models.ErrPostNotFound
+	}
+	return err
+}
+
+// SortReactionTypes orders reaction types the same way models.ReactionTypes
+// does so responses are stable.
+func SortReactionTypes(types []models.ReactionType) {
+	rank := func(t models.ReactionType) int {
+		for i, known := range models.ReactionTypes {
+			if known == t {
+				return i
+			}
+		}
+		return len(models.ReactionTypes)
+	}
+	sort.Slice(types, func(i, j int) bool { return rank(types[i]) < rank(types[j]) })
+}
@@ -0,0 +1,212 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"go-blog/models"
+
+	"gorm.io/gorm"
+)
+
+type ReadingListRepository interface {
+	ListsForUser(ctx context.Context, userID int) ([]models.ReadingList, error)
+	CreateList(ctx context.Context, list *models.ReadingList) error
+	GetList(ctx context.Context, id int) (*models.ReadingList, error)
+	GetListByShareToken(ctx context.Context, token string) (*models.ReadingList, error)
+	UpdateList(ctx context.Context, list *models.ReadingList) error
+	DeleteList(ctx context.Context, id int) error
+
+	Items(ctx context.Context, listID int) ([]models.ReadingListItem, error)
+	AddItem(ctx context.Context, item *models.ReadingListItem) error
+	UpdateNote(ctx context.Context, listID, postID int, note string) error
+	MoveItem(ctx context.Context, listID, postID, position int) error
+	RemoveItem(ctx context.Context, listID, postID int) error
+}
+
+type readingListRepository struct {
+	db *gorm.DB
+}
+
+func NewReadingListRepository(db *gorm.DB) ReadingListRepository {
+	return &readingListRepository{db: db}
+}
+
+func (r *readingListRepository) ListsForUser(ctx context.Context, userID int) ([]models.ReadingList, error) {
+	lists := []models.ReadingList{}
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).
+		Order("is_default DESC, name").Find(&lists).Error; err != nil {
+		return nil, err
+	}
+	return lists, nil
+}
+
+func (r *readingListRepository) CreateList(ctx context.Context, list *models.ReadingList) error {
+	if err := r.db.WithContext(ctx).Create(list).Error; err != nil {
+		return translateReadingListError(err)
+	}
+	return nil
+}
+
+func (r *readingListRepository) GetList(ctx context.Context, id int) (*models.ReadingList, error) {
+	var list models.ReadingList
+	if err := r.db.WithContext(ctx).First(&list, "id = ?", id).Error; err != nil {
+		return nil, translateReadingListError(err)
+	}
+	return &list, nil
+}
+
+func (r *readingListRepository) GetListByShareToken(ctx context.Context, token string) (*models.ReadingList, error) {
+	var list models.ReadingList
+	if err := r.db.WithContext(ctx).First(&list, "share_token = ?", token).Error; err != nil {
+		return nil, translateReadingListError(err)
+	}
+	return &list, nil
+}
+
+func (r *readingListRepository) UpdateList(ctx context.Context, list *models.ReadingList) error {
+	err := r.db.WithContext(ctx).Model(list).
+		Select("name", "share_token", "updated_at").
+		Updates(list).Error
+	return translateReadingListError(err)
+}
+
+func (r *readingListRepository) DeleteList(ctx context.Context, id int) error {
+	return r.db.WithContext(ctx).Delete(&models.ReadingList{}, "id = ?", id).Error
+}
+
+// Items returns the list in position order with each post attached. Posts
+// are loaded in one query; an item whose post is gone or no longer
+// published is marked unavailable.
+func (r *readingListRepository) Items(ctx context.Context, listID int) ([]models.ReadingListItem, error) {
+	items := []models.ReadingListItem{}
+	if err := r.db.WithContext(ctx).Where("list_id = ?", listID).Order("position, id").Find(&items).Error; err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return items, nil
+	}
+
+	postIDs := make([]int, len(items))
+	for i, item := range items {
+		postIDs[i] = item.PostID
+	}
+	var posts []models.Post
+	if err := r.db.WithContext(ctx).Where("id IN ?", postIDs).Find(&posts).Error; err != nil {
+		return nil, err
+	}
+	if err := attachTags(r.db.WithContext(ctx), posts); err != nil {
+		return nil, err
+	}
+	byID := make(map[int]models.Post, len(posts))
+	for _, post := range posts {
+		byID[post.ID] = post
+	}
+	for i := range items {
+		if post, ok := byID[items[i].PostID]; ok && post.IsPublished() {
+			items[i].Available = true
+			items[i].Post = &post
+		}
+	}
+	return items, nil
+}
+
+func (r *readingListRepository) AddItem(ctx context.Context, item *models.ReadingListItem) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var last struct{ Position *int }
+		if err := tx.Model(&models.ReadingListItem{}).Select("MAX(position) AS position").
+			Where("list_id = ?", item.ListID).Scan(&last).Error; err != nil {
+			return err
+		}
+		item.Position = 1
+		if last.Position != nil {
+			item.Position = *last.Position + 1
+		}
+		if err := tx.Create(item).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return models.ErrItemAlreadyInList
+			}
+			return err
+		}
+		return touchList(tx, item.ListID)
+	})
+}
+
+func (r *readingListRepository) UpdateNote(ctx context.Context, listID, postID int, note string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.ReadingListItem{}).
+			Where("list_id = ? AND post_id = ?", listID, postID).
+			Update("note", note)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return models.ErrItemNotInList
+		}
+		return touchList(tx, listID)
+	})
+}
+
+// MoveItem places the post at the 1-based position, clamped to the list
+// bounds, and renumbers the rest of the list so positions stay contiguous.
+func (r *readingListRepository) MoveItem(ctx context.Context, listID, postID, position int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var items []models.ReadingListItem
+		if err := tx.Where("list_id = ?", listID).Order("position, id").Find(&items).Error; err != nil {
+			return err
+		}
+		from := -1
+		for i, item := range items {
+			if item.PostID == postID {
+				from = i
+				break
+			}
+		}
+		if from < 0 {
+			return models.ErrItemNotInList
+		}
+
+		moved := items[from]
+		items = append(items[:from], items[from+1:]...)
+		to := min(max(position, 1), len(items)+1) - 1
+		items = append(items[:to], append([]models.ReadingListItem{moved}, items[to:]...)...)
+
+		for i, item := range items {
+			if item.Position == i+1 {
+				continue
+			}
+			if err := tx.Model(&models.ReadingListItem{}).Where("id = ?", item.ID).
+				Update("position", i+1).Error; err != nil {
+				return err
+			}
+		}
+		return touchList(tx, listID)
+	})
+}
+
+func (r *readingListRepository) RemoveItem(ctx context.Context, listID, postID int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("list_id = ? AND post_id = ?", listID, postID).Delete(&models.ReadingListItem{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return models.ErrItemNotInList
+		}
+		return touchList(tx, listID)
+	})
+}
+
+func touchList(tx *gorm.DB, listID int) error {
+	return tx.Model(&models.ReadingList{}).Where("id = ?", listID).
+		Update("updated_at", tx.NowFunc()).Error
+}
+
+func translateReadingListError(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return models.ErrReadingListNotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return models.ErrReadingListNameExists
+	}
+	return err
+}
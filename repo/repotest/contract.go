@@ -0,0 +1,607 @@
+// Package repotest holds the behavioural contract every repository
+// implementation must satisfy. Both the GORM and the in-memory
+// implementations run these suites so they cannot drift apart.
+package repotest
+
+import (
+	"context"
+	"go-blog/models"
+	"go-blog/repo"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func PostRepositoryContract(t *testing.T, newRepo func(t *testing.T) repo.PostRepository) {
+	ctx := context.Background()
+
+	t.Run("CreateAssignsIDs", func(t *testing.T) {
+		r := newRepo(t)
+		first, err := r.CreatePost(ctx, &models.Post{Title: "first", Content: "body", UserID: 1})
+		require.NoError(t, err)
+		second, err := r.CreatePost(ctx, &models.Post{Title: "second", Content: "body", UserID: 1})
+		require.NoError(t, err)
+		assert.NotZero(t, first.ID)
+		assert.Greater(t, second.ID, first.ID)
+	})
+
+	t.Run("GetReturnsStoredPost", func(t *testing.T) {
+		r := newRepo(t)
+		created, err := r.CreatePost(ctx, &models.Post{Title: "title", Content: "content", UserID: 7})
+		require.NoError(t, err)
+
+		post, err := r.GetPost(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, created.ID, post.ID)
+		assert.Equal(t, "title", post.Title)
+		assert.Equal(t, "content", post.Content)
+		assert.Equal(t, 7, post.UserID)
+	})
+
+	t.Run("GetMissingReturnsNotFound", func(t *testing.T) {
+		r := newRepo(t)
+		_, err := r.GetPost(ctx, 4242)
+		assert.ErrorIs(t, err, models.ErrPostNotFound)
+	})
+
+	t.Run("ListReturnsPostsInIDOrder", func(t *testing.T) {
+		r := newRepo(t)
+		posts, err := r.ListPosts(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, posts)
+
+		for _, title := range []string{"a", "b", "c"} {
+			_, err := r.CreatePost(ctx, &models.Post{Title: title, Content: "body", UserID: 1})
+			require.NoError(t, err)
+		}
+		posts, err = r.ListPosts(ctx)
+		require.NoError(t, err)
+		require.Len(t, posts, 3)
+		assert.Equal(t, "a", posts[0].Title)
+		assert.Equal(t, "c", posts[2].Title)
+	})
+
+	t.Run("UpdateChangesOnlyNonEmptyFields", func(t *testing.T) {
+		r := newRepo(t)
+		created, err := r.CreatePost(ctx, &models.Post{Title: "old", Content: "old body", UserID: 3})
+		require.NoError(t, err)
+
+		updated, err := r.Update(ctx, created.ID, &models.Post{Title: "new"})
+		require.NoError(t, err)
+		assert.Equal(t, created.ID, updated.ID)
+		assert.Equal(t, "new", updated.Title)
+		assert.Equal(t, "old body", updated.Content)
+		assert.Equal(t, 3, updated.UserID)
+	})
+
+	t.Run("UpdateMissingReturnsNotFound", func(t *testing.T) {
+		r := newRepo(t)
+		_, err := r.Update(ctx, 4242, &models.Post{Title: "t", Content: "c"})
+		assert.ErrorIs(t, err, models.ErrPostNotFound)
+	})
+
+	t.Run("DeleteRemovesPostAndIgnoresMissing", func(t *testing.T) {
+		r := newRepo(t)
+		created, err := r.CreatePost(ctx, &models.Post{Title: "t", Content: "c", UserID: 1})
+		require.NoError(t, err)
+
+		require.NoError(t, r.DeletePost(ctx, created.ID))
+		_, err = r.GetPost(ctx, created.ID)
+		assert.ErrorIs(t, err, models.ErrPostNotFound)
+		assert.NoError(t, r.DeletePost(ctx, created.ID))
+	})
+
+	t.Run("ListSkipsDrafts", func(t *testing.T) {
+		r := newRepo(t)
+		draft, err := r.CreatePost(ctx, &models.Post{Title: "draft", Content: "body", UserID: 1, Status: models.PostStatusDraft})
+		require.NoError(t, err)
+		_, err = r.CreatePost(ctx, &models.Post{Title: "live", Content: "body", UserID: 1})
+		require.NoError(t, err)
+
+		posts, err := r.ListPosts(ctx)
+		require.NoError(t, err)
+		require.Len(t, posts, 1)
+		assert.Equal(t, "live", posts[0].Title)
+		assert.Equal(t, models.PostStatusPublished, posts[0].Status)
+		assert.NotNil(t, posts[0].PublishedAt)
+
+		stored, err := r.GetPost(ctx, draft.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.PostStatusDraft, stored.Status)
+		assert.Nil(t, stored.PublishedAt)
+	})
+
+	t.Run("ListPublishedByAuthorsPagesNewestFirst", func(t *testing.T) {
+		r := newRepo(t)
+		base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+		for i, author := range []int{1, 2, 3, 1, 2} {
+			post := &models.Post{Title: "t", Content: "c", UserID: author}
+			// The last two share a timestamp so the id tiebreaker is exercised.
+			post.SetStatus(models.PostStatusPublished, base.Add(time.Duration(min(i, 3))*time.Minute))
+			_, err := r.CreatePost(ctx, post)
+			require.NoError(t, err)
+		}
+		_, err := r.CreatePost(ctx, &models.Post{Title: "draft", Content: "c", UserID: 1, Status: models.PostStatusDraft})
+		require.NoError(t, err)
+
+		first, err := r.ListPublishedByAuthors(ctx, []int{1, 2}, nil, 2)
+		require.NoError(t, err)
+		require.Len(t, first, 2)
+		assert.Equal(t, 2, first[0].UserID)
+		assert.Greater(t, first[0].ID, first[1].ID)
+
+		last := first[len(first)-1]
+		rest, err := r.ListPublishedByAuthors(ctx, []int{1, 2}, &models.PostCursor{PublishedAt: *last.PublishedAt, ID: last.ID}, 10)
+		require.NoError(t, err)
+		require.Len(t, rest, 2)
+		for _, post := range rest {
+			assert.NotEqual(t, 3, post.UserID)
+			assert.True(t, post.PublishedAt.Before(*last.PublishedAt))
+		}
+
+		all, err := r.ListPublishedByAuthors(ctx, []int{2, 1, 2}, nil, 10)
+		require.NoError(t, err)
+		require.Len(t, all, 4, "repeated authors do not repeat posts")
+		assert.Equal(t, first, all[:2])
+		assert.Equal(t, "t", all[3].Title)
+
+		none, err := r.ListPublishedByAuthors(ctx, nil, nil, 10)
+		require.NoError(t, err)
+		assert.Empty(t, none)
+	})
+
+	t.Run("ListPublishedByAuthorsHandlesManyAuthors", func(t *testing.T) {
+		r := newRepo(t)
+		base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+		authorIDs := make([]int, 1200)
+		for i := range authorIDs {
+			authorIDs[i] = i + 1
+		}
+		// Posts at both ends of the list must be merged into one page.
+		for i, author := range []int{1, 1200, 600, 1} {
+			post := &models.Post{Title: "t", Content: "c", UserID: author}
+			post.SetStatus(models.PostStatusPublished, base.Add(time.Duration(i)*time.Minute))
+			_, err := r.CreatePost(ctx, post)
+			require.NoError(t, err)
+		}
+
+		posts, err := r.ListPublishedByAuthors(ctx, authorIDs, nil, 3)
+		require.NoError(t, err)
+		require.Len(t, posts, 3)
+		assert.Equal(t, []int{1, 600, 1200}, []int{posts[0].UserID, posts[1].UserID, posts[2].UserID})
+
+		last := posts[len(posts)-1]
+		rest, err := r.ListPublishedByAuthors(ctx, authorIDs, &models.PostCursor{PublishedAt: *last.PublishedAt, ID: last.ID}, 3)
+		require.NoError(t, err)
+		require.Len(t, rest, 1)
+		assert.Equal(t, 1, rest[0].UserID)
+	})
+
+	t.Run("ListPublishedPerAuthorLimitsEachAuthor", func(t *testing.T) {
+		r := newRepo(t)
+		base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+		for i, author := range []int{1, 1, 1, 2, 3} {
+			post := &models.Post{Title: "t", Content: "c", UserID: author}
+			post.SetStatus(models.PostStatusPublished, base.Add(time.Duration(i)*time.Minute))
+			_, err := r.CreatePost(ctx, post)
+			require.NoError(t, err)
+		}
+		_, err := r.CreatePost(ctx, &models.Post{Title: "draft", Content: "c", UserID: 2, Status: models.PostStatusDraft})
+		require.NoError(t, err)
+
+		posts, err := r.ListPublishedPerAuthor(ctx, []int{1, 2}, 2)
+		require.NoError(t, err)
+		var authors []int
+		for _, post := range posts {
+			authors = append(authors, post.UserID)
+			assert.True(t, post.IsPublished())
+		}
+		assert.Equal(t, []int{2, 1, 1}, authors, "newest first, at most two per author")
+		assert.True(t, posts[1].PublishedAt.After(*posts[2].PublishedAt))
+
+		none, err := r.ListPublishedPerAuthor(ctx, nil, 2)
+		require.NoError(t, err)
+		assert.Empty(t, none)
+	})
+
+	t.Run("ListPublishedPageFollowsIDs", func(t *testing.T) {
+		r := newRepo(t)
+		var ids []int
+		for range 3 {
+			post := &models.Post{Title: "t", Content: "c", UserID: 1, Tags: []string{"go"}}
+			post.SetStatus(models.PostStatusPublished, time.Now())
+			created, err := r.CreatePost(ctx, post)
+			require.NoError(t, err)
+			ids = append(ids, created.ID)
+		}
+		_, err := r.CreatePost(ctx, &models.Post{Title: "draft", Content: "c", UserID: 1, Status: models.PostStatusDraft})
+		require.NoError(t, err)
+
+		page, err := r.ListPublishedPage(ctx, 0, 2)
+		require.NoError(t, err)
+		require.Len(t, page, 2)
+		assert.Equal(t, []int{ids[0], ids[1]}, []int{page[0].ID, page[1].ID})
+		assert.Equal(t, "t", page[0].Title)
+		assert.Equal(t, []string{"go"}, page[0].Tags)
+
+		page, err = r.ListPublishedPage(ctx, ids[1], 2)
+		require.NoError(t, err)
+		require.Len(t, page, 1, "drafts are left out")
+		assert.Equal(t, ids[2], page[0].ID)
+	})
+
+	t.Run("TagsAreStoredAndReplaced", func(t *testing.T) {
+		r := newRepo(t)
+		created, err := r.CreatePost(ctx, &models.Post{Title: "t", Content: "c", UserID: 1, Tags: []string{"go", "sql"}})
+		require.NoError(t, err)
+
+		post, err := r.GetPost(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"go", "sql"}, post.Tags)
+
+		updated, err := r.Update(ctx, created.ID, &models.Post{Title: "t2"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"go", "sql"}, updated.Tags, "nil tags leave them unchanged")
+
+		updated, err = r.Update(ctx, created.ID, &models.Post{Tags: []string{}})
+		require.NoError(t, err)
+		assert.Empty(t, updated.Tags)
+	})
+
+	t.Run("SlugsAreUniquePerAuthor", func(t *testing.T) {
+		r := newRepo(t)
+		first, err := r.CreatePost(ctx, &models.Post{Title: "t", Content: "c", UserID: 1, Slug: "hello"})
+		require.NoError(t, err)
+		_, err = r.CreatePost(ctx, &models.Post{Title: "t", Content: "c", UserID: 1, Slug: "hello"})
+		assert.ErrorIs(t, err, models.ErrSlugTaken)
+		other, err := r.CreatePost(ctx, &models.Post{Title: "t", Content: "c", UserID: 2, Slug: "hello"})
+		require.NoError(t, err, "another author may use the same slug")
+
+		found, err := r.GetPostBySlug(ctx, 1, "hello")
+		require.NoError(t, err)
+		assert.Equal(t, first.ID, found.ID)
+		found, err = r.GetPostBySlug(ctx, 2, "hello")
+		require.NoError(t, err)
+		assert.Equal(t, other.ID, found.ID)
+		_, err = r.GetPostBySlug(ctx, 3, "hello")
+		assert.ErrorIs(t, err, models.ErrPostNotFound)
+
+		second, err := r.CreatePost(ctx, &models.Post{Title: "t", Content: "c", UserID: 1, Slug: "second"})
+		require.NoError(t, err)
+		_, err = r.Update(ctx, second.ID, &models.Post{Slug: "hello"})
+		assert.ErrorIs(t, err, models.ErrSlugTaken)
+		updated, err := r.Update(ctx, second.ID, &models.Post{Slug: "renamed"})
+		require.NoError(t, err)
+		assert.Equal(t, "renamed", updated.Slug)
+	})
+
+	t.Run("ListPostsByUserIncludesDrafts", func(t *testing.T) {
+		r := newRepo(t)
+		published, err := r.CreatePost(ctx, &models.Post{Title: "p", Content: "c", UserID: 5, Slug: "p", Tags: []string{"go"}})
+		require.NoError(t, err)
+		draft, err := r.CreatePost(ctx, &models.Post{Title: "d", Content: "c", UserID: 5, Slug: "d", Status: models.PostStatusDraft})
+		require.NoError(t, err)
+		_, err = r.CreatePost(ctx, &models.Post{Title: "x", Content: "c", UserID: 6, Slug: "x"})
+		require.NoError(t, err)
+
+		posts, err := r.ListPostsByUser(ctx, 5)
+		require.NoError(t, err)
+		require.Len(t, posts, 2)
+		assert.Equal(t, published.ID, posts[0].ID)
+		assert.Equal(t, []string{"go"}, posts[0].Tags)
+		assert.Equal(t, draft.ID, posts[1].ID)
+	})
+
+	t.Run("CreateKeepsPresetTimestamps", func(t *testing.T) {
+		r := newRepo(t)
+		created := time.Date(2019, 3, 4, 5, 6, 7, 0, time.UTC)
+		updated := created.Add(48 * time.Hour)
+		post, err := r.CreatePost(ctx, &models.Post{Title: "old", Content: "c", UserID: 1, CreatedAt: created, UpdatedAt: updated})
+		require.NoError(t, err)
+
+		stored, err := r.GetPost(ctx, post.ID)
+		require.NoError(t, err)
+		assert.True(t, created.Equal(stored.CreatedAt), "created_at %v", stored.CreatedAt)
+		assert.True(t, updated.Equal(stored.UpdatedAt), "updated_at %v", stored.UpdatedAt)
+	})
+
+	t.Run("ListPublishedFiltersAndStats", func(t *testing.T) {
+		r := newRepo(t)
+		stats, err := r.PublishedStats(ctx, models.PostFilter{})
+		require.NoError(t, err)
+		assert.Zero(t, stats.Count)
+		assert.True(t, stats.LastUpdated.IsZero())
+
+		base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+		for i, p := range []models.Post{
+			{Title: "a", Content: "c", UserID: 1, Tags: []string{"go"}},
+			{Title: "b", Content: "c", UserID: 2, Tags: []string{"go", "web"}},
+			{Title: "c", Content: "c", UserID: 1},
+			{Title: "d", Content: "c", UserID: 1, Tags: []string{"go"}, Status: models.PostStatusDraft},
+		} {
+			// Distinct times, so PublishedAfter cannot tie.
+			if p.Status == "" {
+				p.SetStatus(models.PostStatusPublished, base.Add(time.Duration(i)*time.Minute))
+			}
+			_, err := r.CreatePost(ctx, &p)
+			require.NoError(t, err)
+		}
+
+		all, err := r.ListPublished(ctx, models.PostFilter{}, 10)
+		require.NoError(t, err)
+		require.Len(t, all, 3)
+		assert.Equal(t, "c", all[0].Title)
+
+		byAuthor, err := r.ListPublished(ctx, models.PostFilter{AuthorID: 1}, 10)
+		require.NoError(t, err)
+		assert.Len(t, byAuthor, 2)
+
+		byTag, err := r.ListPublished(ctx, models.PostFilter{Tag: "go"}, 1)
+		require.NoError(t, err)
+		require.Len(t, byTag, 1)
+		assert.Equal(t, "b", byTag[0].Title)
+		assert.Equal(t, []string{"go", "web"}, byTag[0].Tags)
+
+		stats, err = r.PublishedStats(ctx, models.PostFilter{Tag: "go", AuthorID: 1})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), stats.Count)
+		assert.False(t, stats.LastUpdated.IsZero())
+
+		newer, err := r.ListPublished(ctx, models.PostFilter{PublishedAfter: *all[1].PublishedAt}, 10)
+		require.NoError(t, err)
+		require.Len(t, newer, 1)
+		assert.Equal(t, "c", newer[0].Title)
+	})
+
+	t.Run("ConcurrentCreatesGetUniqueIDs", func(t *testing.T) {
+		r := newRepo(t)
+		var wg sync.WaitGroup
+		ids := make(chan int, 20)
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				post, err := r.CreatePost(ctx, &models.Post{Title: "t", Content: "c", UserID: 1})
+				if assert.NoError(t, err) {
+					ids <- post.ID
+				}
+			}()
+		}
+		wg.Wait()
+		close(ids)
+
+		seen := map[int]bool{}
+		for id := range ids {
+			assert.False(t, seen[id], "duplicate id %d", id)
+			seen[id] = true
+		}
+		assert.Len(t, seen, 20)
+	})
+}
+
+func UserRepositoryContract(t *testing.T, newRepo func(t *testing.T) repo.UserRepository) {
+	ctx := context.Background()
+
+	t.Run("CreateAndLookUpByUsername", func(t *testing.T) {
+		r := newRepo(t)
+		created, err := r.CreateUser(ctx, &models.User{Username: "alice", Password: "hash", AccountType: models.AccountTypeBlogger})
+		require.NoError(t, err)
+		assert.NotZero(t, created.ID)
+
+		user, err := r.GetUserByUsername(ctx, "alice")
+		require.NoError(t, err)
+		assert.Equal(t, created.ID, user.ID)
+		assert.Equal(t, "hash", user.Password)
+		assert.Equal(t, models.AccountTypeBlogger, user.AccountType)
+	})
+
+	t.Run("GetUsersByIDSkipsMissing", func(t *testing.T) {
+		r := newRepo(t)
+		bob, err := r.CreateUser(ctx, &models.User{Username: "bob", Password: "hash", AccountType: models.AccountTypeViewer})
+		require.NoError(t, err)
+		alice, err := r.CreateUser(ctx, &models.User{Username: "alice", Password: "hash", AccountType: models.AccountTypeBlogger})
+		require.NoError(t, err)
+
+		users, err := r.GetUsersByID(ctx, []int{alice.ID, 999, bob.ID, alice.ID})
+		require.NoError(t, err)
+		require.Len(t, users, 2)
+		assert.Equal(t, "bob", users[0].Username)
+		assert.Equal(t, models.AccountTypeBlogger, users[1].AccountType)
+
+		none, err := r.GetUsersByID(ctx, nil)
+		require.NoError(t, err)
+		assert.Empty(t, none)
+	})
+
+	t.Run("UsernameExists", func(t *testing.T) {
+		r := newRepo(t)
+		exists, err := r.UsernameExists(ctx, "bob")
+		require.NoError(t, err)
+		assert.False(t, exists)
+
+		_, err = r.CreateUser(ctx, &models.User{Username: "bob", Password: "hash", AccountType: models.AccountTypeViewer})
+		require.NoError(t, err)
+		exists, err = r.UsernameExists(ctx, "bob")
+		require.NoError(t, err)
+		assert.True(t, exists)
+	})
+
+	t.Run("DuplicateUsernameIsRejected", func(t *testing.T) {
+		r := newRepo(t)
+		_, err := r.CreateUser(ctx, &models.User{Username: "carol", Password: "hash"})
+		require.NoError(t, err)
+		_, err = r.CreateUser(ctx, &models.User{Username: "carol", Password: "other"})
+		assert.ErrorIs(t, err, models.ErrUsernameExists)
+	})
+
+	t.Run("GetMissingReturnsNotFound", func(t *testing.T) {
+		r := newRepo(t)
+		_, err := r.GetUserByUsername(ctx, "nobody")
+		assert.ErrorIs(t, err, models.ErrUserNotFound)
+	})
+}
+
+func ReactionRepositoryContract(t *testing.T, newRepos func(t *testing.T) (repo.PostRepository, repo.ReactionRepository)) {
+	ctx := context.Background()
+
+	t.Run("AddIsIdempotentAndCounts", func(t *testing.T) {
+		posts, reactions := newRepos(t)
+		post, err := posts.CreatePost(ctx, &models.Post{Title: "t", Content: "c", UserID: 1})
+		require.NoError(t, err)
+
+		added, err := reactions.AddReaction(ctx, post.ID, 2, models.ReactionLike)
+		require.NoError(t, err)
+		assert.True(t, added)
+		added, err = reactions.AddReaction(ctx, post.ID, 2, models.ReactionLike)
+		require.NoError(t, err)
+		assert.False(t, added)
+		_, err = reactions.AddReaction(ctx, post.ID, 2, models.ReactionLove)
+		require.NoError(t, err)
+		_, err = reactions.AddReaction(ctx, post.ID, 3, models.ReactionLike)
+		require.NoError(t, err)
+
+		stored, err := posts.GetPost(ctx, post.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.ReactionCounts{Like: 2, Love: 1}, stored.Reactions)
+
+		mine, err := reactions.UserReactions(ctx, 2, []int{post.ID})
+		require.NoError(t, err)
+		assert.Equal(t, []models.ReactionType{models.ReactionLike, models.ReactionLove}, mine[post.ID])
+	})
+
+	t.Run("RemoveIsIdempotent", func(t *testing.T) {
+		posts, reactions := newRepos(t)
+		post, err := posts.CreatePost(ctx, &models.Post{Title: "t", Content: "c", UserID: 1})
+		require.NoError(t, err)
+		_, err = reactions.AddReaction(ctx, post.ID, 2, models.ReactionWow)
+		require.NoError(t, err)
+
+		removed, err := reactions.RemoveReaction(ctx, post.ID, 2, models.ReactionWow)
+		require.NoError(t, err)
+		assert.True(t, removed)
+		removed, err = reactions.RemoveReaction(ctx, post.ID, 2, models.ReactionWow)
+		require.NoError(t, err)
+		assert.False(t, removed)
+
+		stored, err := posts.GetPost(ctx, post.ID)
+		require.NoError(t, err)
+		assert.Zero(t, stored.Reactions.Wow)
+	})
+
+	t.Run("RejectsUnknownTypeAndMissingPost", func(t *testing.T) {
+		posts, reactions := newRepos(t)
+		post, err := posts.CreatePost(ctx, &models.Post{Title: "t", Content: "c", UserID: 1})
+		require.NoError(t, err)
+
+		_, err = reactions.AddReaction(ctx, post.ID, 2, models.ReactionType("angry"))
+		assert.ErrorIs(t, err, models.ErrInvalidReaction)
+		_, err = reactions.AddReaction(ctx, 4242, 2, models.ReactionLike)
+		assert.ErrorIs(t, err, models.ErrPostNotFound)
+	})
+
+	t.Run("ConcurrentReactionsKeepCountsExact", func(t *testing.T) {
+		posts, reactions := newRepos(t)
+		post, err := posts.CreatePost(ctx, &models.Post{Title: "t", Content: "c", UserID: 1})
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+		for user := 1; user <= 10; user++ {
+			for attempt := 0; attempt < 3; attempt++ {
+				wg.Add(1)
+				go func(user int) {
+					defer wg.Done()
+					_, err := reactions.AddReaction(ctx, post.ID, user, models.ReactionLike)
+					assert.NoError(t, err)
+				}(user)
+			}
+		}
+		wg.Wait()
+
+		stored, err := posts.GetPost(ctx, post.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 10, stored.Reactions.Like)
+		fixed, err := reactions.ReconcileCounts(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, fixed)
+	})
+}
+
+// MediaRepositoryContract needs a user repository because the GORM media
+// table references users.
+func MediaRepositoryContract(t *testing.T, newRepos func(t *testing.T) (repo.UserRepository, repo.PostRepository, repo.MediaRepository)) {
+	ctx := context.Background()
+
+	newOwner := func(t *testing.T, users repo.UserRepository, username string) int {
+		user, err := users.CreateUser(ctx, &models.User{Username: username, Password: "hash", AccountType: models.AccountTypeBlogger})
+		require.NoError(t, err)
+		return user.ID
+	}
+
+	t.Run("CreateGetAndList", func(t *testing.T) {
+		users, _, media := newRepos(t)
+		owner := newOwner(t, users, "owner")
+		other := newOwner(t, users, "other")
+
+		first, err := media.CreateMedia(ctx, &models.Media{UserID: owner, StorageKey: "a.jpg", ContentType: "image/jpeg", Size: 10})
+		require.NoError(t, err)
+		second, err := media.CreateMedia(ctx, &models.Media{UserID: owner, StorageKey: "b.png", ContentType: "image/png", Size: 20})
+		require.NoError(t, err)
+		_, err = media.CreateMedia(ctx, &models.Media{UserID: other, StorageKey: "c.pdf", ContentType: "application/pdf", Size: 30})
+		require.NoError(t, err)
+		assert.Greater(t, second.ID, first.ID)
+
+		stored, err := media.GetMedia(ctx, first.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "a.jpg", stored.StorageKey)
+		assert.Equal(t, owner, stored.UserID)
+		assert.False(t, stored.CreatedAt.IsZero())
+
+		_, err = media.GetMedia(ctx, 4242)
+		assert.ErrorIs(t, err, models.ErrMediaNotFound)
+
+		mine, err := media.ListMediaByUser(ctx, owner)
+		require.NoError(t, err)
+		require.Len(t, mine, 2)
+		assert.Equal(t, second.ID, mine[0].ID)
+
+		byID, err := media.ListMediaByIDs(ctx, []int{second.ID, first.ID, 4242})
+		require.NoError(t, err)
+		require.Len(t, byID, 2)
+		assert.Equal(t, first.ID, byID[0].ID)
+	})
+
+	t.Run("PostsKeepMediaOrderAndLoseDeletedMedia", func(t *testing.T) {
+		users, posts, media := newRepos(t)
+		owner := newOwner(t, users, "writer")
+		first, err := media.CreateMedia(ctx, &models.Media{UserID: owner, StorageKey: "1.jpg", ContentType: "image/jpeg"})
+		require.NoError(t, err)
+		second, err := media.CreateMedia(ctx, &models.Media{UserID: owner, StorageKey: "2.jpg", ContentType: "image/jpeg"})
+		require.NoError(t, err)
+
+		post, err := posts.CreatePost(ctx, &models.Post{Title: "t", Content: "c", UserID: owner, MediaIDs: []int{second.ID, first.ID}})
+		require.NoError(t, err)
+		stored, err := posts.GetPost(ctx, post.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []int{second.ID, first.ID}, stored.MediaIDs)
+
+		_, err = posts.Update(ctx, post.ID, &models.Post{Title: "t2"})
+		require.NoError(t, err)
+		stored, err = posts.GetPost(ctx, post.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []int{second.ID, first.ID}, stored.MediaIDs, "nil MediaIDs leaves references alone")
+
+		require.NoError(t, media.DeleteMedia(ctx, second.ID))
+		stored, err = posts.GetPost(ctx, post.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []int{first.ID}, stored.MediaIDs)
+
+		_, err = posts.Update(ctx, post.ID, &models.Post{MediaIDs: []int{}})
+		require.NoError(t, err)
+		stored, err = posts.GetPost(ctx, post.ID)
+		require.NoError(t, err)
+		assert.Empty(t, stored.MediaIDs)
+	})
+}
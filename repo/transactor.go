@@ -0,0 +1,26 @@
+package repo
+
+import (
+	"context"
+	"go-blog/db"
+
+	"gorm.io/gorm"
+)
+
+// Transactor makes a group of repository calls atomic: every call made with
+// the ctx passed to fn runs in the same database transaction.
+type Transactor interface {
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type gormTransactor struct {
+	db *gorm.DB
+}
+
+func NewTransactor(gormDB *gorm.DB) Transactor {
+	return &gormTransactor{db: gormDB}
+}
+
+func (t *gormTransactor) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return db.Transaction(ctx, t.db, fn)
+}
@@ -1,39 +1,81 @@
 package repo
 
 import (
+	"context"
+	"errors"
+	"go-blog/db"
 	"go-blog/models"
 
 	"gorm.io/gorm"
 )
 
-type UserRepository struct {
-	DB *gorm.DB
+type UserRepository interface {
+	UsernameExists(ctx context.Context, username string) (bool, error)
+	CreateUser(ctx context.Context, user *models.User) (*models.User, error)
+	GetUserByUsername(ctx context.Context, username string) (*models.User, error)
+	UsernamesByID(ctx context.Context, ids []int) (map[int]string, error)
+	GetUsersByID(ctx context.Context, ids []int) ([]models.User, error)
 }
 
-func NewUserRepository(db *gorm.DB) *UserRepository {
-	return &UserRepository{DB: db}
+type userRepository struct {
+	db *gorm.DB
 }
 
-func (r *UserRepository) UsernameExists(username string) (bool, error) {
+func NewUserRepository(db *gorm.DB) UserRepository {
+	return &userRepository{db: db}
+}
+
+func (r *userRepository) UsernameExists(ctx context.Context, username string) (bool, error) {
 	var count int64
-	if err := r.DB.Model(&models.User{}).Where("username = ?", username).Count(&count).Error; err != nil {
+	if err := db.Conn(ctx, r.db).Model(&models.User{}).Where("username = ?", username).Count(&count).Error; err != nil {
 		return false, err
 	}
 	return count > 0, nil
 }
 
-func (r *UserRepository) CreateUser(user *models.User) (*models.User, error) {
-	if err := r.DB.Create(user).Error; err != nil {
+func (r *userRepository) CreateUser(ctx context.Context, user *models.User) (*models.User, error) {
+	if err := db.Conn(ctx, r.db).Create(user).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, models.ErrUsernameExists
+		}
 		return nil, err
 	}
 	return user, nil
 }
 
-func (r *UserRepository) GetUserByUsername(username string) (*models.User, error) {
+func (r *userRepository) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
 	var user models.User
-	if err := r.DB.Where("username = ?", username).First(&user).Error; err != nil {
+	if err := db.Conn(ctx, r.db).Where("username = ?", username).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrUserNotFound
+		}
 		return nil, err
 	}
 	return &user, nil
 }
 
+func (r *userRepository) UsernamesByID(ctx context.Context, ids []int) (map[int]string, error) {
+	names := map[int]string{}
+	if len(ids) == 0 {
+		return names, nil
+	}
+	var users []models.User
+	if err := db.Conn(ctx, r.db).Select("id", "username").Where("id IN ?", ids).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		names[user.ID] = user.Username
+	}
+	return names, nil
+}
+
+func (r *userRepository) GetUsersByID(ctx context.Context, ids []int) ([]models.User, error) {
+	users := []models.User{}
+	if len(ids) == 0 {
+		return users, nil
+	}
+	if err := db.Conn(ctx, r.db).Where("id IN ?", ids).Order("id").Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
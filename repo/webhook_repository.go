@@ -0,0 +1,149 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"go-blog/db"
+	"go-blog/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type WebhookRepository interface {
+	CreateWebhook(ctx context.Context, webhook *models.Webhook) error
+	GetWebhook(ctx context.Context, id int) (*models.Webhook, error)
+	// ListWebhooks returns the webhooks owned by userID, or the site-wide
+	// ones when userID is 0.
+	ListWebhooks(ctx context.Context, userID int) ([]models.Webhook, error)
+	UpdateWebhook(ctx context.Context, webhook *models.Webhook) error
+	DeleteWebhook(ctx context.Context, id int) error
+	// ActiveWebhooks returns the active webhooks of userID together with the
+	// active site-wide ones.
+	ActiveWebhooks(ctx context.Context, userID int) ([]models.Webhook, error)
+
+	CreateDeliveries(ctx context.Context, deliveries []models.WebhookDelivery) error
+	GetDelivery(ctx context.Context, id int) (*models.WebhookDelivery, error)
+	ListDeliveries(ctx context.Context, webhookID, beforeID, limit int) ([]models.WebhookDelivery, error)
+	// RecordAttempt logs attempt and moves its delivery to status.
+	RecordAttempt(ctx context.Context, attempt *models.WebhookAttempt, status models.DeliveryStatus) error
+	ListAttempts(ctx context.Context, deliveryID int) ([]models.WebhookAttempt, error)
+	SetDeliveryStatus(ctx context.Context, id int, status models.DeliveryStatus) error
+}
+
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+func (r *webhookRepository) CreateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	return db.Conn(ctx, r.db).Create(webhook).Error
+}
+
+func (r *webhookRepository) GetWebhook(ctx context.Context, id int) (*models.Webhook, error) {
+	var webhook models.Webhook
+	if err := db.Conn(ctx, r.db).First(&webhook, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrWebhookNotFound
+		}
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (r *webhookRepository) ListWebhooks(ctx context.Context, userID int) ([]models.Webhook, error) {
+	webhooks := []models.Webhook{}
+	query := db.Conn(ctx, r.db)
+	if userID == 0 {
+		query = query.Where("user_id IS NULL")
+	} else {
+		query = query.Where("user_id = ?", userID)
+	}
+	err := query.Order("id").Find(&webhooks).Error
+	return webhooks, err
+}
+
+func (r *webhookRepository) UpdateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	return db.Conn(ctx, r.db).Model(webhook).
+		Select("url", "secret", "events", "active", "updated_at").
+		Updates(webhook).Error
+}
+
+func (r *webhookRepository) DeleteWebhook(ctx context.Context, id int) error {
+	result := db.Conn(ctx, r.db).Delete(&models.Webhook{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrWebhookNotFound
+	}
+	return nil
+}
+
+func (r *webhookRepository) ActiveWebhooks(ctx context.Context, userID int) ([]models.Webhook, error) {
+	webhooks := []models.Webhook{}
+	err := db.Conn(ctx, r.db).
+		Where("active = ? AND (user_id IS NULL OR user_id = ?)", true, userID).
+		Order("id").Find(&webhooks).Error
+	return webhooks, err
+}
+
+func (r *webhookRepository) CreateDeliveries(ctx context.Context, deliveries []models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return db.Conn(ctx, r.db).Create(&deliveries).Error
+}
+
+func (r *webhookRepository) GetDelivery(ctx context.Context, id int) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	if err := db.Conn(ctx, r.db).First(&delivery, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrDeliveryNotFound
+		}
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// ListDeliveries returns one page of a webhook's deliveries, newest first.
+func (r *webhookRepository) ListDeliveries(ctx context.Context, webhookID, beforeID, limit int) ([]models.WebhookDelivery, error) {
+	deliveries := []models.WebhookDelivery{}
+	query := db.Conn(ctx, r.db).Where("webhook_id = ?", webhookID)
+	if beforeID > 0 {
+		query = query.Where("id < ?", beforeID)
+	}
+	err := query.Order("id DESC").Limit(limit).Find(&deliveries).Error
+	return deliveries, err
+}
+
+func (r *webhookRepository) RecordAttempt(ctx context.Context, attempt *models.WebhookAttempt, status models.DeliveryStatus) error {
+	return db.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(attempt).Error; err != nil {
+			return err
+		}
+		updates := map[string]any{
+			"status":     status,
+			"attempts":   gorm.Expr("attempts + 1"),
+			"updated_at": tx.NowFunc(),
+		}
+		if status == models.DeliverySucceeded {
+			updates["delivered_at"] = attempt.CreatedAt
+		}
+		return tx.Model(&models.WebhookDelivery{}).Where("id = ?", attempt.DeliveryID).Updates(updates).Error
+	})
+}
+
+func (r *webhookRepository) ListAttempts(ctx context.Context, deliveryID int) ([]models.WebhookAttempt, error) {
+	attempts := []models.WebhookAttempt{}
+	err := db.Conn(ctx, r.db).Where("delivery_id = ?", deliveryID).Order("id").Find(&attempts).Error
+	return attempts, err
+}
+
+func (r *webhookRepository) SetDeliveryStatus(ctx context.Context, id int, status models.DeliveryStatus) error {
+	return db.Conn(ctx, r.db).Model(&models.WebhookDelivery{}).Where("id = ?", id).
+		Updates(map[string]any{"status": status, "updated_at": time.Now().UTC()}).Error
+}
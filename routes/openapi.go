@@ -0,0 +1,433 @@
+package routes
+
+import (
+	"go-blog/config"
+	"go-blog/graph"
+	"go-blog/handlers"
+	"go-blog/jobs"
+	"go-blog/middleware"
+	"go-blog/models"
+	"go-blog/openapi"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	bearerAuth = "bearer"
+	adminAuth  = "adminToken"
+)
+
+// OpenAPI describes every route SetupRoutes and SetupAdminRoutes can
+// register. Keep it next to them: a test fails when a registered route is
+// missing here.
+func OpenAPI(cfg config.Config) *openapi.Document {
+	b := openapi.NewBuilder(openapi.Info{
+		Title:       cfg.Site.Title,
+		Version:     "1.0.0",
+		Description: cfg.Site.Description,
+	})
+	b.SecurityScheme(bearerAuth, &openapi.SecurityScheme{
+		Type: "http", Scheme: "bearer", BearerFormat: "JWT",
+		Description: "The token returned by POST /api/login.",
+	})
+	b.SecurityScheme(adminAuth, &openapi.SecurityScheme{
+		Type: "http", Scheme: "bearer",
+		Description: "The operator token from admin.token. The admin endpoints are open when it is unset, which is only done on a private admin listener.",
+	})
+	openapi.Enum(b, models.PostStatusDraft, models.PostStatusPublished)
+	openapi.Enum(b, models.AccountTypeBlogger, models.AccountTypeViewer)
+	openapi.Enum(b, models.ReactionTypes...)
+	openapi.Enum(b, models.EventTypes...)
+	openapi.Enum(b, models.DeliveryPending, models.DeliverySucceeded, models.DeliveryRetrying, models.DeliveryFailed)
+	openapi.Enum(b, models.ImportQueued, models.ImportRunning, models.ImportCompleted, models.ImportFailed)
+	openapi.Enum(b, models.ImportFormatArchive, models.ImportFormatWXR)
+	openapi.Enum(b, models.DigestDaily, models.DigestWeekly)
+	openapi.Enum(b, models.NotificationReaction, models.NotificationFollow)
+	openapi.Enum(b, jobs.StatusQueued, jobs.StatusRunning, jobs.StatusSucceeded, jobs.StatusDead)
+	if cfg.Site.BaseURL != "" {
+		b.Server(cfg.Site.BaseURL)
+	}
+
+	message := openapi.Object(map[string]*openapi.Schema{"message": openapi.String()})
+	id := openapi.Integer()
+	limit := openapi.Integer().Range(1, 200)
+	upload := openapi.Object(map[string]*openapi.Schema{"file": {Type: "string", Format: "binary"}})
+
+	describeHealth(b)
+	describeSyndication(b)
+	describeGraphQL(b)
+	describeAdmin(b)
+	b.Op(http.MethodGet, "/openapi.json", "This document", "meta").
+		Returns(http.StatusOK, &openapi.Schema{Type: "object"}).
+		Returns(http.StatusNotModified, nil)
+
+	b.Op(http.MethodPost, "/api/register", "Create an account", "auth").
+		Body(models.RegisterRequest{}).
+		Returns(http.StatusCreated, openapi.Object(map[string]*openapi.Schema{
+			"message": openapi.String(), "user": openapi.String(), "account_type": b.Schema(models.AccountTypeViewer),
+		}))
+	b.Op(http.MethodPost, "/api/login", "Exchange a username and password for a token", "auth").
+		Body(handlers.AuthInput{}).
+		Returns(http.StatusOK, openapi.Object(map[string]*openapi.Schema{"token": openapi.String()}))
+
+	b.Op(http.MethodGet, "/api/posts", "List published posts", "posts").
+		Describe("Signed-in callers also get their own reactions. Served as JSON or MessagePack, by Accept.").
+		OptionalAuth(bearerAuth).
+		Returns(http.StatusOK, []models.Post{}).
+		ReturnsContent(http.StatusOK, "application/msgpack", nil).
+		Returns(http.StatusNotModified, nil).
+		Errors(http.StatusNotAcceptable)
+	b.Op(http.MethodGet, "/api/posts/:id", "Get a post", "posts").
+		Describe("Drafts are only visible to their author. Served as JSON, MessagePack or an HTML preview, by Accept.").
+		OptionalAuth(bearerAuth).
+		Returns(http.StatusOK, models.Post{}).
+		ReturnsContent(http.StatusOK, "application/msgpack", nil).
+		ReturnsContent(http.StatusOK, "text/html", openapi.String()).
+		Returns(http.StatusNotModified, nil).
+		Errors(http.StatusNotFound, http.StatusNotAcceptable)
+	b.Op(http.MethodPost, "/api/posts", "Create a post", "posts").
+		Auth(bearerAuth).
+		Body(models.Post{}).
+		Returns(http.StatusCreated, models.Post{}).
+		Errors(http.StatusForbidden, http.StatusConflict)
+	b.Op(http.MethodPut, "/api/posts/:id", "Update one of your posts", "posts").
+		Auth(bearerAuth).
+		Body(models.Post{}).
+		Returns(http.StatusOK, models.Post{}).
+		Errors(http.StatusForbidden, http.StatusNotFound, http.StatusConflict)
+	b.Op(http.MethodDelete, "/api/posts/:id", "Delete one of your posts", "posts").
+		Auth(bearerAuth).
+		Returns(http.StatusOK, message).
+		Errors(http.StatusForbidden, http.StatusNotFound)
+
+	for _, method := range []string{http.MethodPost, http.MethodDelete} {
+		summary := "React to a post"
+		if method == http.MethodDelete {
+			summary = "Take back a reaction"
+		}
+		b.Op(method, "/api/posts/:id/reactions", summary, "reactions").
+			Describe("The type is read from ?type= or, failing that, the body.").
+			Auth(bearerAuth).
+			Query("type", b.Schema(models.ReactionLike), "").
+			OptionalBody(models.ReactionRequest{}).
+			Returns(http.StatusOK, models.ReactionSummary{}).
+			Errors(http.StatusNotFound)
+	}
+
+	b.Op(http.MethodPost, "/api/media", "Upload an image or file", "media").
+		Auth(bearerAuth).
+		BodyContent("multipart/form-data", upload, true).
+		Returns(http.StatusCreated, models.Media{}).
+		Errors(http.StatusForbidden, http.StatusRequestEntityTooLarge, http.StatusUnsupportedMediaType)
+	b.Op(http.MethodGet, "/api/media/:id", "Get uploaded media", "media").
+		Returns(http.StatusOK, models.Media{}).
+		Errors(http.StatusNotFound)
+	b.Op(http.MethodDelete, "/api/media/:id", "Delete your media", "media").
+		Auth(bearerAuth).
+		Returns(http.StatusOK, message).
+		Errors(http.StatusForbidden, http.StatusNotFound)
+	b.Op(http.MethodGet, "/api/me/media", "List your media", "media").
+		Auth(bearerAuth).
+		Returns(http.StatusOK, []models.Media{})
+	b.Op(http.MethodGet, "/media/*key", "Download a stored file", "media").
+		ReturnsContent(http.StatusOK, "*/*", nil).
+		Returns(http.StatusNotModified, nil).
+		Errors(http.StatusNotFound)
+
+	b.Op(http.MethodGet, "/api/me/export", "Export your posts as a zip", "imports").
+		Auth(bearerAuth).
+		ReturnsContent(http.StatusOK, "application/zip", nil)
+	b.Op(http.MethodPost, "/api/me/import", "Import a go-blog export or a WordPress WXR file", "imports").
+		Auth(bearerAuth).
+		BodyContent("multipart/form-data", upload, true).
+		Returns(http.StatusAccepted, models.ImportJob{}).
+		Errors(http.StatusForbidden, http.StatusRequestEntityTooLarge, http.StatusUnsupportedMediaType)
+	b.Op(http.MethodGet, "/api/me/imports", "List your imports", "imports").
+		Auth(bearerAuth).
+		Returns(http.StatusOK, []models.ImportJob{})
+	b.Op(http.MethodGet, "/api/me/imports/:id", "Get one of your imports", "imports").
+		Auth(bearerAuth).
+		Returns(http.StatusOK, models.ImportJob{}).
+		Errors(http.StatusNotFound)
+
+	b.Op(http.MethodGet, "/api/users/:username/followers", "List a user's followers", "follows").
+		Returns(http.StatusOK, []models.UserProfile{}).
+		Errors(http.StatusNotFound)
+	b.Op(http.MethodGet, "/api/users/:username/following", "List who a user follows", "follows").
+		Returns(http.StatusOK, []models.UserProfile{}).
+		Errors(http.StatusNotFound)
+	following := openapi.Object(map[string]*openapi.Schema{"following": openapi.Boolean()})
+	b.Op(http.MethodPost, "/api/users/:username/follow", "Follow a blogger", "follows").
+		Auth(bearerAuth).
+		Returns(http.StatusOK, following).
+		Errors(http.StatusBadRequest, http.StatusNotFound)
+	b.Op(http.MethodDelete, "/api/users/:username/follow", "Unfollow a blogger", "follows").
+		Auth(bearerAuth).
+		Returns(http.StatusOK, following).
+		Errors(http.StatusBadRequest, http.StatusNotFound)
+	b.Op(http.MethodGet, "/api/feed", "Posts by the bloggers you follow, newest first", "follows").
+		Auth(bearerAuth).
+		Query("cursor", openapi.String(), "next_cursor of the previous page").
+		Query("limit", openapi.Integer(), "page size, at least 1").
+		Returns(http.StatusOK, models.FeedPage{})
+
+	b.Op(http.MethodGet, "/api/shared/lists/:token", "Get a shared reading list", "reading-lists").
+		Returns(http.StatusOK, models.ReadingListDetail{}).
+		Errors(http.StatusNotFound)
+	describeReadingLists(b, message)
+
+	b.Op(http.MethodPost, "/api/digests", "Subscribe an address to an email digest", "digests").
+		Describe("Answers the same whether or not the address is already subscribed; the subscription starts once the emailed link is followed.").
+		OptionalAuth(bearerAuth).
+		Body(models.DigestRequest{}).
+		Returns(http.StatusAccepted, message)
+	b.Op(http.MethodGet, "/api/digests/confirm", "Confirm a digest subscription", "digests").
+		Query("token", openapi.String(), "").
+		Returns(http.StatusOK, models.DigestSubscription{}).
+		Errors(http.StatusNotFound)
+	b.Op(http.MethodGet, "/api/digests/unsubscribe", "The page an unsubscribe link opens", "digests").
+		Query("token", openapi.String(), "").
+		ReturnsContent(http.StatusOK, "text/html", openapi.String()).
+		ReturnsContent(http.StatusNotFound, "text/html", openapi.String())
+	b.Op(http.MethodPost, "/api/digests/unsubscribe", "Unsubscribe from a digest", "digests").
+		Describe("Also takes RFC 8058 one-click requests. Succeeds for unknown tokens too.").
+		Query("token", openapi.String(), "").
+		BodyContent("application/x-www-form-urlencoded", nil, false).
+		ReturnsContent(http.StatusOK, "text/html", openapi.String())
+	b.Op(http.MethodGet, "/api/me/digests", "List your digest subscriptions", "digests").
+		Auth(bearerAuth).
+		Returns(http.StatusOK, []models.DigestSubscription{})
+	b.Op(http.MethodDelete, "/api/me/digests/:id", "Delete one of your digest subscriptions", "digests").
+		Auth(bearerAuth).
+		Returns(http.StatusNoContent, nil).
+		Errors(http.StatusNotFound)
+
+	describeWebhooks(b, "/api/me/webhooks", "webhooks", bearerAuth, limit)
+
+	b.Op(http.MethodGet, "/api/me/notifications", "List your notifications, newest first", "notifications").
+		Auth(bearerAuth).
+		Query("before", id, "only notifications older than this id").
+		Query("limit", limit, "").
+		Query("unread", openapi.Boolean(), "only unread notifications").
+		Returns(http.StatusOK, models.NotificationPage{})
+	b.Op(http.MethodGet, "/api/me/notifications/stream", "Stream your notifications", "notifications").
+		Describe(`Server-Sent Events: a "notification" event per notification, with the notification id as the event id, each followed by an "unread" event with the new count. Reconnecting with Last-Event-ID resumes after it.`).
+		Auth(bearerAuth).
+		Header("Last-Event-ID", openapi.Integer(), "").
+		ReturnsContent(http.StatusOK, "text/event-stream", nil)
+	b.Op(http.MethodPost, "/api/me/notifications/read-all", "Mark your notifications read", "notifications").
+		Auth(bearerAuth).
+		OptionalBody(&openapi.Schema{Type: "object", Properties: map[string]*openapi.Schema{"up_to": id}}).
+		Returns(http.StatusOK, openapi.Object(map[string]*openapi.Schema{"marked": openapi.Integer(), "unread": openapi.Integer()}))
+	b.Op(http.MethodPost, "/api/me/notifications/:id/read", "Mark a notification read", "notifications").
+		Auth(bearerAuth).
+		Returns(http.StatusOK, openapi.Object(map[string]*openapi.Schema{"unread": openapi.Integer()})).
+		Errors(http.StatusNotFound)
+
+	return b.Document()
+}
+
+func describeHealth(b *openapi.Builder) {
+	b.Op(http.MethodGet, "/healthz", "Liveness probe", "health").
+		Returns(http.StatusOK, openapi.Object(map[string]*openapi.Schema{"status": openapi.String()}))
+	readiness := openapi.Object(map[string]*openapi.Schema{
+		"status": {Type: "string", Enum: []any{"ok", "unavailable"}},
+		"checks": {Type: "object"},
+	})
+	b.Op(http.MethodGet, "/readyz", "Readiness probe", "health").
+		Describe("Fails while the database is unreachable, migrations are pending or the server is draining.").
+		Returns(http.StatusOK, readiness).
+		Returns(http.StatusServiceUnavailable, readiness)
+}
+
+func describeGraphQL(b *openapi.Builder) {
+	location := openapi.Object(map[string]*openapi.Schema{"line": openapi.Integer(), "column": openapi.Integer()})
+	graphQLError := &openapi.Schema{
+		Type: "object",
+		Properties: map[string]*openapi.Schema{
+			"message":    openapi.String(),
+			"locations":  openapi.ArrayOf(location),
+			"path":       openapi.ArrayOf(&openapi.Schema{Type: []any{"string", "integer"}}),
+			"extensions": {Type: "object"},
+		},
+		Required: []string{"message"},
+	}
+	b.Op(http.MethodPost, "/graphql", "Run a GraphQL query or mutation", "graphql").
+		Describe("Posts, their authors and the authors' posts, with mutations to create, update and delete posts. "+
+			"Errors in the query, including going over the depth and complexity limits, are reported in errors with a 200.").
+		OptionalAuth(bearerAuth).
+		Body(graph.Request{}).
+		Returns(http.StatusOK, &openapi.Schema{
+			Type: "object",
+			Properties: map[string]*openapi.Schema{
+				"data":   {Type: []any{"object", "null"}},
+				"errors": openapi.ArrayOf(graphQLError),
+			},
+		})
+}
+
+func describeSyndication(b *openapi.Builder) {
+	for _, scope := range []struct{ prefix, summary string }{
+		{"", "all posts"},
+		{"/authors/:username", "one author's posts"},
+		{"/tags/:tag", "posts with a tag"},
+	} {
+		b.Op(http.MethodGet, scope.prefix+"/feed.rss", "RSS feed of "+scope.summary, "feeds").
+			ReturnsContent(http.StatusOK, "application/rss+xml", nil).
+			Returns(http.StatusNotModified, nil).
+			Errors(http.StatusNotFound)
+		b.Op(http.MethodGet, scope.prefix+"/feed.atom", "Atom feed of "+scope.summary, "feeds").
+			ReturnsContent(http.StatusOK, "application/atom+xml", nil).
+			Returns(http.StatusNotModified, nil).
+			Errors(http.StatusNotFound)
+	}
+	b.Op(http.MethodGet, "/posts/:id", "A post's public page, as linked from feeds and sitemaps", "feeds").
+		Describe("The same as GET /api/posts/{id}; browsers get the HTML preview.").
+		OptionalAuth(bearerAuth).
+		Returns(http.StatusOK, models.Post{}).
+		ReturnsContent(http.StatusOK, "application/msgpack", nil).
+		ReturnsContent(http.StatusOK, "text/html", openapi.String()).
+		Returns(http.StatusNotModified, nil).
+		Errors(http.StatusNotFound, http.StatusNotAcceptable)
+	b.Op(http.MethodGet, "/sitemap.xml", "Sitemap, or sitemap index once it is split", "feeds").
+		ReturnsContent(http.StatusOK, "application/xml", nil).
+		Returns(http.StatusNotModified, nil)
+	b.Op(http.MethodGet, "/sitemap.xml.gz", "Gzipped sitemap", "feeds").
+		ReturnsContent(http.StatusOK, "application/gzip", nil).
+		Returns(http.StatusNotModified, nil)
+	b.Op(http.MethodGet, "/sitemaps/:file", "One part of a split sitemap, sitemap-<n>.xml or sitemap-<n>.xml.gz", "feeds").
+		ReturnsContent(http.StatusOK, "application/xml", nil).
+		ReturnsContent(http.StatusOK, "application/gzip", nil).
+		Returns(http.StatusNotModified, nil).
+		Errors(http.StatusNotFound)
+	b.Op(http.MethodGet, "/robots.txt", "robots.txt", "feeds").
+		ReturnsContent(http.StatusOK, "text/plain", openapi.String())
+}
+
+func describeReadingLists(b *openapi.Builder, message *openapi.Schema) {
+	b.Op(http.MethodGet, "/api/me/lists", "List your reading lists", "reading-lists").
+		Auth(bearerAuth).
+		Returns(http.StatusOK, []models.ReadingList{})
+	b.Op(http.MethodPost, "/api/me/lists", "Create a reading list", "reading-lists").
+		Auth(bearerAuth).
+		Body(models.ReadingListRequest{}).
+		Returns(http.StatusCreated, models.ReadingList{}).
+		Errors(http.StatusConflict)
+	b.Op(http.MethodGet, "/api/me/lists/:id", "Get a reading list with its posts", "reading-lists").
+		Auth(bearerAuth).
+		Returns(http.StatusOK, models.ReadingListDetail{}).
+		Errors(http.StatusNotFound)
+	b.Op(http.MethodPatch, "/api/me/lists/:id", "Rename or share a reading list", "reading-lists").
+		Auth(bearerAuth).
+		Body(models.ReadingListRequest{}).
+		Returns(http.StatusOK, models.ReadingList{}).
+		Errors(http.StatusNotFound, http.StatusConflict)
+	b.Op(http.MethodDelete, "/api/me/lists/:id", "Delete a reading list", "reading-lists").
+		Auth(bearerAuth).
+		Returns(http.StatusOK, message).
+		Errors(http.StatusNotFound)
+	b.Op(http.MethodGet, "/api/me/lists/:id/posts", "List the posts in a reading list", "reading-lists").
+		Auth(bearerAuth).
+		Returns(http.StatusOK, []models.ReadingListItem{}).
+		Errors(http.StatusNotFound)
+	b.Op(http.MethodPost, "/api/me/lists/:id/posts", "Add a post to a reading list", "reading-lists").
+		Auth(bearerAuth).
+		Body(models.ReadingListItemRequest{}).
+		Returns(http.StatusCreated, models.ReadingListDetail{}).
+		Errors(http.StatusNotFound, http.StatusConflict)
+	b.Op(http.MethodPatch, "/api/me/lists/:id/posts/:postId", "Move a post or change its note", "reading-lists").
+		Auth(bearerAuth).
+		Body(models.ReadingListItemRequest{}).
+		Returns(http.StatusOK, models.ReadingListDetail{}).
+		Errors(http.StatusNotFound)
+	b.Op(http.MethodDelete, "/api/me/lists/:id/posts/:postId", "Remove a post from a reading list", "reading-lists").
+		Auth(bearerAuth).
+		Returns(http.StatusOK, message).
+		Errors(http.StatusNotFound)
+}
+
+// describeWebhooks covers registerWebhookRoutes, which serves both a
+// user's webhooks and the site-wide ones.
+func describeWebhooks(b *openapi.Builder, prefix, tag, auth string, limit *openapi.Schema) {
+	b.Op(http.MethodGet, prefix, "List webhooks", tag).
+		Auth(auth).
+		Returns(http.StatusOK, []models.Webhook{})
+	b.Op(http.MethodPost, prefix, "Create a webhook", tag).
+		Auth(auth).
+		Body(models.WebhookRequest{}).
+		Returns(http.StatusCreated, models.Webhook{})
+	b.Op(http.MethodGet, prefix+"/:id", "Get a webhook", tag).
+		Auth(auth).
+		Returns(http.StatusOK, models.Webhook{}).
+		Errors(http.StatusNotFound)
+	b.Op(http.MethodPatch, prefix+"/:id", "Update a webhook", tag).
+		Describe("Fields left out are unchanged.").
+		Auth(auth).
+		Body(models.WebhookRequest{}).
+		Returns(http.StatusOK, models.Webhook{}).
+		Errors(http.StatusNotFound)
+	b.Op(http.MethodDelete, prefix+"/:id", "Delete a webhook", tag).
+		Auth(auth).
+		Returns(http.StatusNoContent, nil).
+		Errors(http.StatusNotFound)
+	b.Op(http.MethodGet, prefix+"/:id/deliveries", "List a webhook's deliveries, newest first", tag).
+		Auth(auth).
+		Query("before", openapi.Integer(), "only deliveries older than this id").
+		Query("limit", limit, "").
+		Returns(http.StatusOK, []models.WebhookDelivery{}).
+		Errors(http.StatusNotFound)
+	b.Op(http.MethodGet, prefix+"/:id/deliveries/:deliveryId", "Get a delivery with its attempts", tag).
+		Auth(auth).
+		Returns(http.StatusOK, models.WebhookDelivery{}).
+		Errors(http.StatusNotFound)
+	b.Op(http.MethodPost, prefix+"/:id/deliveries/:deliveryId/redeliver", "Send a delivery again", tag).
+		Auth(auth).
+		Returns(http.StatusAccepted, models.WebhookDelivery{}).
+		Errors(http.StatusNotFound, http.StatusConflict)
+}
+
+func describeAdmin(b *openapi.Builder) {
+	b.Op(http.MethodGet, "/metrics", "Prometheus metrics", "admin").
+		Auth(adminAuth).
+		ReturnsContent(http.StatusOK, "text/plain", openapi.String()).
+		ReturnsContent(http.StatusOK, "application/openmetrics-text", openapi.String())
+	b.Op(http.MethodGet, "/admin/jobs", "List background jobs, newest first", "admin").
+		Auth(adminAuth).
+		Query("status", b.Schema(jobs.StatusQueued), "").
+		Query("kind", openapi.String(), "").
+		Query("before", openapi.Integer(), "only jobs older than this id").
+		Query("limit", openapi.Integer().Range(1, 200), "").
+		Returns(http.StatusOK, []jobs.Job{})
+	b.Op(http.MethodGet, "/admin/jobs/counts", "Count jobs by kind and status", "admin").
+		Auth(adminAuth).
+		Returns(http.StatusOK, []jobs.Count{})
+	b.Op(http.MethodGet, "/admin/jobs/:id", "Get a job", "admin").
+		Auth(adminAuth).
+		Returns(http.StatusOK, jobs.Job{}).
+		Errors(http.StatusNotFound)
+	b.Op(http.MethodPost, "/admin/jobs/:id/retry", "Run a dead job again", "admin").
+		Auth(adminAuth).
+		Returns(http.StatusOK, jobs.Job{}).
+		Errors(http.StatusNotFound, http.StatusConflict)
+	describeWebhooks(b, "/admin/webhooks", "admin", adminAuth, openapi.Integer().Range(1, 200))
+}
+
+// openAPIRoute serves doc and, when cfg asks for it, validates the
+// router's traffic against it.
+func openAPIRoute(router *gin.Engine, cfg config.Config) {
+	doc := OpenAPI(cfg)
+	if cfg.Server.ValidateOpenAPI {
+		validator, err := openapi.NewValidator(doc)
+		if err != nil {
+			panic("routes: the OpenAPI document does not compile: " + err.Error())
+		}
+		router.Use(middleware.ValidateOpenAPI(validator, func(c *gin.Context, scheme string) bool {
+			if scheme == adminAuth {
+				return middleware.HasAdminToken(c, cfg.Admin.Token)
+			}
+			return middleware.HasValidJWT(c, cfg.Auth)
+		}))
+	}
+	router.GET("/openapi.json", handlers.NewOpenAPIHandler(doc).Serve)
+}
@@ -1,27 +1,180 @@
 package routes
 
 import (
+	"go-blog/config"
 	"go-blog/handlers"
+	"go-blog/metrics"
 	"go-blog/middleware"
 	"go-blog/models"
 	"go-blog/repo"
+	"log/slog"
+
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel/trace"
 )
 
-func SetupRoutes(postHandler *handlers.PostHandler, userHandler *handlers.UserHandler, authRepo repo.AuthRepository) *gin.Engine {
-	router := gin.Default()
+type Handlers struct {
+	Post          *handlers.PostHandler
+	User          *handlers.UserHandler
+	Reaction      *handlers.ReactionHandler
+	Follow        *handlers.FollowHandler
+	Lists         *handlers.ReadingListHandler
+	Feeds         *handlers.SyndicationHandler
+	Sitemap       *handlers.SitemapHandler
+	Media         *handlers.MediaHandler
+	Import        *handlers.ImportHandler
+	Webhooks      *handlers.WebhookHandler
+	Notifications *handlers.NotificationHandler
+	Digests       *handlers.DigestHandler
+	Admin         AdminHandlers
+	Health        *handlers.HealthHandler
+	GraphQL       *handlers.GraphQLHandler
+}
+
+// AdminHandlers serve the operator endpoints. A nil handler leaves its
+// routes out.
+type AdminHandlers struct {
+	Jobs     *handlers.JobHandler
+	Webhooks *handlers.WebhookHandler
+}
+
+func SetupRoutes(cfg config.Config, h Handlers, authRepo repo.AuthRepository, m *metrics.Metrics, tracerProvider trace.TracerProvider, logger *slog.Logger) *gin.Engine {
+	router := gin.New()
+	router.Use(
+		otelgin.Middleware(cfg.Tracing.ServiceName, otelgin.WithTracerProvider(tracerProvider)),
+		middleware.RequestID(logger),
+		middleware.RequestLogger(),
+		middleware.Recovery(),
+	)
+	if m != nil {
+		router.Use(m.Middleware())
+	}
+	if cfg.Server.CompressMinSize > 0 {
+		router.Use(middleware.Compress(cfg.Server.CompressMinSize))
+	}
+	openAPIRoute(router, cfg)
+	if cfg.Admin.Addr == "" {
+		registerAdminRoutes(router, cfg, m, h.Admin)
+	}
+
+	router.GET("/healthz", h.Health.Liveness)
+	router.GET("/readyz", h.Health.Readiness)
+
+	router.GET("/feed.rss", h.Feeds.RSS)
+	router.GET("/feed.atom", h.Feeds.Atom)
+	router.GET("/authors/:username/feed.rss", h.Feeds.RSS)
+	router.GET("/authors/:username/feed.atom", h.Feeds.Atom)
+	router.GET("/tags/:tag/feed.rss", h.Feeds.RSS)
+	router.GET("/tags/:tag/feed.atom", h.Feeds.Atom)
+	// The link feeds, sitemaps and digests give for each post.
+	router.GET("/posts/:id", middleware.OptionalJWTAuth(cfg.Auth), h.Post.GetPostByID)
+	router.GET("/sitemap.xml", h.Sitemap.Sitemap)
+	router.GET("/sitemap.xml.gz", h.Sitemap.Sitemap)
+	router.GET("/sitemaps/:file", h.Sitemap.Chunk)
+	router.GET("/robots.txt", h.Sitemap.Robots)
+	router.GET("/media/*key", h.Media.Serve)
+	router.POST("/graphql", middleware.OptionalJWTAuth(cfg.Auth), h.GraphQL.Query)
 
 	api := router.Group("/api")
 	{
-		api.POST("/register", userHandler.Register)
-		api.POST("/login", userHandler.Login)
-		api.GET("/posts", postHandler.GetPosts)
-		api.GET("/posts/:id", postHandler.GetPostByID)
+		api.POST("/register", h.User.Register)
+		api.POST("/login", h.User.Login)
+		api.GET("/posts", middleware.OptionalJWTAuth(cfg.Auth), h.Post.GetPosts)
+		api.GET("/posts/:id", middleware.OptionalJWTAuth(cfg.Auth), h.Post.GetPostByID)
 
 		bloggerType := models.AccountTypeBlogger
-		api.POST("/posts", middleware.JWTAuthMiddleware(&bloggerType), postHandler.CreatePost)
-		api.PUT("/posts/:id", middleware.JWTAuthMiddleware(nil), middleware.CheckPostOwnership(authRepo), postHandler.UpdatePost)
-		api.DELETE("/posts/:id", middleware.JWTAuthMiddleware(nil), middleware.CheckPostOwnership(authRepo), postHandler.DeletePost)
+		api.POST("/posts", middleware.JWTAuthMiddleware(cfg.Auth, &bloggerType), h.Post.CreatePost)
+		api.PUT("/posts/:id", middleware.JWTAuthMiddleware(cfg.Auth, nil), middleware.CheckPostOwnership(authRepo), h.Post.UpdatePost)
+		api.DELETE("/posts/:id", middleware.JWTAuthMiddleware(cfg.Auth, nil), middleware.CheckPostOwnership(authRepo), h.Post.DeletePost)
+		api.POST("/posts/:id/reactions", middleware.JWTAuth(cfg.Auth), h.Reaction.AddReaction)
+		api.DELETE("/posts/:id/reactions", middleware.JWTAuth(cfg.Auth), h.Reaction.RemoveReaction)
+
+		api.POST("/media", middleware.JWTAuthMiddleware(cfg.Auth, &bloggerType), h.Media.Upload)
+		api.GET("/media/:id", h.Media.GetMedia)
+		api.DELETE("/media/:id", middleware.JWTAuth(cfg.Auth), middleware.CheckMediaOwnership(authRepo), h.Media.DeleteMedia)
+		api.GET("/me/media", middleware.JWTAuth(cfg.Auth), h.Media.ListMedia)
+
+		api.GET("/me/export", middleware.JWTAuth(cfg.Auth), h.Import.Export)
+		api.POST("/me/import", middleware.JWTAuthMiddleware(cfg.Auth, &bloggerType), h.Import.Import)
+		api.GET("/me/imports", middleware.JWTAuth(cfg.Auth), h.Import.ListImports)
+		api.GET("/me/imports/:id", middleware.JWTAuth(cfg.Auth), h.Import.GetImport)
+
+		api.GET("/users/:username/followers", h.Follow.Followers)
+		api.GET("/users/:username/following", h.Follow.Following)
+		api.POST("/users/:username/follow", middleware.JWTAuth(cfg.Auth), h.Follow.Follow)
+		api.DELETE("/users/:username/follow", middleware.JWTAuth(cfg.Auth), h.Follow.Unfollow)
+		api.GET("/feed", middleware.JWTAuth(cfg.Auth), h.Follow.Feed)
+
+		api.GET("/shared/lists/:token", h.Lists.GetSharedList)
+
+		api.POST("/digests", middleware.OptionalJWTAuth(cfg.Auth), h.Digests.Subscribe)
+		api.GET("/digests/confirm", h.Digests.Confirm)
+		api.GET("/digests/unsubscribe", h.Digests.UnsubscribePage)
+		api.POST("/digests/unsubscribe", h.Digests.Unsubscribe)
+		api.GET("/me/digests", middleware.JWTAuth(cfg.Auth), h.Digests.ListSubscriptions)
+		api.DELETE("/me/digests/:id", middleware.JWTAuth(cfg.Auth), h.Digests.DeleteSubscription)
+
+		webhooks := api.Group("/me/webhooks", middleware.JWTAuth(cfg.Auth))
+		registerWebhookRoutes(webhooks, h.Webhooks)
+
+		notifications := api.Group("/me/notifications", middleware.JWTAuth(cfg.Auth))
+		notifications.GET("", h.Notifications.ListNotifications)
+		notifications.GET("/stream", h.Notifications.Stream)
+		notifications.POST("/read-all", h.Notifications.MarkAllRead)
+		notifications.POST("/:id/read", h.Notifications.MarkRead)
+
+		lists := api.Group("/me/lists", middleware.JWTAuth(cfg.Auth))
+		lists.GET("", h.Lists.GetLists)
+		lists.POST("", h.Lists.CreateList)
+		lists.GET("/:id", h.Lists.GetList)
+		lists.PATCH("/:id", h.Lists.UpdateList)
+		lists.DELETE("/:id", h.Lists.DeleteList)
+		lists.GET("/:id/posts", h.Lists.GetItems)
+		lists.POST("/:id/posts", h.Lists.AddItem)
+		lists.PATCH("/:id/posts/:postId", h.Lists.UpdateItem)
+		lists.DELETE("/:id/posts/:postId", h.Lists.RemoveItem)
 	}
 	return router
 }
+
+// SetupAdminRoutes builds the router for the separate admin listener used
+// when cfg.Admin.Addr is set.
+func SetupAdminRoutes(cfg config.Config, m *metrics.Metrics, h AdminHandlers, logger *slog.Logger) *gin.Engine {
+	router := gin.New()
+	router.Use(middleware.RequestID(logger), middleware.Recovery())
+	registerAdminRoutes(router, cfg, m, h)
+	return router
+}
+
+func registerAdminRoutes(router *gin.Engine, cfg config.Config, m *metrics.Metrics, h AdminHandlers) {
+	admin := router.Group("/", middleware.AdminToken(cfg.Admin.Token))
+	if m != nil {
+		admin.GET("/metrics", gin.WrapH(m.Handler()))
+	}
+	// The remaining endpoints can change state, so unlike metrics they are
+	// never left open on the public listener.
+	if cfg.Admin.Addr == "" && cfg.Admin.Token == "" {
+		return
+	}
+	if h.Jobs != nil {
+		admin.GET("/admin/jobs", h.Jobs.ListJobs)
+		admin.GET("/admin/jobs/counts", h.Jobs.Counts)
+		admin.GET("/admin/jobs/:id", h.Jobs.GetJob)
+		admin.POST("/admin/jobs/:id/retry", h.Jobs.RetryJob)
+	}
+	if h.Webhooks != nil {
+		registerWebhookRoutes(admin.Group("/admin/webhooks"), h.Webhooks)
+	}
+}
+
+func registerWebhookRoutes(group *gin.RouterGroup, h *handlers.WebhookHandler) {
+	group.GET("", h.ListWebhooks)
+	group.POST("", h.CreateWebhook)
+	group.GET("/:id", h.GetWebhook)
+	group.PATCH("/:id", h.UpdateWebhook)
+	group.DELETE("/:id", h.DeleteWebhook)
+	group.GET("/:id/deliveries", h.ListDeliveries)
+	group.GET("/:id/deliveries/:deliveryId", h.GetDelivery)
+	group.POST("/:id/deliveries/:deliveryId/redeliver", h.Redeliver)
+}
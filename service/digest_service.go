@@ -0,0 +1,494 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"embed"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"go-blog/config"
+	"go-blog/jobs"
+	"go-blog/logging"
+	"go-blog/mail"
+	"go-blog/models"
+	"go-blog/repo"
+	"go-blog/syndication"
+	htmltemplate "html/template"
+	"log/slog"
+	netmail "net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+const (
+	// digestConfirmationTTL is how long a confirmation link works.
+	digestConfirmationTTL = 7 * 24 * time.Hour
+	maxDigestEmailLength  = 254
+	digestScheduleBatch   = 500
+	digestSummaryLength   = 280
+)
+
+//go:embed templates/*.tmpl
+var digestTemplateFiles embed.FS
+
+var (
+	digestHTML = htmltemplate.Must(htmltemplate.ParseFS(digestTemplateFiles, "templates/*.html.tmpl"))
+	digestText = texttemplate.Must(texttemplate.ParseFS(digestTemplateFiles, "templates/*.txt.tmpl"))
+)
+
+// SendDigestConfirmationArgs is the job that emails a new subscriber the
+// link confirming their address.
+type SendDigestConfirmationArgs struct {
+	SubscriptionID int `json:"subscription_id"`
+}
+
+func (SendDigestConfirmationArgs) Kind() string { return "digest.confirm" }
+
+// SendDigestArgs is the job that compiles and sends one due digest.
+type SendDigestArgs struct {
+	SubscriptionID int `json:"subscription_id"`
+}
+
+func (SendDigestArgs) Kind() string { return "digest.send" }
+
+type DigestService interface {
+	// Subscribe starts a subscription and emails a confirmation link. To not
+	// reveal who is subscribed, it succeeds without doing anything when the
+	// address already has a confirmed subscription with the same filter.
+	// userID is 0 for anonymous readers.
+	Subscribe(ctx context.Context, userID int, req models.DigestRequest) error
+	Confirm(ctx context.Context, token string) (*models.DigestSubscription, error)
+	GetByToken(ctx context.Context, token string) (*models.DigestSubscription, error)
+	Unsubscribe(ctx context.Context, token string) error
+	ListSubscriptions(ctx context.Context, userID int) ([]models.DigestSubscription, error)
+	DeleteSubscription(ctx context.Context, userID, id int) error
+	// ScheduleDue queues a SendDigestArgs job for every digest due at now
+	// and returns how many it queued.
+	ScheduleDue(ctx context.Context, now time.Time) (int, error)
+	// SendConfirmation and SendDigest are the job handlers.
+	SendConfirmation(ctx context.Context, id int) error
+	SendDigest(ctx context.Context, id int) error
+}
+
+type digestService struct {
+	cfg    config.DigestsConfig
+	site   config.SiteConfig
+	repo   repo.DigestRepository
+	posts  repo.PostRepository
+	users  repo.UserRepository
+	tx     repo.Transactor
+	queue  *jobs.Queue
+	mailer mail.Mailer
+}
+
+func NewDigestService(cfg config.DigestsConfig, site config.SiteConfig, repo repo.DigestRepository, posts repo.PostRepository, users repo.UserRepository, tx repo.Transactor, queue *jobs.Queue, mailer mail.Mailer) DigestService {
+	return &digestService{cfg: cfg, site: site, repo: repo, posts: posts, users: users, tx: tx, queue: queue, mailer: mailer}
+}
+
+func (s *digestService) Subscribe(ctx context.Context, userID int, req models.DigestRequest) error {
+	email, err := normalizeEmail(req.Email)
+	if err != nil {
+		return err
+	}
+	if req.Frequency == "" {
+		req.Frequency = models.DigestDaily
+	}
+	if !req.Frequency.Valid() {
+		return fmt.Errorf("%w: frequency must be daily or weekly", models.ErrInvalidDigest)
+	}
+	authorID := 0
+	if req.Author != "" {
+		author, err := s.users.GetUserByUsername(ctx, req.Author)
+		if errors.Is(err, models.ErrUserNotFound) {
+			return fmt.Errorf("%w: unknown author %q", models.ErrInvalidDigest, req.Author)
+		}
+		if err != nil {
+			return err
+		}
+		authorID = author.ID
+	}
+	tag := ""
+	if req.Tag != "" {
+		tags, err := NormalizeTags([]string{req.Tag})
+		if err != nil {
+			return fmt.Errorf("%w: %w", models.ErrInvalidDigest, err)
+		}
+		tag = tags[0]
+	}
+
+	return s.tx.Transaction(ctx, func(ctx context.Context) error {
+		subscription, err := s.repo.Find(ctx, email, authorID, tag)
+		switch {
+		case errors.Is(err, models.ErrDigestNotFound):
+			subscription = &models.DigestSubscription{Email: email, Frequency: req.Frequency, Tag: tag, Token: newDigestToken()}
+			if authorID != 0 {
+				subscription.AuthorID = &authorID
+			}
+			if userID != 0 {
+				subscription.UserID = &userID
+			}
+			if err := s.repo.Create(ctx, subscription); err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		case subscription.ConfirmedAt != nil:
+			return nil
+		default:
+			// Asking again renews the link, so an expired one can be
+			// replaced.
+			subscription.Frequency = req.Frequency
+			subscription.Token = newDigestToken()
+			if userID != 0 {
+				subscription.UserID = &userID
+			}
+			if err := s.repo.Update(ctx, subscription); err != nil {
+				return err
+			}
+		}
+		_, err = s.queue.Enqueue(ctx, SendDigestConfirmationArgs{SubscriptionID: subscription.ID}, jobs.Options{
+			UniqueKey: "digest.confirm:" + strconv.Itoa(subscription.ID),
+		})
+		if errors.Is(err, jobs.ErrDuplicate) {
+			return nil
+		}
+		return err
+	})
+}
+
+func (s *digestService) Confirm(ctx context.Context, token string) (*models.DigestSubscription, error) {
+	subscription, err := s.repo.GetByToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if subscription.ConfirmedAt != nil {
+		return subscription, s.attachAuthors(ctx, subscription)
+	}
+	now := time.Now().UTC()
+	if now.Sub(subscription.UpdatedAt) > digestConfirmationTTL {
+		return nil, models.ErrDigestNotFound
+	}
+	next := now.Add(subscription.Frequency.Period())
+	subscription.ConfirmedAt = &now
+	subscription.SentUpTo = &now
+	subscription.NextRunAt = &next
+	if err := s.repo.Update(ctx, subscription); err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx).InfoContext(ctx, "digest subscription confirmed", "subscription_id", subscription.ID, "frequency", subscription.Frequency)
+	return subscription, s.attachAuthors(ctx, subscription)
+}
+
+func (s *digestService) GetByToken(ctx context.Context, token string) (*models.DigestSubscription, error) {
+	return s.repo.GetByToken(ctx, token)
+}
+
+func (s *digestService) Unsubscribe(ctx context.Context, token string) error {
+	subscription, err := s.repo.GetByToken(ctx, token)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, subscription.ID); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).InfoContext(ctx, "digest subscription cancelled", "subscription_id", subscription.ID)
+	return nil
+}
+
+func (s *digestService) ListSubscriptions(ctx context.Context, userID int) ([]models.DigestSubscription, error) {
+	subscriptions, err := s.repo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	pointers := make([]*models.DigestSubscription, len(subscriptions))
+	for i := range subscriptions {
+		pointers[i] = &subscriptions[i]
+	}
+	return subscriptions, s.attachAuthors(ctx, pointers...)
+}
+
+func (s *digestService) DeleteSubscription(ctx context.Context, userID, id int) error {
+	subscription, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if subscription.UserID == nil || *subscription.UserID != userID {
+		return models.ErrDigestNotFound
+	}
+	return s.repo.Delete(ctx, id)
+}
+
+func (s *digestService) ScheduleDue(ctx context.Context, now time.Time) (int, error) {
+	due, err := s.repo.ListDue(ctx, now, digestScheduleBatch)
+	if err != nil {
+		return 0, err
+	}
+	queued := 0
+	for _, subscription := range due {
+		_, err := s.queue.Enqueue(ctx, SendDigestArgs{SubscriptionID: subscription.ID}, jobs.Options{
+			UniqueKey: "digest.send:" + strconv.Itoa(subscription.ID),
+		})
+		if errors.Is(err, jobs.ErrDuplicate) {
+			continue
+		}
+		if err != nil {
+			return queued, err
+		}
+		queued++
+	}
+	return queued, nil
+}
+
+func (s *digestService) SendConfirmation(ctx context.Context, id int) error {
+	subscription, err := s.repo.Get(ctx, id)
+	if errors.Is(err, models.ErrDigestNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if subscription.ConfirmedAt != nil {
+		return nil
+	}
+	data, err := s.emailData(ctx, subscription)
+	if err != nil {
+		return err
+	}
+	data.ConfirmURL = s.site.URL("/api/digests/confirm?token=" + url.QueryEscape(subscription.Token))
+	msg, err := render("confirm", data)
+	if err != nil {
+		return jobs.Permanent(err)
+	}
+	msg.To = subscription.Email
+	msg.Subject = "Confirm your subscription to " + s.site.Title
+	return s.mailer.Send(ctx, msg)
+}
+
+// SendDigest emails the posts published since the previous digest and
+// moves the subscription on to its next period. Nothing is sent when there
+// is nothing new. A subscription that is not due, for example because a
+// duplicate job already sent it, is left alone.
+func (s *digestService) SendDigest(ctx context.Context, id int) error {
+	subscription, err := s.repo.Get(ctx, id)
+	if errors.Is(err, models.ErrDigestNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	if subscription.ConfirmedAt == nil || subscription.NextRunAt == nil || subscription.NextRunAt.After(now) {
+		return nil
+	}
+
+	filter := models.PostFilter{Tag: subscription.Tag}
+	if subscription.AuthorID != nil {
+		filter.AuthorID = *subscription.AuthorID
+	}
+	if subscription.SentUpTo != nil {
+		filter.PublishedAfter = *subscription.SentUpTo
+	}
+	posts, err := s.posts.ListPublished(ctx, filter, s.cfg.MaxPosts)
+	if err != nil {
+		return err
+	}
+	sentUpTo := now
+	if len(posts) > 0 {
+		if err := s.sendDigest(ctx, subscription, filter, posts); err != nil {
+			return err
+		}
+		if posts[0].PublishedAt.After(sentUpTo) {
+			sentUpTo = *posts[0].PublishedAt
+		}
+	}
+
+	next := *subscription.NextRunAt
+	for !next.After(now) {
+		next = next.Add(subscription.Frequency.Period())
+	}
+	subscription.SentUpTo = &sentUpTo
+	subscription.NextRunAt = &next
+	return s.repo.Update(ctx, subscription)
+}
+
+func (s *digestService) sendDigest(ctx context.Context, subscription *models.DigestSubscription, filter models.PostFilter, posts []models.Post) error {
+	data, err := s.emailData(ctx, subscription)
+	if err != nil {
+		return err
+	}
+	authorIDs := make([]int, len(posts))
+	for i, post := range posts {
+		authorIDs[i] = post.UserID
+	}
+	authors, err := s.users.UsernamesByID(ctx, authorIDs)
+	if err != nil {
+		return err
+	}
+	for _, post := range posts {
+		data.Posts = append(data.Posts, digestPost{
+			Title:     post.Title,
+			URL:       PostURL(s.site, post.ID),
+			Author:    authors[post.UserID],
+			Published: *post.PublishedAt,
+			Summary:   syndication.Summarize(post.Content, digestSummaryLength),
+		})
+	}
+	if len(posts) == s.cfg.MaxPosts {
+		stats, err := s.posts.PublishedStats(ctx, filter)
+		if err != nil {
+			return err
+		}
+		data.More = stats.Count - int64(len(posts))
+	}
+
+	msg, err := render("digest", data)
+	if err != nil {
+		return jobs.Permanent(err)
+	}
+	msg.To = subscription.Email
+	msg.Subject = fmt.Sprintf("%s: %d new post", s.site.Title, len(posts))
+	if len(posts) != 1 {
+		msg.Subject += "s"
+	}
+	// RFC 8058: mail clients POST "List-Unsubscribe=One-Click" to the URL
+	// to unsubscribe without opening it.
+	msg.Headers = map[string]string{
+		"List-Unsubscribe":      "<" + data.UnsubscribeURL + ">",
+		"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+	}
+	if err := s.mailer.Send(ctx, msg); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).InfoContext(ctx, "digest sent", "subscription_id", subscription.ID, "posts", len(posts))
+	return nil
+}
+
+type digestEmail struct {
+	Site           string
+	SiteURL        string
+	Frequency      models.DigestFrequency
+	Scope          string
+	Posts          []digestPost
+	More           int64
+	ConfirmURL     string
+	UnsubscribeURL string
+}
+
+type digestPost struct {
+	Title     string
+	URL       string
+	Author    string
+	Published time.Time
+	Summary   string
+}
+
+func (s *digestService) emailData(ctx context.Context, subscription *models.DigestSubscription) (*digestEmail, error) {
+	data := &digestEmail{
+		Site:           s.site.Title,
+		SiteURL:        s.site.BaseURL,
+		Frequency:      subscription.Frequency,
+		UnsubscribeURL: s.site.URL("/api/digests/unsubscribe?token=" + url.QueryEscape(subscription.Token)),
+	}
+	if err := s.attachAuthors(ctx, subscription); err != nil {
+		return nil, err
+	}
+	switch {
+	case subscription.Author != "" && subscription.Tag != "":
+		data.Scope = fmt.Sprintf("posts by %s tagged %s", subscription.Author, subscription.Tag)
+	case subscription.Author != "":
+		data.Scope = "posts by " + subscription.Author
+	case subscription.Tag != "":
+		data.Scope = "posts tagged " + subscription.Tag
+	}
+	return data, nil
+}
+
+// attachAuthors fills in Author on subscriptions filtered by author.
+func (s *digestService) attachAuthors(ctx context.Context, subscriptions ...*models.DigestSubscription) error {
+	var ids []int
+	for _, subscription := range subscriptions {
+		if subscription.AuthorID != nil {
+			ids = append(ids, *subscription.AuthorID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	names, err := s.users.UsernamesByID(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for _, subscription := range subscriptions {
+		if subscription.AuthorID != nil {
+			subscription.Author = names[*subscription.AuthorID]
+		}
+	}
+	return nil
+}
+
+// render executes the text and HTML versions of a template pair.
+func render(name string, data *digestEmail) (mail.Message, error) {
+	var text, html bytes.Buffer
+	if err := digestText.ExecuteTemplate(&text, name+".txt.tmpl", data); err != nil {
+		return mail.Message{}, err
+	}
+	if err := digestHTML.ExecuteTemplate(&html, name+".html.tmpl", data); err != nil {
+		return mail.Message{}, err
+	}
+	return mail.Message{Text: text.String(), HTML: html.String()}, nil
+}
+
+func normalizeEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	address, err := netmail.ParseAddress(email)
+	if err != nil || address.Address != email || len(email) > maxDigestEmailLength {
+		return "", fmt.Errorf("%w: a valid email address is required", models.ErrInvalidDigest)
+	}
+	return strings.ToLower(email), nil
+}
+
+func newDigestToken() string {
+	b := make([]byte, 24)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DigestScheduler periodically queues the digests that are due.
+type DigestScheduler struct {
+	service  DigestService
+	interval time.Duration
+	logger   *slog.Logger
+}
+
+func NewDigestScheduler(service DigestService, interval time.Duration, logger *slog.Logger) *DigestScheduler {
+	return &DigestScheduler{service: service, interval: interval, logger: logger}
+}
+
+func (d *DigestScheduler) Name() string {
+	return "digest scheduler"
+}
+
+func (d *DigestScheduler) Run(ctx context.Context) error {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case now := <-ticker.C:
+			queued, err := d.service.ScheduleDue(ctx, now)
+			if err != nil {
+				d.logger.ErrorContext(ctx, "scheduling digests", "error", err)
+				continue
+			}
+			if queued > 0 {
+				d.logger.InfoContext(ctx, "queued digests", "count", queued)
+			}
+		}
+	}
+}
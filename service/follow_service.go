@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"go-blog/events"
+	"go-blog/logging"
+	"go-blog/models"
+	"go-blog/repo"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultFeedLimit = 20
+	MaxFeedLimit     = 100
+)
+
+type FollowService interface {
+	Follow(ctx context.Context, followerID int, username string) error
+	Unfollow(ctx context.Context, followerID int, username string) error
+	Followers(ctx context.Context, username string) ([]models.UserProfile, error)
+	Following(ctx context.Context, username string) ([]models.UserProfile, error)
+	Feed(ctx context.Context, userID int, cursor string, limit int) (*models.FeedPage, error)
+}
+
+type followService struct {
+	users   repo.UserRepository
+	follows repo.FollowRepository
+	posts   repo.PostRepository
+	tx      repo.Transactor
+	bus     *events.Bus
+}
+
+func NewFollowService(users repo.UserRepository, follows repo.FollowRepository, posts repo.PostRepository, tx repo.Transactor, bus *events.Bus) FollowService {
+	return &followService{users: users, follows: follows, posts: posts, tx: tx, bus: bus}
+}
+
+func (s *followService) Follow(ctx context.Context, followerID int, username string) error {
+	followee, err := s.users.GetUserByUsername(ctx, username)
+	if err != nil {
+		return err
+	}
+	if followee.ID == followerID {
+		return models.ErrCannotFollowSelf
+	}
+	if followee.AccountType != models.AccountTypeBlogger {
+		return models.ErrNotABlogger
+	}
+	added := false
+	err = s.tx.Transaction(ctx, func(ctx context.Context) error {
+		added, err = s.follows.Follow(ctx, followerID, followee.ID)
+		if err != nil || !added {
+			return err
+		}
+		return s.bus.Publish(ctx, events.UserFollowed{FollowerID: followerID, FolloweeID: followee.ID})
+	})
+	if err != nil {
+		return err
+	}
+	if added {
+		logging.FromContext(ctx).InfoContext(ctx, "user followed", "follower_id", followerID, "followee_id", followee.ID)
+	}
+	return nil
+}
+
+func (s *followService) Unfollow(ctx context.Context, followerID int, username string) error {
+	followee, err := s.users.GetUserByUsername(ctx, username)
+	if err != nil {
+		return err
+	}
+	removed, err := s.follows.Unfollow(ctx, followerID, followee.ID)
+	if err != nil {
+		return err
+	}
+	if removed {
+		logging.FromContext(ctx).InfoContext(ctx, "user unfollowed", "follower_id", followerID, "followee_id", followee.ID)
+	}
+	return nil
+}
+
+func (s *followService) Followers(ctx context.Context, username string) ([]models.UserProfile, error) {
+	user, err := s.users.GetUserByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	return s.follows.Followers(ctx, user.ID)
+}
+
+func (s *followService) Following(ctx context.Context, username string) ([]models.UserProfile, error) {
+	user, err := s.users.GetUserByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	return s.follows.Following(ctx, user.ID)
+}
+
+// Feed returns published posts by the authors userID follows, newest first.
+// One extra row is fetched to learn whether another page exists without a
+// separate count query.
+func (s *followService) Feed(ctx context.Context, userID int, cursor string, limit int) (*models.FeedPage, error) {
+	if limit <= 0 {
+		limit = DefaultFeedLimit
+	}
+	if limit > MaxFeedLimit {
+		limit = MaxFeedLimit
+	}
+	var after *models.PostCursor
+	if cursor != "" {
+		decoded, err := DecodeCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		after = decoded
+	}
+
+	authorIDs, err := s.follows.FolloweeIDs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	posts, err := s.posts.ListPublishedByAuthors(ctx, authorIDs, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.FeedPage{Posts: posts}
+	if len(posts) > limit {
+		page.Posts = posts[:limit]
+		last := page.Posts[limit-1]
+		page.NextCursor = EncodeCursor(models.PostCursor{PublishedAt: *last.PublishedAt, ID: last.ID})
+	}
+	return page, nil
+}
+
+// EncodeCursor renders a keyset position as an opaque URL-safe token.
+func EncodeCursor(c models.PostCursor) string {
+	raw := c.PublishedAt.UTC().Format(time.RFC3339Nano) + "|" + strconv.Itoa(c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeCursor(token string) (*models.PostCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, models.ErrInvalidCursor
+	}
+	publishedAt, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return nil, models.ErrInvalidCursor
+	}
+	t, err := time.Parse(time.RFC3339Nano, publishedAt)
+	if err != nil {
+		return nil, models.ErrInvalidCursor
+	}
+	n, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, models.ErrInvalidCursor
+	}
+	return &models.PostCursor{PublishedAt: t, ID: n}, nil
+}
@@ -0,0 +1,279 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go-blog/archive"
+	"go-blog/config"
+	"go-blog/jobs"
+	"go-blog/logging"
+	"go-blog/models"
+	"go-blog/repo"
+	"go-blog/storage"
+	"io"
+	"time"
+)
+
+// maxImportMessages caps the per-entry explanations kept on a job so a file
+// full of broken posts cannot grow the row without bound.
+const maxImportMessages = 200
+
+type ImportService interface {
+	// Export writes every post by userID, drafts included, as an export zip.
+	Export(ctx context.Context, userID int, w io.Writer) error
+	// StartImport checks that data parses, parks it in the blob store and
+	// enqueues an ImportArgs job to process it.
+	StartImport(ctx context.Context, userID int, data []byte) (*models.ImportJob, error)
+	GetImport(ctx context.Context, userID, id int) (*models.ImportJob, error)
+	ListImports(ctx context.Context, userID int) ([]models.ImportJob, error)
+	// ProcessImport runs one import; it is the handler for ImportArgs jobs.
+	ProcessImport(ctx context.Context, id int) error
+}
+
+// ImportArgs is the background job that processes an uploaded import.
+type ImportArgs struct {
+	ImportID int `json:"import_id"`
+}
+
+func (ImportArgs) Kind() string { return "import" }
+
+type importService struct {
+	cfg     config.ImportConfig
+	imports repo.ImportRepository
+	posts   repo.PostRepository
+	users   repo.UserRepository
+	service PostService
+	store   storage.BlobStore
+	queue   *jobs.Queue
+}
+
+func NewImportService(cfg config.ImportConfig, imports repo.ImportRepository, posts repo.PostRepository, users repo.UserRepository, service PostService, store storage.BlobStore, queue *jobs.Queue) ImportService {
+	return &importService{
+		cfg:     cfg,
+		imports: imports,
+		posts:   posts,
+		users:   users,
+		service: service,
+		store:   store,
+		queue:   queue,
+	}
+}
+
+func (s *importService) Export(ctx context.Context, userID int, w io.Writer) error {
+	posts, err := s.posts.ListPostsByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	names, err := s.users.UsernamesByID(ctx, []int{userID})
+	if err != nil {
+		return err
+	}
+	entries := make([]archive.Entry, 0, len(posts))
+	for _, post := range posts {
+		entries = append(entries, archive.EntryFromPost(post))
+	}
+	return archive.WriteZip(w, names[userID], time.Now(), entries)
+}
+
+func (s *importService) StartImport(ctx context.Context, userID int, data []byte) (*models.ImportJob, error) {
+	if len(data) > s.cfg.MaxBytes {
+		return nil, models.ErrImportTooLarge
+	}
+	format, err := sniffImport(data)
+	if err != nil {
+		return nil, err
+	}
+	// Parse now so a broken file is rejected in the request rather than
+	// failing later in the background.
+	if _, err := parseImport(format, data); err != nil {
+		return nil, err
+	}
+
+	token, err := newMediaToken()
+	if err != nil {
+		return nil, err
+	}
+	job := &models.ImportJob{
+		UserID:     userID,
+		Format:     format,
+		StorageKey: "imports/" + time.Now().UTC().Format("2006/01") + "/" + token,
+		Status:     models.ImportQueued,
+	}
+	if err := s.store.Put(ctx, job.StorageKey, bytes.NewReader(data), int64(len(data)), "application/octet-stream"); err != nil {
+		return nil, err
+	}
+	created, err := s.imports.CreateImport(ctx, job)
+	if err != nil {
+		s.removeUpload(ctx, job.StorageKey)
+		return nil, err
+	}
+	if _, err := s.queue.Enqueue(ctx, ImportArgs{ImportID: created.ID}, jobs.Options{UniqueKey: fmt.Sprintf("import:%d", created.ID)}); err != nil {
+		// Nothing will ever pick the import up, so close it off now.
+		s.finish(ctx, created, models.ImportFailed, "could not be queued")
+		return nil, err
+	}
+	logging.FromContext(ctx).InfoContext(ctx, "import queued", "import_id", created.ID, "user_id", userID, "format", format, "size", len(data))
+	return created, nil
+}
+
+// GetImport hides other users' jobs behind ErrImportNotFound.
+func (s *importService) GetImport(ctx context.Context, userID, id int) (*models.ImportJob, error) {
+	job, err := s.imports.GetImport(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if job.UserID != userID {
+		return nil, models.ErrImportNotFound
+	}
+	return job, nil
+}
+
+func (s *importService) ListImports(ctx context.Context, userID int) ([]models.ImportJob, error) {
+	return s.imports.ListImportsByUser(ctx, userID)
+}
+
+// ProcessImport does nothing for an import that already finished, so a
+// retried job cannot run it twice.
+func (s *importService) ProcessImport(ctx context.Context, id int) error {
+	job, err := s.imports.GetImport(ctx, id)
+	if errors.Is(err, models.ErrImportNotFound) {
+		return jobs.Permanent(err)
+	}
+	if err != nil {
+		return err
+	}
+	if job.Done() {
+		return nil
+	}
+	now := time.Now().UTC()
+	job.Status, job.StartedAt = models.ImportRunning, &now
+	return s.run(ctx, job)
+}
+
+// run creates a post for every entry through PostService, so imported posts
+// pass the same validation as ones created over the API. An entry whose
+// slug the user already has is skipped, which also makes re-running an
+// interrupted import safe. Progress is saved after every entry; an error
+// saving it is returned so the job queue retries the import.
+func (s *importService) run(ctx context.Context, job *models.ImportJob) error {
+	logger := logging.FromContext(ctx).With("import_id", job.ID, "user_id", job.UserID)
+	job.Total, job.Processed, job.Created, job.Skipped, job.Failed = 0, 0, 0, 0, 0
+	job.Messages = []string{}
+
+	entries, err := s.load(ctx, job)
+	if err != nil {
+		logger.ErrorContext(ctx, "import failed", "error", err)
+		return s.finish(ctx, job, models.ImportFailed, err.Error())
+	}
+	job.Total = len(entries)
+	if err := s.imports.SaveProgress(ctx, job); err != nil {
+		return err
+	}
+
+	for i, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			// Left as running; the job queue retries it.
+			return err
+		}
+		label := fmt.Sprintf("entry %d (%q)", i+1, entry.Title)
+		created, err := s.importEntry(ctx, job.UserID, entry)
+		switch {
+		case errors.Is(err, models.ErrSlugTaken):
+			job.Skipped++
+			s.note(job, label+": skipped, a post with this slug already exists")
+		case err != nil:
+			job.Failed++
+			s.note(job, label+": "+err.Error())
+		default:
+			job.Created++
+			logger.DebugContext(ctx, "imported post", "post_id", created.ID)
+		}
+		job.Processed++
+		if err := s.imports.SaveProgress(ctx, job); err != nil {
+			return err
+		}
+	}
+
+	logger.InfoContext(ctx, "import finished", "created", job.Created, "skipped", job.Skipped, "failed", job.Failed)
+	return s.finish(ctx, job, models.ImportCompleted, "")
+}
+
+func (s *importService) load(ctx context.Context, job *models.ImportJob) ([]archive.Entry, error) {
+	body, _, err := s.store.Get(ctx, job.StorageKey)
+	if err != nil {
+		return nil, fmt.Errorf("reading uploaded file: %w", err)
+	}
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("reading uploaded file: %w", err)
+	}
+	return parseImport(job.Format, data)
+}
+
+// importEntry leaves the duplicate check to PostService: an explicit slug
+// the user already has comes back as ErrSlugTaken, while entries without a
+// slug get a fresh one from their title.
+func (s *importService) importEntry(ctx context.Context, userID int, entry archive.Entry) (*models.Post, error) {
+	return s.service.ImportPost(ctx, &models.Post{
+		UserID:      userID,
+		Title:       entry.Title,
+		Slug:        entry.Slug,
+		Content:     entry.Content,
+		Status:      entry.Status,
+		Tags:        entry.Tags,
+		PublishedAt: entry.PublishedAt,
+		CreatedAt:   entry.CreatedAt,
+		UpdatedAt:   entry.UpdatedAt,
+	})
+}
+
+func (s *importService) note(job *models.ImportJob, message string) {
+	if len(job.Messages) < maxImportMessages {
+		job.Messages = append(job.Messages, message)
+	}
+}
+
+// finish records the outcome and drops the uploaded file, which is no
+// longer needed once the job is done.
+func (s *importService) finish(ctx context.Context, job *models.ImportJob, status models.ImportStatus, message string) error {
+	now := time.Now().UTC()
+	job.Status, job.FinishedAt = status, &now
+	if message != "" {
+		s.note(job, message)
+	}
+	if err := s.imports.SaveProgress(ctx, job); err != nil {
+		return err
+	}
+	s.removeUpload(ctx, job.StorageKey)
+	return nil
+}
+
+func (s *importService) removeUpload(ctx context.Context, key string) {
+	if err := s.store.Delete(ctx, key); err != nil {
+		logging.FromContext(ctx).WarnContext(ctx, "deleting import upload failed", "key", key, "error", err)
+	}
+}
+
+// sniffImport tells an export zip from WXR by its first bytes.
+func sniffImport(data []byte) (models.ImportFormat, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")):
+		return models.ImportFormatArchive, nil
+	case bytes.HasPrefix(bytes.TrimLeft(data, "\xef\xbb\xbf \t\r\n"), []byte("<")):
+		return models.ImportFormatWXR, nil
+	}
+	return "", models.ErrUnsupportedImport
+}
+
+func parseImport(format models.ImportFormat, data []byte) ([]archive.Entry, error) {
+	switch format {
+	case models.ImportFormatArchive:
+		return archive.ReadZip(data)
+	case models.ImportFormatWXR:
+		return archive.ReadWXR(data)
+	}
+	return nil, models.ErrUnsupportedImport
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"go-blog/config"
+	"go-blog/imaging"
+	"go-blog/logging"
+	"go-blog/models"
+	"go-blog/repo"
+	"go-blog/storage"
+	"io"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const maxMediaPerPost = 20
+
+type MediaService interface {
+	Upload(ctx context.Context, userID int, filename string, data []byte) (*models.Media, error)
+	GetMedia(ctx context.Context, id int) (*models.Media, error)
+	ListMedia(ctx context.Context, userID int) ([]models.Media, error)
+	DeleteMedia(ctx context.Context, id int) error
+	// Open streams a stored blob by key, for serving /media/*.
+	Open(ctx context.Context, key string) (io.ReadCloser, storage.Info, error)
+}
+
+type mediaService struct {
+	cfg   config.MediaConfig
+	site  config.SiteConfig
+	media repo.MediaRepository
+	store storage.BlobStore
+}
+
+func NewMediaService(cfg config.MediaConfig, site config.SiteConfig, media repo.MediaRepository, store storage.BlobStore) MediaService {
+	return &mediaService{cfg: cfg, site: site, media: media, store: store}
+}
+
+// Upload sniffs and cleans the file, writes it and its thumbnail to the blob
+// store and records it. Keys are random so they can be served with a
+// far-future cache lifetime and cannot be guessed.
+func (s *mediaService) Upload(ctx context.Context, userID int, filename string, data []byte) (*models.Media, error) {
+	if len(data) > s.cfg.MaxUploadBytes {
+		return nil, models.ErrMediaTooLarge
+	}
+	result, err := imaging.Process(data, s.cfg.ThumbnailSize)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := newMediaToken()
+	if err != nil {
+		return nil, err
+	}
+	base := time.Now().UTC().Format("2006/01") + "/" + token
+	media := &models.Media{
+		UserID:      userID,
+		StorageKey:  base + imaging.Extensions[result.ContentType],
+		Filename:    cleanFilename(filename),
+		ContentType: result.ContentType,
+		Size:        int64(len(result.Data)),
+		Width:       result.Width,
+		Height:      result.Height,
+	}
+	if err := s.store.Put(ctx, media.StorageKey, bytes.NewReader(result.Data), media.Size, media.ContentType); err != nil {
+		return nil, err
+	}
+	if result.Thumbnail != nil {
+		media.ThumbnailKey = base + "_thumb" + imaging.Extensions[result.ThumbnailContentType]
+		err := s.store.Put(ctx, media.ThumbnailKey, bytes.NewReader(result.Thumbnail), int64(len(result.Thumbnail)), result.ThumbnailContentType)
+		if err != nil {
+			s.removeBlobs(ctx, media)
+			return nil, err
+		}
+	}
+
+	created, err := s.media.CreateMedia(ctx, media)
+	if err != nil {
+		s.removeBlobs(ctx, media)
+		return nil, err
+	}
+	logging.FromContext(ctx).InfoContext(ctx, "media uploaded", "media_id", created.ID, "user_id", userID, "content_type", created.ContentType, "size", created.Size)
+	s.setURLs(created)
+	return created, nil
+}
+
+func (s *mediaService) GetMedia(ctx context.Context, id int) (*models.Media, error) {
+	media, err := s.media.GetMedia(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.setURLs(media)
+	return media, nil
+}
+
+func (s *mediaService) ListMedia(ctx context.Context, userID int) ([]models.Media, error) {
+	media, err := s.media.ListMediaByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range media {
+		s.setURLs(&media[i])
+	}
+	return media, nil
+}
+
+// DeleteMedia removes the record first so nothing can reference a blob that
+// is about to disappear; a blob that then fails to delete is only logged.
+func (s *mediaService) DeleteMedia(ctx context.Context, id int) error {
+	media, err := s.media.GetMedia(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.media.DeleteMedia(ctx, id); err != nil {
+		return err
+	}
+	s.removeBlobs(ctx, media)
+	logging.FromContext(ctx).InfoContext(ctx, "media deleted", "media_id", id)
+	return nil
+}
+
+func (s *mediaService) Open(ctx context.Context, key string) (io.ReadCloser, storage.Info, error) {
+	return s.store.Get(ctx, key)
+}
+
+func (s *mediaService) removeBlobs(ctx context.Context, media *models.Media) {
+	for _, key := range []string{media.StorageKey, media.ThumbnailKey} {
+		if key == "" {
+			continue
+		}
+		if err := s.store.Delete(ctx, key); err != nil {
+			logging.FromContext(ctx).WarnContext(ctx, "deleting blob failed", "key", key, "error", err)
+		}
+	}
+}
+
+func (s *mediaService) setURLs(media *models.Media) {
+	media.URL = s.site.URL("/media/" + media.StorageKey)
+	if media.ThumbnailKey != "" {
+		media.ThumbnailURL = s.site.URL("/media/" + media.ThumbnailKey)
+	}
+}
+
+// checkPostMedia verifies that every ID refers to media owned by userID and
+// returns the IDs with duplicates removed, keeping the caller's order.
+func checkPostMedia(ctx context.Context, media repo.MediaRepository, userID int, ids []int) ([]int, error) {
+	unique := []int{}
+	seen := map[int]bool{}
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	if len(unique) == 0 {
+		return unique, nil
+	}
+	if len(unique) > maxMediaPerPost {
+		return nil, fmt.Errorf("%w: at most %d per post", models.ErrTooManyMedia, maxMediaPerPost)
+	}
+	found, err := media.ListMediaByIDs(ctx, unique)
+	if err != nil {
+		return nil, err
+	}
+	if len(found) != len(unique) {
+		return nil, models.ErrMediaNotFound
+	}
+	for _, m := range found {
+		if m.UserID != userID {
+			return nil, models.ErrMediaUnauthorized
+		}
+	}
+	return unique, nil
+}
+
+func cleanFilename(name string) string {
+	name = strings.TrimSpace(path.Base(strings.ReplaceAll(name, `\`, "/")))
+	if name == "." || name == "/" {
+		return ""
+	}
+	for len(name) > 255 {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name
+}
+
+func newMediaToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"go-blog/events"
+	"go-blog/models"
+	"go-blog/notify"
+	"go-blog/repo"
+)
+
+type NotificationService interface {
+	Create(ctx context.Context, notification models.Notification) error
+	List(ctx context.Context, userID, beforeID, limit int, unreadOnly bool) (*models.NotificationPage, error)
+	// Since returns up to limit notifications newer than afterID, oldest
+	// first.
+	Since(ctx context.Context, userID, afterID, limit int) ([]models.Notification, error)
+	LatestID(ctx context.Context, userID int) (int, error)
+	UnreadCount(ctx context.Context, userID int) (int64, error)
+	MarkRead(ctx context.Context, userID, id int) (int64, error)
+	MarkAllRead(ctx context.Context, userID, upTo int) (marked, unread int64, err error)
+}
+
+type notificationService struct {
+	repo  repo.NotificationRepository
+	users repo.UserRepository
+	hub   *notify.Hub
+}
+
+func NewNotificationService(repo repo.NotificationRepository, users repo.UserRepository, hub *notify.Hub) NotificationService {
+	return &notificationService{repo: repo, users: users, hub: hub}
+}
+
+// SubscribeNotifications stores a notification for the user on the
+// receiving end of a reaction or a follow. Like webhooks it subscribes
+// synchronously, so the notification commits or rolls back with its cause.
+func SubscribeNotifications(bus *events.Bus, notifications NotificationService) {
+	events.Subscribe(bus, "notifications", func(ctx context.Context, e events.PostReacted) error {
+		postID := e.PostID
+		return notifications.Create(ctx, models.Notification{
+			UserID: e.PostOwnerID, Type: models.NotificationReaction, ActorID: e.UserID, PostID: &postID, Reaction: e.Type,
+		})
+	})
+	events.Subscribe(bus, "notifications", func(ctx context.Context, e events.UserFollowed) error {
+		return notifications.Create(ctx, models.Notification{
+			UserID: e.FolloweeID, Type: models.NotificationFollow, ActorID: e.FollowerID,
+		})
+	})
+}
+
+// Create stores notification and wakes the recipient's streams once it has
+// committed. Nobody is notified of their own actions.
+func (s *notificationService) Create(ctx context.Context, notification models.Notification) error {
+	if notification.UserID == notification.ActorID {
+		return nil
+	}
+	if err := s.repo.Create(ctx, &notification); err != nil {
+		return err
+	}
+	return s.hub.Notify(ctx, notification.UserID)
+}
+
+func (s *notificationService) List(ctx context.Context, userID, beforeID, limit int, unreadOnly bool) (*models.NotificationPage, error) {
+	notifications, err := s.repo.List(ctx, userID, beforeID, limit, unreadOnly)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachActors(ctx, notifications); err != nil {
+		return nil, err
+	}
+	unread, err := s.repo.UnreadCount(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.NotificationPage{Notifications: notifications, Unread: unread}, nil
+}
+
+func (s *notificationService) Since(ctx context.Context, userID, afterID, limit int) ([]models.Notification, error) {
+	notifications, err := s.repo.ListAfter(ctx, userID, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return notifications, s.attachActors(ctx, notifications)
+}
+
+func (s *notificationService) LatestID(ctx context.Context, userID int) (int, error) {
+	return s.repo.LatestID(ctx, userID)
+}
+
+func (s *notificationService) UnreadCount(ctx context.Context, userID int) (int64, error) {
+	return s.repo.UnreadCount(ctx, userID)
+}
+
+// MarkRead marks one notification read and returns the new unread count.
+// The user's streams are woken so their other tabs see the count drop.
+func (s *notificationService) MarkRead(ctx context.Context, userID, id int) (int64, error) {
+	if err := s.repo.MarkRead(ctx, userID, id); err != nil {
+		return 0, err
+	}
+	if err := s.hub.Notify(ctx, userID); err != nil {
+		return 0, err
+	}
+	return s.repo.UnreadCount(ctx, userID)
+}
+
+// MarkAllRead marks every unread notification up to upTo read, or all of
+// them when upTo is 0. Passing the newest id the client has seen avoids
+// marking one that arrived after it last looked.
+func (s *notificationService) MarkAllRead(ctx context.Context, userID, upTo int) (int64, int64, error) {
+	marked, err := s.repo.MarkAllRead(ctx, userID, upTo)
+	if err != nil {
+		return 0, 0, err
+	}
+	if marked > 0 {
+		if err := s.hub.Notify(ctx, userID); err != nil {
+			return 0, 0, err
+		}
+	}
+	unread, err := s.repo.UnreadCount(ctx, userID)
+	return marked, unread, err
+}
+
+func (s *notificationService) attachActors(ctx context.Context, notifications []models.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+	ids := make([]int, len(notifications))
+	for i := range notifications {
+		ids[i] = notifications[i].ActorID
+	}
+	names, err := s.users.UsernamesByID(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for i := range notifications {
+		notifications[i].ActorUsername = names[notifications[i].ActorID]
+	}
+	return nil
+}
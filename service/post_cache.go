@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"go-blog/cache"
+	"go-blog/events"
+	"go-blog/logging"
+	"go-blog/metrics"
+	"go-blog/models"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const postListCacheKey = "posts:all"
+
+func postCacheKey(id int) string {
+	return "posts:" + strconv.Itoa(id)
+}
+
+type cachedPostService struct {
+	next    PostService
+	store   cache.Store
+	ttl     time.Duration
+	metrics *metrics.Metrics
+	flights singleflight.Group
+	// generation moves on with every invalidation. A load that started
+	// before one must neither be stored nor be joined by a caller that
+	// arrived after it.
+	generation atomic.Uint64
+}
+
+// NewCachedPostService caches GetAllPosts and GetPostByID in store for ttl.
+// Concurrent misses for the same key share one load. Writes through the
+// service invalidate straight away; reactions and writes made elsewhere
+// invalidate once they commit, via bus. A nil store disables caching.
+func NewCachedPostService(next PostService, store cache.Store, ttl time.Duration, bus *events.Bus, metrics *metrics.Metrics) PostService {
+	if store == nil {
+		return next
+	}
+	s := &cachedPostService{next: next, store: store, ttl: ttl, metrics: metrics}
+	if bus != nil {
+		events.SubscribeAsync(bus, "post cache", func(ctx context.Context, e events.PostCreated) error {
+			return s.invalidate(ctx, e.Post.ID)
+		})
+		events.SubscribeAsync(bus, "post cache", func(ctx context.Context, e events.PostUpdated) error {
+			return s.invalidate(ctx, e.Post.ID)
+		})
+		events.SubscribeAsync(bus, "post cache", func(ctx context.Context, e events.PostDeleted) error {
+			return s.invalidate(ctx, e.Post.ID)
+		})
+		events.SubscribeAsync(bus, "post cache", func(ctx context.Context, e events.PostReacted) error {
+			return s.invalidate(ctx, e.PostID)
+		})
+		events.SubscribeAsync(bus, "post cache", func(ctx context.Context, e events.PostUnreacted) error {
+			return s.invalidate(ctx, e.PostID)
+		})
+	}
+	return s
+}
+
+func (s *cachedPostService) GetAllPosts(ctx context.Context) ([]models.Post, error) {
+	var posts []models.Post
+	err := s.load(ctx, postListCacheKey, &posts, func(ctx context.Context) (any, error) {
+		return s.next.GetAllPosts(ctx)
+	})
+	return posts, err
+}
+
+func (s *cachedPostService) GetPostByID(ctx context.Context, id int) (*models.Post, error) {
+	var post *models.Post
+	err := s.load(ctx, postCacheKey(id), &post, func(ctx context.Context) (any, error) {
+		return s.next.GetPostByID(ctx, id)
+	})
+	return post, err
+}
+
+// GetPostsByAuthors is not cached: its results depend on the whole set of
+// authors asked for.
+func (s *cachedPostService) GetPostsByAuthors(ctx context.Context, authorIDs []int, limit int) ([]models.Post, error) {
+	return s.next.GetPostsByAuthors(ctx, authorIDs, limit)
+}
+
+func (s *cachedPostService) GetPublishedPage(ctx context.Context, afterID, limit int) ([]models.Post, error) {
+	return s.next.GetPublishedPage(ctx, afterID, limit)
+}
+
+func (s *cachedPostService) CreatePost(ctx context.Context, post *models.Post) (*models.Post, error) {
+	created, err := s.next.CreatePost(ctx, post)
+	if err == nil {
+		s.invalidateNow(ctx, created.ID)
+	}
+	return created, err
+}
+
+func (s *cachedPostService) UpdatePost(ctx context.Context, id int, post *models.Post) (*models.Post, error) {
+	updated, err := s.next.UpdatePost(ctx, id, post)
+	if err == nil {
+		s.invalidateNow(ctx, id)
+	}
+	return updated, err
+}
+
+func (s *cachedPostService) DeletePost(ctx context.Context, id int) error {
+	err := s.next.DeletePost(ctx, id)
+	if err == nil {
+		s.invalidateNow(ctx, id)
+	}
+	return err
+}
+
+func (s *cachedPostService) ImportPost(ctx context.Context, post *models.Post) (*models.Post, error) {
+	created, err := s.next.ImportPost(ctx, post)
+	if err == nil {
+		s.invalidateNow(ctx, created.ID)
+	}
+	return created, err
+}
+
+// load decodes the cached value of key into dst, or runs fetch and caches
+// what it returns. Values round-trip through JSON even on a miss, so every
+// caller gets its own copy to modify.
+func (s *cachedPostService) load(ctx context.Context, key string, dst any, fetch func(context.Context) (any, error)) error {
+	data, ok, err := s.store.Get(ctx, key)
+	if err != nil {
+		logging.FromContext(ctx).WarnContext(ctx, "post cache lookup failed", "key", key, "error", err)
+	}
+	s.metrics.CacheLookup("posts", ok)
+	if !ok {
+		generation := s.generation.Load()
+		flight := key + "@" + strconv.FormatUint(generation, 10)
+		value, err, _ := s.flights.Do(flight, func() (any, error) {
+			// Callers that joined must not fail because the one that
+			// started the load went away.
+			ctx := context.WithoutCancel(ctx)
+			value, err := fetch(ctx)
+			if err != nil {
+				return nil, err
+			}
+			data, err := json.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+			if s.generation.Load() == generation {
+				if err := s.store.Set(ctx, key, data, s.ttl); err != nil {
+					logging.FromContext(ctx).WarnContext(ctx, "post cache store failed", "key", key, "error", err)
+				}
+			}
+			return data, nil
+		})
+		if err != nil {
+			return err
+		}
+		data = value.([]byte)
+	}
+	return json.Unmarshal(data, dst)
+}
+
+// invalidate drops the listing and the post with id. The listing holds
+// every post, so any change to one makes it stale.
+func (s *cachedPostService) invalidate(ctx context.Context, id int) error {
+	s.generation.Add(1)
+	return s.store.Delete(ctx, postListCacheKey, postCacheKey(id))
+}
+
+// invalidateNow is invalidate for the write paths, whose change has already
+// been made: a failure to reach the store is logged rather than returned.
+func (s *cachedPostService) invalidateNow(ctx context.Context, id int) {
+	if err := s.invalidate(ctx, id); err != nil {
+		logging.FromContext(ctx).ErrorContext(ctx, "post cache invalidation failed", "post_id", id, "error", err)
+	}
+}
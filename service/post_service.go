@@ -1,43 +1,128 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"go-blog/events"
+	"go-blog/logging"
+	"go-blog/metrics"
 	"go-blog/models"
 	"go-blog/repo"
+	"regexp"
+	"slices"
 	"strings"
+	"time"
 )
 
 type PostService interface {
-	GetAllPosts() ([]models.Post, error)
-	GetPostByID(id int) (*models.Post, error)
-	CreatePost(post *models.Post) (*models.Post, error)
-	UpdatePost(id int, post *models.Post) (*models.Post, error)
-	DeletePost(id int) error
+	GetAllPosts(ctx context.Context) ([]models.Post, error)
+	GetPostByID(ctx context.Context, id int) (*models.Post, error)
+	CreatePost(ctx context.Context, post *models.Post) (*models.Post, error)
+	UpdatePost(ctx context.Context, id int, post *models.Post) (*models.Post, error)
+	DeletePost(ctx context.Context, id int) error
+	// GetPostsByAuthors returns the newest limit published posts of each
+	// author, newest first, so callers can batch per-author lookups.
+	GetPostsByAuthors(ctx context.Context, authorIDs []int, limit int) ([]models.Post, error)
+	// GetPublishedPage returns up to limit published posts with an id above
+	// afterID, in id order.
+	GetPublishedPage(ctx context.Context, afterID, limit int) ([]models.Post, error)
+	// ImportPost creates a post with the same validation as CreatePost but
+	// keeps the dates it was given, so imported history is preserved.
+	ImportPost(ctx context.Context, post *models.Post) (*models.Post, error)
 }
 
 type postService struct {
-	repo repo.PostRepository
+	repo    repo.PostRepository
+	media   repo.MediaRepository
+	tx      repo.Transactor
+	bus     *events.Bus
+	metrics *metrics.Metrics
 }
 
-func NewPostService(repo repo.PostRepository) PostService {
-	return &postService{repo: repo}
+// NewPostService publishes post events on bus, which may be nil, in the
+// same transaction as the change.
+func NewPostService(repo repo.PostRepository, media repo.MediaRepository, tx repo.Transactor, bus *events.Bus, metrics *metrics.Metrics) PostService {
+	return &postService{repo: repo, media: media, tx: tx, bus: bus, metrics: metrics}
 }
 
-func (s *postService) GetAllPosts() ([]models.Post, error) {
-	return s.repo.ListPosts()
+func (s *postService) GetAllPosts(ctx context.Context) ([]models.Post, error) {
+	return s.repo.ListPosts(ctx)
 }
 
-func (s *postService) CreatePost(post *models.Post) (*models.Post, error) {
+func (s *postService) CreatePost(ctx context.Context, post *models.Post) (*models.Post, error) {
+	// Server-managed fields are never taken from the request.
+	post.PublishedAt = nil
+	post.CreatedAt, post.UpdatedAt = time.Time{}, time.Time{}
+	return s.create(ctx, post, time.Now())
+}
+
+func (s *postService) ImportPost(ctx context.Context, post *models.Post) (*models.Post, error) {
+	if post.CreatedAt.IsZero() {
+		post.CreatedAt = time.Now()
+	}
+	post.CreatedAt = post.CreatedAt.UTC().Truncate(time.Microsecond)
+	post.UpdatedAt = post.UpdatedAt.UTC().Truncate(time.Microsecond)
+	if post.UpdatedAt.Before(post.CreatedAt) {
+		post.UpdatedAt = post.CreatedAt
+	}
+	return s.create(ctx, post, post.CreatedAt)
+}
+
+// create validates post and stores it. A published post without a publish
+// date is stamped with now.
+func (s *postService) create(ctx context.Context, post *models.Post, now time.Time) (*models.Post, error) {
 	if strings.TrimSpace(post.Title) == "" {
 		return nil, errors.New("title cannot be empty")
 	}
 	if strings.TrimSpace(post.Content) == "" {
 		return nil, errors.New("content cannot be empty")
 	}
+	status := post.Status
+	if status == "" {
+		status = models.PostStatusPublished
+	}
+	if !status.Valid() {
+		return nil, models.ErrInvalidStatus
+	}
+	post.ID = 0
+	post.Reactions = models.ReactionCounts{}
+	post.Status = ""
+	publishedAt := post.PublishedAt
+	post.PublishedAt = nil
+	post.SetStatus(status, now)
+	if post.IsPublished() && publishedAt != nil {
+		at := publishedAt.UTC().Truncate(time.Microsecond)
+		post.PublishedAt = &at
+	}
+	tags, err := NormalizeTags(post.Tags)
+	if err != nil {
+		return nil, err
+	}
+	post.Tags = tags
+	if post.Slug, err = s.resolveSlug(ctx, post.UserID, 0, post.Slug, post.Title); err != nil {
+		return nil, err
+	}
+	if post.MediaIDs, err = checkPostMedia(ctx, s.media, post.UserID, post.MediaIDs); err != nil {
+		return nil, err
+	}
 
-	createdPost, err := s.repo.CreatePost(post)
+	var createdPost *models.Post
+	err = s.tx.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+		if createdPost, err = s.repo.CreatePost(ctx, post); err != nil {
+			return err
+		}
+		if err := s.bus.Publish(ctx, events.PostCreated{Post: *createdPost}); err != nil {
+			return err
+		}
+		if createdPost.IsPublished() {
+			return s.bus.Publish(ctx, events.PostPublished{Post: *createdPost})
+		}
+		return nil
+	})
 	if err != nil {
+		logging.FromContext(ctx).ErrorContext(ctx, "create post failed", "user_id", post.UserID, "error", err)
 		return nil, fmt.Errorf("failed to create post: %w", err)
 	}
 
@@ -49,10 +134,12 @@ func (s *postService) CreatePost(post *models.Post) (*models.Post, error) {
 		return nil, errors.New("post creation failed - data mismatch")
 	}
 
+	s.metrics.PostCreated()
+	logging.FromContext(ctx).InfoContext(ctx, "post created", "post_id", createdPost.ID, "user_id", createdPost.UserID)
 	return createdPost, nil
 }
 
-func (s *postService) UpdatePost(id int, post *models.Post) (*models.Post, error) {
+func (s *postService) UpdatePost(ctx context.Context, id int, post *models.Post) (*models.Post, error) {
 	if strings.TrimSpace(post.Title) == "" {
 		return nil, errors.New("title cannot be empty")
 	}
@@ -60,16 +147,59 @@ func (s *postService) UpdatePost(id int, post *models.Post) (*models.Post, error
 		return nil, errors.New("content cannot be empty")
 	}
 
-	beforePosts, err := s.repo.GetPost(id)
+	beforePosts, err := s.repo.GetPost(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("post with ID %d does not exist", id)
 	}
 	if beforePosts == nil {
 		return nil, fmt.Errorf("post with ID %d not found", id)
 	}
+	wasPublished := beforePosts.IsPublished()
+	if post.Status != "" {
+		if !post.Status.Valid() {
+			return nil, models.ErrInvalidStatus
+		}
+		beforePosts.SetStatus(post.Status, time.Now())
+		post.PublishedAt = beforePosts.PublishedAt
+	}
+	if post.Tags != nil {
+		tags, err := NormalizeTags(post.Tags)
+		if err != nil {
+			return nil, err
+		}
+		post.Tags = tags
+	}
+	if post.Slug != "" {
+		if post.Slug, err = s.resolveSlug(ctx, beforePosts.UserID, id, post.Slug, ""); err != nil {
+			return nil, err
+		}
+	}
+	if post.MediaIDs != nil {
+		// Only the post's author may attach media, and only their own.
+		if post.MediaIDs, err = checkPostMedia(ctx, s.media, beforePosts.UserID, post.MediaIDs); err != nil {
+			return nil, err
+		}
+	}
 
-	updatedPost, err := s.repo.Update(id, post)
+	var updatedPost *models.Post
+	err = s.tx.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+		if updatedPost, err = s.repo.Update(ctx, id, post); err != nil {
+			return err
+		}
+		if err := s.bus.Publish(ctx, events.PostUpdated{Post: *updatedPost}); err != nil {
+			return err
+		}
+		switch {
+		case updatedPost.IsPublished() && !wasPublished:
+			return s.bus.Publish(ctx, events.PostPublished{Post: *updatedPost})
+		case !updatedPost.IsPublished() && wasPublished:
+			return s.bus.Publish(ctx, events.PostUnpublished{Post: *updatedPost})
+		}
+		return nil
+	})
 	if err != nil {
+		logging.FromContext(ctx).ErrorContext(ctx, "update post failed", "post_id", id, "error", err)
 		return nil, fmt.Errorf("failed to update post: %w", err)
 	}
 
@@ -85,11 +215,12 @@ func (s *postService) UpdatePost(id int, post *models.Post) (*models.Post, error
 		return nil, errors.New("post update failed - ID mismatch")
 	}
 
+	logging.FromContext(ctx).InfoContext(ctx, "post updated", "post_id", id)
 	return updatedPost, nil
 }
 
-func (s *postService) DeletePost(id int) error {
-	Prevpost, err := s.repo.GetPost(id)
+func (s *postService) DeletePost(ctx context.Context, id int) error {
+	Prevpost, err := s.repo.GetPost(ctx, id)
 	if err != nil {
 		return fmt.Errorf("post with ID %d does not exist to delete", id)
 	}
@@ -97,21 +228,28 @@ func (s *postService) DeletePost(id int) error {
 		return fmt.Errorf("post with ID %d not found", id)
 	}
 
-	err = s.repo.DeletePost(id)
+	err = s.tx.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.DeletePost(ctx, id); err != nil {
+			return err
+		}
+		return s.bus.Publish(ctx, events.PostDeleted{Post: *Prevpost})
+	})
 	if err != nil {
+		logging.FromContext(ctx).ErrorContext(ctx, "delete post failed", "post_id", id, "error", err)
 		return fmt.Errorf("failed to delete post: %w", err)
 	}
 
-	afterPosts, err := s.repo.GetPost(id)
+	afterPosts, err := s.repo.GetPost(ctx, id)
 	if err == nil && afterPosts != nil {
 		return fmt.Errorf("deletion failed for %d", id)
 	}
 
+	logging.FromContext(ctx).InfoContext(ctx, "post deleted", "post_id", id)
 	return nil
 }
 
-func (s *postService) GetPostByID(id int) (*models.Post, error) {
-	post, err := s.repo.GetPost(id)
+func (s *postService) GetPostByID(ctx context.Context, id int) (*models.Post, error) {
+	post, err := s.repo.GetPost(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("post not found %d", id)
 	}
@@ -120,3 +258,76 @@ func (s *postService) GetPostByID(id int) (*models.Post, error) {
 	}
 	return post, nil
 }
+
+func (s *postService) GetPostsByAuthors(ctx context.Context, authorIDs []int, limit int) ([]models.Post, error) {
+	return s.repo.ListPublishedPerAuthor(ctx, authorIDs, limit)
+}
+
+func (s *postService) GetPublishedPage(ctx context.Context, afterID, limit int) ([]models.Post, error) {
+	return s.repo.ListPublishedPage(ctx, afterID, limit)
+}
+
+// resolveSlug returns the slug for a post by userID. An explicit slug is
+// normalised and must not belong to another of their posts; without one, a
+// slug is derived from the title and numbered until it is free.
+func (s *postService) resolveSlug(ctx context.Context, userID, postID int, slug, title string) (string, error) {
+	if slug != "" {
+		slug = Slugify(slug)
+		if slug == "" {
+			return "", models.ErrInvalidSlug
+		}
+		existing, err := s.repo.GetPostBySlug(ctx, userID, slug)
+		switch {
+		case errors.Is(err, models.ErrPostNotFound):
+			return slug, nil
+		case err != nil:
+			return "", err
+		case existing.ID != postID:
+			return "", models.ErrSlugTaken
+		}
+		return slug, nil
+	}
+
+	base := Slugify(title)
+	if base == "" {
+		base = "post"
+	}
+	candidate := base
+	for n := 2; ; n++ {
+		_, err := s.repo.GetPostBySlug(ctx, userID, candidate)
+		if errors.Is(err, models.ErrPostNotFound) {
+			return candidate, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		candidate = fmt.Sprintf("%s-%d", base, n)
+	}
+}
+
+const maxTagsPerPost = 10
+
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,49}$`)
+
+// NormalizeTags lower-cases tags, turns spaces into dashes, drops duplicates
+// and sorts them, so "Go Lang" and "go-lang" are the same tag.
+func NormalizeTags(tags []string) ([]string, error) {
+	normalized := []string{}
+	for _, tag := range tags {
+		tag = strings.Join(strings.Fields(strings.ToLower(tag)), "-")
+		if tag == "" {
+			continue
+		}
+		if !tagPattern.MatchString(tag) {
+			return nil, fmt.Errorf("%w: %q", models.ErrInvalidTag, tag)
+		}
+		if !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	if len(normalized) > maxTagsPerPost {
+		return nil, fmt.Errorf("%w: at most %d tags per post", models.ErrInvalidTag, maxTagsPerPost)
+	}
+	slices.Sort(normalized)
+	return normalized, nil
+}
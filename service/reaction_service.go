@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"go-blog/events"
+	"go-blog/logging"
+	"go-blog/models"
+	"go-blog/repo"
+	"log/slog"
+	"time"
+)
+
+type ReactionService interface {
+	React(ctx context.Context, postID, userID int, reactionType models.ReactionType) (*models.ReactionSummary, error)
+	Unreact(ctx context.Context, postID, userID int, reactionType models.ReactionType) (*models.ReactionSummary, error)
+	AttachUserReactions(ctx context.Context, userID int, posts []models.Post) error
+	ReconcileCounts(ctx context.Context) (int64, error)
+}
+
+type reactionService struct {
+	posts     repo.PostRepository
+	reactions repo.ReactionRepository
+	tx        repo.Transactor
+	bus       *events.Bus
+}
+
+func NewReactionService(posts repo.PostRepository, reactions repo.ReactionRepository, tx repo.Transactor, bus *events.Bus) ReactionService {
+	return &reactionService{posts: posts, reactions: reactions, tx: tx, bus: bus}
+}
+
+func (s *reactionService) React(ctx context.Context, postID, userID int, reactionType models.ReactionType) (*models.ReactionSummary, error) {
+	if !reactionType.Valid() {
+		return nil, models.ErrInvalidReaction
+	}
+	added := false
+	err := s.tx.Transaction(ctx, func(ctx context.Context) error {
+		post, err := s.posts.GetPost(ctx, postID)
+		if err != nil {
+			return err
+		}
+		// Only the author may react to a draft; to anyone else it does not
+		// exist.
+		if !post.IsPublished() && post.UserID != userID {
+			return models.ErrPostNotFound
+		}
+		added, err = s.reactions.AddReaction(ctx, postID, userID, reactionType)
+		if err != nil || !added {
+			return err
+		}
+		return s.bus.Publish(ctx, events.PostReacted{PostID: postID, PostOwnerID: post.UserID, UserID: userID, Type: reactionType})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if added {
+		logging.FromContext(ctx).InfoContext(ctx, "reaction added", "post_id", postID, "user_id", userID, "type", reactionType)
+	}
+	return s.summary(ctx, postID, userID)
+}
+
+func (s *reactionService) Unreact(ctx context.Context, postID, userID int, reactionType models.ReactionType) (*models.ReactionSummary, error) {
+	removed := false
+	err := s.tx.Transaction(ctx, func(ctx context.Context) error {
+		post, err := s.posts.GetPost(ctx, postID)
+		if err != nil {
+			return err
+		}
+		// As in React, a draft does not exist to anyone but its author.
+		if !post.IsPublished() && post.UserID != userID {
+			return models.ErrPostNotFound
+		}
+		removed, err = s.reactions.RemoveReaction(ctx, postID, userID, reactionType)
+		if err != nil || !removed {
+			return err
+		}
+		return s.bus.Publish(ctx, events.PostUnreacted{PostID: postID, UserID: userID, Type: reactionType})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if removed {
+		logging.FromContext(ctx).InfoContext(ctx, "reaction removed", "post_id", postID, "user_id", userID, "type", reactionType)
+	}
+	return s.summary(ctx, postID, userID)
+}
+
+// AttachUserReactions fills MyReactions on each post with the reactions the
+// given user has left, using a single query for the whole page.
+func (s *reactionService) AttachUserReactions(ctx context.Context, userID int, posts []models.Post) error {
+	ids := make([]int, len(posts))
+	for i := range posts {
+		ids[i] = posts[i].ID
+	}
+	byPost, err := s.reactions.UserReactions(ctx, userID, ids)
+	if err != nil {
+		return err
+	}
+	for i := range posts {
+		posts[i].MyReactions = byPost[posts[i].ID]
+		if posts[i].MyReactions == nil {
+			posts[i].MyReactions = []models.ReactionType{}
+		}
+	}
+	return nil
+}
+
+func (s *reactionService) ReconcileCounts(ctx context.Context) (int64, error) {
+	return s.reactions.ReconcileCounts(ctx)
+}
+
+func (s *reactionService) summary(ctx context.Context, postID, userID int) (*models.ReactionSummary, error) {
+	post, err := s.posts.GetPost(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	posts := []models.Post{*post}
+	if err := s.AttachUserReactions(ctx, userID, posts); err != nil {
+		return nil, err
+	}
+	return &models.ReactionSummary{PostID: postID, Reactions: posts[0].Reactions, MyReactions: posts[0].MyReactions}, nil
+}
+
+// ReactionReconciler periodically repairs counter drift, for example after
+// reactions were edited by hand or a counter update was lost.
+type ReactionReconciler struct {
+	service  ReactionService
+	interval time.Duration
+	logger   *slog.Logger
+}
+
+func NewReactionReconciler(service ReactionService, interval time.Duration, logger *slog.Logger) *ReactionReconciler {
+	return &ReactionReconciler{service: service, interval: interval, logger: logger}
+}
+
+func (r *ReactionReconciler) Name() string {
+	return "reaction reconciler"
+}
+
+func (r *ReactionReconciler) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			fixed, err := r.service.ReconcileCounts(ctx)
+			if err != nil {
+				r.logger.ErrorContext(ctx, "reconciling reaction counts", "error", err)
+				continue
+			}
+			if fixed > 0 {
+				r.logger.WarnContext(ctx, "repaired reaction counts", "posts", fixed)
+			}
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"go-blog/logging"
+	"go-blog/models"
+	"go-blog/repo"
+	"strings"
+)
+
+type ReadingListService interface {
+	Lists(ctx context.Context, userID int) ([]models.ReadingList, error)
+	CreateList(ctx context.Context, userID int, req models.ReadingListRequest) (*models.ReadingList, error)
+	GetList(ctx context.Context, userID, listID int) (*models.ReadingListDetail, error)
+	UpdateList(ctx context.Context, userID, listID int, req models.ReadingListRequest) (*models.ReadingList, error)
+	DeleteList(ctx context.Context, userID, listID int) error
+	SharedList(ctx context.Context, token string) (*models.ReadingListDetail, error)
+
+	AddPost(ctx context.Context, userID, listID int, req models.ReadingListItemRequest) (*models.ReadingListDetail, error)
+	UpdateItem(ctx context.Context, userID, listID, postID int, req models.ReadingListItemRequest) (*models.ReadingListDetail, error)
+	RemovePost(ctx context.Context, userID, listID, postID int) error
+}
+
+type readingListService struct {
+	lists repo.ReadingListRepository
+	posts repo.PostRepository
+}
+
+func NewReadingListService(lists repo.ReadingListRepository, posts repo.PostRepository) ReadingListService {
+	return &readingListService{lists: lists, posts: posts}
+}
+
+// Lists returns the user's lists, creating the default "Read later" list
+// the first time it is needed.
+func (s *readingListService) Lists(ctx context.Context, userID int) ([]models.ReadingList, error) {
+	lists, err := s.lists.ListsForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, list := range lists {
+		if list.IsDefault {
+			return lists, nil
+		}
+	}
+	err = s.lists.CreateList(ctx, &models.ReadingList{UserID: userID, Name: models.DefaultReadingListName, IsDefault: true})
+	// A concurrent request may have created it first; either way it exists now.
+	if err != nil && !errors.Is(err, models.ErrReadingListNameExists) {
+		return nil, err
+	}
+	return s.lists.ListsForUser(ctx, userID)
+}
+
+func (s *readingListService) CreateList(ctx context.Context, userID int, req models.ReadingListRequest) (*models.ReadingList, error) {
+	if req.Name == nil || strings.TrimSpace(*req.Name) == "" {
+		return nil, models.ErrReadingListNameEmpty
+	}
+	if _, err := s.Lists(ctx, userID); err != nil {
+		return nil, err
+	}
+	list := &models.ReadingList{UserID: userID, Name: strings.TrimSpace(*req.Name)}
+	if req.Shared != nil && *req.Shared {
+		token, err := newShareToken()
+		if err != nil {
+			return nil, err
+		}
+		list.ShareToken = &token
+	}
+	if err := s.lists.CreateList(ctx, list); err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx).InfoContext(ctx, "reading list created", "list_id", list.ID, "user_id", userID)
+	return list, nil
+}
+
+func (s *readingListService) GetList(ctx context.Context, userID, listID int) (*models.ReadingListDetail, error) {
+	list, err := s.ownedList(ctx, userID, listID)
+	if err != nil {
+		return nil, err
+	}
+	return s.detail(ctx, list)
+}
+
+func (s *readingListService) UpdateList(ctx context.Context, userID, listID int, req models.ReadingListRequest) (*models.ReadingList, error) {
+	list, err := s.ownedList(ctx, userID, listID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Name != nil {
+		if strings.TrimSpace(*req.Name) == "" {
+			return nil, models.ErrReadingListNameEmpty
+		}
+		list.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Shared != nil {
+		switch {
+		case *req.Shared && !list.Shared():
+			token, err := newShareToken()
+			if err != nil {
+				return nil, err
+			}
+			list.ShareToken = &token
+		case !*req.Shared:
+			// Unsharing revokes the old link; sharing again issues a new one.
+			list.ShareToken = nil
+		}
+	}
+	if err := s.lists.UpdateList(ctx, list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (s *readingListService) DeleteList(ctx context.Context, userID, listID int) error {
+	list, err := s.ownedList(ctx, userID, listID)
+	if err != nil {
+		return err
+	}
+	if list.IsDefault {
+		return models.ErrDefaultReadingList
+	}
+	return s.lists.DeleteList(ctx, listID)
+}
+
+func (s *readingListService) SharedList(ctx context.Context, token string) (*models.ReadingListDetail, error) {
+	list, err := s.lists.GetListByShareToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	detail, err := s.detail(ctx, list)
+	if err != nil {
+		return nil, err
+	}
+	// The token is the owner's secret to revoke; visitors don't need it.
+	detail.ShareToken = nil
+	return detail, nil
+}
+
+func (s *readingListService) AddPost(ctx context.Context, userID, listID int, req models.ReadingListItemRequest) (*models.ReadingListDetail, error) {
+	list, err := s.ownedList(ctx, userID, listID)
+	if err != nil {
+		return nil, err
+	}
+	post, err := s.posts.GetPost(ctx, req.PostID)
+	if err != nil {
+		return nil, err
+	}
+	if !post.IsPublished() && post.UserID != userID {
+		return nil, models.ErrPostNotFound
+	}
+
+	item := &models.ReadingListItem{ListID: list.ID, PostID: post.ID}
+	if req.Note != nil {
+		item.Note = *req.Note
+	}
+	if err := s.lists.AddItem(ctx, item); err != nil {
+		return nil, err
+	}
+	if req.Position != nil {
+		if err := s.lists.MoveItem(ctx, list.ID, post.ID, *req.Position); err != nil {
+			return nil, err
+		}
+	}
+	return s.detail(ctx, list)
+}
+
+func (s *readingListService) UpdateItem(ctx context.Context, userID, listID, postID int, req models.ReadingListItemRequest) (*models.ReadingListDetail, error) {
+	list, err := s.ownedList(ctx, userID, listID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Note != nil {
+		if err := s.lists.UpdateNote(ctx, list.ID, postID, *req.Note); err != nil {
+			return nil, err
+		}
+	}
+	if req.Position != nil {
+		if err := s.lists.MoveItem(ctx, list.ID, postID, *req.Position); err != nil {
+			return nil, err
+		}
+	}
+	return s.detail(ctx, list)
+}
+
+func (s *readingListService) RemovePost(ctx context.Context, userID, listID, postID int) error {
+	list, err := s.ownedList(ctx, userID, listID)
+	if err != nil {
+		return err
+	}
+	return s.lists.RemoveItem(ctx, list.ID, postID)
+}
+
+// ownedList hides other users' lists behind the same not-found error so list
+// ids cannot be probed.
+func (s *readingListService) ownedList(ctx context.Context, userID, listID int) (*models.ReadingList, error) {
+	list, err := s.lists.GetList(ctx, listID)
+	if err != nil {
+		return nil, err
+	}
+	if list.UserID != userID {
+		return nil, models.ErrReadingListNotFound
+	}
+	return list, nil
+}
+
+func (s *readingListService) detail(ctx context.Context, list *models.ReadingList) (*models.ReadingListDetail, error) {
+	items, err := s.lists.Items(ctx, list.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ReadingListDetail{ReadingList: *list, Items: items}, nil
+}
+
+func newShareToken() (string, error) {
+	b := make([]byte, 18)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
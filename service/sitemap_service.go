@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go-blog/config"
+	"go-blog/logging"
+	"go-blog/models"
+	"go-blog/repo"
+	"go-blog/sitemap"
+	"strings"
+	"sync"
+)
+
+const sitemapBatchSize = 1000
+
+type SitemapService interface {
+	// Sitemap returns the current sitemap and the fingerprint it was built
+	// from, rebuilding it only when published posts have changed.
+	Sitemap(ctx context.Context) (*sitemap.Sitemap, models.PostStats, error)
+	Robots() string
+}
+
+type sitemapService struct {
+	site  config.SiteConfig
+	posts repo.PostRepository
+
+	mu          sync.Mutex
+	cached      *sitemap.Sitemap
+	fingerprint models.PostStats
+}
+
+func NewSitemapService(site config.SiteConfig, posts repo.PostRepository) SitemapService {
+	return &sitemapService{site: site, posts: posts}
+}
+
+func (s *sitemapService) Sitemap(ctx context.Context) (*sitemap.Sitemap, models.PostStats, error) {
+	stats, err := s.posts.PublishedStats(ctx, models.PostFilter{})
+	if err != nil {
+		return nil, stats, err
+	}
+
+	// Holding the lock while building means concurrent requests after a
+	// change wait for one rebuild instead of each starting their own.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cached != nil && s.fingerprint == stats {
+		return s.cached, stats, nil
+	}
+
+	built, err := s.build(ctx)
+	if err != nil {
+		return nil, stats, err
+	}
+	s.cached, s.fingerprint = built, stats
+	logging.FromContext(ctx).InfoContext(ctx, "sitemap rebuilt", "posts", stats.Count, "files", len(built.Chunks))
+	return built, stats, nil
+}
+
+func (s *sitemapService) build(ctx context.Context) (*sitemap.Sitemap, error) {
+	builder := sitemap.NewBuilder(s.site.SitemapChunkSize, func(n int) string {
+		return s.site.URL(fmt.Sprintf("/sitemaps/sitemap-%d.xml.gz", n))
+	})
+	afterID := 0
+	for {
+		batch, err := s.posts.ListPublishedAfterID(ctx, afterID, sitemapBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, post := range batch {
+			if err := builder.Add(sitemap.URL{Loc: PostURL(s.site, post.ID), LastMod: post.UpdatedAt}); err != nil {
+				return nil, err
+			}
+		}
+		if len(batch) < sitemapBatchSize {
+			break
+		}
+		afterID = batch[len(batch)-1].ID
+	}
+	return builder.Finish()
+}
+
+func (s *sitemapService) Robots() string {
+	var b strings.Builder
+	b.WriteString("User-agent: *\n")
+	disallowed := false
+	for _, path := range strings.Split(s.site.RobotsDisallow, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			b.WriteString("Disallow: " + path + "\n")
+			disallowed = true
+		}
+	}
+	if !disallowed {
+		b.WriteString("Disallow:\n")
+	}
+	b.WriteString("\nSitemap: " + s.site.URL("/sitemap.xml") + "\n")
+	return b.String()
+}
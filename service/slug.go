@@ -0,0 +1,41 @@
+package service
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const maxSlugLength = 80
+
+// Slugify reduces s to lower-case ASCII letters, digits and single dashes,
+// dropping accents first so "Crème brûlée" becomes "creme-brulee". It
+// returns "" when nothing usable is left.
+func Slugify(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range norm.NFKD.String(strings.ToLower(s)) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		case unicode.Is(unicode.Mn, r):
+			// Combining marks left behind by NFKD.
+		default:
+			dash = true
+		}
+	}
+	slug := b.String()
+	if len(slug) > maxSlugLength {
+		slug = slug[:maxSlugLength]
+		if cut := strings.LastIndexByte(slug, '-'); cut > maxSlugLength/2 {
+			slug = slug[:cut]
+		}
+		slug = strings.TrimRight(slug, "-")
+	}
+	return slug
+}
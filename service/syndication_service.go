@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go-blog/config"
+	"go-blog/models"
+	"go-blog/repo"
+	"go-blog/syndication"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// FeedScope selects the posts a feed covers: the whole blog, one author or
+// one tag.
+type FeedScope struct {
+	Author string
+	Tag    string
+}
+
+// Path is the site-relative location of the scope's feeds, without the
+// format extension.
+func (s FeedScope) Path() string {
+	switch {
+	case s.Author != "":
+		return "/authors/" + url.PathEscape(s.Author) + "/feed"
+	case s.Tag != "":
+		return "/tags/" + url.PathEscape(s.Tag) + "/feed"
+	}
+	return "/feed"
+}
+
+type SyndicationService interface {
+	// Stats is cheap enough to run on every poll; handlers derive the
+	// validators from it before deciding whether to build the feed.
+	Stats(ctx context.Context, scope FeedScope) (models.PostStats, error)
+	Feed(ctx context.Context, scope FeedScope, selfLink string) (*syndication.Feed, error)
+}
+
+type syndicationService struct {
+	site  config.SiteConfig
+	posts repo.PostRepository
+	users repo.UserRepository
+}
+
+func NewSyndicationService(site config.SiteConfig, posts repo.PostRepository, users repo.UserRepository) SyndicationService {
+	return &syndicationService{site: site, posts: posts, users: users}
+}
+
+func (s *syndicationService) Stats(ctx context.Context, scope FeedScope) (models.PostStats, error) {
+	filter, err := s.filter(ctx, scope)
+	if err != nil {
+		return models.PostStats{}, err
+	}
+	return s.posts.PublishedStats(ctx, filter)
+}
+
+func (s *syndicationService) Feed(ctx context.Context, scope FeedScope, selfLink string) (*syndication.Feed, error) {
+	filter, err := s.filter(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+	posts, err := s.posts.ListPublished(ctx, filter, s.site.FeedItems)
+	if err != nil {
+		return nil, err
+	}
+	authorIDs := make([]int, len(posts))
+	for i, post := range posts {
+		authorIDs[i] = post.UserID
+	}
+	authors, err := s.users.UsernamesByID(ctx, authorIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	feed := &syndication.Feed{
+		ID:          s.site.URL(scope.Path()),
+		Title:       s.site.Title,
+		Description: s.site.Description,
+		Link:        s.site.BaseURL,
+		SelfLink:    selfLink,
+		Items:       make([]syndication.Item, 0, len(posts)),
+	}
+	switch {
+	case scope.Author != "":
+		feed.Title = fmt.Sprintf("%s: posts by %s", s.site.Title, scope.Author)
+	case scope.Tag != "":
+		feed.Title = fmt.Sprintf("%s: posts tagged %s", s.site.Title, scope.Tag)
+	}
+	for _, post := range posts {
+		if post.UpdatedAt.After(feed.Updated) {
+			feed.Updated = post.UpdatedAt
+		}
+		feed.Items = append(feed.Items, syndication.Item{
+			ID:         syndication.TagURI(s.site.BaseURL, post.CreatedAt, "post-"+strconv.Itoa(post.ID)),
+			Title:      post.Title,
+			Link:       PostURL(s.site, post.ID),
+			Author:     authors[post.UserID],
+			Published:  *post.PublishedAt,
+			Updated:    post.UpdatedAt,
+			Summary:    syndication.Summarize(post.Content, 280),
+			HTML:       syndication.TextToHTML(post.Content),
+			Categories: post.Tags,
+		})
+	}
+	return feed, nil
+}
+
+func (s *syndicationService) filter(ctx context.Context, scope FeedScope) (models.PostFilter, error) {
+	filter := models.PostFilter{Tag: strings.ToLower(scope.Tag)}
+	if scope.Author != "" {
+		author, err := s.users.GetUserByUsername(ctx, scope.Author)
+		if err != nil {
+			return filter, err
+		}
+		filter.AuthorID = author.ID
+	}
+	return filter, nil
+}
+
+// PostURL is the canonical public link to a post.
+func PostURL(site config.SiteConfig, postID int) string {
+	return site.URL("/posts/" + strconv.Itoa(postID))
+}
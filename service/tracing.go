@@ -0,0 +1,385 @@
+package service
+
+import (
+	"context"
+	"go-blog/models"
+	"go-blog/storage"
+	"go-blog/syndication"
+	"go-blog/tracing"
+	"io"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startSpan looks the tracer up on every call so a provider installed after
+// package initialisation (per test suite, for example) is always honoured.
+func startSpan(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer("go-blog/service").Start(ctx, name, opts...)
+}
+
+type tracedPostService struct {
+	next PostService
+}
+
+// NewTracedPostService wraps every PostService method in a span.
+func NewTracedPostService(next PostService) PostService {
+	return &tracedPostService{next: next}
+}
+
+func (s *tracedPostService) GetAllPosts(ctx context.Context) ([]models.Post, error) {
+	ctx, span := startSpan(ctx, "PostService.GetAllPosts")
+	posts, err := s.next.GetAllPosts(ctx)
+	span.SetAttributes(attribute.Int("posts.count", len(posts)))
+	tracing.End(span, err)
+	return posts, err
+}
+
+func (s *tracedPostService) GetPostByID(ctx context.Context, id int) (*models.Post, error) {
+	ctx, span := startSpan(ctx, "PostService.GetPostByID", trace.WithAttributes(attribute.Int("post.id", id)))
+	post, err := s.next.GetPostByID(ctx, id)
+	tracing.End(span, err)
+	return post, err
+}
+
+func (s *tracedPostService) CreatePost(ctx context.Context, post *models.Post) (*models.Post, error) {
+	ctx, span := startSpan(ctx, "PostService.CreatePost", trace.WithAttributes(attribute.Int("user.id", post.UserID)))
+	created, err := s.next.CreatePost(ctx, post)
+	if created != nil {
+		span.SetAttributes(attribute.Int("post.id", created.ID))
+	}
+	tracing.End(span, err)
+	return created, err
+}
+
+func (s *tracedPostService) UpdatePost(ctx context.Context, id int, post *models.Post) (*models.Post, error) {
+	ctx, span := startSpan(ctx, "PostService.UpdatePost", trace.WithAttributes(attribute.Int("post.id", id)))
+	updated, err := s.next.UpdatePost(ctx, id, post)
+	tracing.End(span, err)
+	return updated, err
+}
+
+func (s *tracedPostService) DeletePost(ctx context.Context, id int) error {
+	ctx, span := startSpan(ctx, "PostService.DeletePost", trace.WithAttributes(attribute.Int("post.id", id)))
+	err := s.next.DeletePost(ctx, id)
+	tracing.End(span, err)
+	return err
+}
+
+func (s *tracedPostService) GetPostsByAuthors(ctx context.Context, authorIDs []int, limit int) ([]models.Post, error) {
+	ctx, span := startSpan(ctx, "PostService.GetPostsByAuthors", trace.WithAttributes(attribute.Int("authors.count", len(authorIDs))))
+	posts, err := s.next.GetPostsByAuthors(ctx, authorIDs, limit)
+	span.SetAttributes(attribute.Int("posts.count", len(posts)))
+	tracing.End(span, err)
+	return posts, err
+}
+
+func (s *tracedPostService) GetPublishedPage(ctx context.Context, afterID, limit int) ([]models.Post, error) {
+	ctx, span := startSpan(ctx, "PostService.GetPublishedPage", trace.WithAttributes(attribute.Int("page.after_id", afterID)))
+	posts, err := s.next.GetPublishedPage(ctx, afterID, limit)
+	span.SetAttributes(attribute.Int("posts.count", len(posts)))
+	tracing.End(span, err)
+	return posts, err
+}
+
+func (s *tracedPostService) ImportPost(ctx context.Context, post *models.Post) (*models.Post, error) {
+	ctx, span := startSpan(ctx, "PostService.ImportPost", trace.WithAttributes(attribute.Int("user.id", post.UserID)))
+	created, err := s.next.ImportPost(ctx, post)
+	if created != nil {
+		span.SetAttributes(attribute.Int("post.id", created.ID))
+	}
+	tracing.End(span, err)
+	return created, err
+}
+
+type tracedUserService struct {
+	next UserService
+}
+
+// NewTracedUserService wraps every UserService method in a span.
+func NewTracedUserService(next UserService) UserService {
+	return &tracedUserService{next: next}
+}
+
+func (s *tracedUserService) Register(ctx context.Context, req *models.RegisterRequest) (*models.User, error) {
+	ctx, span := startSpan(ctx, "UserService.Register", trace.WithAttributes(attribute.String("user.account_type", string(req.AccountType))))
+	user, err := s.next.Register(ctx, req)
+	if user != nil {
+		span.SetAttributes(attribute.Int("user.id", user.ID))
+	}
+	tracing.End(span, err)
+	return user, err
+}
+
+func (s *tracedUserService) Login(ctx context.Context, username, password string) (*models.User, error) {
+	ctx, span := startSpan(ctx, "UserService.Login")
+	user, err := s.next.Login(ctx, username, password)
+	if user != nil {
+		span.SetAttributes(attribute.Int("user.id", user.ID))
+	}
+	tracing.End(span, err)
+	return user, err
+}
+
+func (s *tracedUserService) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	ctx, span := startSpan(ctx, "UserService.GetUserByUsername")
+	user, err := s.next.GetUserByUsername(ctx, username)
+	if user != nil {
+		span.SetAttributes(attribute.Int("user.id", user.ID))
+	}
+	tracing.End(span, err)
+	return user, err
+}
+
+func (s *tracedUserService) GetUsersByID(ctx context.Context, ids []int) ([]models.User, error) {
+	ctx, span := startSpan(ctx, "UserService.GetUsersByID", trace.WithAttributes(attribute.Int("users.requested", len(ids))))
+	users, err := s.next.GetUsersByID(ctx, ids)
+	span.SetAttributes(attribute.Int("users.count", len(users)))
+	tracing.End(span, err)
+	return users, err
+}
+
+type tracedReactionService struct {
+	next ReactionService
+}
+
+// NewTracedReactionService wraps every ReactionService method in a span.
+func NewTracedReactionService(next ReactionService) ReactionService {
+	return &tracedReactionService{next: next}
+}
+
+func (s *tracedReactionService) React(ctx context.Context, postID, userID int, reactionType models.ReactionType) (*models.ReactionSummary, error) {
+	ctx, span := startSpan(ctx, "ReactionService.React", trace.WithAttributes(
+		attribute.Int("post.id", postID), attribute.Int("user.id", userID), attribute.String("reaction.type", string(reactionType))))
+	summary, err := s.next.React(ctx, postID, userID, reactionType)
+	tracing.End(span, err)
+	return summary, err
+}
+
+func (s *tracedReactionService) Unreact(ctx context.Context, postID, userID int, reactionType models.ReactionType) (*models.ReactionSummary, error) {
+	ctx, span := startSpan(ctx, "ReactionService.Unreact", trace.WithAttributes(
+		attribute.Int("post.id", postID), attribute.Int("user.id", userID), attribute.String("reaction.type", string(reactionType))))
+	summary, err := s.next.Unreact(ctx, postID, userID, reactionType)
+	tracing.End(span, err)
+	return summary, err
+}
+
+func (s *tracedReactionService) AttachUserReactions(ctx context.Context, userID int, posts []models.Post) error {
+	ctx, span := startSpan(ctx, "ReactionService.AttachUserReactions", trace.WithAttributes(
+		attribute.Int("user.id", userID), attribute.Int("posts.count", len(posts))))
+	err := s.next.AttachUserReactions(ctx, userID, posts)
+	tracing.End(span, err)
+	return err
+}
+
+func (s *tracedReactionService) ReconcileCounts(ctx context.Context) (int64, error) {
+	ctx, span := startSpan(ctx, "ReactionService.ReconcileCounts")
+	fixed, err := s.next.ReconcileCounts(ctx)
+	span.SetAttributes(attribute.Int64("posts.repaired", fixed))
+	tracing.End(span, err)
+	return fixed, err
+}
+
+type tracedFollowService struct {
+	next FollowService
+}
+
+// NewTracedFollowService wraps every FollowService method in a span.
+func NewTracedFollowService(next FollowService) FollowService {
+	return &tracedFollowService{next: next}
+}
+
+func (s *tracedFollowService) Follow(ctx context.Context, followerID int, username string) error {
+	ctx, span := startSpan(ctx, "FollowService.Follow", trace.WithAttributes(attribute.Int("user.id", followerID)))
+	err := s.next.Follow(ctx, followerID, username)
+	tracing.End(span, err)
+	return err
+}
+
+func (s *tracedFollowService) Unfollow(ctx context.Context, followerID int, username string) error {
+	ctx, span := startSpan(ctx, "FollowService.Unfollow", trace.WithAttributes(attribute.Int("user.id", followerID)))
+	err := s.next.Unfollow(ctx, followerID, username)
+	tracing.End(span, err)
+	return err
+}
+
+func (s *tracedFollowService) Followers(ctx context.Context, username string) ([]models.UserProfile, error) {
+	ctx, span := startSpan(ctx, "FollowService.Followers")
+	users, err := s.next.Followers(ctx, username)
+	span.SetAttributes(attribute.Int("users.count", len(users)))
+	tracing.End(span, err)
+	return users, err
+}
+
+func (s *tracedFollowService) Following(ctx context.Context, username string) ([]models.UserProfile, error) {
+	ctx, span := startSpan(ctx, "FollowService.Following")
+	users, err := s.next.Following(ctx, username)
+	span.SetAttributes(attribute.Int("users.count", len(users)))
+	tracing.End(span, err)
+	return users, err
+}
+
+func (s *tracedFollowService) Feed(ctx context.Context, userID int, cursor string, limit int) (*models.FeedPage, error) {
+	ctx, span := startSpan(ctx, "FollowService.Feed", trace.WithAttributes(attribute.Int("user.id", userID), attribute.Int("feed.limit", limit)))
+	page, err := s.next.Feed(ctx, userID, cursor, limit)
+	if page != nil {
+		span.SetAttributes(attribute.Int("posts.count", len(page.Posts)))
+	}
+	tracing.End(span, err)
+	return page, err
+}
+
+type tracedReadingListService struct {
+	next ReadingListService
+}
+
+// NewTracedReadingListService wraps every ReadingListService method in a span.
+func NewTracedReadingListService(next ReadingListService) ReadingListService {
+	return &tracedReadingListService{next: next}
+}
+
+func listSpan(ctx context.Context, name string, userID, listID int) (context.Context, trace.Span) {
+	return startSpan(ctx, "ReadingListService."+name, trace.WithAttributes(
+		attribute.Int("user.id", userID), attribute.Int("reading_list.id", listID)))
+}
+
+func (s *tracedReadingListService) Lists(ctx context.Context, userID int) ([]models.ReadingList, error) {
+	ctx, span := listSpan(ctx, "Lists", userID, 0)
+	lists, err := s.next.Lists(ctx, userID)
+	tracing.End(span, err)
+	return lists, err
+}
+
+func (s *tracedReadingListService) CreateList(ctx context.Context, userID int, req models.ReadingListRequest) (*models.ReadingList, error) {
+	ctx, span := listSpan(ctx, "CreateList", userID, 0)
+	list, err := s.next.CreateList(ctx, userID, req)
+	tracing.End(span, err)
+	return list, err
+}
+
+func (s *tracedReadingListService) GetList(ctx context.Context, userID, listID int) (*models.ReadingListDetail, error) {
+	ctx, span := listSpan(ctx, "GetList", userID, listID)
+	detail, err := s.next.GetList(ctx, userID, listID)
+	tracing.End(span, err)
+	return detail, err
+}
+
+func (s *tracedReadingListService) UpdateList(ctx context.Context, userID, listID int, req models.ReadingListRequest) (*models.ReadingList, error) {
+	ctx, span := listSpan(ctx, "UpdateList", userID, listID)
+	list, err := s.next.UpdateList(ctx, userID, listID, req)
+	tracing.End(span, err)
+	return list, err
+}
+
+func (s *tracedReadingListService) DeleteList(ctx context.Context, userID, listID int) error {
+	ctx, span := listSpan(ctx, "DeleteList", userID, listID)
+	err := s.next.DeleteList(ctx, userID, listID)
+	tracing.End(span, err)
+	return err
+}
+
+func (s *tracedReadingListService) SharedList(ctx context.Context, token string) (*models.ReadingListDetail, error) {
+	ctx, span := startSpan(ctx, "ReadingListService.SharedList")
+	detail, err := s.next.SharedList(ctx, token)
+	tracing.End(span, err)
+	return detail, err
+}
+
+func (s *tracedReadingListService) AddPost(ctx context.Context, userID, listID int, req models.ReadingListItemRequest) (*models.ReadingListDetail, error) {
+	ctx, span := listSpan(ctx, "AddPost", userID, listID)
+	span.SetAttributes(attribute.Int("post.id", req.PostID))
+	detail, err := s.next.AddPost(ctx, userID, listID, req)
+	tracing.End(span, err)
+	return detail, err
+}
+
+func (s *tracedReadingListService) UpdateItem(ctx context.Context, userID, listID, postID int, req models.ReadingListItemRequest) (*models.ReadingListDetail, error) {
+	ctx, span := listSpan(ctx, "UpdateItem", userID, listID)
+	span.SetAttributes(attribute.Int("post.id", postID))
+	detail, err := s.next.UpdateItem(ctx, userID, listID, postID, req)
+	tracing.End(span, err)
+	return detail, err
+}
+
+func (s *tracedReadingListService) RemovePost(ctx context.Context, userID, listID, postID int) error {
+	ctx, span := listSpan(ctx, "RemovePost", userID, listID)
+	span.SetAttributes(attribute.Int("post.id", postID))
+	err := s.next.RemovePost(ctx, userID, listID, postID)
+	tracing.End(span, err)
+	return err
+}
+
+type tracedSyndicationService struct {
+	next SyndicationService
+}
+
+// NewTracedSyndicationService wraps every SyndicationService method in a span.
+func NewTracedSyndicationService(next SyndicationService) SyndicationService {
+	return &tracedSyndicationService{next: next}
+}
+
+func (s *tracedSyndicationService) Stats(ctx context.Context, scope FeedScope) (models.PostStats, error) {
+	ctx, span := startSpan(ctx, "SyndicationService.Stats", trace.WithAttributes(attribute.String("feed.scope", scope.Path())))
+	stats, err := s.next.Stats(ctx, scope)
+	tracing.End(span, err)
+	return stats, err
+}
+
+func (s *tracedSyndicationService) Feed(ctx context.Context, scope FeedScope, selfLink string) (*syndication.Feed, error) {
+	ctx, span := startSpan(ctx, "SyndicationService.Feed", trace.WithAttributes(attribute.String("feed.scope", scope.Path())))
+	feed, err := s.next.Feed(ctx, scope, selfLink)
+	if feed != nil {
+		span.SetAttributes(attribute.Int("posts.count", len(feed.Items)))
+	}
+	tracing.End(span, err)
+	return feed, err
+}
+
+type tracedMediaService struct {
+	next MediaService
+}
+
+// NewTracedMediaService wraps every MediaService method in a span.
+func NewTracedMediaService(next MediaService) MediaService {
+	return &tracedMediaService{next: next}
+}
+
+func (s *tracedMediaService) Upload(ctx context.Context, userID int, filename string, data []byte) (*models.Media, error) {
+	ctx, span := startSpan(ctx, "MediaService.Upload", trace.WithAttributes(attribute.Int("user.id", userID), attribute.Int("media.upload_size", len(data))))
+	media, err := s.next.Upload(ctx, userID, filename, data)
+	if media != nil {
+		span.SetAttributes(attribute.Int("media.id", media.ID), attribute.String("media.content_type", media.ContentType))
+	}
+	tracing.End(span, err)
+	return media, err
+}
+
+func (s *tracedMediaService) GetMedia(ctx context.Context, id int) (*models.Media, error) {
+	ctx, span := startSpan(ctx, "MediaService.GetMedia", trace.WithAttributes(attribute.Int("media.id", id)))
+	media, err := s.next.GetMedia(ctx, id)
+	tracing.End(span, err)
+	return media, err
+}
+
+func (s *tracedMediaService) ListMedia(ctx context.Context, userID int) ([]models.Media, error) {
+	ctx, span := startSpan(ctx, "MediaService.ListMedia", trace.WithAttributes(attribute.Int("user.id", userID)))
+	media, err := s.next.ListMedia(ctx, userID)
+	span.SetAttributes(attribute.Int("media.count", len(media)))
+	tracing.End(span, err)
+	return media, err
+}
+
+func (s *tracedMediaService) DeleteMedia(ctx context.Context, id int) error {
+	ctx, span := startSpan(ctx, "MediaService.DeleteMedia", trace.WithAttributes(attribute.Int("media.id", id)))
+	err := s.next.DeleteMedia(ctx, id)
+	tracing.End(span, err)
+	return err
+}
+
+func (s *tracedMediaService) Open(ctx context.Context, key string) (io.ReadCloser, storage.Info, error) {
+	ctx, span := startSpan(ctx, "MediaService.Open")
+	body, info, err := s.next.Open(ctx, key)
+	tracing.End(span, err)
+	return body, info, err
+}
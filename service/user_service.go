@@ -1,27 +1,41 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"go-blog/events"
+	"go-blog/logging"
 	"go-blog/models"
 	"go-blog/repo"
 	"golang.org/x/crypto/bcrypt"
 )
 
-type UserService struct {
-	repo *repo.UserRepository
+type UserService interface {
+	Register(ctx context.Context, req *models.RegisterRequest) (*models.User, error)
+	Login(ctx context.Context, username, password string) (*models.User, error)
+	GetUserByUsername(ctx context.Context, username string) (*models.User, error)
+	// GetUsersByID returns the users that exist among ids, in id order.
+	GetUsersByID(ctx context.Context, ids []int) ([]models.User, error)
 }
 
-func NewUserService(repo *repo.UserRepository) *UserService {
-	return &UserService{repo: repo}
+type userService struct {
+	repo repo.UserRepository
+	tx   repo.Transactor
+	bus  *events.Bus
 }
 
-func (s *UserService) Register(req *models.RegisterRequest) (*models.User, error) {
-	exists, err := s.repo.UsernameExists(req.Username)
+// NewUserService publishes user events on bus, which may be nil.
+func NewUserService(repo repo.UserRepository, tx repo.Transactor, bus *events.Bus) UserService {
+	return &userService{repo: repo, tx: tx, bus: bus}
+}
+
+func (s *userService) Register(ctx context.Context, req *models.RegisterRequest) (*models.User, error) {
+	exists, err := s.repo.UsernameExists(ctx, req.Username)
 	if err != nil {
 		return nil, err
 	}
 	if exists {
-		return nil, errors.New("username exists")
+		return nil, models.ErrUsernameExists
 	}
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
@@ -35,19 +49,42 @@ func (s *UserService) Register(req *models.RegisterRequest) (*models.User, error
 		AccountType: req.AccountType,
 	}
 
-	return s.repo.CreateUser(user)
+	var created *models.User
+	err = s.tx.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+		if created, err = s.repo.CreateUser(ctx, user); err != nil {
+			return err
+		}
+		return s.bus.Publish(ctx, events.UserRegistered{
+			User: models.UserProfile{ID: created.ID, Username: created.Username, AccountType: created.AccountType},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx).InfoContext(ctx, "user registered", "user_id", created.ID, "account_type", created.AccountType)
+	return created, nil
 }
 
-func (s *UserService) Login(username, password string) (*models.User, error) {
-	user, err := s.repo.GetUserByUsername(username)
+func (s *userService) Login(ctx context.Context, username, password string) (*models.User, error) {
+	user, err := s.repo.GetUserByUsername(ctx, username)
 	if err != nil {
 		return nil, err
 	}
 
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
 	if err != nil {
+		logging.FromContext(ctx).WarnContext(ctx, "login rejected", "user_id", user.ID, "reason", "invalid password")
 		return nil, errors.New("invalid password")
 	}
 
 	return user, nil
 }
+
+func (s *userService) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	return s.repo.GetUserByUsername(ctx, username)
+}
+
+func (s *userService) GetUsersByID(ctx context.Context, ids []int) ([]models.User, error) {
+	return s.repo.GetUsersByID(ctx, ids)
+}
@@ -0,0 +1,460 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-blog/config"
+	"go-blog/events"
+	"go-blog/jobs"
+	"go-blog/logging"
+	"go-blog/models"
+	"go-blog/repo"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Webhook requests carry these headers. The signature header has the form
+// "t=<unix seconds>,v1=<hex HMAC-SHA256>", where the HMAC is keyed with the
+// webhook's secret and covers "<t>.<body>"; receivers should recompute it
+// and reject stale timestamps to stop replays.
+const (
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+const (
+	maxWebhooksPerOwner   = 20
+	maxWebhookURLLength   = 2048
+	minWebhookSecret      = 16
+	maxWebhookSecret      = 256
+	maxStoredResponseBody = 1024
+)
+
+// DeliverWebhookArgs is the job that sends one webhook delivery.
+type DeliverWebhookArgs struct {
+	DeliveryID int `json:"delivery_id"`
+}
+
+func (DeliverWebhookArgs) Kind() string { return "webhook.deliver" }
+
+// WebhookService manages webhooks and their deliveries. A userID of 0 means
+// the site-wide webhooks managed through the admin API.
+type WebhookService interface {
+	// Publish records event for delivery. See SubscribeWebhooks.
+	Publish(ctx context.Context, event models.Event) error
+	CreateWebhook(ctx context.Context, userID int, req models.WebhookRequest) (*models.Webhook, error)
+	ListWebhooks(ctx context.Context, userID int) ([]models.Webhook, error)
+	GetWebhook(ctx context.Context, userID, id int) (*models.Webhook, error)
+	UpdateWebhook(ctx context.Context, userID, id int, req models.WebhookRequest) (*models.Webhook, error)
+	DeleteWebhook(ctx context.Context, userID, id int) error
+	ListDeliveries(ctx context.Context, userID, webhookID, beforeID, limit int) ([]models.WebhookDelivery, error)
+	// GetDelivery returns a delivery with the log of its attempts.
+	GetDelivery(ctx context.Context, userID, webhookID, deliveryID int) (*models.WebhookDelivery, error)
+	// Redeliver queues a delivery again with a fresh set of attempts.
+	Redeliver(ctx context.Context, userID, webhookID, deliveryID int) (*models.WebhookDelivery, error)
+	// Deliver makes one attempt at a delivery. It runs on the job queue and
+	// returns an error when the endpoint did not accept the event.
+	Deliver(ctx context.Context, deliveryID int) error
+}
+
+type webhookService struct {
+	cfg    config.WebhooksConfig
+	repo   repo.WebhookRepository
+	tx     repo.Transactor
+	queue  *jobs.Queue
+	client *http.Client
+}
+
+// SubscribeWebhooks turns domain events into webhook deliveries. The
+// subscriptions are synchronous: deliveries are written in the transaction
+// of the change, which makes the deliveries table an outbox, so an event is
+// sent if, and only if, its change committed.
+func SubscribeWebhooks(bus *events.Bus, webhooks WebhookService) {
+	post := func(eventType models.EventType, post models.Post) models.Event {
+		return models.Event{Type: eventType, UserID: post.UserID, Data: post}
+	}
+	events.Subscribe(bus, "webhooks", func(ctx context.Context, e events.PostCreated) error {
+		return webhooks.Publish(ctx, post(models.EventPostCreated, e.Post))
+	})
+	events.Subscribe(bus, "webhooks", func(ctx context.Context, e events.PostPublished) error {
+		return webhooks.Publish(ctx, post(models.EventPostPublished, e.Post))
+	})
+	events.Subscribe(bus, "webhooks", func(ctx context.Context, e events.PostUpdated) error {
+		return webhooks.Publish(ctx, post(models.EventPostUpdated, e.Post))
+	})
+	events.Subscribe(bus, "webhooks", func(ctx context.Context, e events.PostDeleted) error {
+		return webhooks.Publish(ctx, post(models.EventPostDeleted, e.Post))
+	})
+	events.Subscribe(bus, "webhooks", func(ctx context.Context, e events.UserRegistered) error {
+		return webhooks.Publish(ctx, models.Event{Type: models.EventUserRegistered, UserID: e.User.ID, Data: e.User})
+	})
+}
+
+func NewWebhookService(cfg config.WebhooksConfig, repo repo.WebhookRepository, tx repo.Transactor, queue *jobs.Queue) WebhookService {
+	return &webhookService{cfg: cfg, repo: repo, tx: tx, queue: queue, client: newWebhookClient(cfg)}
+}
+
+func (s *webhookService) CreateWebhook(ctx context.Context, userID int, req models.WebhookRequest) (*models.Webhook, error) {
+	if req.URL == nil || req.Secret == nil || req.Events == nil {
+		return nil, fmt.Errorf("%w: url, secret and events are required", models.ErrInvalidWebhook)
+	}
+	webhook := &models.Webhook{Active: true}
+	if userID != 0 {
+		webhook.UserID = &userID
+	}
+	if err := s.apply(webhook, req); err != nil {
+		return nil, err
+	}
+	existing, err := s.repo.ListWebhooks(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= maxWebhooksPerOwner {
+		return nil, fmt.Errorf("%w: at most %d webhooks", models.ErrInvalidWebhook, maxWebhooksPerOwner)
+	}
+	if err := s.repo.CreateWebhook(ctx, webhook); err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx).InfoContext(ctx, "webhook created", "webhook_id", webhook.ID, "user_id", userID)
+	return webhook, nil
+}
+
+func (s *webhookService) ListWebhooks(ctx context.Context, userID int) ([]models.Webhook, error) {
+	return s.repo.ListWebhooks(ctx, userID)
+}
+
+// GetWebhook hides other owners' webhooks behind ErrWebhookNotFound.
+func (s *webhookService) GetWebhook(ctx context.Context, userID, id int) (*models.Webhook, error) {
+	webhook, err := s.repo.GetWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	owner := 0
+	if webhook.UserID != nil {
+		owner = *webhook.UserID
+	}
+	if owner != userID {
+		return nil, models.ErrWebhookNotFound
+	}
+	return webhook, nil
+}
+
+func (s *webhookService) UpdateWebhook(ctx context.Context, userID, id int, req models.WebhookRequest) (*models.Webhook, error) {
+	webhook, err := s.GetWebhook(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.apply(webhook, req); err != nil {
+		return nil, err
+	}
+	webhook.UpdatedAt = time.Now().UTC()
+	if err := s.repo.UpdateWebhook(ctx, webhook); err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+func (s *webhookService) DeleteWebhook(ctx context.Context, userID, id int) error {
+	if _, err := s.GetWebhook(ctx, userID, id); err != nil {
+		return err
+	}
+	return s.repo.DeleteWebhook(ctx, id)
+}
+
+// apply validates the fields set in req and copies them onto webhook.
+func (s *webhookService) apply(webhook *models.Webhook, req models.WebhookRequest) error {
+	if req.URL != nil {
+		if err := checkWebhookURL(*req.URL, s.cfg.AllowPrivateNetworks); err != nil {
+			return err
+		}
+		webhook.URL = *req.URL
+	}
+	if req.Secret != nil {
+		if n := len(*req.Secret); n < minWebhookSecret || n > maxWebhookSecret {
+			return fmt.Errorf("%w: secret must be %d to %d characters", models.ErrInvalidWebhook, minWebhookSecret, maxWebhookSecret)
+		}
+		webhook.Secret = *req.Secret
+	}
+	if req.Events != nil {
+		subscribed := []models.EventType{}
+		for _, event := range req.Events {
+			if !event.Valid() {
+				return fmt.Errorf("%w: unknown event %q", models.ErrInvalidWebhook, event)
+			}
+			// A user's own webhooks only hear about that user, who exists
+			// before any of their webhooks can.
+			if event == models.EventUserRegistered && webhook.UserID != nil {
+				return fmt.Errorf("%w: %s is only available to site-wide webhooks", models.ErrInvalidWebhook, event)
+			}
+			if !slices.Contains(subscribed, event) {
+				subscribed = append(subscribed, event)
+			}
+		}
+		if len(subscribed) == 0 {
+			return fmt.Errorf("%w: subscribe to at least one event", models.ErrInvalidWebhook)
+		}
+		webhook.Events = subscribed
+	}
+	if req.Active != nil {
+		webhook.Active = *req.Active
+	}
+	return nil
+}
+
+func checkWebhookURL(raw string, allowPrivate bool) error {
+	u, err := url.Parse(raw)
+	if err != nil || len(raw) > maxWebhookURLLength || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.User != nil {
+		return fmt.Errorf("%w: url must be an absolute http or https URL without credentials", models.ErrInvalidWebhook)
+	}
+	if allowPrivate {
+		return nil
+	}
+	host := u.Hostname()
+	if ip, err := netip.ParseAddr(host); (err == nil && !publicAddr(ip)) || host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("%w: url must not point at a private address", models.ErrInvalidWebhook)
+	}
+	return nil
+}
+
+func (s *webhookService) ListDeliveries(ctx context.Context, userID, webhookID, beforeID, limit int) ([]models.WebhookDelivery, error) {
+	if _, err := s.GetWebhook(ctx, userID, webhookID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListDeliveries(ctx, webhookID, beforeID, limit)
+}
+
+func (s *webhookService) GetDelivery(ctx context.Context, userID, webhookID, deliveryID int) (*models.WebhookDelivery, error) {
+	if _, err := s.GetWebhook(ctx, userID, webhookID); err != nil {
+		return nil, err
+	}
+	delivery, err := s.repo.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.WebhookID != webhookID {
+		return nil, models.ErrDeliveryNotFound
+	}
+	if delivery.Log, err = s.repo.ListAttempts(ctx, deliveryID); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+func (s *webhookService) Redeliver(ctx context.Context, userID, webhookID, deliveryID int) (*models.WebhookDelivery, error) {
+	delivery, err := s.GetDelivery(ctx, userID, webhookID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	err = s.tx.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.enqueue(ctx, delivery.ID); err != nil {
+			return err
+		}
+		return s.repo.SetDeliveryStatus(ctx, delivery.ID, models.DeliveryPending)
+	})
+	if errors.Is(err, jobs.ErrDuplicate) {
+		return nil, models.ErrDeliveryPending
+	}
+	if err != nil {
+		return nil, err
+	}
+	delivery.Status = models.DeliveryPending
+	return delivery, nil
+}
+
+func (s *webhookService) enqueue(ctx context.Context, deliveryID int) error {
+	_, err := s.queue.Enqueue(ctx, DeliverWebhookArgs{DeliveryID: deliveryID}, jobs.Options{
+		MaxAttempts: s.cfg.MaxAttempts,
+		UniqueKey:   "webhook.deliver:" + strconv.Itoa(deliveryID),
+	})
+	return err
+}
+
+// Publish stores a delivery for every active webhook subscribed to event
+// and queues it. Inside a transaction both the deliveries and their jobs
+// are part of it.
+func (s *webhookService) Publish(ctx context.Context, event models.Event) error {
+	webhooks, err := s.repo.ActiveWebhooks(ctx, event.UserID)
+	if err != nil {
+		return err
+	}
+	var deliveries []models.WebhookDelivery
+	var payload []byte
+	for _, webhook := range webhooks {
+		if !webhook.Subscribed(event.Type) {
+			continue
+		}
+		if payload == nil {
+			if event.ID == "" {
+				if event.ID, err = newEventID(); err != nil {
+					return err
+				}
+			}
+			if event.OccurredAt.IsZero() {
+				event.OccurredAt = time.Now().UTC().Truncate(time.Microsecond)
+			}
+			if payload, err = json.Marshal(event); err != nil {
+				return fmt.Errorf("encoding %s event: %w", event.Type, err)
+			}
+		}
+		deliveries = append(deliveries, models.WebhookDelivery{
+			WebhookID: webhook.ID,
+			EventID:   event.ID,
+			Event:     event.Type,
+			Payload:   payload,
+			Status:    models.DeliveryPending,
+		})
+	}
+	if err := s.repo.CreateDeliveries(ctx, deliveries); err != nil {
+		return err
+	}
+	for _, delivery := range deliveries {
+		if err := s.enqueue(ctx, delivery.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *webhookService) Deliver(ctx context.Context, deliveryID int) error {
+	delivery, err := s.repo.GetDelivery(ctx, deliveryID)
+	if errors.Is(err, models.ErrDeliveryNotFound) {
+		// The webhook was deleted along with its deliveries.
+		return jobs.Permanent(err)
+	}
+	if err != nil {
+		return err
+	}
+	if delivery.Status == models.DeliverySucceeded {
+		return nil
+	}
+	webhook, err := s.repo.GetWebhook(ctx, delivery.WebhookID)
+	if err != nil {
+		return jobs.Permanent(err)
+	}
+
+	attempt := &models.WebhookAttempt{DeliveryID: delivery.ID, CreatedAt: time.Now().UTC().Truncate(time.Microsecond)}
+	sendErr := errors.New("webhook is disabled")
+	if webhook.Active {
+		sendErr = s.send(ctx, webhook, delivery, attempt)
+	} else {
+		attempt.Error = sendErr.Error()
+		sendErr = jobs.Permanent(sendErr)
+	}
+
+	status := models.DeliverySucceeded
+	if sendErr != nil {
+		status = models.DeliveryRetrying
+		if n, max := jobs.Attempt(ctx); n >= max || jobs.IsPermanent(sendErr) {
+			status = models.DeliveryFailed
+		}
+	}
+	// The attempt is logged even when the job was cancelled mid-request.
+	if err := s.repo.RecordAttempt(context.WithoutCancel(ctx), attempt, status); err != nil {
+		return errors.Join(sendErr, fmt.Errorf("logging webhook attempt: %w", err))
+	}
+	return sendErr
+}
+
+// send makes the HTTP request and fills in attempt with what came back.
+func (s *webhookService) send(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery, attempt *models.WebhookAttempt) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		attempt.Error = err.Error()
+		return jobs.Permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "go-blog-webhooks")
+	req.Header.Set(WebhookEventHeader, string(delivery.Event))
+	req.Header.Set(WebhookDeliveryHeader, strconv.Itoa(delivery.ID))
+	req.Header.Set(WebhookSignatureHeader, SignWebhook(webhook.Secret, time.Now(), delivery.Payload))
+
+	start := time.Now()
+	resp, err := s.client.Do(req)
+	attempt.DurationMS = time.Since(start).Milliseconds()
+	if err != nil {
+		attempt.Error = err.Error()
+		return err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxStoredResponseBody))
+	attempt.StatusCode = resp.StatusCode
+	attempt.ResponseBody = strings.ToValidUTF8(string(body), "")
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		err := fmt.Errorf("webhook endpoint answered %s", resp.Status)
+		attempt.Error = err.Error()
+		return err
+	}
+	return nil
+}
+
+// SignWebhook returns the X-Webhook-Signature value for body sent at t.
+func SignWebhook(secret string, t time.Time, body []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func newEventID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+var errPrivateAddress = errors.New("webhook address is not public")
+
+// newWebhookClient does not follow redirects, since a redirect could lead
+// anywhere, and unless private networks are allowed it refuses to connect
+// to non-public addresses. The check runs on the resolved address, so a
+// public host name that resolves to an internal IP is refused as well.
+func newWebhookClient(cfg config.WebhooksConfig) *http.Client {
+	dialer := &net.Dialer{Timeout: cfg.Timeout}
+	if !cfg.AllowPrivateNetworks {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip, err := netip.ParseAddr(host)
+			if err != nil || !publicAddr(ip) {
+				return errPrivateAddress
+			}
+			return nil
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Transport: transport,
+		Timeout:   cfg.Timeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func publicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() && !ip.IsLoopback() && !ip.IsLinkLocalUnicast()
+}
@@ -0,0 +1,169 @@
+// Package sitemap writes sitemaps.org XML. A Builder accepts URLs one at a
+// time and splits them into chunks of at most MaxURLs, so callers can stream
+// from the database without holding every row in memory.
+package sitemap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"fmt"
+	"time"
+)
+
+// MaxURLs is the sitemaps.org limit on URLs per sitemap file.
+const MaxURLs = 50000
+
+const namespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+type URL struct {
+	Loc     string
+	LastMod time.Time
+}
+
+// Sitemap is a finished, immutable sitemap. With a single chunk the root
+// document is that chunk; otherwise it is a sitemap index pointing at each
+// chunk's gzipped file.
+type Sitemap struct {
+	Root   File
+	Chunks []File
+}
+
+// File holds a document both plain and gzip-compressed so neither has to be
+// produced per request.
+type File struct {
+	XML  []byte
+	Gzip []byte
+}
+
+// Chunk returns the 1-based chunk n.
+func (s *Sitemap) Chunk(n int) (File, bool) {
+	if n < 1 || n > len(s.Chunks) {
+		return File{}, false
+	}
+	return s.Chunks[n-1], true
+}
+
+type Builder struct {
+	chunkSize int
+	chunkURL  func(n int) string
+	chunks    []File
+	lastMods  []time.Time
+
+	buf     bytes.Buffer
+	enc     *xml.Encoder
+	count   int
+	lastMod time.Time
+}
+
+type urlElement struct {
+	XMLName xml.Name `xml:"url"`
+	Loc     string   `xml:"loc"`
+	LastMod string   `xml:"lastmod,omitempty"`
+}
+
+// NewBuilder starts a sitemap. chunkURL returns the absolute URL of the
+// gzipped chunk n and is only used when more than one chunk is needed.
+func NewBuilder(chunkSize int, chunkURL func(n int) string) *Builder {
+	if chunkSize < 1 || chunkSize > MaxURLs {
+		chunkSize = MaxURLs
+	}
+	return &Builder{chunkSize: chunkSize, chunkURL: chunkURL}
+}
+
+func (b *Builder) Add(u URL) error {
+	if b.enc == nil {
+		b.buf.Reset()
+		b.buf.WriteString(xml.Header)
+		b.buf.WriteString(`<urlset xmlns="` + namespace + `">`)
+		b.enc = xml.NewEncoder(&b.buf)
+	}
+	element := urlElement{Loc: u.Loc}
+	if !u.LastMod.IsZero() {
+		element.LastMod = u.LastMod.UTC().Format(time.RFC3339)
+		if u.LastMod.After(b.lastMod) {
+			b.lastMod = u.LastMod
+		}
+	}
+	if err := b.enc.Encode(element); err != nil {
+		return err
+	}
+	b.count++
+	if b.count == b.chunkSize {
+		return b.flush()
+	}
+	return nil
+}
+
+func (b *Builder) flush() error {
+	if err := b.enc.Flush(); err != nil {
+		return err
+	}
+	b.buf.WriteString("</urlset>\n")
+	file, err := newFile(b.buf.Bytes())
+	if err != nil {
+		return err
+	}
+	b.chunks = append(b.chunks, file)
+	b.lastMods = append(b.lastMods, b.lastMod)
+	b.enc, b.count, b.lastMod = nil, 0, time.Time{}
+	return nil
+}
+
+func (b *Builder) Finish() (*Sitemap, error) {
+	if b.enc != nil {
+		if err := b.flush(); err != nil {
+			return nil, err
+		}
+	}
+	if len(b.chunks) == 0 {
+		empty, err := newFile([]byte(xml.Header + `<urlset xmlns="` + namespace + `"></urlset>` + "\n"))
+		if err != nil {
+			return nil, err
+		}
+		return &Sitemap{Root: empty, Chunks: []File{empty}}, nil
+	}
+	if len(b.chunks) == 1 {
+		return &Sitemap{Root: b.chunks[0], Chunks: b.chunks}, nil
+	}
+
+	var index bytes.Buffer
+	index.WriteString(xml.Header)
+	index.WriteString(`<sitemapindex xmlns="` + namespace + `">`)
+	enc := xml.NewEncoder(&index)
+	for i := range b.chunks {
+		entry := struct {
+			XMLName xml.Name `xml:"sitemap"`
+			Loc     string   `xml:"loc"`
+			LastMod string   `xml:"lastmod,omitempty"`
+		}{Loc: b.chunkURL(i + 1)}
+		if !b.lastMods[i].IsZero() {
+			entry.LastMod = b.lastMods[i].UTC().Format(time.RFC3339)
+		}
+		if err := enc.Encode(entry); err != nil {
+			return nil, err
+		}
+	}
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+	index.WriteString("</sitemapindex>\n")
+	root, err := newFile(index.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return &Sitemap{Root: root, Chunks: b.chunks}, nil
+}
+
+func newFile(doc []byte) (File, error) {
+	plain := bytes.Clone(doc)
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	if _, err := zw.Write(plain); err != nil {
+		return File{}, fmt.Errorf("compressing sitemap: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return File{}, fmt.Errorf("compressing sitemap: %w", err)
+	}
+	return File{XML: plain, Gzip: compressed.Bytes()}, nil
+}
@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"context"
+	"go-blog/config"
+	"io"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Store keeps blobs in a bucket on any S3-compatible service: AWS S3,
+// MinIO, Ceph or an in-process fake in tests. It uses path-style addressing
+// so bucket names need not resolve in DNS.
+type S3Store struct {
+	client *minio.Client
+	bucket string
+}
+
+var _ BlobStore = (*S3Store)(nil)
+
+func NewS3Store(cfg config.MediaConfig) (*S3Store, error) {
+	endpoint := strings.TrimPrefix(strings.TrimPrefix(cfg.S3Endpoint, "https://"), "http://")
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:        credentials.NewStaticV4(cfg.S3AccessKey, cfg.S3SecretKey, ""),
+		Secure:       cfg.S3UseSSL,
+		Region:       cfg.S3Region,
+		BucketLookup: minio.BucketLookupPath,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &S3Store{client: client, bucket: cfg.S3Bucket}, nil
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	if !ValidKey(key) {
+		return ErrInvalidKey
+	}
+	_, err := s.client.PutObject(ctx, s.bucket, key, r, size, minio.PutObjectOptions{ContentType: contentType})
+	return err
+}
+
+func (s *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, Info, error) {
+	if !ValidKey(key) {
+		return nil, Info{}, ErrInvalidKey
+	}
+	object, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, Info{}, translateS3Error(err)
+	}
+	// GetObject is lazy; Stat performs the request and surfaces NoSuchKey.
+	stat, err := object.Stat()
+	if err != nil {
+		object.Close()
+		return nil, Info{}, translateS3Error(err)
+	}
+	return object, Info{Size: stat.Size, ContentType: stat.ContentType, ModTime: stat.LastModified}, nil
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	if !ValidKey(key) {
+		return ErrInvalidKey
+	}
+	return translateS3Error(s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{}))
+}
+
+func translateS3Error(err error) error {
+	if err == nil {
+		return nil
+	}
+	if code := minio.ToErrorResponse(err).Code; code == minio.NoSuchKey || code == "NotFound" {
+		return ErrNotFound
+	}
+	return err
+}
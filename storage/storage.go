@@ -0,0 +1,51 @@
+// Package storage keeps uploaded files in a blob store addressed by
+// slash-separated keys such as "2026/10/3f2a9c.jpg".
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-blog/config"
+	"io"
+	"io/fs"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound   = errors.New("blob not found")
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+type Info struct {
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+// BlobStore is the interface every storage backend implements. Delete is
+// idempotent: removing a missing key is not an error.
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, Info, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// New opens the backend selected by cfg.Backend.
+func New(cfg config.MediaConfig) (BlobStore, error) {
+	switch cfg.Backend {
+	case "local":
+		return NewLocalStore(cfg.LocalDir)
+	case "s3":
+		return NewS3Store(cfg)
+	default:
+		return nil, fmt.Errorf("unsupported media backend %q", cfg.Backend)
+	}
+}
+
+// ValidKey rejects keys that could escape the store's root, such as
+// absolute paths or ones containing "..".
+func ValidKey(key string) bool {
+	return key != "" && key != "." && fs.ValidPath(key) && !strings.Contains(key, `\`)
+}
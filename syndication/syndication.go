@@ -0,0 +1,77 @@
+// Package syndication renders RSS 2.0 and Atom 1.0 documents from a
+// format-neutral Feed.
+package syndication
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type Feed struct {
+	ID          string
+	Title       string
+	Description string
+	Link        string
+	SelfLink    string
+	Updated     time.Time
+	Items       []Item
+}
+
+type Item struct {
+	ID         string
+	Title      string
+	Link       string
+	Author     string
+	Published  time.Time
+	Updated    time.Time
+	Summary    string
+	HTML       string
+	Categories []string
+}
+
+// TagURI builds an RFC 4151 tag URI. Unlike a URL it stays the same if the
+// site moves to a new domain scheme or path layout, which makes it a stable
+// GUID for feed readers.
+func TagURI(baseURL string, created time.Time, specific string) string {
+	host := baseURL
+	if u, err := url.Parse(baseURL); err == nil && u.Hostname() != "" {
+		host = u.Hostname()
+	}
+	return fmt.Sprintf("tag:%s,%s:%s", host, created.UTC().Format("2006-01-02"), specific)
+}
+
+// TextToHTML turns plain post content into HTML: blank lines separate
+// paragraphs and single newlines become line breaks.
+func TextToHTML(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	var b strings.Builder
+	for _, paragraph := range strings.Split(text, "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		lines := strings.Split(paragraph, "\n")
+		for i := range lines {
+			lines[i] = html.EscapeString(lines[i])
+		}
+		b.WriteString("<p>" + strings.Join(lines, "<br>") + "</p>")
+	}
+	return b.String()
+}
+
+// Summarize returns the first maxRunes runes of text on a word boundary.
+func Summarize(text string, maxRunes int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	runes := []rune(text)
+	if len(runes) <= maxRunes {
+		return text
+	}
+	cut := string(runes[:maxRunes])
+	if i := strings.LastIndex(cut, " "); i > 0 {
+		cut = cut[:i]
+	}
+	return cut + "…"
+}
@@ -0,0 +1,103 @@
+package tests
+
+import (
+	"go-blog/config"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfigFile(t *testing.T, name, contents string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+	return path
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	path := writeConfigFile(t, "blog.yaml", `
+server:
+  addr: ":9000"
+database:
+  type: sqlite
+  path: from-file.db
+auth:
+  jwt_secret: file-secret
+  token_ttl: 2h
+`)
+	t.Setenv("DB_PATH", "from-env.db")
+	t.Setenv("JWT_SECRET", "env-secret")
+
+	cfg, _, err := config.Load([]string{"-config", path, "-jwt-secret", "flag-secret"})
+	require.NoError(t, err)
+	assert.Equal(t, ":9000", cfg.Server.Addr, "file overrides defaults")
+	assert.Equal(t, "sqlite", cfg.Database.Type)
+	assert.Equal(t, "from-env.db", cfg.Database.Path, "env overrides file")
+	assert.Equal(t, "flag-secret", cfg.Auth.JWTSecret, "flags override env")
+	assert.Equal(t, 2*time.Hour, cfg.Auth.TokenTTL)
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestConfigLoadsTOML(t *testing.T) {
+	path := writeConfigFile(t, "blog.toml", `
+[server]
+addr = ":7000"
+
+[auth]
+jwt_secret = "toml-secret"
+`)
+	cfg, _, err := config.Load([]string{"-config", path})
+	require.NoError(t, err)
+	assert.Equal(t, ":7000", cfg.Server.Addr)
+	assert.Equal(t, "toml-secret", cfg.Auth.JWTSecret)
+}
+
+func TestConfigFileListsAreCommaSeparated(t *testing.T) {
+	path := writeConfigFile(t, "blog.yaml", "site:\n  robots_disallow: [/admin, /drafts]\n")
+	cfg, _, err := config.Load([]string{"-config", path})
+	require.NoError(t, err)
+	assert.Equal(t, "/admin,/drafts", cfg.Site.RobotsDisallow)
+
+	path = writeConfigFile(t, "blog.toml", "[site]\nrobots_disallow = [\"/admin\", \"/drafts\"]\n")
+	cfg, _, err = config.Load([]string{"-config", path})
+	require.NoError(t, err)
+	assert.Equal(t, "/admin,/drafts", cfg.Site.RobotsDisallow)
+
+	path = writeConfigFile(t, "blog.yaml", "site:\n  robots_disallow: [{path: /admin}]\n")
+	_, _, err = config.Load([]string{"-config", path})
+	assert.ErrorContains(t, err, "site.robots_disallow")
+}
+
+func TestConfigRejectsUnknownFileKeys(t *testing.T) {
+	path := writeConfigFile(t, "blog.yaml", "server:\n  adr: \":9000\"\n")
+	_, _, err := config.Load([]string{"-config", path})
+	assert.ErrorContains(t, err, "server.adr")
+}
+
+func TestConfigValidateRequiresJWTSecret(t *testing.T) {
+	cfg := config.Default()
+	assert.ErrorContains(t, cfg.Validate(), "jwt_secret")
+
+	cfg.Auth.JWTSecret = "secret"
+	cfg.Database.Type = "mysql"
+	assert.ErrorContains(t, cfg.Validate(), "database.type")
+}
+
+func TestConfigRedactsSecrets(t *testing.T) {
+	cfg := config.Default()
+	cfg.Auth.JWTSecret = "super-secret"
+	cfg.Database.Password = "db-pass"
+	cfg.Database.DSN = "postgres://blog:dsn-pass@db:5432/blog"
+	cfg.Media.S3SecretKey = "s3-secret"
+
+	printed := cfg.String()
+	assert.NotContains(t, printed, "super-secret")
+	assert.NotContains(t, printed, "db-pass")
+	assert.NotContains(t, printed, "dsn-pass")
+	assert.NotContains(t, printed, "s3-secret")
+	assert.Contains(t, printed, "postgres://blog:")
+	assert.Equal(t, "super-secret", cfg.Auth.JWTSecret, "redaction must not modify the original")
+}
@@ -0,0 +1,177 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-blog/mail"
+	"go-blog/models"
+	"go-blog/testutils"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var tokenPattern = regexp.MustCompile(`token=([A-Za-z0-9_%-]+)`)
+
+func subscribeDigest(t *testing.T, suite *testutils.TestSuite, req map[string]string, headers map[string]string) {
+	body, _ := json.Marshal(req)
+	w := suite.MakeRequest("POST", "/api/digests", bytes.NewBuffer(body), headers)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+}
+
+// sentMail runs the queued jobs and returns the mail they sent.
+func sentMail(t *testing.T, suite *testutils.TestSuite) []mail.Message {
+	_, err := suite.App.Jobs.Drain(context.Background())
+	require.NoError(t, err)
+	mailer := suite.App.Mailer.(*mail.MemoryMailer)
+	messages := mailer.Messages()
+	mailer.Reset()
+	return messages
+}
+
+func linkToken(t *testing.T, text string) string {
+	match := tokenPattern.FindStringSubmatch(text)
+	require.NotNil(t, match, "no link in %q", text)
+	token, err := url.QueryUnescape(match[1])
+	require.NoError(t, err)
+	return token
+}
+
+func TestDigestSubscriptionNeedsConfirmation(t *testing.T) {
+	suite := testutils.Setup()
+	registerAndLogin(t, suite, "writer", "password123", "blogger")
+
+	for _, req := range []map[string]string{
+		{"email": "not-an-address"},
+		{"email": "a@example.com", "frequency": "hourly"},
+		{"email": "a@example.com", "author": "nobody"},
+	} {
+		body, _ := json.Marshal(req)
+		w := suite.MakeRequest("POST", "/api/digests", bytes.NewBuffer(body), nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code, req)
+	}
+
+	subscribeDigest(t, suite, map[string]string{"email": " Reader@Example.com ", "author": "writer"}, nil)
+	subscribeDigest(t, suite, map[string]string{"email": "reader@example.com", "author": "writer", "frequency": "weekly"}, nil)
+	messages := sentMail(t, suite)
+	require.Len(t, messages, 1, "asking twice before confirming sends one email")
+	confirmation := messages[0]
+	assert.Equal(t, "reader@example.com", confirmation.To)
+	assert.Contains(t, confirmation.Subject, "Confirm your subscription")
+	assert.Contains(t, confirmation.HTML, "/api/digests/confirm?token=")
+	token := linkToken(t, confirmation.Text)
+
+	w := suite.MakeRequest("GET", "/api/digests/confirm?token=bogus", nil, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = suite.MakeRequest("GET", "/api/digests/confirm?token="+url.QueryEscape(token), nil, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var subscription models.DigestSubscription
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &subscription))
+	assert.Equal(t, models.DigestWeekly, subscription.Frequency, "the latest request's frequency wins")
+	assert.Equal(t, "writer", subscription.Author)
+	assert.NotNil(t, subscription.ConfirmedAt)
+	assert.NotContains(t, w.Body.String(), token)
+
+	subscribeDigest(t, suite, map[string]string{"email": "reader@example.com", "author": "writer"}, nil)
+	assert.Empty(t, sentMail(t, suite), "confirmed subscriptions are not asked again")
+}
+
+func TestDigestsSendNewPostsAndUnsubscribe(t *testing.T) {
+	suite := testutils.Setup()
+	ctx := context.Background()
+	writer := registerAndLogin(t, suite, "digester", "password123", "blogger")
+	other := registerAndLogin(t, suite, "bystander", "password123", "blogger")
+	reader := registerAndLogin(t, suite, "reader", "password123", "viewer")
+	readerAuth := map[string]string{"Authorization": "Bearer " + reader}
+
+	subscribeDigest(t, suite, map[string]string{"email": "fan@example.com", "author": "digester", "tag": "Go"}, readerAuth)
+	token := linkToken(t, sentMail(t, suite)[0].Text)
+	w := suite.MakeRequest("GET", "/api/digests/confirm?token="+url.QueryEscape(token), nil, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	createPostJSON(t, suite, writer, map[string]any{"title": "Generics in practice", "content": "Body", "tags": []string{"go"}})
+	createPostJSON(t, suite, writer, map[string]any{"title": "Baking bread", "content": "Body", "tags": []string{"food"}})
+	createPostJSON(t, suite, writer, map[string]any{"title": "Unfinished", "content": "Body", "tags": []string{"go"}, "status": "draft"})
+	createPostJSON(t, suite, other, map[string]any{"title": "Someone else's Go", "content": "Body", "tags": []string{"go"}})
+
+	queued, err := suite.App.DigestService.ScheduleDue(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Zero(t, queued, "nothing is due straight after confirming")
+
+	w = suite.MakeRequest("GET", "/api/me/digests", nil, readerAuth)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var subscriptions []models.DigestSubscription
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &subscriptions))
+	require.Len(t, subscriptions, 1)
+	assert.Equal(t, "go", subscriptions[0].Tag)
+
+	// Make the subscription due.
+	subscription, err := suite.App.DigestRepo.Get(ctx, subscriptions[0].ID)
+	require.NoError(t, err)
+	past := time.Now().Add(-time.Minute)
+	subscription.NextRunAt = &past
+	require.NoError(t, suite.App.DigestRepo.Update(ctx, subscription))
+	queued, err = suite.App.DigestService.ScheduleDue(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, queued)
+
+	messages := sentMail(t, suite)
+	require.Len(t, messages, 1)
+	digest := messages[0]
+	assert.Equal(t, "fan@example.com", digest.To)
+	assert.Contains(t, digest.Subject, "1 new post")
+	assert.Contains(t, digest.Text, "Generics in practice")
+	for _, excluded := range []string{"Baking bread", "Unfinished", "Someone else's Go"} {
+		assert.NotContains(t, digest.Text, excluded)
+	}
+	unsubscribeURL := strings.Trim(digest.Headers["List-Unsubscribe"], "<>")
+	assert.Contains(t, unsubscribeURL, "/api/digests/unsubscribe?token=")
+	assert.Equal(t, "List-Unsubscribe=One-Click", digest.Headers["List-Unsubscribe-Post"])
+
+	subscription, err = suite.App.DigestRepo.Get(ctx, subscription.ID)
+	require.NoError(t, err)
+	assert.True(t, subscription.NextRunAt.After(time.Now()), "the next digest is a period away")
+	queued, err = suite.App.DigestService.ScheduleDue(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Zero(t, queued)
+
+	unsubscribe := "/api/digests/unsubscribe?token=" + url.QueryEscape(linkToken(t, unsubscribeURL))
+	w = suite.MakeRequest("GET", unsubscribe, nil, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `<form method="post">`, "following the link alone does not unsubscribe")
+	oneClick := map[string]string{"Content-Type": "application/x-www-form-urlencoded"}
+	w = suite.MakeRequest("POST", unsubscribe, bytes.NewBufferString("List-Unsubscribe=One-Click"), oneClick)
+	require.Equal(t, http.StatusOK, w.Code)
+	w = suite.MakeRequest("POST", unsubscribe, bytes.NewBufferString("List-Unsubscribe=One-Click"), oneClick)
+	assert.Equal(t, http.StatusOK, w.Code, "unsubscribing twice is fine")
+	w = suite.MakeRequest("GET", unsubscribe, nil, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = suite.MakeRequest("GET", "/api/me/digests", nil, readerAuth)
+	assert.JSONEq(t, `[]`, w.Body.String())
+	w = suite.MakeRequest("DELETE", fmt.Sprintf("/api/me/digests/%d", subscription.ID), nil, readerAuth)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestMailMessageBuild(t *testing.T) {
+	msg := mail.Message{
+		To: "reader@example.com", Subject: "Café news", Text: "Plain body", HTML: "<p>Rich body</p>",
+		Headers: map[string]string{"List-Unsubscribe": "<https://blog.example/u>"},
+	}
+	raw, err := msg.Build("go-blog <no-reply@example.com>", time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+	require.NoError(t, err)
+	out := string(raw)
+	assert.Contains(t, out, "To: reader@example.com\r\n")
+	assert.Contains(t, out, "Subject: =?utf-8?q?Caf=C3=A9_news?=\r\n")
+	assert.Contains(t, out, "List-Unsubscribe: <https://blog.example/u>\r\n")
+	assert.Contains(t, out, "multipart/alternative")
+	assert.Less(t, strings.Index(out, "Plain body"), strings.Index(out, "Rich body"), "the plain part comes first")
+}
@@ -0,0 +1,237 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-blog/db"
+	"go-blog/events"
+	"go-blog/metrics"
+	"go-blog/models"
+	"go-blog/testutils"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// eventLog collects the names of events a subscriber saw, in order.
+type eventLog struct {
+	mu    sync.Mutex
+	names []string
+}
+
+func (l *eventLog) add(name string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.names = append(l.names, name)
+}
+
+func (l *eventLog) get() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.names...)
+}
+
+func metricsBody(t *testing.T, suite *testutils.TestSuite) string {
+	w := suite.MakeRequest("GET", "/metrics", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	return w.Body.String()
+}
+
+func TestServicesPublishTypedDomainEvents(t *testing.T) {
+	suite := testutils.Setup()
+	var log eventLog
+	var deleted events.PostDeleted
+	var registered events.UserRegistered
+	events.Subscribe(suite.App.Events, "sync-log", func(ctx context.Context, e events.PostCreated) error {
+		log.add(e.EventName() + ":" + e.Post.Title)
+		return nil
+	})
+	events.Subscribe(suite.App.Events, "sync-log", func(ctx context.Context, e events.PostPublished) error {
+		log.add(e.EventName() + ":" + e.Post.Title)
+		return nil
+	})
+	events.Subscribe(suite.App.Events, "sync-log", func(ctx context.Context, e events.PostUpdated) error {
+		log.add(e.EventName() + ":" + e.Post.Title)
+		return nil
+	})
+	events.Subscribe(suite.App.Events, "sync-log", func(ctx context.Context, e events.PostDeleted) error {
+		deleted = e
+		log.add(e.EventName() + ":" + e.Post.Title)
+		return nil
+	})
+	events.Subscribe(suite.App.Events, "sync-log", func(ctx context.Context, e events.UserRegistered) error {
+		registered = e
+		log.add(e.EventName() + ":" + e.User.Username)
+		return nil
+	})
+
+	token := registerAndLogin(t, suite, "eventful", "password123", "blogger")
+	auth := map[string]string{"Authorization": "Bearer " + token}
+	assert.Equal(t, models.UserProfile{ID: registered.User.ID, Username: "eventful", AccountType: models.AccountTypeBlogger}, registered.User)
+
+	draft := createPostJSON(t, suite, token, map[string]any{"title": "Draft", "content": "Body", "status": "draft"})
+	published := createPostJSON(t, suite, token, map[string]any{"title": "Live", "content": "Body"})
+	for _, status := range []string{"published", "published"} {
+		body, _ := json.Marshal(map[string]any{"title": "Draft", "content": "Body", "status": status})
+		w := suite.MakeRequest("PUT", fmt.Sprintf("/api/posts/%d", draft.ID), bytes.NewBuffer(body), auth)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+	w := suite.MakeRequest("DELETE", fmt.Sprintf("/api/posts/%d", published.ID), nil, auth)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	assert.Equal(t, []string{
+		"user.registered:eventful",
+		"post.created:Draft",
+		"post.created:Live", "post.published:Live",
+		"post.updated:Draft", "post.published:Draft",
+		"post.updated:Draft",
+		"post.deleted:Live",
+	}, log.get(), "publishing happens once, when a draft goes live")
+	assert.Equal(t, published.ID, deleted.Post.ID)
+	assert.Equal(t, published.Slug, deleted.Post.Slug)
+}
+
+func TestSyncSubscriberFailuresRollTheChangeBack(t *testing.T) {
+	suite := testutils.Setup()
+	token := registerAndLogin(t, suite, "rollback", "password123", "blogger")
+	var asyncRan eventLog
+	events.SubscribeAsync(suite.App.Events, "after-commit", func(ctx context.Context, e events.PostCreated) error {
+		asyncRan.add(e.Post.Title)
+		return nil
+	})
+	events.Subscribe(suite.App.Events, "gatekeeper", func(ctx context.Context, e events.PostCreated) error {
+		switch e.Post.Title {
+		case "Refused":
+			return errors.New("not today")
+		case "Explosive":
+			panic("boom")
+		}
+		return nil
+	})
+
+	for _, title := range []string{"Refused", "Explosive"} {
+		body, _ := json.Marshal(map[string]any{"title": title, "content": "Body"})
+		w := suite.MakeRequest("POST", "/api/posts", bytes.NewBuffer(body), map[string]string{"Authorization": "Bearer " + token})
+		assert.Equal(t, http.StatusInternalServerError, w.Code, title)
+	}
+	var posts int64
+	require.NoError(t, suite.DB.Model(&models.Post{}).Count(&posts).Error)
+	assert.Zero(t, posts, "both changes were rolled back")
+
+	createPostJSON(t, suite, token, map[string]any{"title": "Accepted", "content": "Body"})
+	suite.App.Events.Wait()
+	assert.Equal(t, []string{"Accepted"}, asyncRan.get(), "after-commit handlers never see rolled back changes")
+
+	body := metricsBody(t, suite)
+	assert.Contains(t, body, `blog_events_handled_total{event="post.created",outcome="error",subscriber="gatekeeper"} 1`)
+	assert.Contains(t, body, `blog_events_handled_total{event="post.created",outcome="panic",subscriber="gatekeeper"} 1`)
+	assert.Contains(t, body, `blog_events_handled_total{event="post.created",outcome="ok",subscriber="gatekeeper"} 1`)
+	assert.Contains(t, body, `blog_events_handled_total{event="post.created",outcome="ok",subscriber="after-commit"} 1`)
+	assert.Contains(t, body, `blog_event_handler_duration_seconds_count{event="post.created",subscriber="gatekeeper"} 3`)
+}
+
+func TestFailedUnreactKeepsTheReaction(t *testing.T) {
+	suite := testutils.Setup()
+	token := registerAndLogin(t, suite, "unreacter", "password123", "blogger")
+	auth := map[string]string{"Authorization": "Bearer " + token}
+	post := createPostJSON(t, suite, token, map[string]any{"title": "Liked", "content": "Body"})
+	url := fmt.Sprintf("/api/posts/%d/reactions?type=like", post.ID)
+	require.Equal(t, http.StatusOK, suite.MakeRequest("POST", url, nil, auth).Code)
+	events.Subscribe(suite.App.Events, "gatekeeper", func(ctx context.Context, e events.PostUnreacted) error {
+		return errors.New("not today")
+	})
+
+	assert.Equal(t, http.StatusInternalServerError, suite.MakeRequest("DELETE", url, nil, auth).Code)
+	var reactions int64
+	require.NoError(t, suite.DB.Model(&models.Reaction{}).Where("post_id = ?", post.ID).Count(&reactions).Error)
+	assert.EqualValues(t, 1, reactions, "the removal was rolled back")
+}
+
+func TestAsyncSubscribersAreIsolatedFromThePublisher(t *testing.T) {
+	suite := testutils.Setup()
+	token := registerAndLogin(t, suite, "isolated", "password123", "blogger")
+	release := make(chan struct{})
+	var log eventLog
+	events.SubscribeAsync(suite.App.Events, "panics", func(ctx context.Context, e events.PostPublished) error {
+		panic("async boom")
+	})
+	events.SubscribeAsync(suite.App.Events, "fails", func(ctx context.Context, e events.PostPublished) error {
+		return errors.New("async failure")
+	})
+	events.SubscribeAsync(suite.App.Events, "slow", func(ctx context.Context, e events.PostPublished) error {
+		<-release
+		assert.NoError(t, ctx.Err(), "the request context's cancellation is not inherited")
+		log.add(e.Post.Title)
+		return nil
+	})
+
+	start := time.Now()
+	createPostJSON(t, suite, token, map[string]any{"title": "Fire and forget", "content": "Body"})
+	assert.Less(t, time.Since(start), 5*time.Second, "the publisher does not wait for async handlers")
+	assert.Empty(t, log.get())
+
+	close(release)
+	suite.App.Events.Wait()
+	assert.Equal(t, []string{"Fire and forget"}, log.get())
+	body := metricsBody(t, suite)
+	assert.Contains(t, body, `blog_events_handled_total{event="post.published",outcome="panic",subscriber="panics"} 1`)
+	assert.Contains(t, body, `blog_events_handled_total{event="post.published",outcome="error",subscriber="fails"} 1`)
+	assert.Contains(t, body, `blog_events_handled_total{event="post.published",outcome="ok",subscriber="slow"} 1`)
+}
+
+func TestAsyncSubscribersReadTheCommittedChange(t *testing.T) {
+	suite := testutils.Setup()
+	token := registerAndLogin(t, suite, "committed", "password123", "blogger")
+	var count int64
+	var countErr error
+	events.SubscribeAsync(suite.App.Events, "counts", func(ctx context.Context, e events.PostCreated) error {
+		countErr = db.Conn(ctx, suite.App.DB).Model(&models.Post{}).Where("id = ?", e.Post.ID).Count(&count).Error
+		return countErr
+	})
+
+	createPostJSON(t, suite, token, map[string]any{"title": "Counted", "content": "Body"})
+	suite.App.Events.Wait()
+	require.NoError(t, countErr, "the handler does not get the committed transaction")
+	assert.EqualValues(t, 1, count)
+}
+
+func TestEventBusRunWaitsForAsyncHandlers(t *testing.T) {
+	bus := events.NewBus(metrics.New(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	release := make(chan struct{})
+	var log eventLog
+	events.SubscribeAsync(bus, "slow", func(ctx context.Context, e events.PostDeleted) error {
+		<-release
+		log.add("handled " + e.Post.Title)
+		return nil
+	})
+	var nilBus *events.Bus
+	require.NoError(t, nilBus.Publish(context.Background(), events.PostDeleted{}), "a nil bus drops events")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() { stopped <- bus.Run(ctx) }()
+	require.NoError(t, bus.Publish(context.Background(), events.PostDeleted{Post: models.Post{Title: "one"}}))
+
+	cancel()
+	select {
+	case <-stopped:
+		t.Fatal("Run returned while a handler was still running")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	require.ErrorIs(t, <-stopped, context.Canceled)
+	assert.Equal(t, []string{"handled one"}, log.get())
+
+	// Once stopped, async handlers run before Publish returns.
+	require.NoError(t, bus.Publish(context.Background(), events.PostDeleted{Post: models.Post{Title: "two"}}))
+	assert.Equal(t, []string{"handled one", "handled two"}, log.get())
+}
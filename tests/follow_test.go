@@ -0,0 +1,132 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-blog/models"
+	"go-blog/testutils"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFollowAndFollowerLists(t *testing.T) {
+	suite := testutils.Setup()
+	registerAndLogin(t, suite, "followedblogger", "password", "blogger")
+	viewer := registerAndLogin(t, suite, "follower", "password", "viewer")
+	auth := map[string]string{"Authorization": "Bearer " + viewer}
+
+	for i := 0; i < 2; i++ {
+		w := suite.MakeRequest("POST", "/api/users/followedblogger/follow", nil, auth)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+
+	w := suite.MakeRequest("GET", "/api/users/followedblogger/followers", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "password")
+	var followers []models.UserProfile
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &followers))
+	require.Len(t, followers, 1)
+	assert.Equal(t, "follower", followers[0].Username)
+
+	w = suite.MakeRequest("GET", "/api/users/follower/following", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var following []models.UserProfile
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &following))
+	require.Len(t, following, 1)
+	assert.Equal(t, "followedblogger", following[0].Username)
+
+	w = suite.MakeRequest("DELETE", "/api/users/followedblogger/follow", nil, auth)
+	require.Equal(t, http.StatusOK, w.Code)
+	w = suite.MakeRequest("GET", "/api/users/followedblogger/followers", nil)
+	assert.JSONEq(t, "[]", w.Body.String())
+}
+
+func TestFollowValidation(t *testing.T) {
+	suite := testutils.Setup()
+	blogger := registerAndLogin(t, suite, "selffollow", "password", "blogger")
+	registerAndLogin(t, suite, "justaviewer", "password", "viewer")
+	auth := map[string]string{"Authorization": "Bearer " + blogger}
+
+	assert.Equal(t, http.StatusBadRequest, suite.MakeRequest("POST", "/api/users/selffollow/follow", nil, auth).Code)
+	assert.Equal(t, http.StatusBadRequest, suite.MakeRequest("POST", "/api/users/justaviewer/follow", nil, auth).Code)
+	assert.Equal(t, http.StatusNotFound, suite.MakeRequest("POST", "/api/users/nobody/follow", nil, auth).Code)
+	assert.Equal(t, http.StatusUnauthorized, suite.MakeRequest("POST", "/api/users/selffollow/follow", nil).Code)
+}
+
+func TestFeedShowsFollowedAuthorsWithCursorPagination(t *testing.T) {
+	suite := testutils.Setup()
+	alice := registerAndLogin(t, suite, "feedalice", "password", "blogger")
+	bob := registerAndLogin(t, suite, "feedbob", "password", "blogger")
+	carol := registerAndLogin(t, suite, "feedcarol", "password", "blogger")
+	viewer := registerAndLogin(t, suite, "feedviewer", "password", "viewer")
+	auth := map[string]string{"Authorization": "Bearer " + viewer}
+
+	var expected []int
+	for i := 0; i < 3; i++ {
+		expected = append(expected, createPostAs(t, suite, alice, fmt.Sprintf("alice %d", i), "body"))
+		expected = append(expected, createPostAs(t, suite, bob, fmt.Sprintf("bob %d", i), "body"))
+		createPostAs(t, suite, carol, fmt.Sprintf("carol %d", i), "body")
+	}
+	w := suite.MakeRequest("POST", "/api/posts", jsonBody(t, map[string]string{"title": "draft", "content": "body", "status": "draft"}),
+		map[string]string{"Authorization": "Bearer " + alice})
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	for _, username := range []string{"feedalice", "feedbob"} {
+		require.Equal(t, http.StatusOK, suite.MakeRequest("POST", "/api/users/"+username+"/follow", nil, auth).Code)
+	}
+
+	var got []int
+	cursor := ""
+	for pages := 0; pages < 10; pages++ {
+		w := suite.MakeRequest("GET", "/api/feed?limit=4&cursor="+cursor, nil, auth)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var page models.FeedPage
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		assert.LessOrEqual(t, len(page.Posts), 4)
+		for _, post := range page.Posts {
+			got = append(got, post.ID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	require.Len(t, got, len(expected))
+	for i := range expected {
+		assert.Equal(t, expected[len(expected)-1-i], got[i], "feed should be newest first")
+	}
+
+	assert.Equal(t, http.StatusBadRequest, suite.MakeRequest("GET", "/api/feed?cursor=not-a-cursor", nil, auth).Code)
+	assert.Equal(t, http.StatusUnauthorized, suite.MakeRequest("GET", "/api/feed", nil).Code)
+}
+
+func TestDraftPostsAreHiddenFromOthers(t *testing.T) {
+	suite := testutils.Setup()
+	author := registerAndLogin(t, suite, "draftauthor", "password", "blogger")
+	other := registerAndLogin(t, suite, "draftother", "password", "viewer")
+	w := suite.MakeRequest("POST", "/api/posts", jsonBody(t, map[string]string{"title": "draft", "content": "body", "status": "draft"}),
+		map[string]string{"Authorization": "Bearer " + author})
+	require.Equal(t, http.StatusCreated, w.Code)
+	var draft models.Post
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &draft))
+	assert.Nil(t, draft.PublishedAt)
+
+	url := fmt.Sprintf("/api/posts/%d", draft.ID)
+	assert.Equal(t, http.StatusOK, suite.MakeRequest("GET", url, nil, map[string]string{"Authorization": "Bearer " + author}).Code)
+	assert.Equal(t, http.StatusNotFound, suite.MakeRequest("GET", url, nil, map[string]string{"Authorization": "Bearer " + other}).Code)
+	assert.Equal(t, http.StatusNotFound, suite.MakeRequest("GET", url, nil).Code)
+	assert.NotContains(t, suite.MakeRequest("GET", "/api/posts", nil).Body.String(), `"draft"`)
+
+	w = suite.MakeRequest("PUT", url, jsonBody(t, map[string]string{"title": "draft", "content": "body", "status": "published"}),
+		map[string]string{"Authorization": "Bearer " + author})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var published models.Post
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &published))
+	assert.Equal(t, models.PostStatusPublished, published.Status)
+	assert.NotNil(t, published.PublishedAt)
+	assert.Equal(t, http.StatusOK, suite.MakeRequest("GET", url, nil).Code)
+}
@@ -0,0 +1,244 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-blog/config"
+	"go-blog/graph"
+	"go-blog/models"
+	"go-blog/service"
+	"go-blog/testutils"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type graphQLResponse struct {
+	Data   map[string]json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string         `json:"message"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+// graphQL posts query with token, which may be empty, and decodes the
+// response.
+func graphQL(t *testing.T, suite *testutils.TestSuite, token, query string, variables map[string]any) graphQLResponse {
+	t.Helper()
+	var headers []map[string]string
+	if token != "" {
+		headers = append(headers, map[string]string{"Authorization": "Bearer " + token})
+	}
+	w := suite.MakeRequest("POST", "/graphql", jsonBody(t, map[string]any{"query": query, "variables": variables}), headers...)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var response graphQLResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	return response
+}
+
+func errorCode(t *testing.T, response graphQLResponse) string {
+	t.Helper()
+	require.NotEmpty(t, response.Errors, "expected an error")
+	code, _ := response.Errors[0].Extensions["code"].(string)
+	return code
+}
+
+func TestGraphQLResolvesPostsWithAuthors(t *testing.T) {
+	suite := testutils.Setup()
+	token := registerAndLogin(t, suite, "graphauthor", "password123", "blogger")
+	first := createPostJSON(t, suite, token, map[string]any{"title": "First", "content": "Body", "tags": []string{"go"}})
+	createPostJSON(t, suite, token, map[string]any{"title": "Second", "content": "Body"})
+
+	response := graphQL(t, suite, "", `query Post($id: ID!) {
+		post(id: $id) {
+			id title status tags publishedAt
+			reactions { like }
+			author { username accountType posts(first: 5) { title } }
+		}
+	}`, map[string]any{"id": first.ID})
+	require.Empty(t, response.Errors)
+
+	var post struct {
+		ID          string   `json:"id"`
+		Title       string   `json:"title"`
+		Status      string   `json:"status"`
+		Tags        []string `json:"tags"`
+		PublishedAt *string  `json:"publishedAt"`
+		Reactions   struct {
+			Like int `json:"like"`
+		} `json:"reactions"`
+		Author struct {
+			Username    string `json:"username"`
+			AccountType string `json:"accountType"`
+			Posts       []struct {
+				Title string `json:"title"`
+			} `json:"posts"`
+		} `json:"author"`
+	}
+	require.NoError(t, json.Unmarshal(response.Data["post"], &post))
+	assert.Equal(t, fmt.Sprint(first.ID), post.ID)
+	assert.Equal(t, "First", post.Title)
+	assert.Equal(t, "PUBLISHED", post.Status)
+	assert.Equal(t, []string{"go"}, post.Tags)
+	assert.NotNil(t, post.PublishedAt)
+	assert.Zero(t, post.Reactions.Like)
+	assert.Equal(t, "graphauthor", post.Author.Username)
+	assert.Equal(t, "BLOGGER", post.Author.AccountType)
+	require.Len(t, post.Author.Posts, 2)
+	assert.Equal(t, "Second", post.Author.Posts[0].Title, "newest first")
+
+	response = graphQL(t, suite, "", `{ posts(first: 1, after: 1) { title } user(username: "nobody") { id } }`, nil)
+	require.Empty(t, response.Errors)
+	assert.JSONEq(t, `[{"title": "Second"}]`, string(response.Data["posts"]))
+	assert.JSONEq(t, `null`, string(response.Data["user"]))
+}
+
+func TestGraphQLHidesDraftsFromOthers(t *testing.T) {
+	suite := testutils.Setup()
+	owner := registerAndLogin(t, suite, "graphdrafter", "password123", "blogger")
+	other := registerAndLogin(t, suite, "graphpeeker", "password123", "blogger")
+	draft := createPostJSON(t, suite, owner, map[string]any{"title": "Secret", "content": "Body", "status": "draft"})
+	query := fmt.Sprintf(`{ post(id: %d) { title } }`, draft.ID)
+
+	assert.JSONEq(t, `null`, string(graphQL(t, suite, "", query, nil).Data["post"]))
+	assert.JSONEq(t, `null`, string(graphQL(t, suite, other, query, nil).Data["post"]))
+	assert.JSONEq(t, `{"title": "Secret"}`, string(graphQL(t, suite, owner, query, nil).Data["post"]))
+
+	response := graphQL(t, suite, "", `{ user(username: "graphdrafter") { posts { title } } }`, nil)
+	assert.JSONEq(t, `{"posts": []}`, string(response.Data["user"]))
+}
+
+func TestGraphQLMutationsMatchRESTAuth(t *testing.T) {
+	suite := testutils.Setup()
+	blogger := registerAndLogin(t, suite, "graphwriter", "password123", "blogger")
+	intruder := registerAndLogin(t, suite, "graphintruder", "password123", "blogger")
+	viewer := registerAndLogin(t, suite, "graphreader", "password123", "viewer")
+	create := `mutation($input: PostInput!) { createPost(input: $input) { id title author { username } } }`
+	input := map[string]any{"input": map[string]any{"title": "From GraphQL", "content": "Body", "tags": []string{"graphql"}}}
+
+	assert.Equal(t, "UNAUTHENTICATED", errorCode(t, graphQL(t, suite, "", create, input)))
+	assert.Equal(t, "FORBIDDEN", errorCode(t, graphQL(t, suite, viewer, create, input)))
+
+	w := suite.MakeRequest("POST", "/graphql", jsonBody(t, map[string]any{"query": create, "variables": input}),
+		map[string]string{"Authorization": "Bearer not-a-token"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "an invalid token is rejected as it is on REST")
+
+	response := graphQL(t, suite, blogger, create, input)
+	require.Empty(t, response.Errors)
+	var created struct {
+		ID     string `json:"id"`
+		Author struct {
+			Username string `json:"username"`
+		} `json:"author"`
+	}
+	require.NoError(t, json.Unmarshal(response.Data["createPost"], &created))
+	assert.Equal(t, "graphwriter", created.Author.Username)
+
+	invalid := map[string]any{"input": map[string]any{"title": "t", "content": "c", "tags": []string{"no_underscores"}}}
+	assert.Equal(t, "BAD_USER_INPUT", errorCode(t, graphQL(t, suite, blogger, create, invalid)))
+
+	update := `mutation($id: ID!, $title: String!) { updatePost(id: $id, input: {title: $title, content: "Edited"}) { title content tags } }`
+	assert.Equal(t, "UNAUTHENTICATED", errorCode(t, graphQL(t, suite, "", update, map[string]any{"id": created.ID, "title": "x"})))
+	response = graphQL(t, suite, intruder, update, map[string]any{"id": created.ID, "title": "Hijacked"})
+	assert.Equal(t, "FORBIDDEN", errorCode(t, response))
+	assert.Equal(t, "you can only update your own posts", response.Errors[0].Message)
+	assert.Equal(t, "NOT_FOUND", errorCode(t, graphQL(t, suite, blogger, update, map[string]any{"id": 9999, "title": "x"})))
+
+	response = graphQL(t, suite, blogger, update, map[string]any{"id": created.ID, "title": "Renamed"})
+	require.Empty(t, response.Errors)
+	assert.JSONEq(t, `{"title": "Renamed", "content": "Edited", "tags": ["graphql"]}`, string(response.Data["updatePost"]), "fields left out are kept")
+
+	remove := `mutation($id: ID!) { deletePost(id: $id) }`
+	assert.Equal(t, "FORBIDDEN", errorCode(t, graphQL(t, suite, intruder, remove, map[string]any{"id": created.ID})))
+	response = graphQL(t, suite, blogger, remove, map[string]any{"id": created.ID})
+	require.Empty(t, response.Errors)
+	assert.JSONEq(t, fmt.Sprintf(`%q`, created.ID), string(response.Data["deletePost"]))
+	assert.Equal(t, http.StatusNotFound, suite.MakeRequest("GET", "/api/posts/"+created.ID, nil).Code)
+}
+
+func TestGraphQLRejectsDeepAndComplexQueries(t *testing.T) {
+	cfg := testutils.TestConfig()
+	cfg.GraphQL = config.GraphQLConfig{MaxDepth: 4, MaxComplexity: 200}
+	suite := testutils.SetupWithConfig(cfg)
+
+	deep := `{ posts { author { posts { author { username } } } } }`
+	response := graphQL(t, suite, "", deep, nil)
+	assert.Equal(t, "QUERY_TOO_COMPLEX", errorCode(t, response))
+	assert.Contains(t, response.Errors[0].Message, "depth 5")
+
+	viaFragment := `{ posts { ...withAuthor } } fragment withAuthor on Post { author { posts { author { id } } } }`
+	assert.Equal(t, "QUERY_TOO_COMPLEX", errorCode(t, graphQL(t, suite, "", viaFragment, nil)))
+
+	// 1 + 20 * (1 + 1 + 20 * 1) = 441
+	wide := `query($n: Int) { posts(first: $n) { author { posts(first: $n) { id } } } }`
+	response = graphQL(t, suite, "", wide, map[string]any{"n": 20})
+	assert.Equal(t, "QUERY_TOO_COMPLEX", errorCode(t, response))
+	assert.Contains(t, response.Errors[0].Message, "complexity 441")
+
+	response = graphQL(t, suite, "", wide, map[string]any{"n": 5})
+	assert.Empty(t, response.Errors)
+
+	introspection := `{ __schema { types { name fields { name type { name ofType { name ofType { name } } } } } } }`
+	assert.Empty(t, graphQL(t, suite, "", introspection, nil).Errors, "introspection is not limited")
+
+	response = graphQL(t, suite, "", `{ posts(first: 500) { id } }`, nil)
+	assert.NotEmpty(t, response.Errors)
+
+	w := suite.MakeRequest("POST", "/graphql", jsonBody(t, map[string]any{"variables": map[string]any{}}))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// countingUsers and countingPosts count the batch lookups the loaders make.
+type countingUsers struct {
+	service.UserService
+	calls atomic.Int32
+}
+
+func (s *countingUsers) GetUsersByID(ctx context.Context, ids []int) ([]models.User, error) {
+	s.calls.Add(1)
+	return s.UserService.GetUsersByID(ctx, ids)
+}
+
+type countingPosts struct {
+	service.PostService
+	calls atomic.Int32
+}
+
+func (s *countingPosts) GetPostsByAuthors(ctx context.Context, authorIDs []int, limit int) ([]models.Post, error) {
+	s.calls.Add(1)
+	return s.PostService.GetPostsByAuthors(ctx, authorIDs, limit)
+}
+
+func TestGraphQLBatchesAuthorLookups(t *testing.T) {
+	suite := testutils.Setup()
+	for _, name := range []string{"batchone", "batchtwo", "batchthree"} {
+		token := registerAndLogin(t, suite, name, "password123", "blogger")
+		for i := range 3 {
+			createPostJSON(t, suite, token, map[string]any{"title": fmt.Sprintf("%s %d", name, i), "content": "Body"})
+		}
+	}
+	users := &countingUsers{UserService: suite.App.UserService}
+	posts := &countingPosts{PostService: suite.App.PostService}
+	server, err := graph.NewServer(suite.App.Config.GraphQL, posts, users, suite.App.AuthRepo)
+	require.NoError(t, err)
+
+	result := server.Execute(context.Background(), graph.Viewer{}, graph.Request{
+		Query: `{ posts(first: 9) { author { username posts(first: 2) { author { username } } } } }`,
+	})
+	require.Empty(t, result.Errors)
+	listed := result.Data.(map[string]any)["posts"].([]any)
+	require.Len(t, listed, 9)
+	for _, post := range listed {
+		author := post.(map[string]any)["author"].(map[string]any)
+		require.Len(t, author["posts"], 2)
+		for _, own := range author["posts"].([]any) {
+			assert.Equal(t, author["username"], own.(map[string]any)["author"].(map[string]any)["username"])
+		}
+	}
+	assert.EqualValues(t, 1, users.calls.Load(), "authors are loaded in one batch; known ones are not looked up again")
+	assert.EqualValues(t, 1, posts.calls.Load(), "authors' posts are loaded in one batch")
+}
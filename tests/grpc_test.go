@@ -0,0 +1,275 @@
+package tests
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	blogv1 "go-blog/proto/blog/v1"
+	"go-blog/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type grpcClients struct {
+	posts blogv1.PostServiceClient
+	users blogv1.UserServiceClient
+}
+
+// startGRPC serves the suite's gRPC server on an in-memory listener and
+// stops it when the test ends.
+func startGRPC(t *testing.T, suite *testutils.TestSuite) grpcClients {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	served := make(chan error, 1)
+	go func() { served <- suite.App.GRPCServer.Serve(listener) }()
+	t.Cleanup(func() {
+		suite.App.GRPCServer.Stop()
+		assert.NoError(t, <-served)
+	})
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return grpcClients{posts: blogv1.NewPostServiceClient(conn), users: blogv1.NewUserServiceClient(conn)}
+}
+
+// asUser returns a context carrying token, which may be empty, as the
+// authorization metadata.
+func asUser(t *testing.T, token string) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	if token == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+}
+
+func assertCode(t *testing.T, want codes.Code, err error) {
+	t.Helper()
+	require.Error(t, err)
+	assert.Equal(t, want, status.Code(err), err.Error())
+}
+
+func TestGRPCPostCRUD(t *testing.T) {
+	suite := testutils.Setup()
+	client := startGRPC(t, suite)
+	token := registerAndLogin(t, suite, "grpcauthor", "password123", "blogger")
+
+	created, err := client.posts.CreatePost(asUser(t, token), &blogv1.CreatePostRequest{
+		Title: "Over gRPC", Content: "Body", Tags: []string{"go"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, blogv1.PostStatus_POST_STATUS_PUBLISHED, created.GetStatus())
+	assert.Equal(t, []string{"go"}, created.GetTags())
+	assert.NotNil(t, created.GetPublishedAt())
+
+	fetched, err := client.posts.GetPost(asUser(t, ""), &blogv1.GetPostRequest{Id: created.GetId()})
+	require.NoError(t, err)
+	assert.Equal(t, "Over gRPC", fetched.GetTitle())
+
+	updated, err := client.posts.UpdatePost(asUser(t, token), &blogv1.UpdatePostRequest{
+		Id: created.GetId(), Title: "Renamed", Content: "Edited",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Renamed", updated.GetTitle())
+	assert.Equal(t, []string{"go"}, updated.GetTags(), "tags are kept unless update_tags is set")
+
+	updated, err = client.posts.UpdatePost(asUser(t, token), &blogv1.UpdatePostRequest{
+		Id: created.GetId(), Title: "Renamed", Content: "Edited", UpdateTags: true,
+	})
+	require.NoError(t, err)
+	assert.Empty(t, updated.GetTags())
+
+	_, err = client.posts.CreatePost(asUser(t, token), &blogv1.CreatePostRequest{Title: "t", Content: "c", Tags: []string{"no_underscores"}})
+	assertCode(t, codes.InvalidArgument, err)
+
+	_, err = client.posts.DeletePost(asUser(t, token), &blogv1.DeletePostRequest{Id: created.GetId()})
+	require.NoError(t, err)
+	_, err = client.posts.GetPost(asUser(t, ""), &blogv1.GetPostRequest{Id: created.GetId()})
+	assertCode(t, codes.NotFound, err)
+}
+
+func TestGRPCListPostsPages(t *testing.T) {
+	suite := testutils.Setup()
+	client := startGRPC(t, suite)
+	token := registerAndLogin(t, suite, "grpcpager", "password123", "blogger")
+	for _, title := range []string{"One", "Two", "Three"} {
+		createPostJSON(t, suite, token, map[string]any{"title": title, "content": "Body"})
+	}
+	createPostJSON(t, suite, token, map[string]any{"title": "Draft", "content": "Body", "status": "draft"})
+
+	page, err := client.posts.ListPosts(asUser(t, ""), &blogv1.ListPostsRequest{PageSize: 2})
+	require.NoError(t, err)
+	require.Len(t, page.GetPosts(), 2)
+	assert.Equal(t, "One", page.GetPosts()[0].GetTitle())
+	assert.Equal(t, page.GetPosts()[1].GetId(), page.GetNextAfterId())
+
+	page, err = client.posts.ListPosts(asUser(t, ""), &blogv1.ListPostsRequest{PageSize: 2, AfterId: page.GetNextAfterId()})
+	require.NoError(t, err)
+	require.Len(t, page.GetPosts(), 1, "drafts are not listed")
+	assert.Equal(t, "Three", page.GetPosts()[0].GetTitle())
+	assert.Zero(t, page.GetNextAfterId())
+
+	_, err = client.posts.ListPosts(asUser(t, ""), &blogv1.ListPostsRequest{PageSize: 101})
+	assertCode(t, codes.InvalidArgument, err)
+}
+
+func TestGRPCAuthMatchesREST(t *testing.T) {
+	suite := testutils.Setup()
+	client := startGRPC(t, suite)
+	owner := registerAndLogin(t, suite, "grpcowner", "password123", "blogger")
+	intruder := registerAndLogin(t, suite, "grpcintruder", "password123", "blogger")
+	viewer := registerAndLogin(t, suite, "grpcviewer", "password123", "viewer")
+	create := &blogv1.CreatePostRequest{Title: "Mine", Content: "Body"}
+
+	_, err := client.posts.CreatePost(asUser(t, ""), create)
+	assertCode(t, codes.Unauthenticated, err)
+	_, err = client.posts.CreatePost(asUser(t, "not-a-token"), create)
+	assertCode(t, codes.Unauthenticated, err)
+	assert.Equal(t, "invalid token", status.Convert(err).Message())
+	_, err = client.posts.CreatePost(asUser(t, viewer), create)
+	assertCode(t, codes.PermissionDenied, err)
+	assert.Equal(t, "insufficient permissions", status.Convert(err).Message())
+
+	_, err = client.posts.ListPosts(asUser(t, "not-a-token"), &blogv1.ListPostsRequest{})
+	assertCode(t, codes.Unauthenticated, err)
+
+	post, err := client.posts.CreatePost(asUser(t, owner), create)
+	require.NoError(t, err)
+	update := &blogv1.UpdatePostRequest{Id: post.GetId(), Title: "Hijacked", Content: "Body"}
+	_, err = client.posts.UpdatePost(asUser(t, ""), update)
+	assertCode(t, codes.Unauthenticated, err)
+	_, err = client.posts.UpdatePost(asUser(t, intruder), update)
+	assertCode(t, codes.PermissionDenied, err)
+	assert.Equal(t, "you can only update your own posts", status.Convert(err).Message())
+	_, err = client.posts.DeletePost(asUser(t, intruder), &blogv1.DeletePostRequest{Id: post.GetId()})
+	assertCode(t, codes.PermissionDenied, err)
+	_, err = client.posts.DeletePost(asUser(t, owner), &blogv1.DeletePostRequest{Id: 9999})
+	assertCode(t, codes.NotFound, err)
+}
+
+func TestGRPCHidesDraftsFromOthers(t *testing.T) {
+	suite := testutils.Setup()
+	client := startGRPC(t, suite)
+	owner := registerAndLogin(t, suite, "grpcdrafter", "password123", "blogger")
+	other := registerAndLogin(t, suite, "grpcpeeker", "password123", "blogger")
+	draft := createPostJSON(t, suite, owner, map[string]any{"title": "Secret", "content": "Body", "status": "draft"})
+	req := &blogv1.GetPostRequest{Id: int64(draft.ID)}
+
+	_, err := client.posts.GetPost(asUser(t, ""), req)
+	assertCode(t, codes.NotFound, err)
+	_, err = client.posts.GetPost(asUser(t, other), req)
+	assertCode(t, codes.NotFound, err)
+	post, err := client.posts.GetPost(asUser(t, owner), req)
+	require.NoError(t, err)
+	assert.Equal(t, blogv1.PostStatus_POST_STATUS_DRAFT, post.GetStatus())
+	assert.Nil(t, post.GetPublishedAt())
+}
+
+func TestGRPCUserLookups(t *testing.T) {
+	suite := testutils.Setup()
+	client := startGRPC(t, suite)
+	registerAndLogin(t, suite, "grpcfirst", "password123", "blogger")
+	registerAndLogin(t, suite, "grpcsecond", "password123", "viewer")
+
+	user, err := client.users.GetUser(asUser(t, ""), &blogv1.GetUserRequest{Username: "grpcsecond"})
+	require.NoError(t, err)
+	assert.Equal(t, blogv1.AccountType_ACCOUNT_TYPE_VIEWER, user.GetAccountType())
+	_, err = client.users.GetUser(asUser(t, ""), &blogv1.GetUserRequest{Username: "nobody"})
+	assertCode(t, codes.NotFound, err)
+
+	batch, err := client.users.BatchGetUsers(asUser(t, ""), &blogv1.BatchGetUsersRequest{Ids: []int64{user.GetId(), 9999, user.GetId() - 1}})
+	require.NoError(t, err)
+	require.Len(t, batch.GetUsers(), 2)
+	assert.Equal(t, "grpcfirst", batch.GetUsers()[0].GetUsername())
+	assert.Equal(t, "grpcsecond", batch.GetUsers()[1].GetUsername())
+}
+
+// watchPosts opens a WatchPosts stream and waits until it is watching.
+func watchPosts(t *testing.T, client grpcClients, token string) grpc.ServerStreamingClient[blogv1.PostEvent] {
+	t.Helper()
+	stream, err := client.posts.WatchPosts(asUser(t, token), &blogv1.WatchPostsRequest{})
+	require.NoError(t, err)
+	_, err = stream.Header()
+	require.NoError(t, err)
+	return stream
+}
+
+func TestGRPCWatchPostsStreamsCommittedChanges(t *testing.T) {
+	suite := testutils.Setup()
+	client := startGRPC(t, suite)
+	owner := registerAndLogin(t, suite, "grpcwatched", "password123", "blogger")
+	anonymous := watchPosts(t, client, "")
+	own := watchPosts(t, client, owner)
+
+	createPostJSON(t, suite, owner, map[string]any{"title": "Draft", "content": "Body", "status": "draft"})
+	published := createPostJSON(t, suite, owner, map[string]any{"title": "Public", "content": "Body"})
+
+	var ownTypes []blogv1.PostEvent_Type
+	for range 3 {
+		event, err := own.Recv()
+		require.NoError(t, err)
+		ownTypes = append(ownTypes, event.GetType())
+	}
+	assert.Equal(t, []blogv1.PostEvent_Type{
+		blogv1.PostEvent_TYPE_CREATED, blogv1.PostEvent_TYPE_CREATED, blogv1.PostEvent_TYPE_PUBLISHED,
+	}, ownTypes, "the author sees their draft")
+
+	event, err := anonymous.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, blogv1.PostEvent_TYPE_CREATED, event.GetType())
+	assert.Equal(t, int64(published.ID), event.GetPost().GetId(), "others do not see drafts")
+	event, err = anonymous.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, blogv1.PostEvent_TYPE_PUBLISHED, event.GetType())
+
+	_, err = client.posts.DeletePost(asUser(t, owner), &blogv1.DeletePostRequest{Id: int64(published.ID)})
+	require.NoError(t, err)
+	event, err = anonymous.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, blogv1.PostEvent_TYPE_DELETED, event.GetType())
+	assert.Equal(t, "Public", event.GetPost().GetTitle())
+
+	suite.App.GRPCServer.Stop()
+	_, err = anonymous.Recv()
+	assertCode(t, codes.Unavailable, err)
+}
+
+func TestGRPCWatchPostsTellsReadersAboutUnpublishedPosts(t *testing.T) {
+	suite := testutils.Setup()
+	client := startGRPC(t, suite)
+	owner := registerAndLogin(t, suite, "grpcretractor", "password123", "blogger")
+	post := createPostJSON(t, suite, owner, map[string]any{"title": "Public", "content": "Body"})
+	anonymous := watchPosts(t, client, "")
+	own := watchPosts(t, client, owner)
+
+	_, err := client.posts.UpdatePost(asUser(t, owner), &blogv1.UpdatePostRequest{
+		Id: int64(post.ID), Title: "Retracted", Content: "Body", Status: blogv1.PostStatus_POST_STATUS_DRAFT,
+	})
+	require.NoError(t, err)
+
+	event, err := anonymous.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, blogv1.PostEvent_TYPE_UNPUBLISHED, event.GetType())
+	assert.Equal(t, int64(post.ID), event.GetPost().GetId())
+	assert.Equal(t, blogv1.PostStatus_POST_STATUS_DRAFT, event.GetPost().GetStatus())
+	assert.Empty(t, event.GetPost().GetTitle(), "readers are not shown the draft")
+
+	for _, want := range []blogv1.PostEvent_Type{blogv1.PostEvent_TYPE_UPDATED, blogv1.PostEvent_TYPE_UNPUBLISHED} {
+		event, err = own.Recv()
+		require.NoError(t, err)
+		assert.Equal(t, want, event.GetType())
+		assert.Equal(t, "Retracted", event.GetPost().GetTitle())
+	}
+}
@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"go-blog/testutils"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthEndpoints(t *testing.T) {
+	suite := testutils.Setup()
+
+	w := suite.MakeRequest("GET", "/healthz", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = suite.MakeRequest("GET", "/readyz", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	checks := resp["checks"].(map[string]interface{})
+	assert.Equal(t, "ok", checks["database"])
+
+	_, err := suite.App.Migrator.Down(context.Background(), 1)
+	require.NoError(t, err)
+	w = suite.MakeRequest("GET", "/readyz", nil)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code, "pending migrations make the instance unready")
+}
+
+type recordingWorker struct {
+	stopped chan struct{}
+}
+
+func (w *recordingWorker) Name() string { return "recording" }
+
+func (w *recordingWorker) Run(ctx context.Context) error {
+	<-ctx.Done()
+	close(w.stopped)
+	return ctx.Err()
+}
+
+func TestAppRunShutsDownOnCancel(t *testing.T) {
+	cfg := testutils.TestConfig()
+	cfg.Server.Addr = "127.0.0.1:0"
+	suite := testutils.SetupWithConfig(cfg)
+	worker := &recordingWorker{stopped: make(chan struct{})}
+	suite.App.AddWorker(worker)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- suite.App.Run(ctx) }()
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after cancellation")
+	}
+	select {
+	case <-worker.stopped:
+	default:
+		t.Fatal("worker was not stopped")
+	}
+	w := suite.MakeRequest("GET", "/readyz", nil)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
@@ -0,0 +1,336 @@
+package tests
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-blog/archive"
+	"go-blog/models"
+	"go-blog/testutils"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createPostJSON(t *testing.T, suite *testutils.TestSuite, token string, post map[string]any) models.Post {
+	body, _ := json.Marshal(post)
+	w := suite.MakeRequest("POST", "/api/posts", bytes.NewBuffer(body), map[string]string{"Authorization": "Bearer " + token})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created models.Post
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	return created
+}
+
+func exportBlog(t *testing.T, suite *testutils.TestSuite, token string) []byte {
+	w := suite.MakeRequest("GET", "/api/me/export", nil, map[string]string{"Authorization": "Bearer " + token})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "attachment")
+	return w.Body.Bytes()
+}
+
+func uploadImport(t *testing.T, suite *testutils.TestSuite, token, filename string, data []byte) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", filename)
+	require.NoError(t, err)
+	_, err = part.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+	return suite.MakeRequest("POST", "/api/me/import", &body, map[string]string{
+		"Authorization": "Bearer " + token,
+		"Content-Type":  form.FormDataContentType(),
+	})
+}
+
+// importAndRun queues an import, runs the queue to completion and returns
+// the finished job as the API reports it.
+func importAndRun(t *testing.T, suite *testutils.TestSuite, token, filename string, data []byte) models.ImportJob {
+	w := uploadImport(t, suite, token, filename, data)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	var job models.ImportJob
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &job))
+	assert.Equal(t, models.ImportQueued, job.Status)
+	assert.Equal(t, fmt.Sprintf("/api/me/imports/%d", job.ID), w.Header().Get("Location"))
+
+	_, err := suite.App.Jobs.Drain(context.Background())
+	require.NoError(t, err)
+
+	w = suite.MakeRequest("GET", fmt.Sprintf("/api/me/imports/%d", job.ID), nil, map[string]string{"Authorization": "Bearer " + token})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &job))
+	return job
+}
+
+func TestExportWritesMarkdownAndManifest(t *testing.T) {
+	suite := testutils.Setup()
+	token := registerAndLogin(t, suite, "exporter", "password", "blogger")
+	published := createPostJSON(t, suite, token, map[string]any{"title": "Hello, World", "content": "# Hi\n\nFirst post.", "tags": []string{"Go", "intro"}})
+	draft := createPostJSON(t, suite, token, map[string]any{"title": "Unfinished", "slug": "wip", "content": "tbd", "status": "draft"})
+
+	data := exportBlog(t, suite, token)
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		raw, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+		files[f.Name] = string(raw)
+	}
+
+	var manifest archive.Manifest
+	require.NoError(t, json.Unmarshal([]byte(files[archive.ManifestFile]), &manifest))
+	assert.Equal(t, archive.ManifestFormat, manifest.Format)
+	assert.Equal(t, "exporter", manifest.Author)
+	require.Len(t, manifest.Posts, 2)
+	assert.Equal(t, "posts/hello-world.md", manifest.Posts[0].File)
+	assert.Equal(t, []string{"go", "intro"}, manifest.Posts[0].Tags)
+	assert.Equal(t, "posts/wip.md", manifest.Posts[1].File)
+	assert.Equal(t, models.PostStatusDraft, manifest.Posts[1].Status)
+
+	markdown := files["posts/hello-world.md"]
+	assert.Contains(t, markdown, "title: Hello, World\n")
+	assert.Contains(t, markdown, "slug: hello-world\n")
+	assert.Contains(t, markdown, "status: published\n")
+	assert.Contains(t, markdown, "date: "+published.PublishedAt.Format(time.RFC3339Nano))
+	assert.Contains(t, markdown, "tags:\n    - go\n    - intro\n")
+	assert.Contains(t, markdown, "---\n\n# Hi\n\nFirst post.")
+
+	entry, err := archive.ParseMarkdown([]byte(files["posts/wip.md"]))
+	require.NoError(t, err)
+	assert.Equal(t, draft.Title, entry.Title)
+	assert.Nil(t, entry.PublishedAt)
+	assert.Equal(t, "tbd", entry.Content)
+
+	reader := registerAndLogin(t, suite, "exportreader", "password", "viewer")
+	entries, err := archive.ReadZip(exportBlog(t, suite, reader))
+	require.NoError(t, err)
+	assert.Empty(t, entries, "a user without posts gets an empty archive")
+}
+
+func TestImportExportRoundTripAndDeduplicates(t *testing.T) {
+	suite := testutils.Setup()
+	source := registerAndLogin(t, suite, "oldblog", "password", "blogger")
+	createPostJSON(t, suite, source, map[string]any{"title": "One", "content": "first body", "tags": []string{"a"}})
+	createPostJSON(t, suite, source, map[string]any{"title": "Two", "content": "second body", "status": "draft"})
+	createPostJSON(t, suite, source, map[string]any{"title": "Three", "content": "third body"})
+	original, err := archive.ReadZip(exportBlog(t, suite, source))
+	require.NoError(t, err)
+
+	target := registerAndLogin(t, suite, "newblog", "password", "blogger")
+	createPostJSON(t, suite, target, map[string]any{"title": "Three", "content": "already here"})
+
+	job := importAndRun(t, suite, target, "export.zip", exportBlog(t, suite, source))
+	assert.Equal(t, models.ImportCompleted, job.Status)
+	assert.Equal(t, models.ImportFormatArchive, job.Format)
+	assert.Equal(t, 3, job.Total)
+	assert.Equal(t, 3, job.Processed)
+	assert.Equal(t, 2, job.Created)
+	assert.Equal(t, 1, job.Skipped)
+	assert.Equal(t, 0, job.Failed)
+	require.Len(t, job.Messages, 1)
+	assert.Contains(t, job.Messages[0], `"Three"`)
+	assert.NotNil(t, job.StartedAt)
+	assert.NotNil(t, job.FinishedAt)
+
+	imported, err := archive.ReadZip(exportBlog(t, suite, target))
+	require.NoError(t, err)
+	require.Len(t, imported, 3)
+	assert.Equal(t, "already here", imported[0].Content)
+	for i, want := range original[:2] {
+		got := imported[i+1]
+		assert.Equal(t, want.Slug, got.Slug)
+		assert.Equal(t, want.Title, got.Title)
+		assert.Equal(t, want.Status, got.Status)
+		assert.Equal(t, want.Content, got.Content)
+		assert.Equal(t, want.Tags, got.Tags)
+		assert.True(t, want.CreatedAt.Equal(got.CreatedAt), "created_at is kept")
+		if want.PublishedAt != nil {
+			require.NotNil(t, got.PublishedAt)
+			assert.True(t, want.PublishedAt.Equal(*got.PublishedAt), "published_at is kept")
+		} else {
+			assert.Nil(t, got.PublishedAt)
+		}
+	}
+
+	// Importing the same file again changes nothing.
+	again := importAndRun(t, suite, target, "export.zip", exportBlog(t, suite, source))
+	assert.Equal(t, 0, again.Created)
+	assert.Equal(t, 3, again.Skipped)
+
+	w := suite.MakeRequest("GET", "/api/me/imports", nil, map[string]string{"Authorization": "Bearer " + target})
+	require.Equal(t, http.StatusOK, w.Code)
+	var jobs []models.ImportJob
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &jobs))
+	require.Len(t, jobs, 2)
+	assert.Equal(t, again.ID, jobs[0].ID)
+	assert.NotContains(t, w.Body.String(), "storage_key")
+
+	w = suite.MakeRequest("GET", fmt.Sprintf("/api/me/imports/%d", job.ID), nil, map[string]string{"Authorization": "Bearer " + source})
+	assert.Equal(t, http.StatusNotFound, w.Code, "other users' imports are hidden")
+}
+
+func TestImportAppliesPostValidation(t *testing.T) {
+	suite := testutils.Setup()
+	token := registerAndLogin(t, suite, "strictimport", "password", "blogger")
+
+	var buf bytes.Buffer
+	require.NoError(t, archive.WriteZip(&buf, "someone", time.Now(), []archive.Entry{
+		{Title: "Fine", Slug: "fine", Status: models.PostStatusPublished, Content: "ok", CreatedAt: time.Now()},
+		{Title: "Empty", Slug: "empty", Status: models.PostStatusPublished, Content: "", CreatedAt: time.Now()},
+		{Title: "Tagged", Slug: "tagged", Status: models.PostStatusPublished, Content: "ok", Tags: []string{"no_underscores"}},
+		{Title: "Odd", Slug: "odd", Status: "scheduled", Content: "ok"},
+	}))
+	job := importAndRun(t, suite, token, "posts.zip", buf.Bytes())
+	assert.Equal(t, models.ImportCompleted, job.Status)
+	assert.Equal(t, 4, job.Processed)
+	assert.Equal(t, 1, job.Created)
+	assert.Equal(t, 3, job.Failed)
+	require.Len(t, job.Messages, 3)
+	assert.Contains(t, job.Messages[0], "content cannot be empty")
+	assert.Contains(t, job.Messages[1], models.ErrInvalidTag.Error())
+	assert.Contains(t, job.Messages[2], models.ErrInvalidStatus.Error())
+}
+
+const sampleWXR = `<?xml version="1.0" encoding="UTF-8" ?>
+<rss version="2.0"
+	xmlns:content="http://purl.org/rss/1.0/modules/content/"
+	xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+	<title>My WordPress blog</title>
+	<item>
+		<title>Moving house</title>
+		<pubDate>Mon, 02 Mar 2020 10:00:00 +0000</pubDate>
+		<content:encoded><![CDATA[<p>We moved!</p>]]></content:encoded>
+		<wp:post_date_gmt>2020-03-02 09:30:00</wp:post_date_gmt>
+		<wp:post_modified_gmt>2020-03-05 12:00:00</wp:post_modified_gmt>
+		<wp:post_name>moving-house</wp:post_name>
+		<wp:status>publish</wp:status>
+		<wp:post_type>post</wp:post_type>
+		<category domain="category" nicename="life"><![CDATA[Life]]></category>
+		<category domain="post_tag" nicename="boxes"><![CDATA[Boxes]]></category>
+		<category domain="post_tag" nicename="new-home"><![CDATA[New Home]]></category>
+	</item>
+	<item>
+		<title>Half written</title>
+		<content:encoded><![CDATA[Draft text]]></content:encoded>
+		<wp:post_date_gmt>0000-00-00 00:00:00</wp:post_date_gmt>
+		<wp:post_name></wp:post_name>
+		<wp:status>draft</wp:status>
+		<wp:post_type>post</wp:post_type>
+	</item>
+	<item>
+		<title>About</title>
+		<content:encoded><![CDATA[A page]]></content:encoded>
+		<wp:post_name>about</wp:post_name>
+		<wp:status>publish</wp:status>
+		<wp:post_type>page</wp:post_type>
+	</item>
+	<item>
+		<title>Deleted</title>
+		<content:encoded><![CDATA[Gone]]></content:encoded>
+		<wp:post_name>deleted</wp:post_name>
+		<wp:status>trash</wp:status>
+		<wp:post_type>post</wp:post_type>
+	</item>
+</channel>
+</rss>`
+
+func TestImportWordPressWXR(t *testing.T) {
+	suite := testutils.Setup()
+	token := registerAndLogin(t, suite, "wpmigrant", "password", "blogger")
+
+	job := importAndRun(t, suite, token, "wordpress.xml", []byte(sampleWXR))
+	assert.Equal(t, models.ImportCompleted, job.Status)
+	assert.Equal(t, models.ImportFormatWXR, job.Format)
+	assert.Equal(t, 2, job.Total)
+	assert.Equal(t, 2, job.Created)
+
+	entries, err := archive.ReadZip(exportBlog(t, suite, token))
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	moving := entries[0]
+	assert.Equal(t, "moving-house", moving.Slug)
+	assert.Equal(t, models.PostStatusPublished, moving.Status)
+	assert.Equal(t, "<p>We moved!</p>", moving.Content)
+	assert.Equal(t, []string{"boxes", "new-home"}, moving.Tags, "tags are kept, categories are not")
+	require.NotNil(t, moving.PublishedAt)
+	assert.True(t, time.Date(2020, 3, 2, 9, 30, 0, 0, time.UTC).Equal(*moving.PublishedAt))
+	assert.True(t, time.Date(2020, 3, 5, 12, 0, 0, 0, time.UTC).Equal(moving.UpdatedAt))
+
+	draft := entries[1]
+	assert.Equal(t, "half-written", draft.Slug, "a missing slug is derived from the title")
+	assert.Equal(t, models.PostStatusDraft, draft.Status)
+	assert.Nil(t, draft.PublishedAt)
+}
+
+func TestImportRejectsBadUploads(t *testing.T) {
+	cfg := testutils.TestConfig()
+	cfg.Import.MaxBytes = 4096
+	suite := testutils.SetupWithConfig(cfg)
+	blogger := registerAndLogin(t, suite, "badimport", "password", "blogger")
+	viewer := registerAndLogin(t, suite, "importviewer", "password", "viewer")
+
+	w := uploadImport(t, suite, blogger, "notes.txt", []byte("just some text"))
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code, w.Body.String())
+
+	w = uploadImport(t, suite, blogger, "broken.zip", []byte("PK\x03\x04 not really a zip"))
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	var noManifest bytes.Buffer
+	zw := zip.NewWriter(&noManifest)
+	f, err := zw.Create("posts/a.md")
+	require.NoError(t, err)
+	f.Write([]byte("---\ntitle: a\n---\n\nbody"))
+	require.NoError(t, zw.Close())
+	w = uploadImport(t, suite, blogger, "export.zip", noManifest.Bytes())
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), archive.ManifestFile)
+
+	w = uploadImport(t, suite, blogger, "wordpress.xml", []byte("<rss><channel><item>"))
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	w = uploadImport(t, suite, blogger, "huge.xml", append([]byte("<rss>"), make([]byte, 8192)...))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code, w.Body.String())
+
+	w = uploadImport(t, suite, viewer, "wordpress.xml", []byte(sampleWXR))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = suite.MakeRequest("GET", "/api/me/imports", nil, map[string]string{"Authorization": "Bearer " + blogger})
+	assert.Equal(t, "[]", w.Body.String(), "rejected uploads queue nothing")
+	w = suite.MakeRequest("GET", "/api/me/export", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestPostSlugsOverTheAPI(t *testing.T) {
+	suite := testutils.Setup()
+	token := registerAndLogin(t, suite, "slugger", "password", "blogger")
+
+	post := createPostJSON(t, suite, token, map[string]any{"title": "Hello There", "content": "c"})
+	assert.Equal(t, "hello-there", post.Slug)
+
+	body, _ := json.Marshal(map[string]any{"title": "Other", "slug": "hello-there", "content": "c"})
+	w := suite.MakeRequest("POST", "/api/posts", bytes.NewBuffer(body), map[string]string{"Authorization": "Bearer " + token})
+	assert.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+
+	body, _ = json.Marshal(map[string]any{"title": "Other", "slug": "---", "content": "c"})
+	w = suite.MakeRequest("POST", "/api/posts", bytes.NewBuffer(body), map[string]string{"Authorization": "Bearer " + token})
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	body, _ = json.Marshal(map[string]any{"title": "Hello There", "content": "c", "slug": "Greetings"})
+	w = suite.MakeRequest("PUT", fmt.Sprintf("/api/posts/%d", post.ID), bytes.NewBuffer(body), map[string]string{"Authorization": "Bearer " + token})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"slug":"greetings"`)
+}
@@ -0,0 +1,356 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-blog/jobs"
+	"go-blog/testutils"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type echoArgs struct {
+	Message string `json:"message"`
+}
+
+func (echoArgs) Kind() string { return "test.echo" }
+
+type flakyArgs struct {
+	FailTimes int `json:"fail_times"`
+}
+
+func (flakyArgs) Kind() string { return "test.flaky" }
+
+func getJob(t *testing.T, suite *testutils.TestSuite, id int) *jobs.Job {
+	job, err := suite.App.Jobs.Get(context.Background(), id)
+	require.NoError(t, err)
+	return job
+}
+
+// makeDue pulls a retried job's run_at into the past so Drain picks it up.
+func makeDue(t *testing.T, suite *testutils.TestSuite, id int) {
+	require.NoError(t, suite.DB.Model(&jobs.Job{}).Where("id = ?", id).Update("run_at", time.Now().UTC().Add(-time.Second)).Error)
+}
+
+func TestJobsRunTypedHandlers(t *testing.T) {
+	suite := testutils.Setup()
+	ctx := context.Background()
+	var got []string
+	jobs.Handle(suite.App.Jobs, func(ctx context.Context, args echoArgs) error {
+		got = append(got, args.Message)
+		return nil
+	})
+
+	first, err := suite.App.Jobs.Enqueue(ctx, echoArgs{Message: "one"}, jobs.Options{})
+	require.NoError(t, err)
+	assert.Equal(t, jobs.StatusQueued, first.Status)
+	assert.Equal(t, suite.App.Config.Jobs.MaxAttempts, first.MaxAttempts)
+	_, err = suite.App.Jobs.Enqueue(ctx, echoArgs{Message: "two"}, jobs.Options{})
+	require.NoError(t, err)
+	later, err := suite.App.Jobs.Enqueue(ctx, echoArgs{Message: "later"}, jobs.Options{RunAt: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+
+	ran, err := suite.App.Jobs.Drain(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, ran, "scheduled jobs wait for their run_at")
+	assert.Equal(t, []string{"one", "two"}, got)
+
+	done := getJob(t, suite, first.ID)
+	assert.Equal(t, jobs.StatusSucceeded, done.Status)
+	assert.Equal(t, 1, done.Attempts)
+	assert.NotNil(t, done.FinishedAt)
+	assert.Nil(t, done.LockedAt)
+	assert.JSONEq(t, `{"message":"one"}`, string(done.Payload))
+	assert.Equal(t, jobs.StatusQueued, getJob(t, suite, later.ID).Status)
+
+	makeDue(t, suite, later.ID)
+	ran, err = suite.App.Jobs.Drain(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, ran)
+	assert.Equal(t, "later", got[2])
+
+	_, err = suite.App.Jobs.Enqueue(ctx, flakyArgs{}, jobs.Options{})
+	assert.ErrorIs(t, err, jobs.ErrUnknownKind, "enqueueing needs a registered handler")
+}
+
+func TestJobsRetryWithBackoffThenDeadLetter(t *testing.T) {
+	cfg := testutils.TestConfig()
+	cfg.Jobs.RetryBackoff = time.Minute
+	cfg.Jobs.MaxRetryBackoff = 10 * time.Minute
+	suite := testutils.SetupWithConfig(cfg)
+	ctx := context.Background()
+	calls := 0
+	jobs.Handle(suite.App.Jobs, func(ctx context.Context, args flakyArgs) error {
+		calls++
+		if calls <= args.FailTimes {
+			return fmt.Errorf("failure %d", calls)
+		}
+		return nil
+	})
+
+	job, err := suite.App.Jobs.Enqueue(ctx, flakyArgs{FailTimes: 1}, jobs.Options{})
+	require.NoError(t, err)
+	before := time.Now()
+	_, err = suite.App.Jobs.Drain(ctx)
+	require.NoError(t, err)
+	retried := getJob(t, suite, job.ID)
+	assert.Equal(t, jobs.StatusQueued, retried.Status)
+	assert.Equal(t, 1, retried.Attempts)
+	assert.Equal(t, "failure 1", retried.LastError)
+	assert.WithinRange(t, retried.RunAt, before.Add(time.Minute), before.Add(time.Minute+7*time.Second))
+
+	makeDue(t, suite, job.ID)
+	_, err = suite.App.Jobs.Drain(ctx)
+	require.NoError(t, err)
+	succeeded := getJob(t, suite, job.ID)
+	assert.Equal(t, jobs.StatusSucceeded, succeeded.Status)
+	assert.Equal(t, 2, succeeded.Attempts)
+	assert.Empty(t, succeeded.LastError)
+
+	calls = 0
+	doomed, err := suite.App.Jobs.Enqueue(ctx, flakyArgs{FailTimes: 5}, jobs.Options{MaxAttempts: 3})
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		makeDue(t, suite, doomed.ID)
+		_, err = suite.App.Jobs.Drain(ctx)
+		require.NoError(t, err)
+	}
+	dead := getJob(t, suite, doomed.ID)
+	assert.Equal(t, jobs.StatusDead, dead.Status)
+	assert.Equal(t, 3, dead.Attempts)
+	assert.Equal(t, "failure 3", dead.LastError)
+	assert.NotNil(t, dead.FinishedAt)
+	assert.Equal(t, 3, calls)
+}
+
+func TestJobsPermanentErrorsAndPanicsAreContained(t *testing.T) {
+	suite := testutils.Setup()
+	ctx := context.Background()
+	jobs.Handle(suite.App.Jobs, func(ctx context.Context, args echoArgs) error {
+		switch args.Message {
+		case "permanent":
+			return jobs.Permanent(errors.New("bad input"))
+		case "panic":
+			panic("boom")
+		}
+		return nil
+	})
+
+	permanent, err := suite.App.Jobs.Enqueue(ctx, echoArgs{Message: "permanent"}, jobs.Options{})
+	require.NoError(t, err)
+	panicky, err := suite.App.Jobs.Enqueue(ctx, echoArgs{Message: "panic"}, jobs.Options{})
+	require.NoError(t, err)
+	_, err = suite.App.Jobs.Drain(ctx)
+	require.NoError(t, err)
+
+	dead := getJob(t, suite, permanent.ID)
+	assert.Equal(t, jobs.StatusDead, dead.Status, "permanent errors skip the retries")
+	assert.Equal(t, 1, dead.Attempts)
+	assert.Equal(t, "bad input", dead.LastError)
+
+	retried := getJob(t, suite, panicky.ID)
+	assert.Equal(t, jobs.StatusQueued, retried.Status)
+	assert.Contains(t, retried.LastError, "panic: boom")
+
+	// Args that no longer decode are dead-lettered too.
+	require.NoError(t, suite.DB.Model(&jobs.Job{}).Where("id = ?", panicky.ID).
+		Updates(map[string]any{"payload": `{"message": 42}`, "run_at": time.Now().UTC().Add(-time.Second)}).Error)
+	_, err = suite.App.Jobs.Drain(ctx)
+	require.NoError(t, err)
+	undecodable := getJob(t, suite, panicky.ID)
+	assert.Equal(t, jobs.StatusDead, undecodable.Status)
+	assert.Contains(t, undecodable.LastError, "decoding test.echo args")
+}
+
+func TestJobsUniqueKeys(t *testing.T) {
+	suite := testutils.Setup()
+	ctx := context.Background()
+	jobs.Handle(suite.App.Jobs, func(ctx context.Context, args echoArgs) error { return nil })
+
+	first, err := suite.App.Jobs.Enqueue(ctx, echoArgs{Message: "a"}, jobs.Options{UniqueKey: "digest:1"})
+	require.NoError(t, err)
+	dup, err := suite.App.Jobs.Enqueue(ctx, echoArgs{Message: "b"}, jobs.Options{UniqueKey: "digest:1"})
+	assert.ErrorIs(t, err, jobs.ErrDuplicate)
+	require.NotNil(t, dup)
+	assert.Equal(t, first.ID, dup.ID)
+	other, err := suite.App.Jobs.Enqueue(ctx, echoArgs{Message: "c"}, jobs.Options{UniqueKey: "digest:2"})
+	require.NoError(t, err)
+	assert.NotEqual(t, first.ID, other.ID)
+
+	ran, err := suite.App.Jobs.Drain(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, ran)
+
+	again, err := suite.App.Jobs.Enqueue(ctx, echoArgs{Message: "d"}, jobs.Options{UniqueKey: "digest:1"})
+	require.NoError(t, err, "a finished job no longer holds its key")
+	assert.NotEqual(t, first.ID, again.ID)
+}
+
+func TestJobWorkerPoolRunsConcurrentlyAndDrainsOnShutdown(t *testing.T) {
+	cfg := testutils.TestConfig()
+	cfg.Jobs.Concurrency = 3
+	cfg.Jobs.PollInterval = 10 * time.Millisecond
+	suite := testutils.SetupWithConfig(cfg)
+
+	var active, peak, finished atomic.Int32
+	release := make(chan struct{})
+	jobs.Handle(suite.App.Jobs, func(ctx context.Context, args echoArgs) error {
+		n := active.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		<-release
+		active.Add(-1)
+		finished.Add(1)
+		return nil
+	})
+	for i := 0; i < 5; i++ {
+		_, err := suite.App.Jobs.Enqueue(context.Background(), echoArgs{Message: fmt.Sprint(i)}, jobs.Options{})
+		require.NoError(t, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() { stopped <- suite.App.Jobs.Run(ctx) }()
+	require.Eventually(t, func() bool { return active.Load() == 3 }, 5*time.Second, 5*time.Millisecond)
+
+	// Shutting down stops new claims but waits for the running jobs.
+	cancel()
+	select {
+	case <-stopped:
+		t.Fatal("Run returned while jobs were still running")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	select {
+	case err := <-stopped:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after its jobs finished")
+	}
+	assert.Equal(t, int32(3), finished.Load())
+	assert.Equal(t, int32(3), peak.Load(), "no more than Concurrency jobs run at once")
+
+	counts, err := suite.App.Jobs.Counts(context.Background())
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []jobs.Count{
+		{Kind: "test.echo", Status: jobs.StatusQueued, Count: 2},
+		{Kind: "test.echo", Status: jobs.StatusSucceeded, Count: 3},
+	}, counts)
+}
+
+func TestJobWorkerRescuesJobsFromDeadWorkers(t *testing.T) {
+	cfg := testutils.TestConfig()
+	cfg.Jobs.PollInterval = 10 * time.Millisecond
+	cfg.Jobs.Timeout = time.Minute
+	suite := testutils.SetupWithConfig(cfg)
+	var mu sync.Mutex
+	var got []string
+	jobs.Handle(suite.App.Jobs, func(ctx context.Context, args echoArgs) error {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, args.Message)
+		return nil
+	})
+
+	stale := time.Now().UTC().Add(-2 * time.Minute)
+	orphan, err := suite.App.Jobs.Enqueue(context.Background(), echoArgs{Message: "orphan"}, jobs.Options{})
+	require.NoError(t, err)
+	exhausted, err := suite.App.Jobs.Enqueue(context.Background(), echoArgs{Message: "exhausted"}, jobs.Options{MaxAttempts: 1})
+	require.NoError(t, err)
+	require.NoError(t, suite.DB.Model(&jobs.Job{}).Where("id IN ?", []int{orphan.ID, exhausted.ID}).
+		Updates(map[string]any{"status": jobs.StatusRunning, "attempts": 1, "locked_at": stale, "locked_by": "gone:1"}).Error)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go suite.App.Jobs.Run(ctx)
+	require.Eventually(t, func() bool {
+		return getJob(t, suite, orphan.ID).Status == jobs.StatusSucceeded
+	}, 5*time.Second, 10*time.Millisecond)
+	cancel()
+
+	assert.Equal(t, 2, getJob(t, suite, orphan.ID).Attempts)
+	dead := getJob(t, suite, exhausted.ID)
+	assert.Equal(t, jobs.StatusDead, dead.Status, "a job out of attempts is not rescued")
+	assert.Equal(t, "worker stopped responding", dead.LastError)
+	mu.Lock()
+	assert.Equal(t, []string{"orphan"}, got)
+	mu.Unlock()
+}
+
+func TestJobAdminEndpoints(t *testing.T) {
+	cfg := testutils.TestConfig()
+	cfg.Admin.Token = "ops-token"
+	suite := testutils.SetupWithConfig(cfg)
+	ctx := context.Background()
+	jobs.Handle(suite.App.Jobs, func(ctx context.Context, args echoArgs) error {
+		if args.Message == "fail" {
+			return jobs.Permanent(errors.New("nope"))
+		}
+		return nil
+	})
+	ok, err := suite.App.Jobs.Enqueue(ctx, echoArgs{Message: "ok"}, jobs.Options{})
+	require.NoError(t, err)
+	failed, err := suite.App.Jobs.Enqueue(ctx, echoArgs{Message: "fail"}, jobs.Options{})
+	require.NoError(t, err)
+	_, err = suite.App.Jobs.Drain(ctx)
+	require.NoError(t, err)
+	admin := map[string]string{"Authorization": "Bearer ops-token"}
+
+	w := suite.MakeRequest("GET", "/admin/jobs?status=dead", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = suite.MakeRequest("GET", "/admin/jobs?status=dead", nil, admin)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var list []jobs.Job
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list, 1)
+	assert.Equal(t, failed.ID, list[0].ID)
+	assert.Equal(t, "nope", list[0].LastError)
+
+	w = suite.MakeRequest("GET", "/admin/jobs?kind=test.echo&limit=1", nil, admin)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list, 1)
+	assert.Equal(t, failed.ID, list[0].ID, "newest first")
+	w = suite.MakeRequest("GET", fmt.Sprintf("/admin/jobs?before=%d", failed.ID), nil, admin)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list, 1)
+	assert.Equal(t, ok.ID, list[0].ID)
+
+	w = suite.MakeRequest("GET", "/admin/jobs?status=lost", nil, admin)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = suite.MakeRequest("GET", "/admin/jobs/counts", nil, admin)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"dead","count":1`)
+
+	w = suite.MakeRequest("GET", fmt.Sprintf("/admin/jobs/%d", failed.ID), nil, admin)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"payload":{"message":"fail"}`)
+	w = suite.MakeRequest("GET", "/admin/jobs/9999", nil, admin)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = suite.MakeRequest("POST", fmt.Sprintf("/admin/jobs/%d/retry", ok.ID), nil, admin)
+	assert.Equal(t, http.StatusConflict, w.Code, "only dead jobs can be retried")
+	w = suite.MakeRequest("POST", fmt.Sprintf("/admin/jobs/%d/retry", failed.ID), nil, admin)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	retried := getJob(t, suite, failed.ID)
+	assert.Equal(t, jobs.StatusQueued, retried.Status)
+	assert.Equal(t, 0, retried.Attempts)
+	assert.Nil(t, retried.FinishedAt)
+
+	open := testutils.Setup()
+	w = open.MakeRequest("GET", "/admin/jobs", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code, "without a token or admin listener the job endpoints are not served")
+}
@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"go-blog/config"
+	"go-blog/logging"
+	"go-blog/routes"
+	"go-blog/testutils"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func routerWithLogBuffer(t *testing.T, logCfg config.LogConfig) (*gin.Engine, *bytes.Buffer) {
+	suite := testutils.Setup()
+	buf := &bytes.Buffer{}
+	logger, err := logging.NewWithWriter(logCfg, buf)
+	require.NoError(t, err)
+	suite.DB.Logger = logging.NewGormLogger(logCfg)
+
+	router := routes.SetupRoutes(suite.App.Config, routes.Handlers{
+		Post:   suite.App.PostHandler,
+		User:   suite.App.UserHandler,
+		Health: suite.App.HealthHandler,
+	}, suite.App.AuthRepo, nil, suite.App.Tracing, logger)
+	return router, buf
+}
+
+func logEntries(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	var entries []map[string]interface{}
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestRequestIDIsPropagatedToSQLLogs(t *testing.T) {
+	router, buf := routerWithLogBuffer(t, config.LogConfig{Level: "debug", Format: "json"})
+
+	body, _ := json.Marshal(map[string]string{"username": "secretname", "password": "pw", "account_type": "viewer"})
+	req := httptest.NewRequest("POST", "/api/register", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Request-ID", "req-123")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "req-123", w.Header().Get("X-Request-ID"))
+
+	var sawSQL, sawRequest bool
+	for _, entry := range logEntries(t, buf) {
+		assert.Equal(t, "req-123", entry["request_id"], "every entry carries the request ID: %v", entry)
+		switch entry["msg"] {
+		case "sql":
+			sawSQL = true
+			assert.NotContains(t, entry["sql"], "secretname", "bound parameters must be redacted")
+		case "request":
+			sawRequest = true
+			assert.Equal(t, "/api/register", entry["route"])
+			assert.Equal(t, float64(http.StatusCreated), entry["status"])
+		}
+	}
+	assert.True(t, sawSQL, "SQL statements are logged at debug level")
+	assert.True(t, sawRequest, "the request itself is logged")
+}
+
+func TestRequestIDIsGeneratedWhenMissingOrInvalid(t *testing.T) {
+	router, _ := routerWithLogBuffer(t, config.LogConfig{Level: "error", Format: "json"})
+
+	req := httptest.NewRequest("GET", "/healthz", nil)
+	req.Header.Set("X-Request-ID", "bad id with spaces")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	generated := w.Header().Get("X-Request-ID")
+	assert.Len(t, generated, 32)
+	assert.False(t, strings.Contains(generated, " "))
+}
+
+func TestSQLParamsCanBeLogged(t *testing.T) {
+	router, buf := routerWithLogBuffer(t, config.LogConfig{Level: "debug", Format: "json", LogSQLParams: true})
+
+	body, _ := json.Marshal(map[string]string{"username": "visiblename", "password": "pw", "account_type": "viewer"})
+	req := httptest.NewRequest("POST", "/api/register", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Contains(t, buf.String(), "visiblename")
+}
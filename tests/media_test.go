@@ -0,0 +1,272 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"go-blog/config"
+	"go-blog/models"
+	"go-blog/storage"
+	"go-blog/testutils"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/johannesboyne/gofakes3"
+	"github.com/johannesboyne/gofakes3/backend/s3mem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const exifMarker = "GPS-51.5007N-0.1246W"
+
+// jpegWithEXIF encodes a width x height JPEG and splices in an APP1 EXIF
+// segment carrying the given orientation and a recognisable marker string.
+func jpegWithEXIF(t *testing.T, width, height int, orientation uint16) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x * 4), G: uint8(y * 4), B: 128, A: 255})
+		}
+	}
+	var encoded bytes.Buffer
+	require.NoError(t, jpeg.Encode(&encoded, img, nil))
+
+	var tiff bytes.Buffer
+	tiff.WriteString("MM")
+	binary.Write(&tiff, binary.BigEndian, uint16(42))
+	binary.Write(&tiff, binary.BigEndian, uint32(8))
+	binary.Write(&tiff, binary.BigEndian, uint16(1))
+	binary.Write(&tiff, binary.BigEndian, []uint16{0x0112, 3})
+	binary.Write(&tiff, binary.BigEndian, uint32(1))
+	binary.Write(&tiff, binary.BigEndian, []uint16{orientation, 0})
+	binary.Write(&tiff, binary.BigEndian, uint32(0))
+	tiff.WriteString(exifMarker)
+
+	payload := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	raw := encoded.Bytes()
+	out := append([]byte{}, raw[:2]...)
+	out = append(out, segment...)
+	return append(out, raw[2:]...)
+}
+
+func uploadMedia(t *testing.T, suite *testutils.TestSuite, token, filename string, data []byte) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", filename)
+	require.NoError(t, err)
+	_, err = part.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+	return suite.MakeRequest("POST", "/api/media", &body, map[string]string{
+		"Authorization": "Bearer " + token,
+		"Content-Type":  form.FormDataContentType(),
+	})
+}
+
+func decodeMedia(t *testing.T, w *httptest.ResponseRecorder) models.Media {
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var media models.Media
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &media))
+	return media
+}
+
+func sitePath(suite *testutils.TestSuite, url string) string {
+	return strings.TrimPrefix(url, strings.TrimRight(suite.App.Config.Site.BaseURL, "/"))
+}
+
+func TestMediaUploadStripsEXIFAndMakesThumbnail(t *testing.T) {
+	suite := testutils.Setup()
+	blogger := registerAndLogin(t, suite, "mediablogger", "password", "blogger")
+
+	media := decodeMedia(t, uploadMedia(t, suite, blogger, "holiday.jpg", jpegWithEXIF(t, 640, 400, 6)))
+	assert.Equal(t, "image/jpeg", media.ContentType)
+	assert.Equal(t, "holiday.jpg", media.Filename)
+	// Orientation 6 means the camera was turned; the stored pixels are upright.
+	assert.Equal(t, 400, media.Width)
+	assert.Equal(t, 640, media.Height)
+	require.NotEmpty(t, media.ThumbnailURL)
+
+	w := suite.MakeRequest("GET", sitePath(suite, media.URL), nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/jpeg", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Cache-Control"), "immutable")
+	stored := w.Body.Bytes()
+	assert.NotContains(t, string(stored), "Exif")
+	assert.NotContains(t, string(stored), exifMarker)
+	assert.Equal(t, media.Size, int64(len(stored)))
+
+	etag := w.Header().Get("ETag")
+	w = suite.MakeRequest("GET", sitePath(suite, media.URL), nil, map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	w = suite.MakeRequest("GET", sitePath(suite, media.ThumbnailURL), nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	thumb, _, err := image.DecodeConfig(bytes.NewReader(w.Body.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, 200, thumb.Width)
+	assert.Equal(t, 320, thumb.Height)
+
+	w = suite.MakeRequest("GET", fmt.Sprintf("/api/media/%d", media.ID), nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), media.URL)
+	assert.NotContains(t, w.Body.String(), "storage_key")
+}
+
+func TestMediaUploadRejectsUnsupportedAndOversizedFiles(t *testing.T) {
+	cfg := testutils.TestConfig()
+	cfg.Media.MaxUploadBytes = 4096
+	suite := testutils.SetupWithConfig(cfg)
+	blogger := registerAndLogin(t, suite, "mediarules", "password", "blogger")
+	viewer := registerAndLogin(t, suite, "mediaviewer", "password", "viewer")
+
+	// The extension and the client's content type are ignored; the bytes decide.
+	w := uploadMedia(t, suite, blogger, "script.jpg", []byte("#!/bin/sh\necho hello\n"))
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code, w.Body.String())
+
+	// The size limit applies before anything is decoded.
+	large := append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 8192)...)
+	w = uploadMedia(t, suite, blogger, "large.png", large)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code, w.Body.String())
+
+	w = uploadMedia(t, suite, viewer, "tiny.png", []byte("\x89PNG\r\n\x1a\n"))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = suite.MakeRequest("POST", "/api/media", nil, map[string]string{"Authorization": "Bearer " + blogger})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	pdf := []byte("%PDF-1.4\n1 0 obj << >> endobj\ntrailer << >>\n%%EOF\n")
+	media := decodeMedia(t, uploadMedia(t, suite, blogger, "notes.pdf", pdf))
+	assert.Equal(t, "application/pdf", media.ContentType)
+	assert.Empty(t, media.ThumbnailURL)
+}
+
+func TestMediaOwnershipAndPostReferences(t *testing.T) {
+	suite := testutils.Setup()
+	owner := registerAndLogin(t, suite, "mediaowner", "password", "blogger")
+	other := registerAndLogin(t, suite, "mediaother", "password", "blogger")
+	ownerAuth := map[string]string{"Authorization": "Bearer " + owner}
+	otherAuth := map[string]string{"Authorization": "Bearer " + other}
+
+	first := decodeMedia(t, uploadMedia(t, suite, owner, "a.jpg", jpegWithEXIF(t, 32, 32, 1)))
+	second := decodeMedia(t, uploadMedia(t, suite, owner, "b.jpg", jpegWithEXIF(t, 32, 32, 1)))
+	mediaURL := fmt.Sprintf("/api/media/%d", first.ID)
+
+	assert.Equal(t, http.StatusForbidden, suite.MakeRequest("DELETE", mediaURL, nil, otherAuth).Code)
+	assert.Equal(t, http.StatusNotFound, suite.MakeRequest("DELETE", "/api/media/9999", nil, ownerAuth).Code)
+
+	w := suite.MakeRequest("POST", "/api/posts", jsonBody(t, map[string]interface{}{
+		"title": "Stolen", "content": "body", "media_ids": []int{first.ID},
+	}), otherAuth)
+	assert.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+
+	w = suite.MakeRequest("POST", "/api/posts", jsonBody(t, map[string]interface{}{
+		"title": "Missing", "content": "body", "media_ids": []int{9999},
+	}), ownerAuth)
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	w = suite.MakeRequest("POST", "/api/posts", jsonBody(t, map[string]interface{}{
+		"title": "Gallery", "content": "body", "media_ids": []int{second.ID, first.ID, second.ID},
+	}), ownerAuth)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var post models.Post
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &post))
+	assert.Equal(t, []int{second.ID, first.ID}, post.MediaIDs)
+
+	w = suite.MakeRequest("GET", "/api/me/media", nil, ownerAuth)
+	require.Equal(t, http.StatusOK, w.Code)
+	var mine []models.Media
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &mine))
+	assert.Len(t, mine, 2)
+
+	w = suite.MakeRequest("DELETE", mediaURL, nil, ownerAuth)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, http.StatusNotFound, suite.MakeRequest("GET", mediaURL, nil).Code)
+	assert.Equal(t, http.StatusNotFound, suite.MakeRequest("GET", sitePath(suite, first.URL), nil).Code)
+
+	w = suite.MakeRequest("GET", fmt.Sprintf("/api/posts/%d", post.ID), nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &post))
+	assert.Equal(t, []int{second.ID}, post.MediaIDs)
+}
+
+func blobStoreContract(t *testing.T, store storage.BlobStore) {
+	ctx := context.Background()
+	data := []byte("\x89PNG\r\n\x1a\nnot really a png")
+
+	require.NoError(t, store.Put(ctx, "2026/10/abc.png", bytes.NewReader(data), int64(len(data)), "image/png"))
+	body, info, err := store.Get(ctx, "2026/10/abc.png")
+	require.NoError(t, err)
+	got, err := io.ReadAll(body)
+	require.NoError(t, err)
+	require.NoError(t, body.Close())
+	assert.Equal(t, data, got)
+	assert.Equal(t, int64(len(data)), info.Size)
+	assert.Equal(t, "image/png", info.ContentType)
+
+	require.NoError(t, store.Delete(ctx, "2026/10/abc.png"))
+	require.NoError(t, store.Delete(ctx, "2026/10/abc.png"))
+	_, _, err = store.Get(ctx, "2026/10/abc.png")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	for _, key := range []string{"../escape.png", "/etc/passwd", "", `a\..\b`} {
+		assert.ErrorIs(t, store.Put(ctx, key, bytes.NewReader(data), int64(len(data)), "image/png"), storage.ErrInvalidKey, key)
+	}
+}
+
+func fakeS3(t *testing.T, bucket string) config.MediaConfig {
+	backend := s3mem.New()
+	require.NoError(t, backend.CreateBucket(bucket))
+	server := httptest.NewServer(gofakes3.New(backend).Server())
+	t.Cleanup(server.Close)
+
+	cfg := testutils.TestConfig().Media
+	cfg.Backend = "s3"
+	cfg.S3Endpoint = server.URL
+	cfg.S3Bucket = bucket
+	cfg.S3AccessKey = "test"
+	cfg.S3SecretKey = "test-secret"
+	cfg.S3UseSSL = false
+	return cfg
+}
+
+func TestBlobStores(t *testing.T) {
+	t.Run("Local", func(t *testing.T) {
+		store, err := storage.NewLocalStore(t.TempDir())
+		require.NoError(t, err)
+		blobStoreContract(t, store)
+	})
+	t.Run("S3", func(t *testing.T) {
+		store, err := storage.New(fakeS3(t, "blobs"))
+		require.NoError(t, err)
+		blobStoreContract(t, store)
+	})
+}
+
+func TestMediaUploadToS3Backend(t *testing.T) {
+	cfg := testutils.TestConfig()
+	cfg.Media = fakeS3(t, "media")
+	suite := testutils.SetupWithConfig(cfg)
+	blogger := registerAndLogin(t, suite, "s3blogger", "password", "blogger")
+
+	media := decodeMedia(t, uploadMedia(t, suite, blogger, "photo.jpg", jpegWithEXIF(t, 48, 32, 1)))
+	w := suite.MakeRequest("GET", sitePath(suite, media.URL), nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/jpeg", w.Header().Get("Content-Type"))
+	assert.NotContains(t, w.Body.String(), exifMarker)
+
+	require.Equal(t, http.StatusOK, suite.MakeRequest("DELETE", fmt.Sprintf("/api/media/%d", media.ID), nil, map[string]string{"Authorization": "Bearer " + blogger}).Code)
+	assert.Equal(t, http.StatusNotFound, suite.MakeRequest("GET", sitePath(suite, media.ThumbnailURL), nil).Code)
+}
@@ -0,0 +1,55 @@
+package tests
+
+import (
+	"go-blog/routes"
+	"go-blog/testutils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsEndpointReportsRequestsAndDomainEvents(t *testing.T) {
+	suite := testutils.Setup()
+	token := registerAndLogin(t, suite, "metricsblogger", "pass", "blogger")
+	createPostAs(t, suite, token, "Metrics", "Counting this post.")
+	suite.MakeRequest("GET", "/api/posts", nil)
+
+	w := suite.MakeRequest("GET", "/metrics", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, `blog_http_requests_total{method="GET",route="/api/posts",status="200"} 1`)
+	assert.Contains(t, body, `blog_http_request_duration_seconds_bucket{method="POST",route="/api/posts",status="201"`)
+	assert.Contains(t, body, `blog_posts_created_total 1`)
+	assert.Contains(t, body, `blog_logins_total{result="succeeded"} 1`)
+	assert.Contains(t, body, `blog_tokens_issued_total 1`)
+	assert.Contains(t, body, `blog_db_query_duration_seconds_count{error="false",operation="create",table="posts"} 1`)
+	assert.Contains(t, body, `go_sql_open_connections{db_name="sqlite"}`)
+}
+
+func TestMetricsEndpointRequiresAdminToken(t *testing.T) {
+	cfg := testutils.TestConfig()
+	cfg.Admin.Token = "admin-secret"
+	suite := testutils.SetupWithConfig(cfg)
+
+	w := suite.MakeRequest("GET", "/metrics", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = suite.MakeRequest("GET", "/metrics", nil, map[string]string{"Authorization": "Bearer admin-secret"})
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestMetricsOnSeparateAdminRouter(t *testing.T) {
+	cfg := testutils.TestConfig()
+	cfg.Admin.Addr = "127.0.0.1:0"
+	suite := testutils.SetupWithConfig(cfg)
+
+	w := suite.MakeRequest("GET", "/metrics", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code, "metrics are not served on the public router")
+
+	admin := routes.SetupAdminRoutes(cfg, suite.App.Metrics, suite.App.AdminHandlers(), suite.App.Logger)
+	rec := httptest.NewRecorder()
+	admin.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
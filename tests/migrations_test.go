@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"context"
+	"go-blog/config"
+	"go-blog/db"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadMigrationsOrdersByVersion(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0002_add_index.up.sql":    {Data: []byte("CREATE INDEX i ON t (c);")},
+		"0002_add_index.down.sql":  {Data: []byte("DROP INDEX i;")},
+		"0001_create_table.up.sql": {Data: []byte("CREATE TABLE t (c TEXT);")},
+	}
+	migrations, err := db.LoadMigrations(fsys)
+	require.NoError(t, err)
+	require.Len(t, migrations, 2)
+	assert.Equal(t, int64(1), migrations[0].Version)
+	assert.Equal(t, "create_table", migrations[0].Name)
+	assert.Empty(t, migrations[0].Down)
+	assert.Equal(t, "add_index", migrations[1].Name)
+	assert.Equal(t, "DROP INDEX i;", migrations[1].Down)
+}
+
+func TestLoadMigrationsRejectsBadNames(t *testing.T) {
+	_, err := db.LoadMigrations(fstest.MapFS{"create_table.sql": {Data: []byte("")}})
+	assert.Error(t, err)
+
+	_, err = db.LoadMigrations(fstest.MapFS{"0001_only_down.down.sql": {Data: []byte("DROP TABLE t;")}})
+	assert.Error(t, err)
+}
+
+func TestMigratorUpDownStatusOnSQLite(t *testing.T) {
+	ctx := context.Background()
+	gormDB, err := db.InitDB(config.DatabaseConfig{Type: db.DialectSQLite, Path: ":memory:"})
+	require.NoError(t, err)
+	migrator, err := db.NewMigrator(gormDB)
+	require.NoError(t, err)
+
+	applied, err := migrator.Up(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, applied)
+
+	again, err := migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, again, "a second run should find nothing pending")
+
+	statuses, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	for _, s := range statuses {
+		assert.True(t, s.Applied, "migration %d should be applied", s.Version)
+	}
+
+	require.NoError(t, gormDB.Exec("INSERT INTO users (username) VALUES ('dup')").Error)
+	assert.Error(t, gormDB.Exec("INSERT INTO users (username) VALUES ('dup')").Error, "username must be unique")
+
+	reverted, err := migrator.Down(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, reverted, 1)
+	assert.Equal(t, applied[len(applied)-1].Version, reverted[0].Version)
+
+	statuses, err = migrator.Status(ctx)
+	require.NoError(t, err)
+	assert.False(t, statuses[len(statuses)-1].Applied)
+}
@@ -0,0 +1,174 @@
+package tests
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-blog/models"
+	"go-blog/testutils"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ugorji/go/codec"
+)
+
+func TestResponsesAreCompressedByAcceptEncoding(t *testing.T) {
+	suite := testutils.Setup()
+	token := registerAndLogin(t, suite, "squeezer", "password123", "blogger")
+	for i := range 10 {
+		createPostJSON(t, suite, token, map[string]any{"title": fmt.Sprintf("Post %d", i), "content": strings.Repeat("words ", 50)})
+	}
+	plain := suite.MakeRequest("GET", "/api/posts", nil)
+	require.Equal(t, http.StatusOK, plain.Code)
+	assert.Empty(t, plain.Header().Get("Content-Encoding"))
+
+	decoders := map[string]func(io.Reader) (io.Reader, error){
+		"gzip": func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		"br":   func(r io.Reader) (io.Reader, error) { return brotli.NewReader(r), nil },
+	}
+	for acceptEncoding, want := range map[string]string{
+		"gzip":                   "gzip",
+		"gzip, deflate, br":      "br",
+		"br;q=0.5, gzip;q=0.8":   "gzip",
+		"*":                      "br",
+		"identity, gzip;q=0, br": "br",
+	} {
+		w := suite.MakeRequest("GET", "/api/posts", nil, map[string]string{"Accept-Encoding": acceptEncoding})
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, want, w.Header().Get("Content-Encoding"), acceptEncoding)
+		assert.Contains(t, w.Header().Values("Vary"), "Accept-Encoding")
+		assert.Less(t, w.Body.Len(), plain.Body.Len())
+		reader, err := decoders[want](w.Body)
+		require.NoError(t, err)
+		body, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, plain.Body.String(), string(body))
+		assert.Equal(t, plain.Header().Get("ETag"), w.Header().Get("ETag"))
+	}
+
+	w := suite.MakeRequest("GET", "/api/posts", nil, map[string]string{"Accept-Encoding": "compress"})
+	assert.Empty(t, w.Header().Get("Content-Encoding"), "unsupported codings are ignored")
+	w = suite.MakeRequest("GET", "/healthz", nil, map[string]string{"Accept-Encoding": "gzip"})
+	assert.Empty(t, w.Header().Get("Content-Encoding"), "small responses are sent as they are")
+	w = suite.MakeRequest("GET", "/sitemap.xml.gz", nil, map[string]string{"Accept-Encoding": "gzip"})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Content-Encoding"), "compressed files are not compressed again")
+}
+
+func TestEventStreamsAreNotCompressed(t *testing.T) {
+	suite := testutils.Setup()
+	server := httptest.NewServer(suite.Router)
+	t.Cleanup(server.Close)
+	token := registerAndLogin(t, suite, "streamreader", "password123", "viewer")
+
+	req, err := http.NewRequest("GET", server.URL+"/api/me/notifications/stream", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept-Encoding", "gzip, br")
+	resp, err := server.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get("Content-Encoding"))
+
+	scanner := bufio.NewScanner(resp.Body)
+	require.True(t, scanner.Scan())
+	assert.Equal(t, "retry: 3000", scanner.Text(), "events arrive as they are written")
+}
+
+func TestCompressedStreamsOutliveTheWriteTimeout(t *testing.T) {
+	suite := testutils.Setup()
+	server := httptest.NewUnstartedServer(suite.Router)
+	server.Config.WriteTimeout = 200 * time.Millisecond
+	server.Start()
+	t.Cleanup(server.Close)
+	author := registerAndLogin(t, suite, "patient", "password123", "blogger")
+	fan := registerAndLogin(t, suite, "latecomer", "password123", "viewer")
+	post := createPostJSON(t, suite, author, map[string]any{"title": "Slow", "content": "Body"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	req, err := http.NewRequestWithContext(ctx, "GET", server.URL+"/api/me/notifications/stream", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+author)
+	req.Header.Set("Accept-Encoding", "gzip, br")
+	resp, err := server.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	lines := make(chan string, 16)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+	time.Sleep(2 * server.Config.WriteTimeout)
+	react(t, suite, fan, post.ID, "like")
+	for {
+		select {
+		case line, ok := <-lines:
+			require.True(t, ok, "the stream was cut off")
+			if line == "event: notification" {
+				return
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the notification")
+		}
+	}
+}
+
+func TestPostFormatIsNegotiated(t *testing.T) {
+	suite := testutils.Setup()
+	token := registerAndLogin(t, suite, "formats", "password123", "blogger")
+	post := createPostJSON(t, suite, token, map[string]any{
+		"title": "Many <formats>", "content": "First paragraph.\n\n<script>alert(1)</script>", "tags": []string{"go"},
+	})
+	path := fmt.Sprintf("/api/posts/%d", post.ID)
+
+	w := suite.MakeRequest("GET", path, nil, map[string]string{"Accept": "application/msgpack"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "application/msgpack", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Values("Vary"), "Accept")
+	var decoded models.Post
+	require.NoError(t, codec.NewDecoderBytes(w.Body.Bytes(), &codec.MsgpackHandle{}).Decode(&decoded))
+	assert.Equal(t, post.ID, decoded.ID)
+	assert.Equal(t, post.Title, decoded.Title)
+	assert.Equal(t, []string{"go"}, decoded.Tags)
+	require.NotNil(t, decoded.PublishedAt)
+	assert.True(t, post.PublishedAt.Equal(*decoded.PublishedAt))
+	msgpackETag := w.Header().Get("ETag")
+	w = suite.MakeRequest("GET", path, nil, map[string]string{"Accept": "application/x-msgpack", "If-None-Match": msgpackETag})
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	browser := "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+	w = suite.MakeRequest("GET", path, nil, map[string]string{"Accept": browser})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	page := w.Body.String()
+	assert.Contains(t, page, "<h1>Many &lt;formats&gt;</h1>")
+	assert.Contains(t, page, "<p>First paragraph.</p>")
+	assert.Contains(t, page, "&lt;script&gt;alert(1)&lt;/script&gt;")
+	assert.NotContains(t, page, "<script>")
+	assert.NotEqual(t, msgpackETag, w.Header().Get("ETag"))
+
+	for _, accept := range []string{"", "*/*", "application/json", browser} {
+		w = suite.MakeRequest("GET", "/api/posts", nil, map[string]string{"Accept": accept})
+		require.Equal(t, http.StatusOK, w.Code, accept)
+		var posts []models.Post
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &posts), "listings have no HTML form, so %q gets JSON", accept)
+	}
+	w = suite.MakeRequest("GET", path, nil, map[string]string{"Accept": "application/xml"})
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+}
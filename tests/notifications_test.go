@@ -0,0 +1,187 @@
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-blog/models"
+	"go-blog/testutils"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func react(t *testing.T, suite *testutils.TestSuite, token string, postID int, reaction string) {
+	body, _ := json.Marshal(map[string]string{"type": reaction})
+	w := suite.MakeRequest("POST", fmt.Sprintf("/api/posts/%d/reactions", postID), bytes.NewBuffer(body),
+		map[string]string{"Authorization": "Bearer " + token})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
+
+func listNotifications(t *testing.T, suite *testutils.TestSuite, token, query string) models.NotificationPage {
+	w := suite.MakeRequest("GET", "/api/me/notifications"+query, nil, map[string]string{"Authorization": "Bearer " + token})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var page models.NotificationPage
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	return page
+}
+
+func TestReactionsAndFollowsNotifyTheAuthor(t *testing.T) {
+	suite := testutils.Setup()
+	author := registerAndLogin(t, suite, "noticed", "password123", "blogger")
+	fan := registerAndLogin(t, suite, "fan", "password123", "viewer")
+	authorAuth := map[string]string{"Authorization": "Bearer " + author}
+	post := createPostJSON(t, suite, author, map[string]any{"title": "Hello", "content": "Body"})
+
+	react(t, suite, fan, post.ID, "like")
+	react(t, suite, fan, post.ID, "like")
+	react(t, suite, author, post.ID, "love")
+	w := suite.MakeRequest("POST", "/api/users/noticed/follow", nil, map[string]string{"Authorization": "Bearer " + fan})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	page := listNotifications(t, suite, author, "")
+	require.Len(t, page.Notifications, 2, "repeat reactions and your own actions are not notified")
+	assert.Equal(t, int64(2), page.Unread)
+	follow, reaction := page.Notifications[0], page.Notifications[1]
+	assert.Equal(t, models.NotificationFollow, follow.Type)
+	assert.Equal(t, "fan", follow.ActorUsername)
+	assert.Nil(t, follow.PostID)
+	assert.Equal(t, models.NotificationReaction, reaction.Type)
+	assert.Equal(t, "fan", reaction.ActorUsername)
+	require.NotNil(t, reaction.PostID)
+	assert.Equal(t, post.ID, *reaction.PostID)
+	assert.Equal(t, models.ReactionLike, reaction.Reaction)
+	assert.Empty(t, listNotifications(t, suite, fan, "").Notifications)
+
+	w = suite.MakeRequest("POST", fmt.Sprintf("/api/me/notifications/%d/read", reaction.ID), nil, map[string]string{"Authorization": "Bearer " + fan})
+	assert.Equal(t, http.StatusNotFound, w.Code, "notifications of other users are invisible")
+	w = suite.MakeRequest("POST", fmt.Sprintf("/api/me/notifications/%d/read", reaction.ID), nil, authorAuth)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(t, `{"unread": 1}`, w.Body.String())
+
+	unread := listNotifications(t, suite, author, "?unread=true")
+	require.Len(t, unread.Notifications, 1)
+	assert.Equal(t, follow.ID, unread.Notifications[0].ID)
+	older := listNotifications(t, suite, author, fmt.Sprintf("?before=%d", follow.ID))
+	require.Len(t, older.Notifications, 1)
+	assert.NotNil(t, older.Notifications[0].ReadAt)
+
+	body, _ := json.Marshal(map[string]int{"up_to": reaction.ID})
+	w = suite.MakeRequest("POST", "/api/me/notifications/read-all", bytes.NewBuffer(body), authorAuth)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(t, `{"marked": 0, "unread": 1}`, w.Body.String(), "up_to leaves newer notifications unread")
+	w = suite.MakeRequest("POST", "/api/me/notifications/read-all", nil, authorAuth)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(t, `{"marked": 1, "unread": 0}`, w.Body.String())
+}
+
+type sseEvent struct {
+	ID    string
+	Event string
+	Data  string
+}
+
+// openStream connects to the notification stream and returns its events as
+// they arrive. The stream is closed when the test ends.
+func openStream(t *testing.T, server *httptest.Server, token, lastEventID string) <-chan sseEvent {
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, "GET", server.URL+"/api/me/notifications/stream", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+token)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := server.Client().Do(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	t.Cleanup(func() {
+		cancel()
+		resp.Body.Close()
+	})
+
+	stream := make(chan sseEvent, 16)
+	go func() {
+		defer close(stream)
+		scanner := bufio.NewScanner(resp.Body)
+		var event sseEvent
+		for scanner.Scan() {
+			field, value, _ := strings.Cut(scanner.Text(), ": ")
+			switch field {
+			case "id":
+				event.ID = value
+			case "event":
+				event.Event = value
+			case "data":
+				event.Data = value
+			case "":
+				if event.Event != "" {
+					stream <- event
+				}
+				event = sseEvent{}
+			}
+		}
+	}()
+	return stream
+}
+
+func nextEvent(t *testing.T, stream <-chan sseEvent) sseEvent {
+	t.Helper()
+	select {
+	case event, ok := <-stream:
+		require.True(t, ok, "stream closed")
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for an event")
+		return sseEvent{}
+	}
+}
+
+func TestNotificationStreamPushesAndResumes(t *testing.T) {
+	suite := testutils.Setup()
+	server := httptest.NewServer(suite.Router)
+	// Registered first so it runs last, after the streams are closed.
+	t.Cleanup(server.Close)
+	author := registerAndLogin(t, suite, "streamer", "password123", "blogger")
+	fan := registerAndLogin(t, suite, "watcher", "password123", "viewer")
+	post := createPostJSON(t, suite, author, map[string]any{"title": "Live", "content": "Body"})
+
+	resp, err := http.Get(server.URL + "/api/me/notifications/stream")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	react(t, suite, fan, post.ID, "wow")
+	stream := openStream(t, server, author, "")
+	assert.Equal(t, sseEvent{Event: "unread", Data: `{"unread":1}`}, nextEvent(t, stream), "a fresh stream has no backlog")
+
+	react(t, suite, fan, post.ID, "like")
+	event := nextEvent(t, stream)
+	assert.Equal(t, "notification", event.Event)
+	var pushed models.Notification
+	require.NoError(t, json.Unmarshal([]byte(event.Data), &pushed))
+	assert.Equal(t, strconv.Itoa(pushed.ID), event.ID)
+	assert.Equal(t, models.ReactionLike, pushed.Reaction)
+	assert.Equal(t, "watcher", pushed.ActorUsername)
+	assert.Equal(t, sseEvent{Event: "unread", Data: `{"unread":2}`}, nextEvent(t, stream))
+
+	w := suite.MakeRequest("POST", "/api/me/notifications/read-all", nil, map[string]string{"Authorization": "Bearer " + author})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, sseEvent{Event: "unread", Data: `{"unread":0}`}, nextEvent(t, stream), "reading elsewhere updates the count")
+
+	resumed := openStream(t, server, author, "0")
+	first, second := nextEvent(t, resumed), nextEvent(t, resumed)
+	assert.Equal(t, "notification", first.Event)
+	assert.Equal(t, event.ID, second.ID, "Last-Event-ID replays what was missed, in order")
+	firstID, _ := strconv.Atoi(first.ID)
+	assert.Less(t, firstID, pushed.ID)
+	assert.Equal(t, sseEvent{Event: "unread", Data: `{"unread":0}`}, nextEvent(t, resumed))
+}
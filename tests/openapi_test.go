@@ -0,0 +1,128 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go-blog/middleware"
+	"go-blog/openapi"
+	"go-blog/routes"
+	"go-blog/testutils"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAPIDocumentsEveryRoute(t *testing.T) {
+	cfg := testutils.TestConfig()
+	cfg.Metrics.Enabled = true
+	cfg.Admin.Token = "admin-secret"
+	suite := testutils.SetupWithConfig(cfg)
+	doc := routes.OpenAPI(cfg)
+
+	registered := map[string]bool{}
+	for _, route := range suite.Router.Routes() {
+		path := openapi.PathFor(route.Path)
+		registered[route.Method+" "+path] = true
+		assert.NotNil(t, doc.Operation(route.Method, path), "%s %s is missing from the OpenAPI document", route.Method, route.Path)
+	}
+	for path, item := range doc.Paths {
+		for method, op := range *item {
+			key := strings.ToUpper(method) + " " + path
+			assert.True(t, registered[key], "%s is documented but not registered", key)
+			assert.NotEmpty(t, op.Responses, key)
+		}
+	}
+}
+
+func TestOpenAPIDocumentIsServed(t *testing.T) {
+	suite := testutils.Setup()
+	w := suite.MakeRequest("GET", "/openapi.json", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var doc struct {
+		OpenAPI    string                               `json:"openapi"`
+		Paths      map[string]map[string]map[string]any `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]map[string]any `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+	assert.Equal(t, "3.1.0", doc.OpenAPI)
+	assert.Equal(t, "getApiPostsById", doc.Paths["/api/posts/{id}"]["get"]["operationId"])
+	post := doc.Components.Schemas["Post"].Properties
+	assert.Equal(t, []any{"draft", "published"}, post["status"]["enum"])
+	assert.Equal(t, []any{"string", "null"}, post["published_at"]["type"])
+	assert.Contains(t, doc.Components.Schemas, "ReactionCounts")
+
+	w = suite.MakeRequest("GET", "/openapi.json", nil, map[string]string{"If-None-Match": w.Header().Get("ETag")})
+	assert.Equal(t, http.StatusNotModified, w.Code)
+}
+
+func TestOpenAPIValidationRejectsInvalidRequests(t *testing.T) {
+	suite := testutils.Setup()
+	token := registerAndLogin(t, suite, "validated", "password123", "blogger")
+	auth := map[string]string{"Authorization": "Bearer " + token}
+
+	for _, tc := range []struct {
+		method, path, body, want string
+	}{
+		{"POST", "/api/posts", `{"title": 5, "content": "x"}`, "/title"},
+		{"POST", "/api/posts", `{"title": "x", "status": "archived"}`, "/status"},
+		{"POST", "/api/posts", `{"title": `, "not valid JSON"},
+		{"GET", "/api/me/notifications?limit=500", "", "query parameter limit"},
+		{"GET", "/api/me/notifications?unread=maybe", "", "true or false"},
+		{"GET", "/api/me/lists/first", "", "path parameter id must be an integer"},
+		{"POST", "/api/posts/1/reactions?type=meh", "", "query parameter type"},
+	} {
+		var w *httptest.ResponseRecorder
+		if tc.body != "" {
+			w = suite.MakeRequest(tc.method, tc.path, bytes.NewBufferString(tc.body), auth)
+		} else {
+			w = suite.MakeRequest(tc.method, tc.path, nil, auth)
+		}
+		assert.Equal(t, http.StatusBadRequest, w.Code, "%s %s", tc.method, tc.path)
+		assert.Contains(t, w.Body.String(), tc.want, "%s %s", tc.method, tc.path)
+	}
+
+	w := suite.MakeRequest("POST", "/api/posts", bytes.NewBufferString(`{"title": 5}`))
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "missing credentials are reported before invalid bodies")
+}
+
+func TestOpenAPIValidationCatchesUndocumentedResponses(t *testing.T) {
+	b := openapi.NewBuilder(openapi.Info{Title: "test", Version: "1"})
+	b.Op("GET", "/things/:id", "Get a thing", "things").
+		Returns(http.StatusOK, openapi.Object(map[string]*openapi.Schema{"name": openapi.String()}))
+	validator, err := openapi.NewValidator(b.Document())
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.ValidateOpenAPI(validator, func(*gin.Context, string) bool { return true }))
+	router.GET("/things/:id", func(c *gin.Context) {
+		switch c.Param("id") {
+		case "1":
+			c.JSON(http.StatusOK, gin.H{"name": "one"})
+		case "2":
+			c.JSON(http.StatusOK, gin.H{"name": 2})
+		default:
+			c.JSON(http.StatusTeapot, gin.H{"name": "teapot"})
+		}
+	})
+
+	for id, want := range map[int]int{1: http.StatusOK, 2: http.StatusInternalServerError, 3: http.StatusInternalServerError} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", fmt.Sprintf("/things/%d", id), nil))
+		assert.Equal(t, want, w.Code, "thing %d: %s", id, w.Body.String())
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/things/x", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
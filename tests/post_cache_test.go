@@ -0,0 +1,175 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-blog/cache"
+	"go-blog/models"
+	"go-blog/service"
+	"go-blog/testutils"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRUEvictsLeastRecentlyUsedAndExpires(t *testing.T) {
+	ctx := context.Background()
+	lru := cache.NewLRU(2)
+	require.NoError(t, lru.Set(ctx, "a", []byte("1"), 0))
+	require.NoError(t, lru.Set(ctx, "b", []byte("2"), 0))
+	_, ok, _ := lru.Get(ctx, "a")
+	require.True(t, ok)
+	require.NoError(t, lru.Set(ctx, "c", []byte("3"), 0))
+
+	_, ok, _ = lru.Get(ctx, "b")
+	assert.False(t, ok, "b was the least recently used")
+	value, ok, _ := lru.Get(ctx, "a")
+	assert.True(t, ok)
+	assert.Equal(t, "1", string(value))
+
+	require.NoError(t, lru.Set(ctx, "short", []byte("x"), 10*time.Millisecond))
+	time.Sleep(20 * time.Millisecond)
+	_, ok, _ = lru.Get(ctx, "short")
+	assert.False(t, ok, "expired entries are not served")
+
+	require.NoError(t, lru.Delete(ctx, "a", "missing"))
+	_, ok, _ = lru.Get(ctx, "a")
+	assert.False(t, ok)
+	assert.Zero(t, lru.Len())
+}
+
+// countingPostService counts the reads that reach it. GetAllPosts waits for
+// release, if set, so concurrent callers can pile up.
+type countingPostService struct {
+	service.PostService
+	loads   atomic.Int32
+	release chan struct{}
+	title   string
+}
+
+func (s *countingPostService) GetAllPosts(ctx context.Context) ([]models.Post, error) {
+	s.loads.Add(1)
+	if s.release != nil {
+		<-s.release
+	}
+	return []models.Post{{ID: 1, Title: s.title, Tags: []string{"go"}}}, nil
+}
+
+func (s *countingPostService) GetPostByID(ctx context.Context, id int) (*models.Post, error) {
+	s.loads.Add(1)
+	if id != 1 {
+		return nil, fmt.Errorf("post not found %d", id)
+	}
+	return &models.Post{ID: 1, Title: s.title}, nil
+}
+
+func (s *countingPostService) UpdatePost(ctx context.Context, id int, post *models.Post) (*models.Post, error) {
+	s.title = post.Title
+	return &models.Post{ID: id, Title: post.Title}, nil
+}
+
+func TestCachedPostServiceCoalescesAndInvalidates(t *testing.T) {
+	ctx := context.Background()
+	next := &countingPostService{title: "Before", release: make(chan struct{})}
+	posts := service.NewCachedPostService(next, cache.NewLRU(10), time.Minute, nil, nil)
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got, err := posts.GetAllPosts(ctx)
+			assert.NoError(t, err)
+			assert.Len(t, got, 1)
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(next.release)
+	wg.Wait()
+	assert.Equal(t, int32(1), next.loads.Load(), "concurrent misses share one load")
+
+	got, err := posts.GetAllPosts(ctx)
+	require.NoError(t, err)
+	got[0].Tags[0] = "changed"
+	got, err = posts.GetAllPosts(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"go"}, got[0].Tags, "callers cannot change the cached value")
+	assert.Equal(t, int32(1), next.loads.Load())
+
+	_, err = posts.GetPostByID(ctx, 2)
+	require.Error(t, err)
+	_, err = posts.GetPostByID(ctx, 2)
+	require.Error(t, err)
+	assert.Equal(t, int32(3), next.loads.Load(), "errors are not cached")
+
+	post, err := posts.GetPostByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Before", post.Title)
+	_, err = posts.UpdatePost(ctx, 1, &models.Post{Title: "After"})
+	require.NoError(t, err)
+	post, err = posts.GetPostByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "After", post.Title)
+	got, err = posts.GetAllPosts(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "After", got[0].Title, "the listing is invalidated along with the post")
+}
+
+func TestPostResponsesRevalidateWithETags(t *testing.T) {
+	suite := testutils.Setup()
+	token := registerAndLogin(t, suite, "cacher", "password123", "blogger")
+	auth := map[string]string{"Authorization": "Bearer " + token}
+	post := createPostJSON(t, suite, token, map[string]any{"title": "Cached", "content": "Body"})
+	path := fmt.Sprintf("/api/posts/%d", post.ID)
+
+	for _, url := range []string{"/api/posts", path} {
+		w := suite.MakeRequest("GET", url, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		etag := w.Header().Get("ETag")
+		require.NotEmpty(t, etag)
+		assert.Empty(t, w.Header().Get("Last-Modified"), "updated_at misses deletions and reactions")
+		assert.Equal(t, "public, no-cache", w.Header().Get("Cache-Control"))
+		assert.Equal(t, "Authorization", w.Header().Get("Vary"))
+
+		w = suite.MakeRequest("GET", url, nil, map[string]string{"If-None-Match": etag})
+		assert.Equal(t, http.StatusNotModified, w.Code, url)
+		assert.Empty(t, w.Body.String())
+
+		w = suite.MakeRequest("GET", url, nil, auth)
+		assert.Equal(t, "private, no-cache", w.Header().Get("Cache-Control"), "authenticated responses carry the caller's reactions")
+	}
+
+	w := suite.MakeRequest("GET", path, nil)
+	etag := w.Header().Get("ETag")
+	react(t, suite, token, post.ID, "like")
+	suite.App.Events.Wait()
+	since := map[string]string{"If-Modified-Since": time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)}
+	for _, url := range []string{"/api/posts", path} {
+		w = suite.MakeRequest("GET", url, nil, since)
+		require.Equal(t, http.StatusOK, w.Code, "If-Modified-Since is not trusted for %s", url)
+		assert.Contains(t, w.Body.String(), `"like":1`)
+	}
+	w = suite.MakeRequest("GET", path, nil, map[string]string{"If-None-Match": etag})
+	require.Equal(t, http.StatusOK, w.Code, "a reaction changes the ETag")
+	etag = w.Header().Get("ETag")
+	w = suite.MakeRequest("PUT", path, jsonBody(t, map[string]string{"title": "Edited", "content": "Body"}), auth)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = suite.MakeRequest("GET", path, nil, map[string]string{"If-None-Match": etag})
+	require.Equal(t, http.StatusOK, w.Code, "an edit is visible straight away")
+	var edited models.Post
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &edited))
+	assert.Equal(t, "Edited", edited.Title)
+
+	w = suite.MakeRequest("DELETE", path, nil, auth)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = suite.MakeRequest("GET", path, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = suite.MakeRequest("GET", "/api/posts", nil)
+	assert.JSONEq(t, `[]`, w.Body.String())
+}
@@ -0,0 +1,136 @@
+package tests
+
+import (
+	"context"
+	"go-blog/models"
+	"go-blog/repo/memory"
+	"go-blog/service"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMemoryPostService() service.PostService {
+	posts := memory.NewPostRepository()
+	return service.NewPostService(posts, memory.NewMediaRepository(posts), memory.Transactor{}, nil, nil)
+}
+
+func TestPostServiceCreateValidates(t *testing.T) {
+	ctx := context.Background()
+	postService := newMemoryPostService()
+
+	_, err := postService.CreatePost(ctx, &models.Post{Title: " ", Content: "body", UserID: 1})
+	assert.EqualError(t, err, "title cannot be empty")
+	_, err = postService.CreatePost(ctx, &models.Post{Title: "title", Content: "", UserID: 1})
+	assert.EqualError(t, err, "content cannot be empty")
+
+	created, err := postService.CreatePost(ctx, &models.Post{Title: "title", Content: "body", UserID: 1})
+	require.NoError(t, err)
+	assert.NotZero(t, created.ID)
+}
+
+func TestPostServiceUpdateAndDeleteMissingPost(t *testing.T) {
+	ctx := context.Background()
+	postService := newMemoryPostService()
+
+	_, err := postService.UpdatePost(ctx, 99, &models.Post{Title: "t", Content: "c"})
+	assert.Error(t, err)
+	assert.Error(t, postService.DeletePost(ctx, 99))
+}
+
+func TestPostServiceLifecycle(t *testing.T) {
+	ctx := context.Background()
+	postService := newMemoryPostService()
+
+	created, err := postService.CreatePost(ctx, &models.Post{Title: "title", Content: "body", UserID: 1})
+	require.NoError(t, err)
+
+	updated, err := postService.UpdatePost(ctx, created.ID, &models.Post{Title: "new title", Content: "new body"})
+	require.NoError(t, err)
+	assert.Equal(t, "new title", updated.Title)
+
+	require.NoError(t, postService.DeletePost(ctx, created.ID))
+	_, err = postService.GetPostByID(ctx, created.ID)
+	assert.Error(t, err)
+}
+
+func TestPostServiceSlugs(t *testing.T) {
+	ctx := context.Background()
+	postService := newMemoryPostService()
+
+	first, err := postService.CreatePost(ctx, &models.Post{Title: "Crème Brûlée, 2nd try!", Content: "body", UserID: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "creme-brulee-2nd-try", first.Slug)
+	second, err := postService.CreatePost(ctx, &models.Post{Title: "Crème brûlée 2nd try", Content: "body", UserID: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "creme-brulee-2nd-try-2", second.Slug)
+	untitled, err := postService.CreatePost(ctx, &models.Post{Title: "???", Content: "body", UserID: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "post", untitled.Slug)
+
+	explicit, err := postService.CreatePost(ctx, &models.Post{Title: "t", Slug: "My Own Slug", Content: "body", UserID: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "my-own-slug", explicit.Slug)
+	_, err = postService.CreatePost(ctx, &models.Post{Title: "t", Slug: "my-own-slug", Content: "body", UserID: 1})
+	assert.ErrorIs(t, err, models.ErrSlugTaken)
+	_, err = postService.CreatePost(ctx, &models.Post{Title: "t", Slug: "!!!", Content: "body", UserID: 1})
+	assert.ErrorIs(t, err, models.ErrInvalidSlug)
+
+	_, err = postService.UpdatePost(ctx, second.ID, &models.Post{Title: "t", Content: "c", Slug: "my-own-slug"})
+	assert.ErrorIs(t, err, models.ErrSlugTaken)
+	updated, err := postService.UpdatePost(ctx, explicit.ID, &models.Post{Title: "t", Content: "c", Slug: "my-own-slug"})
+	require.NoError(t, err, "keeping a post's own slug is not a conflict")
+	assert.Equal(t, "my-own-slug", updated.Slug)
+	updated, err = postService.UpdatePost(ctx, second.ID, &models.Post{Title: "t", Content: "c"})
+	require.NoError(t, err)
+	assert.Equal(t, "creme-brulee-2nd-try-2", updated.Slug, "no slug leaves it unchanged")
+}
+
+func TestPostServiceImportKeepsDates(t *testing.T) {
+	ctx := context.Background()
+	postService := newMemoryPostService()
+
+	published := time.Date(2015, 6, 7, 8, 9, 10, 0, time.UTC)
+	updated := published.Add(time.Hour)
+	post, err := postService.ImportPost(ctx, &models.Post{
+		Title: "Old news", Content: "body", UserID: 1, Tags: []string{"History"},
+		PublishedAt: &published, CreatedAt: published, UpdatedAt: updated,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, models.PostStatusPublished, post.Status)
+	require.NotNil(t, post.PublishedAt)
+	assert.True(t, published.Equal(*post.PublishedAt))
+	assert.True(t, published.Equal(post.CreatedAt))
+	assert.True(t, updated.Equal(post.UpdatedAt))
+	assert.Equal(t, []string{"history"}, post.Tags)
+	assert.Equal(t, "old-news", post.Slug)
+
+	draft, err := postService.ImportPost(ctx, &models.Post{Title: "Draft", Content: "body", UserID: 1, Status: models.PostStatusDraft, PublishedAt: &published})
+	require.NoError(t, err)
+	assert.Nil(t, draft.PublishedAt, "drafts have no publish date")
+
+	_, err = postService.ImportPost(ctx, &models.Post{Title: "Empty", Content: " ", UserID: 1})
+	assert.EqualError(t, err, "content cannot be empty")
+	_, err = postService.ImportPost(ctx, &models.Post{Title: "Bad", Content: "body", UserID: 1, Status: "scheduled"})
+	assert.ErrorIs(t, err, models.ErrInvalidStatus)
+}
+
+func TestUserServiceRegisterAndLogin(t *testing.T) {
+	ctx := context.Background()
+	userService := service.NewUserService(memory.NewUserRepository(), memory.Transactor{}, nil)
+
+	_, err := userService.Register(ctx, &models.RegisterRequest{Username: "dana", Password: "secret", AccountType: models.AccountTypeViewer})
+	require.NoError(t, err)
+	_, err = userService.Register(ctx, &models.RegisterRequest{Username: "dana", Password: "secret", AccountType: models.AccountTypeViewer})
+	assert.ErrorIs(t, err, models.ErrUsernameExists)
+
+	user, err := userService.Login(ctx, "dana", "secret")
+	require.NoError(t, err)
+	assert.Equal(t, "dana", user.Username)
+	_, err = userService.Login(ctx, "dana", "wrong")
+	assert.EqualError(t, err, "invalid password")
+	_, err = userService.Login(ctx, "nobody", "secret")
+	assert.ErrorIs(t, err, models.ErrUserNotFound)
+}
@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"context"
 	"bytes"
 	"encoding/json"
 	"fmt"
@@ -34,10 +35,21 @@ func newDeletePostResponseForTest() map[string]interface{} {
 	}
 }
 
+var bloggerTokens = map[*testutils.TestSuite]string{}
+
+func bloggerAuthHeader(t *testing.T, suite *testutils.TestSuite) map[string]string {
+	token, ok := bloggerTokens[suite]
+	if !ok {
+		token = registerAndLogin(t, suite, "postauthor", "postauthorpass", "blogger")
+		bloggerTokens[suite] = token
+	}
+	return map[string]string{"Authorization": "Bearer " + token}
+}
+
 func createPostTest(t *testing.T, suite *testutils.TestSuite, title string, content string) int {
 	payload := map[string]string{"title": title, "content": content}
 	body, _ := json.Marshal(payload)
-	w := suite.MakeRequest("POST", "/api/posts", bytes.NewBuffer(body))
+	w := suite.MakeRequest("POST", "/api/posts", bytes.NewBuffer(body), bloggerAuthHeader(t, suite))
 	if w.Code != http.StatusCreated {
 		t.Fatalf("Expected status 201 Created, got %d", w.Code)
 	}
@@ -53,7 +65,7 @@ func createPostTest(t *testing.T, suite *testutils.TestSuite, title string, cont
 	assert.Equal(t, expectedResponse.Content, actualResponse.Content, "content is different from expectations")
 	assert.NotZero(t, actualResponse.ID, "Post ID should be set")
 
-	fetchedPost, err := suite.PostRepo.GetPost(actualResponse.ID)
+	fetchedPost, err := suite.PostRepo.GetPost(context.Background(), actualResponse.ID)
 	require.NoError(t, err, "no error while fetching from db")
 	require.NotNil(t, fetchedPost, "post not nil in db")
 	assert.Equal(t, actualResponse.ID, fetchedPost.ID, "postId matches")
@@ -78,7 +90,7 @@ func getPostsTest(t *testing.T, suite *testutils.TestSuite) []models.Post {
 func updatePostTest(t *testing.T, suite *testutils.TestSuite, id int, title string, content string) {
 	payload := map[string]string{"title": title, "content": content}
 	body, _ := json.Marshal(payload)
-	w := suite.MakeRequest("PUT", fmt.Sprintf("/api/posts/%d/update", id), bytes.NewBuffer(body))
+	w := suite.MakeRequest("PUT", fmt.Sprintf("/api/posts/%d", id), bytes.NewBuffer(body), bloggerAuthHeader(t, suite))
 	if w.Code != http.StatusOK {
 		t.Fatalf("Expected status 200 OK, got %d", w.Code)
 	}
@@ -92,7 +104,7 @@ func updatePostTest(t *testing.T, suite *testutils.TestSuite, id int, title stri
 }
 
 func deletePostTest(t *testing.T, suite *testutils.TestSuite, id int) {
-	w := suite.MakeRequest("DELETE", fmt.Sprintf("/api/posts/%d", id), nil)
+	w := suite.MakeRequest("DELETE", fmt.Sprintf("/api/posts/%d", id), nil, bloggerAuthHeader(t, suite))
 	if w.Code != http.StatusOK {
 		t.Fatalf("Expected status 200 OK, got %d", w.Code)
 	}
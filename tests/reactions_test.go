@@ -0,0 +1,122 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-blog/models"
+	"go-blog/testutils"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func jsonBody(t *testing.T, v interface{}) *bytes.Buffer {
+	body, err := json.Marshal(v)
+	require.NoError(t, err)
+	return bytes.NewBuffer(body)
+}
+
+func decodeReactionSummary(t *testing.T, body []byte) models.ReactionSummary {
+	var summary models.ReactionSummary
+	require.NoError(t, json.Unmarshal(body, &summary))
+	return summary
+}
+
+func TestReactionsAddRemoveAndPerUserState(t *testing.T) {
+	suite := testutils.Setup()
+	blogger := registerAndLogin(t, suite, "reactblogger", "password", "blogger")
+	viewer := registerAndLogin(t, suite, "reactviewer", "password", "viewer")
+	postID := createPostAs(t, suite, blogger, "Reactions", "Body")
+	viewerAuth := map[string]string{"Authorization": "Bearer " + viewer}
+	url := fmt.Sprintf("/api/posts/%d/reactions", postID)
+
+	for i := 0; i < 2; i++ {
+		w := suite.MakeRequest("POST", url+"?type=like", nil, viewerAuth)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		summary := decodeReactionSummary(t, w.Body.Bytes())
+		assert.Equal(t, 1, summary.Reactions.Like)
+		assert.Equal(t, []models.ReactionType{models.ReactionLike}, summary.MyReactions)
+	}
+	w := suite.MakeRequest("POST", url, jsonBody(t, map[string]string{"type": "love"}), map[string]string{"Authorization": "Bearer " + blogger})
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = suite.MakeRequest("GET", fmt.Sprintf("/api/posts/%d", postID), nil, viewerAuth)
+	require.Equal(t, http.StatusOK, w.Code)
+	var post models.Post
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &post))
+	assert.Equal(t, models.ReactionCounts{Like: 1, Love: 1}, post.Reactions)
+	assert.Equal(t, []models.ReactionType{models.ReactionLike}, post.MyReactions)
+
+	w = suite.MakeRequest("GET", "/api/posts", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "my_reactions")
+
+	w = suite.MakeRequest("DELETE", url+"?type=like", nil, viewerAuth)
+	require.Equal(t, http.StatusOK, w.Code)
+	summary := decodeReactionSummary(t, w.Body.Bytes())
+	assert.Zero(t, summary.Reactions.Like)
+	assert.Empty(t, summary.MyReactions)
+}
+
+func TestReactionsValidation(t *testing.T) {
+	suite := testutils.Setup()
+	token := registerAndLogin(t, suite, "reactvalidate", "password", "viewer")
+	auth := map[string]string{"Authorization": "Bearer " + token}
+
+	w := suite.MakeRequest("POST", "/api/posts/1/reactions?type=like", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = suite.MakeRequest("POST", "/api/posts/1/reactions?type=angry", nil, auth)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = suite.MakeRequest("POST", "/api/posts/9999/reactions?type=like", nil, auth)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = suite.MakeRequest("GET", "/api/posts", nil, map[string]string{"Authorization": "Bearer nope"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestReactionsOnDraftsAreLimitedToTheAuthor(t *testing.T) {
+	suite := testutils.Setup()
+	author := registerAndLogin(t, suite, "reactdrafter", "password", "blogger")
+	other := registerAndLogin(t, suite, "reactsnooper", "password", "viewer")
+	draft := createPostJSON(t, suite, author, map[string]any{"title": "Draft", "content": "Body", "status": "draft"})
+	url := fmt.Sprintf("/api/posts/%d/reactions?type=like", draft.ID)
+
+	w := suite.MakeRequest("POST", url, nil, map[string]string{"Authorization": "Bearer " + other})
+	assert.Equal(t, http.StatusNotFound, w.Code, "a draft cannot be found by reacting to it")
+	w = suite.MakeRequest("GET", "/api/me/notifications", nil, map[string]string{"Authorization": "Bearer " + author})
+	require.Equal(t, http.StatusOK, w.Code)
+	var page models.NotificationPage
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	assert.Empty(t, page.Notifications, "the author is not notified")
+
+	w = suite.MakeRequest("POST", url, nil, map[string]string{"Authorization": "Bearer " + author})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, 1, decodeReactionSummary(t, w.Body.Bytes()).Reactions.Like)
+
+	w = suite.MakeRequest("DELETE", url, nil, map[string]string{"Authorization": "Bearer " + other})
+	assert.Equal(t, http.StatusNotFound, w.Code, "removing a reaction does not reveal the draft")
+	assert.NotContains(t, w.Body.String(), "reactions")
+	w = suite.MakeRequest("DELETE", url, nil, map[string]string{"Authorization": "Bearer " + author})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Zero(t, decodeReactionSummary(t, w.Body.Bytes()).Reactions.Like)
+}
+
+func TestReactionCountsAreReconciled(t *testing.T) {
+	suite := testutils.Setup()
+	blogger := registerAndLogin(t, suite, "reconcileblogger", "password", "blogger")
+	postID := createPostAs(t, suite, blogger, "Drift", "Body")
+	w := suite.MakeRequest("POST", fmt.Sprintf("/api/posts/%d/reactions?type=sad", postID), nil, map[string]string{"Authorization": "Bearer " + blogger})
+	require.Equal(t, http.StatusOK, w.Code)
+
+	require.NoError(t, suite.DB.Exec("UPDATE posts SET reactions_sad = 7, reactions_wow = 2 WHERE id = ?", postID).Error)
+	fixed, err := suite.App.ReactionService.ReconcileCounts(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), fixed)
+
+	post, err := suite.PostRepo.GetPost(context.Background(), postID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ReactionCounts{Sad: 1}, post.Reactions)
+}
@@ -0,0 +1,148 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-blog/models"
+	"go-blog/testutils"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeListDetail(t *testing.T, body []byte) models.ReadingListDetail {
+	var detail models.ReadingListDetail
+	require.NoError(t, json.Unmarshal(body, &detail), string(body))
+	return detail
+}
+
+func itemPostIDs(items []models.ReadingListItem) []int {
+	ids := make([]int, len(items))
+	for i, item := range items {
+		ids[i] = item.PostID
+	}
+	return ids
+}
+
+func TestDefaultReadingListIsCreatedLazily(t *testing.T) {
+	suite := testutils.Setup()
+	viewer := registerAndLogin(t, suite, "listdefault", "password", "viewer")
+	auth := map[string]string{"Authorization": "Bearer " + viewer}
+
+	for i := 0; i < 2; i++ {
+		w := suite.MakeRequest("GET", "/api/me/lists", nil, auth)
+		require.Equal(t, http.StatusOK, w.Code)
+		var lists []models.ReadingList
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &lists))
+		require.Len(t, lists, 1)
+		assert.Equal(t, models.DefaultReadingListName, lists[0].Name)
+		assert.True(t, lists[0].IsDefault)
+	}
+
+	w := suite.MakeRequest("POST", "/api/me/lists", jsonBody(t, map[string]string{"name": models.DefaultReadingListName}), auth)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, http.StatusUnauthorized, suite.MakeRequest("GET", "/api/me/lists", nil).Code)
+}
+
+func TestReadingListItemsOrderNotesAndUnavailablePosts(t *testing.T) {
+	suite := testutils.Setup()
+	blogger := registerAndLogin(t, suite, "listblogger", "password", "blogger")
+	viewer := registerAndLogin(t, suite, "listviewer", "password", "viewer")
+	auth := map[string]string{"Authorization": "Bearer " + viewer}
+	bloggerAuth := map[string]string{"Authorization": "Bearer " + blogger}
+
+	first := createPostAs(t, suite, blogger, "First", "body")
+	second := createPostAs(t, suite, blogger, "Second", "body")
+	third := createPostAs(t, suite, blogger, "Third", "body")
+
+	w := suite.MakeRequest("POST", "/api/me/lists", jsonBody(t, map[string]interface{}{"name": "Go"}), auth)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var list models.ReadingList
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	itemsURL := fmt.Sprintf("/api/me/lists/%d/posts", list.ID)
+
+	for _, id := range []int{first, second, third} {
+		w = suite.MakeRequest("POST", itemsURL, jsonBody(t, map[string]interface{}{"post_id": id}), auth)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	}
+	w = suite.MakeRequest("POST", itemsURL, jsonBody(t, map[string]interface{}{"post_id": first}), auth)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = suite.MakeRequest("PATCH", fmt.Sprintf("%s/%d", itemsURL, third), jsonBody(t, map[string]interface{}{"position": 1, "note": "read first"}), auth)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	detail := decodeListDetail(t, w.Body.Bytes())
+	assert.Equal(t, []int{third, first, second}, itemPostIDs(detail.Items))
+	assert.Equal(t, "read first", detail.Items[0].Note)
+	for i, item := range detail.Items {
+		assert.Equal(t, i+1, item.Position)
+	}
+
+	w = suite.MakeRequest("PUT", fmt.Sprintf("/api/posts/%d", first), jsonBody(t, map[string]string{"title": "First", "content": "body", "status": "draft"}), bloggerAuth)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = suite.MakeRequest("DELETE", fmt.Sprintf("/api/posts/%d", second), nil, bloggerAuth)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = suite.MakeRequest("GET", itemsURL, nil, auth)
+	require.Equal(t, http.StatusOK, w.Code)
+	var items []models.ReadingListItem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &items))
+	require.Len(t, items, 3)
+	assert.True(t, items[0].Available)
+	require.NotNil(t, items[0].Post)
+	assert.Equal(t, "Third", items[0].Post.Title)
+	for _, item := range items[1:] {
+		assert.False(t, item.Available, "post %d should be unavailable", item.PostID)
+		assert.Nil(t, item.Post)
+	}
+
+	w = suite.MakeRequest("DELETE", fmt.Sprintf("%s/%d", itemsURL, second), nil, auth)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = suite.MakeRequest("DELETE", fmt.Sprintf("%s/%d", itemsURL, second), nil, auth)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestReadingListsArePrivateUnlessShared(t *testing.T) {
+	suite := testutils.Setup()
+	blogger := registerAndLogin(t, suite, "sharedblogger", "password", "blogger")
+	owner := registerAndLogin(t, suite, "listowner", "password", "viewer")
+	other := registerAndLogin(t, suite, "listsnoop", "password", "viewer")
+	ownerAuth := map[string]string{"Authorization": "Bearer " + owner}
+	postID := createPostAs(t, suite, blogger, "Shared", "body")
+
+	w := suite.MakeRequest("POST", "/api/me/lists", jsonBody(t, map[string]interface{}{"name": "Favourites"}), ownerAuth)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var list models.ReadingList
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Nil(t, list.ShareToken)
+	listURL := fmt.Sprintf("/api/me/lists/%d", list.ID)
+	require.Equal(t, http.StatusCreated, suite.MakeRequest("POST", listURL+"/posts", jsonBody(t, map[string]interface{}{"post_id": postID, "note": "great"}), ownerAuth).Code)
+
+	assert.Equal(t, http.StatusNotFound, suite.MakeRequest("GET", listURL, nil, map[string]string{"Authorization": "Bearer " + other}).Code)
+
+	w = suite.MakeRequest("PATCH", listURL, jsonBody(t, map[string]interface{}{"shared": true}), ownerAuth)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.NotNil(t, list.ShareToken)
+	token := *list.ShareToken
+
+	w = suite.MakeRequest("GET", "/api/shared/lists/"+token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	detail := decodeListDetail(t, w.Body.Bytes())
+	assert.Equal(t, "Favourites", detail.Name)
+	assert.Nil(t, detail.ShareToken)
+	require.Len(t, detail.Items, 1)
+	assert.Equal(t, "great", detail.Items[0].Note)
+
+	w = suite.MakeRequest("PATCH", listURL, jsonBody(t, map[string]interface{}{"shared": false}), ownerAuth)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, http.StatusNotFound, suite.MakeRequest("GET", "/api/shared/lists/"+token, nil).Code)
+
+	w = suite.MakeRequest("GET", "/api/me/lists", nil, ownerAuth)
+	var lists []models.ReadingList
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &lists))
+	require.Len(t, lists, 2)
+	assert.Equal(t, http.StatusBadRequest, suite.MakeRequest("DELETE", fmt.Sprintf("/api/me/lists/%d", lists[0].ID), nil, ownerAuth).Code)
+	assert.Equal(t, http.StatusOK, suite.MakeRequest("DELETE", listURL, nil, ownerAuth).Code)
+}
@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"go-blog/repo"
+	"go-blog/repo/memory"
+	"go-blog/repo/repotest"
+	"go-blog/testutils"
+	"testing"
+)
+
+func TestPostRepositoryContract(t *testing.T) {
+	t.Run("GORM", func(t *testing.T) {
+		repotest.PostRepositoryContract(t, func(t *testing.T) repo.PostRepository {
+			return repo.NewPostRepository(testutils.Setup().DB)
+		})
+	})
+	t.Run("Memory", func(t *testing.T) {
+		repotest.PostRepositoryContract(t, func(t *testing.T) repo.PostRepository {
+			return memory.NewPostRepository()
+		})
+	})
+}
+
+func TestUserRepositoryContract(t *testing.T) {
+	t.Run("GORM", func(t *testing.T) {
+		repotest.UserRepositoryContract(t, func(t *testing.T) repo.UserRepository {
+			return repo.NewUserRepository(testutils.Setup().DB)
+		})
+	})
+	t.Run("Memory", func(t *testing.T) {
+		repotest.UserRepositoryContract(t, func(t *testing.T) repo.UserRepository {
+			return memory.NewUserRepository()
+		})
+	})
+}
+
+func TestReactionRepositoryContract(t *testing.T) {
+	t.Run("GORM", func(t *testing.T) {
+		repotest.ReactionRepositoryContract(t, func(t *testing.T) (repo.PostRepository, repo.ReactionRepository) {
+			suite := testutils.Setup()
+			return suite.PostRepo, repo.NewReactionRepository(suite.DB)
+		})
+	})
+	t.Run("Memory", func(t *testing.T) {
+		repotest.ReactionRepositoryContract(t, func(t *testing.T) (repo.PostRepository, repo.ReactionRepository) {
+			posts := memory.NewPostRepository()
+			return posts, memory.NewReactionRepository(posts)
+		})
+	})
+}
+
+func TestMediaRepositoryContract(t *testing.T) {
+	t.Run("GORM", func(t *testing.T) {
+		repotest.MediaRepositoryContract(t, func(t *testing.T) (repo.UserRepository, repo.PostRepository, repo.MediaRepository) {
+			suite := testutils.Setup()
+			return repo.NewUserRepository(suite.DB), suite.PostRepo, repo.NewMediaRepository(suite.DB)
+		})
+	})
+	t.Run("Memory", func(t *testing.T) {
+		repotest.MediaRepositoryContract(t, func(t *testing.T) (repo.UserRepository, repo.PostRepository, repo.MediaRepository) {
+			posts := memory.NewPostRepository()
+			return memory.NewUserRepository(), posts, memory.NewMediaRepository(posts)
+		})
+	})
+}
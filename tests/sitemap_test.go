@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"fmt"
+	"go-blog/testutils"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type urlSet struct {
+	URLs []struct {
+		Loc     string `xml:"loc"`
+		LastMod string `xml:"lastmod"`
+	} `xml:"url"`
+}
+
+type sitemapIndex struct {
+	Sitemaps []struct {
+		Loc string `xml:"loc"`
+	} `xml:"sitemap"`
+}
+
+func gunzip(t *testing.T, data []byte) []byte {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	plain, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	return plain
+}
+
+func TestSitemapListsPublishedPostsAndRefreshes(t *testing.T) {
+	suite := testutils.Setup()
+	blogger := registerAndLogin(t, suite, "sitemapper", "password", "blogger")
+	first := createPostAs(t, suite, blogger, "One", "body")
+	w := suite.MakeRequest("POST", "/api/posts", jsonBody(t, map[string]string{"title": "Draft", "content": "body", "status": "draft"}),
+		map[string]string{"Authorization": "Bearer " + blogger})
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	w = suite.MakeRequest("GET", "/sitemap.xml", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/xml; charset=utf-8", w.Header().Get("Content-Type"))
+	var set urlSet
+	require.NoError(t, xml.Unmarshal(w.Body.Bytes(), &set))
+	require.Len(t, set.URLs, 1)
+	assert.Equal(t, fmt.Sprintf("http://localhost:8080/posts/%d", first), set.URLs[0].Loc)
+	_, err := time.Parse(time.RFC3339, set.URLs[0].LastMod)
+	assert.NoError(t, err)
+	etag := w.Header().Get("ETag")
+	assert.Equal(t, http.StatusNotModified, suite.MakeRequest("GET", "/sitemap.xml", nil, map[string]string{"If-None-Match": etag}).Code)
+
+	createPostAs(t, suite, blogger, "Two", "body")
+	w = suite.MakeRequest("GET", "/sitemap.xml", nil, map[string]string{"If-None-Match": etag})
+	require.Equal(t, http.StatusOK, w.Code)
+	var refreshed urlSet
+	require.NoError(t, xml.Unmarshal(w.Body.Bytes(), &refreshed))
+	assert.Len(t, refreshed.URLs, 2)
+
+	w = suite.MakeRequest("GET", "/sitemap.xml.gz", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/gzip", w.Header().Get("Content-Type"))
+	var unzipped urlSet
+	require.NoError(t, xml.Unmarshal(gunzip(t, w.Body.Bytes()), &unzipped))
+	assert.Len(t, unzipped.URLs, 2)
+}
+
+func TestSitemapBecomesIndexWhenChunked(t *testing.T) {
+	cfg := testutils.TestConfig()
+	cfg.Site.SitemapChunkSize = 2
+	suite := testutils.SetupWithConfig(cfg)
+	blogger := registerAndLogin(t, suite, "chunker", "password", "blogger")
+	for i := 0; i < 5; i++ {
+		createPostAs(t, suite, blogger, fmt.Sprintf("Post %d", i), "body")
+	}
+
+	w := suite.MakeRequest("GET", "/sitemap.xml", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var index sitemapIndex
+	require.NoError(t, xml.Unmarshal(w.Body.Bytes(), &index))
+	require.Len(t, index.Sitemaps, 3)
+	assert.Equal(t, "http://localhost:8080/sitemaps/sitemap-3.xml.gz", index.Sitemaps[2].Loc)
+
+	total := 0
+	for n := 1; n <= 3; n++ {
+		w := suite.MakeRequest("GET", fmt.Sprintf("/sitemaps/sitemap-%d.xml.gz", n), nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var set urlSet
+		require.NoError(t, xml.Unmarshal(gunzip(t, w.Body.Bytes()), &set))
+		total += len(set.URLs)
+	}
+	assert.Equal(t, 5, total)
+	assert.Equal(t, http.StatusOK, suite.MakeRequest("GET", "/sitemaps/sitemap-1.xml", nil).Code)
+	assert.Equal(t, http.StatusNotFound, suite.MakeRequest("GET", "/sitemaps/sitemap-4.xml.gz", nil).Code)
+	assert.Equal(t, http.StatusNotFound, suite.MakeRequest("GET", "/sitemaps/other.xml", nil).Code)
+}
+
+func TestRobotsTxtPointsAtSitemap(t *testing.T) {
+	cfg := testutils.TestConfig()
+	cfg.Site.BaseURL = "https://blog.example.com"
+	cfg.Site.RobotsDisallow = "/api/, /admin/"
+	suite := testutils.SetupWithConfig(cfg)
+
+	w := suite.MakeRequest("GET", "/robots.txt", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "User-agent: *\nDisallow: /api/\nDisallow: /admin/\n\nSitemap: https://blog.example.com/sitemap.xml\n", w.Body.String())
+}
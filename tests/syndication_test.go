@@ -0,0 +1,176 @@
+package tests
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"go-blog/testutils"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type rssFeed struct {
+	Title    string `xml:"channel>title"`
+	SelfLink struct {
+		Href string `xml:"href,attr"`
+	} `xml:"http://www.w3.org/2005/Atom channel>link"`
+	Items []struct {
+		Title      string   `xml:"title"`
+		Link       string   `xml:"link"`
+		GUID       string   `xml:"guid"`
+		PubDate    string   `xml:"pubDate"`
+		Creator    string   `xml:"http://purl.org/dc/elements/1.1/ creator"`
+		Categories []string `xml:"category"`
+		Content    string   `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	} `xml:"channel>item"`
+}
+
+type atomFeed struct {
+	ID      string `xml:"id"`
+	Updated string `xml:"updated"`
+	Entries []struct {
+		ID        string `xml:"id"`
+		Title     string `xml:"title"`
+		Published string `xml:"published"`
+		Author    string `xml:"author>name"`
+		Content   struct {
+			Type  string `xml:"type,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"content"`
+	} `xml:"entry"`
+}
+
+func seedFeedPosts(t *testing.T, suite *testutils.TestSuite) (alice string, first int) {
+	alice = registerAndLogin(t, suite, "rssalice", "password", "blogger")
+	bob := registerAndLogin(t, suite, "rssbob", "password", "blogger")
+	create := func(token, title, content string, tags []string) int {
+		w := suite.MakeRequest("POST", "/api/posts", jsonBody(t, map[string]interface{}{"title": title, "content": content, "tags": tags}),
+			map[string]string{"Authorization": "Bearer " + token})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var resp struct{ ID int }
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp.ID
+	}
+	first = create(alice, "Hello <world>", "First paragraph.\n\nSecond & last.", []string{"Go", "intro"})
+	create(bob, "Bob's post", "Bob writes.", []string{"go"})
+	create(alice, "Alice again", "More.", nil)
+	return alice, first
+}
+
+func TestRSSFeedContainsPublishedPosts(t *testing.T) {
+	suite := testutils.Setup()
+	_, first := seedFeedPosts(t, suite)
+
+	w := suite.MakeRequest("GET", "/feed.rss", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/rss+xml; charset=utf-8", w.Header().Get("Content-Type"))
+
+	var feed rssFeed
+	require.NoError(t, xml.Unmarshal(w.Body.Bytes(), &feed), w.Body.String())
+	require.Len(t, feed.Items, 3)
+	assert.Equal(t, "http://localhost:8080/feed.rss", feed.SelfLink.Href)
+	assert.Equal(t, "Alice again", feed.Items[0].Title)
+
+	oldest := feed.Items[2]
+	assert.Equal(t, "Hello <world>", oldest.Title)
+	assert.Equal(t, fmt.Sprintf("http://localhost:8080/posts/%d", first), oldest.Link)
+	assert.True(t, strings.HasPrefix(oldest.GUID, "tag:localhost,"), oldest.GUID)
+	assert.Equal(t, "rssalice", oldest.Creator)
+	assert.Equal(t, []string{"go", "intro"}, oldest.Categories)
+	assert.Equal(t, "<p>First paragraph.</p><p>Second &amp; last.</p>", oldest.Content)
+	_, err := time.Parse(time.RFC1123Z, oldest.PubDate)
+	assert.NoError(t, err)
+}
+
+func TestFeedAndSitemapLinksResolve(t *testing.T) {
+	suite := testutils.Setup()
+	seedFeedPosts(t, suite)
+	var links []string
+
+	w := suite.MakeRequest("GET", "/feed.rss", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var feed rssFeed
+	require.NoError(t, xml.Unmarshal(w.Body.Bytes(), &feed))
+	require.NotEmpty(t, feed.Items)
+	links = append(links, feed.Items[0].Link)
+
+	w = suite.MakeRequest("GET", "/sitemap.xml", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var set urlSet
+	require.NoError(t, xml.Unmarshal(w.Body.Bytes(), &set))
+	require.NotEmpty(t, set.URLs)
+	links = append(links, set.URLs[0].Loc)
+
+	for _, link := range links {
+		path, ok := strings.CutPrefix(link, "http://localhost:8080")
+		require.True(t, ok, link)
+		w = suite.MakeRequest("GET", path, nil, map[string]string{"Accept": "text/html"})
+		require.Equal(t, http.StatusOK, w.Code, link)
+		assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+		assert.Contains(t, w.Body.String(), "<h1>")
+	}
+}
+
+func TestAtomFeedsPerAuthorAndTag(t *testing.T) {
+	suite := testutils.Setup()
+	seedFeedPosts(t, suite)
+
+	w := suite.MakeRequest("GET", "/authors/rssalice/feed.atom", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/atom+xml; charset=utf-8", w.Header().Get("Content-Type"))
+	var feed atomFeed
+	require.NoError(t, xml.Unmarshal(w.Body.Bytes(), &feed))
+	require.Len(t, feed.Entries, 2)
+	assert.Equal(t, "http://localhost:8080/authors/rssalice/feed", feed.ID)
+	for _, entry := range feed.Entries {
+		assert.Equal(t, "rssalice", entry.Author)
+		assert.Equal(t, "html", entry.Content.Type)
+		_, err := time.Parse(time.RFC3339, entry.Published)
+		assert.NoError(t, err)
+	}
+
+	w = suite.MakeRequest("GET", "/tags/GO/feed.atom", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var tagged atomFeed
+	require.NoError(t, xml.Unmarshal(w.Body.Bytes(), &tagged))
+	assert.Len(t, tagged.Entries, 2)
+
+	w = suite.MakeRequest("GET", "/tags/go/feed.rss", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var rss rssFeed
+	require.NoError(t, xml.Unmarshal(w.Body.Bytes(), &rss))
+	assert.Len(t, rss.Items, 2)
+
+	assert.Equal(t, http.StatusNotFound, suite.MakeRequest("GET", "/authors/nobody/feed.rss", nil).Code)
+}
+
+func TestFeedConditionalGet(t *testing.T) {
+	suite := testutils.Setup()
+	alice, first := seedFeedPosts(t, suite)
+
+	w := suite.MakeRequest("GET", "/feed.atom", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	lastModified := w.Header().Get("Last-Modified")
+	require.NotEmpty(t, etag)
+	require.NotEmpty(t, lastModified)
+
+	w = suite.MakeRequest("GET", "/feed.atom", nil, map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+	w = suite.MakeRequest("GET", "/feed.atom", nil, map[string]string{"If-Modified-Since": lastModified})
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	w = suite.MakeRequest("GET", "/feed.rss", nil, map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusOK, w.Code, "RSS and Atom have different validators")
+
+	w = suite.MakeRequest("DELETE", fmt.Sprintf("/api/posts/%d", first), nil, map[string]string{"Authorization": "Bearer " + alice})
+	require.Equal(t, http.StatusOK, w.Code)
+	w = suite.MakeRequest("GET", "/feed.atom", nil, map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+}
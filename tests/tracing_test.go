@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"go-blog/config"
+	"go-blog/logging"
+	"go-blog/routes"
+	"go-blog/testutils"
+	"go-blog/tracing"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func spanByName(spans tracetest.SpanStubs, name string) *tracetest.SpanStub {
+	for i := range spans {
+		if spans[i].Name == name {
+			return &spans[i]
+		}
+	}
+	return nil
+}
+
+func TestTracingBuildsSpanTreeFromIncomingTraceparent(t *testing.T) {
+	cfg := testutils.TestConfig()
+	cfg.Tracing.Exporter = tracing.ExporterMemory
+	suite := testutils.SetupWithConfig(cfg)
+	memory := suite.App.Tracing.Memory
+	require.NotNil(t, memory)
+
+	logBuf := &bytes.Buffer{}
+	logger, err := logging.NewWithWriter(config.LogConfig{Level: "info", Format: "json"}, logBuf)
+	require.NoError(t, err)
+	router := routes.SetupRoutes(suite.App.Config, routes.Handlers{
+		Post:   suite.App.PostHandler,
+		User:   suite.App.UserHandler,
+		Health: suite.App.HealthHandler,
+	}, suite.App.AuthRepo, nil, suite.App.Tracing, logger)
+
+	token := registerAndLogin(t, suite, "tracer", "pass", "blogger")
+	memory.Reset()
+	logBuf.Reset()
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	body, _ := json.Marshal(map[string]string{"title": "Traced", "content": "Follow me"})
+	req := httptest.NewRequest("POST", "/api/posts", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	spans := memory.GetSpans()
+	server := spanByName(spans, "POST /api/posts")
+	require.NotNil(t, server, "gin request span")
+	create := spanByName(spans, "PostService.CreatePost")
+	require.NotNil(t, create, "service span")
+	insert := spanByName(spans, "gorm.create")
+	require.NotNil(t, insert, "database span")
+
+	assert.Equal(t, traceID, server.SpanContext.TraceID().String(), "incoming traceparent is continued")
+	assert.Equal(t, server.SpanContext.SpanID(), create.Parent.SpanID())
+	assert.Equal(t, create.SpanContext.SpanID(), insert.Parent.SpanID())
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(bytes.Split(logBuf.Bytes(), []byte("\n"))[0], &entry))
+	assert.Equal(t, traceID, entry["trace_id"], "log entries carry the trace ID")
+}
@@ -72,7 +72,9 @@ func TestViewerCannotCreateOrUpdatePost(t *testing.T) {
 	assert.Equal(t, http.StatusForbidden, createW.Code)
 	updatePayload := map[string]interface{}{"title": "Updated Title", "content": "Updated Content"}
 	updateBody, _ := json.Marshal(updatePayload)
-	updateW := suite.MakeRequest("PUT", "/api/posts/1/update", bytes.NewBuffer(updateBody), map[string]string{"Authorization": "Bearer " + viewerToken})
+	bloggerToken := registerAndLogin(t, suite, "blogOwner", "bloggerpass", "blogger")
+	postID := createPostAs(t, suite, bloggerToken, "Blogger Post", "Only the blogger may update this.")
+	updateW := suite.MakeRequest("PUT", fmt.Sprintf("/api/posts/%d", postID), bytes.NewBuffer(updateBody), map[string]string{"Authorization": "Bearer " + viewerToken})
 	assert.Equal(t, http.StatusForbidden, updateW.Code)
 }
 
@@ -89,14 +91,14 @@ func TestBloggerCanCreateAndUpdatePost(t *testing.T) {
 	postID := createPostAs(t, suite, bloggerToken, "Blogger Create", "Blogger can create this post.")
 	updatePayload := map[string]interface{}{"title": "Updated by Blogger", "content": "Updated Content"}
 	updateBody, _ := json.Marshal(updatePayload)
-	updateW := suite.MakeRequest("PUT", fmt.Sprintf("/api/posts/%d/update", postID), bytes.NewBuffer(updateBody), map[string]string{"Authorization": "Bearer " + bloggerToken})
+	updateW := suite.MakeRequest("PUT", fmt.Sprintf("/api/posts/%d", postID), bytes.NewBuffer(updateBody), map[string]string{"Authorization": "Bearer " + bloggerToken})
 	assert.Equal(t, http.StatusOK, updateW.Code)
 }
 
 func TestDeleteNonExistentPost(t *testing.T) {
 	suite := testutils.Setup()
 	bloggerToken := registerAndLogin(t, suite, "blogNoPost", "bloggerpass", "blogger")
-	deleteW := suite.MakeRequest("DELETE", "/api/posts/A8921", nil, map[string]string{"Authorization": "Bearer " + bloggerToken})
+	deleteW := suite.MakeRequest("DELETE", "/api/posts/8921", nil, map[string]string{"Authorization": "Bearer " + bloggerToken})
 	assert.True(t, deleteW.Code == http.StatusNotFound || deleteW.Code == http.StatusForbidden)
 }
 
@@ -112,13 +114,13 @@ func TestViewerCannotDeletePost(t *testing.T) {
 func TestInvalidToken(t *testing.T) {
 	suite := testutils.Setup()
 	invalidToken := "should.make.no.sense.buffer.value.with.no.meaning."
-	getW := suite.MakeRequest("GET", "/api/posts", nil, map[string]string{"Authorization": "Bearer " + invalidToken})
+	getW := suite.MakeRequest("POST", "/api/posts", nil, map[string]string{"Authorization": "Bearer " + invalidToken})
 	assert.Equal(t, http.StatusUnauthorized, getW.Code)
 }
 
 func TestEmptyToken(t *testing.T) {
 	suite := testutils.Setup()
-	getW := suite.MakeRequest("GET", "/api/posts", nil, map[string]string{"Authorization": ""})
+	getW := suite.MakeRequest("POST", "/api/posts", nil, map[string]string{"Authorization": ""})
 	assert.Equal(t, http.StatusUnauthorized, getW.Code)
 }
 
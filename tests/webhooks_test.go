@@ -0,0 +1,354 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-blog/config"
+	"go-blog/jobs"
+	"go-blog/models"
+	"go-blog/service"
+	"go-blog/testutils"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const webhookSecret = "0123456789abcdef-secret"
+
+type receivedWebhook struct {
+	header http.Header
+	body   []byte
+}
+
+// webhookReceiver is an endpoint that answers with the queued status codes
+// in turn, then 204.
+type webhookReceiver struct {
+	*httptest.Server
+	mu       sync.Mutex
+	received []receivedWebhook
+	statuses []int
+}
+
+func newWebhookReceiver(t *testing.T, statuses ...int) *webhookReceiver {
+	r := &webhookReceiver{statuses: statuses}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.mu.Lock()
+		r.received = append(r.received, receivedWebhook{header: req.Header.Clone(), body: body})
+		status := http.StatusNoContent
+		if len(r.statuses) > 0 {
+			status, r.statuses = r.statuses[0], r.statuses[1:]
+		}
+		r.mu.Unlock()
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte("status " + strconv.Itoa(status)))
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *webhookReceiver) events(t *testing.T) []models.EventType {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var events []models.EventType
+	for _, got := range r.received {
+		var event models.Event
+		require.NoError(t, json.Unmarshal(got.body, &event))
+		assert.Equal(t, string(event.Type), got.header.Get(service.WebhookEventHeader))
+		events = append(events, event.Type)
+	}
+	return events
+}
+
+func webhookConfig() config.Config {
+	cfg := testutils.TestConfig()
+	cfg.Webhooks.AllowPrivateNetworks = true
+	cfg.Webhooks.MaxAttempts = 2
+	cfg.Admin.Token = "admin-token"
+	return cfg
+}
+
+func createWebhook(t *testing.T, suite *testutils.TestSuite, path string, headers map[string]string, hook map[string]any) models.Webhook {
+	body, _ := json.Marshal(hook)
+	w := suite.MakeRequest("POST", path, bytes.NewBuffer(body), headers)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created models.Webhook
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	return created
+}
+
+func drainJobs(t *testing.T, suite *testutils.TestSuite) {
+	_, err := suite.App.Jobs.Drain(context.Background())
+	require.NoError(t, err)
+}
+
+func getDelivery(t *testing.T, suite *testutils.TestSuite, auth map[string]string, webhookID, deliveryID int) models.WebhookDelivery {
+	w := suite.MakeRequest("GET", fmt.Sprintf("/api/me/webhooks/%d/deliveries/%d", webhookID, deliveryID), nil, auth)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var delivery models.WebhookDelivery
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &delivery))
+	return delivery
+}
+
+func TestWebhooksDeliverSignedPostEvents(t *testing.T) {
+	suite := testutils.SetupWithConfig(webhookConfig())
+	receiver := newWebhookReceiver(t)
+	token := registerAndLogin(t, suite, "hooked", "password123", "blogger")
+	auth := map[string]string{"Authorization": "Bearer " + token}
+	hook := createWebhook(t, suite, "/api/me/webhooks", auth, map[string]any{
+		"url":    receiver.URL,
+		"secret": webhookSecret,
+		"events": []string{"post.created", "post.published", "post.updated", "post.deleted", "post.created"},
+	})
+	assert.True(t, hook.Active)
+	assert.Equal(t, []models.EventType{models.EventPostCreated, models.EventPostPublished, models.EventPostUpdated, models.EventPostDeleted}, hook.Events)
+	assert.NotContains(t, suite.MakeRequest("GET", "/api/me/webhooks", nil, auth).Body.String(), webhookSecret)
+
+	// Another author's posts are not delivered to this webhook.
+	otherToken := registerAndLogin(t, suite, "unhooked", "password123", "blogger")
+	createPostJSON(t, suite, otherToken, map[string]any{"title": "Elsewhere", "content": "Body"})
+
+	draft := createPostJSON(t, suite, token, map[string]any{"title": "Draft", "content": "Body", "status": "draft"})
+	drainJobs(t, suite)
+	assert.Equal(t, []models.EventType{models.EventPostCreated}, receiver.events(t))
+
+	body, _ := json.Marshal(map[string]any{"title": "Draft", "content": "Body", "status": "published"})
+	w := suite.MakeRequest("PUT", fmt.Sprintf("/api/posts/%d", draft.ID), bytes.NewBuffer(body), auth)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = suite.MakeRequest("DELETE", fmt.Sprintf("/api/posts/%d", draft.ID), nil, auth)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	drainJobs(t, suite)
+	assert.Equal(t, []models.EventType{
+		models.EventPostCreated, models.EventPostUpdated, models.EventPostPublished, models.EventPostDeleted,
+	}, receiver.events(t))
+
+	for _, got := range receiver.received {
+		signature := got.header.Get(service.WebhookSignatureHeader)
+		ts, ok := strings.CutPrefix(strings.Split(signature, ",")[0], "t=")
+		require.True(t, ok, signature)
+		unix, err := strconv.ParseInt(ts, 10, 64)
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now(), time.Unix(unix, 0), time.Minute)
+		assert.Equal(t, service.SignWebhook(webhookSecret, time.Unix(unix, 0), got.body), signature)
+		assert.NotEqual(t, service.SignWebhook("some-other-secret!", time.Unix(unix, 0), got.body), signature)
+		assert.Equal(t, "application/json", got.header.Get("Content-Type"))
+	}
+	var deleted struct {
+		ID   string      `json:"id"`
+		Data models.Post `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(receiver.received[3].body, &deleted))
+	assert.Len(t, deleted.ID, 32)
+	assert.Equal(t, draft.ID, deleted.Data.ID)
+	assert.Equal(t, "Draft", deleted.Data.Title)
+
+	w = suite.MakeRequest("GET", fmt.Sprintf("/api/me/webhooks/%d/deliveries?limit=2", hook.ID), nil, auth)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var page []models.WebhookDelivery
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	require.Len(t, page, 2)
+	assert.Equal(t, models.EventPostDeleted, page[0].Event, "newest first")
+	assert.Equal(t, models.DeliverySucceeded, page[0].Status)
+	assert.NotNil(t, page[0].DeliveredAt)
+	assert.Equal(t, receiver.received[3].header.Get(service.WebhookDeliveryHeader), strconv.Itoa(page[0].ID))
+
+	delivery := getDelivery(t, suite, auth, hook.ID, page[0].ID)
+	require.Len(t, delivery.Log, 1)
+	assert.Equal(t, http.StatusNoContent, delivery.Log[0].StatusCode)
+	assert.Empty(t, delivery.Log[0].Error)
+}
+
+func TestWebhookFailuresAreRetriedLoggedAndRedelivered(t *testing.T) {
+	suite := testutils.SetupWithConfig(webhookConfig())
+	receiver := newWebhookReceiver(t, http.StatusInternalServerError, http.StatusBadGateway)
+	token := registerAndLogin(t, suite, "flaky", "password123", "blogger")
+	auth := map[string]string{"Authorization": "Bearer " + token}
+	hook := createWebhook(t, suite, "/api/me/webhooks", auth, map[string]any{
+		"url": receiver.URL, "secret": webhookSecret, "events": []string{"post.created"},
+	})
+	createPostJSON(t, suite, token, map[string]any{"title": "Retry me", "content": "Body"})
+
+	var job jobs.Job
+	require.NoError(t, suite.DB.Where("kind = ?", service.DeliverWebhookArgs{}.Kind()).First(&job).Error)
+	drainJobs(t, suite)
+	var deliveries []models.WebhookDelivery
+	require.NoError(t, suite.DB.Find(&deliveries).Error)
+	require.Len(t, deliveries, 1)
+	delivery := getDelivery(t, suite, auth, hook.ID, deliveries[0].ID)
+	assert.Equal(t, models.DeliveryRetrying, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, jobs.StatusQueued, getJob(t, suite, job.ID).Status, "the retry waits for its backoff")
+
+	makeDue(t, suite, job.ID)
+	drainJobs(t, suite)
+	delivery = getDelivery(t, suite, auth, hook.ID, delivery.ID)
+	assert.Equal(t, models.DeliveryFailed, delivery.Status, "out of attempts")
+	assert.Nil(t, delivery.DeliveredAt)
+	require.Len(t, delivery.Log, 2)
+	assert.Equal(t, http.StatusInternalServerError, delivery.Log[0].StatusCode)
+	assert.Equal(t, "status 500", delivery.Log[0].ResponseBody)
+	assert.Contains(t, delivery.Log[0].Error, "500")
+	assert.Equal(t, http.StatusBadGateway, delivery.Log[1].StatusCode)
+	assert.Equal(t, jobs.StatusDead, getJob(t, suite, job.ID).Status)
+
+	path := fmt.Sprintf("/api/me/webhooks/%d/deliveries/%d/redeliver", hook.ID, delivery.ID)
+	w := suite.MakeRequest("POST", path, nil, auth)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	w = suite.MakeRequest("POST", path, nil, auth)
+	assert.Equal(t, http.StatusConflict, w.Code, "already queued")
+	drainJobs(t, suite)
+
+	delivery = getDelivery(t, suite, auth, hook.ID, delivery.ID)
+	assert.Equal(t, models.DeliverySucceeded, delivery.Status)
+	assert.Len(t, delivery.Log, 3)
+	assert.Equal(t, 3, delivery.Attempts)
+	require.Len(t, receiver.received, 3)
+	assert.Equal(t, receiver.received[0].body, receiver.received[2].body, "redelivery sends the original event")
+
+	// A disabled webhook fails its deliveries without calling the endpoint.
+	body, _ := json.Marshal(map[string]any{"active": false})
+	w = suite.MakeRequest("PATCH", fmt.Sprintf("/api/me/webhooks/%d", hook.ID), bytes.NewBuffer(body), auth)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Equal(t, http.StatusAccepted, suite.MakeRequest("POST", path, nil, auth).Code)
+	drainJobs(t, suite)
+	delivery = getDelivery(t, suite, auth, hook.ID, delivery.ID)
+	assert.Equal(t, models.DeliveryFailed, delivery.Status)
+	assert.Equal(t, "webhook is disabled", delivery.Log[3].Error)
+	assert.Len(t, receiver.received, 3)
+}
+
+func TestWebhookOutboxCommitsWithTheChange(t *testing.T) {
+	suite := testutils.SetupWithConfig(webhookConfig())
+	receiver := newWebhookReceiver(t)
+	token := registerAndLogin(t, suite, "outbox", "password123", "blogger")
+	auth := map[string]string{"Authorization": "Bearer " + token}
+	createWebhook(t, suite, "/api/me/webhooks", auth, map[string]any{
+		"url": receiver.URL, "secret": webhookSecret, "events": []string{"post.created"},
+	})
+	var userID int
+	require.NoError(t, suite.DB.Model(&models.User{}).Where("username = ?", "outbox").Pluck("id", &userID).Error)
+
+	// Events published in a transaction that rolls back are never sent.
+	ctx := context.Background()
+	err := suite.App.Transactor.Transaction(ctx, func(ctx context.Context) error {
+		event := models.Event{Type: models.EventPostCreated, UserID: userID, Data: map[string]any{"id": 1}}
+		require.NoError(t, suite.App.WebhookService.Publish(ctx, event))
+		return errors.New("rolled back")
+	})
+	require.EqualError(t, err, "rolled back")
+	var deliveries, queued int64
+	require.NoError(t, suite.DB.Model(&models.WebhookDelivery{}).Count(&deliveries).Error)
+	require.NoError(t, suite.DB.Model(&jobs.Job{}).Count(&queued).Error)
+	assert.Zero(t, deliveries)
+	assert.Zero(t, queued)
+
+	// And a change whose event cannot be recorded is not committed either.
+	require.NoError(t, suite.DB.Exec("DROP TABLE webhook_attempts").Error)
+	require.NoError(t, suite.DB.Exec("DROP TABLE webhook_deliveries").Error)
+	body, _ := json.Marshal(map[string]any{"title": "Lost", "content": "Body"})
+	w := suite.MakeRequest("POST", "/api/posts", bytes.NewBuffer(body), auth)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	var posts int64
+	require.NoError(t, suite.DB.Model(&models.Post{}).Count(&posts).Error)
+	assert.Zero(t, posts)
+}
+
+func TestWebhookValidationAndOwnership(t *testing.T) {
+	cfg := webhookConfig()
+	cfg.Webhooks.AllowPrivateNetworks = false
+	suite := testutils.SetupWithConfig(cfg)
+	token := registerAndLogin(t, suite, "owner", "password123", "blogger")
+	auth := map[string]string{"Authorization": "Bearer " + token}
+
+	for name, hook := range map[string]map[string]any{
+		"missing secret":   {"url": "https://example.com/hook", "events": []string{"post.created"}},
+		"short secret":     {"url": "https://example.com/hook", "secret": "short", "events": []string{"post.created"}},
+		"relative url":     {"url": "/hook", "secret": webhookSecret, "events": []string{"post.created"}},
+		"ftp url":          {"url": "ftp://example.com/hook", "secret": webhookSecret, "events": []string{"post.created"}},
+		"loopback":         {"url": "http://127.0.0.1:9000/hook", "secret": webhookSecret, "events": []string{"post.created"}},
+		"private":          {"url": "http://10.1.2.3/hook", "secret": webhookSecret, "events": []string{"post.created"}},
+		"localhost":        {"url": "http://localhost/hook", "secret": webhookSecret, "events": []string{"post.created"}},
+		"no events":        {"url": "https://example.com/hook", "secret": webhookSecret, "events": []string{}},
+		"unknown event":    {"url": "https://example.com/hook", "secret": webhookSecret, "events": []string{"post.liked"}},
+		"site-wide events": {"url": "https://example.com/hook", "secret": webhookSecret, "events": []string{"user.registered"}},
+	} {
+		body, _ := json.Marshal(hook)
+		w := suite.MakeRequest("POST", "/api/me/webhooks", bytes.NewBuffer(body), auth)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "%s: %s", name, w.Body.String())
+	}
+
+	hook := createWebhook(t, suite, "/api/me/webhooks", auth, map[string]any{
+		"url": "https://example.com/hook", "secret": webhookSecret, "events": []string{"post.created"},
+	})
+	path := fmt.Sprintf("/api/me/webhooks/%d", hook.ID)
+	assert.Equal(t, http.StatusUnauthorized, suite.MakeRequest("GET", "/api/me/webhooks", nil).Code)
+
+	otherAuth := map[string]string{"Authorization": "Bearer " + registerAndLogin(t, suite, "intruder", "password123", "viewer")}
+	assert.Equal(t, http.StatusNotFound, suite.MakeRequest("GET", path, nil, otherAuth).Code)
+	assert.Equal(t, http.StatusNotFound, suite.MakeRequest("DELETE", path, nil, otherAuth).Code)
+	assert.Equal(t, http.StatusNotFound, suite.MakeRequest("GET", path+"/deliveries", nil, otherAuth).Code)
+	assert.Equal(t, "[]", suite.MakeRequest("GET", "/api/me/webhooks", nil, otherAuth).Body.String())
+	adminAuth := map[string]string{"Authorization": "Bearer admin-token"}
+	assert.Equal(t, http.StatusNotFound, suite.MakeRequest("GET", fmt.Sprintf("/admin/webhooks/%d", hook.ID), nil, adminAuth).Code,
+		"a user's webhook is not site-wide")
+
+	body, _ := json.Marshal(map[string]any{"url": "http://192.168.0.1/"})
+	assert.Equal(t, http.StatusBadRequest, suite.MakeRequest("PATCH", path, bytes.NewBuffer(body), auth).Code)
+	assert.Equal(t, http.StatusNoContent, suite.MakeRequest("DELETE", path, nil, auth).Code)
+	assert.Equal(t, http.StatusNotFound, suite.MakeRequest("GET", path, nil, auth).Code)
+}
+
+func TestSiteWideWebhooksReceiveRegistrationsAndRefusePrivateAddresses(t *testing.T) {
+	cfg := webhookConfig()
+	cfg.Webhooks.AllowPrivateNetworks = false
+	suite := testutils.SetupWithConfig(cfg)
+	receiver := newWebhookReceiver(t)
+	adminAuth := map[string]string{"Authorization": "Bearer admin-token"}
+	assert.Equal(t, http.StatusUnauthorized, suite.MakeRequest("GET", "/admin/webhooks", nil).Code)
+
+	hook := createWebhook(t, suite, "/admin/webhooks", adminAuth, map[string]any{
+		"url": "https://hooks.example.com/users", "secret": webhookSecret, "events": []string{"user.registered", "post.created"},
+	})
+	assert.Nil(t, hook.UserID)
+	// Point it at the local receiver behind the API's back: the address is
+	// checked again when connecting, which also covers DNS names that
+	// resolve to internal addresses.
+	require.NoError(t, suite.DB.Model(&models.Webhook{}).Where("id = ?", hook.ID).Update("url", receiver.URL).Error)
+
+	registerAndLogin(t, suite, "newcomer", "password123", "viewer")
+	drainJobs(t, suite)
+	assert.Empty(t, receiver.received)
+
+	w := suite.MakeRequest("GET", fmt.Sprintf("/admin/webhooks/%d/deliveries", hook.ID), nil, adminAuth)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var deliveries []models.WebhookDelivery
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &deliveries))
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, models.EventUserRegistered, deliveries[0].Event)
+	assert.NotContains(t, string(deliveries[0].Payload), "password")
+	var event struct {
+		Type models.EventType   `json:"type"`
+		Data models.UserProfile `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(deliveries[0].Payload, &event))
+	assert.Equal(t, models.UserProfile{ID: event.Data.ID, Username: "newcomer", AccountType: models.AccountTypeViewer}, event.Data)
+
+	w = suite.MakeRequest("GET", fmt.Sprintf("/admin/webhooks/%d/deliveries/%d", hook.ID, deliveries[0].ID), nil, adminAuth)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var delivery models.WebhookDelivery
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &delivery))
+	require.Len(t, delivery.Log, 1)
+	assert.Contains(t, delivery.Log[0].Error, "not public")
+	assert.Zero(t, delivery.Log[0].StatusCode)
+}